@@ -6,9 +6,12 @@
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
+	atunits "github.com/alecthomas/units"
 	"github.com/fatih/color"
 	"github.com/mattn/go-colorable"
 	"github.com/pkg/errors"
@@ -90,6 +93,8 @@ type appServices interface {
 	openRepository(ctx context.Context, mustBeConnected bool) (repo.Repository, error)
 	advancedCommand(ctx context.Context)
 	repositoryConfigFileName() string
+	configProfileName() string
+	repositoryConfigKey() string
 	getProgress() *cliProgress
 	getRestoreProgress() RestoreProgress
 	notificationTemplateOptions() notifytemplate.Options
@@ -97,6 +102,7 @@ type appServices interface {
 	stdout() io.Writer
 	Stderr() io.Writer
 	stdin() io.Reader
+	getPasswordPromptAttempts() int
 	onTerminate(callback func())
 	onRepositoryFatalError(callback func(err error))
 	enableTestOnlyFlags() bool
@@ -113,6 +119,10 @@ type advancedAppServices interface {
 	openRepository(ctx context.Context, required bool) (repo.Repository, error)
 	maybeInitializeUpdateCheck(ctx context.Context, co *connectOptions)
 	removeUpdateState()
+	updateStateFilename() string
+	getUpdateState() (*updateState, error)
+	checkForUpdatesNow(ctx context.Context) (*updateState, error)
+	updateCheckDisabledByEnvironment() bool
 	passwordPersistenceStrategy() passwordpersist.Strategy
 	getPasswordFromFlags(ctx context.Context, isCreate, allowPersistent bool) (string, error)
 	optionsFromFlags(ctx context.Context) *repo.Options
@@ -124,6 +134,14 @@ type advancedAppServices interface {
 type App struct {
 	// global flags
 	enableAutomaticMaintenance    bool
+	maxAutoMaintenanceDuration    time.Duration
+	verboseAutoMaintenance        bool
+	retryOpen                     int
+	preMaintenanceHook            string
+	postMaintenanceHook           string
+	maintenanceHookTimeout        time.Duration
+	quiet                         bool
+	failOnWarnings                bool
 	pf                            profileFlags
 	progress                      *cliProgress
 	restoreProgress               RestoreProgress
@@ -131,8 +149,17 @@ type App struct {
 	updateCheckInterval           time.Duration
 	updateAvailableNotifyInterval time.Duration
 	password                      string
+	passwordFile                  string
+	passwordCommand               string
+	passwordCommandTimeout        time.Duration
+	passwordPromptAttempts        int
+	commandTimeout                time.Duration
 	configPath                    string
+	configProfile                 string
+	tokenOptions                  repo.TokenOptions
 	traceStorage                  bool
+	uploadLimit                   atunits.Base2Bytes
+	downloadLimit                 atunits.Base2Bytes
 	keyRingEnabled                bool
 	persistCredentials            bool
 	disableInternalLog            bool
@@ -151,6 +178,10 @@ type App struct {
 	onExitCallbacks       []func()
 	onFatalErrorCallbacks []func(err error)
 
+	terminateMutex sync.Mutex
+	// +checklocks:terminateMutex
+	terminateCallbacks []func()
+
 	// subcommands
 	blob         commandBlob
 	benchmark    commandBenchmark
@@ -182,6 +213,8 @@ type App struct {
 	stderrWriter    io.Writer
 	rootctx         context.Context //nolint:containedctx
 	loggerFactory   logging.LoggerFactory
+	warningCount    atomic.Int32
+	wroteRepository atomic.Bool
 	simulatedCtrlC  chan bool
 	envNamePrefix   string
 }
@@ -190,6 +223,11 @@ func (c *App) enableTestOnlyFlags() bool {
 	return c.isInProcessTest || os.Getenv("KOPIA_TESTONLY_FLAGS") != ""
 }
 
+// Quiet returns true when only warnings and errors should be written to the console.
+func (c *App) Quiet() bool {
+	return c.quiet
+}
+
 func (c *App) getProgress() *cliProgress {
 	return c.progress
 }
@@ -266,15 +304,31 @@ func (c *App) setup(app *kingpin.Application) {
 	}).Bool()
 
 	app.Flag("auto-maintenance", "Automatic maintenance").Default("true").Hidden().BoolVar(&c.enableAutomaticMaintenance)
+	app.Flag("verbose-auto-maintenance", "Report why automatic maintenance was skipped").Hidden().Envar(c.EnvName("KOPIA_VERBOSE_AUTO_MAINTENANCE")).BoolVar(&c.verboseAutoMaintenance)
+	app.Flag("max-auto-maintenance-duration", "Maximum duration of automatic maintenance, no new maintenance tasks are started after it elapses and remaining work is continued next time (0 = unlimited)").Envar(c.EnvName("KOPIA_MAX_AUTO_MAINTENANCE_DURATION")).DurationVar(&c.maxAutoMaintenanceDuration)
+	app.Flag("pre-maintenance-hook", "Command to run before automatic maintenance, maintenance is skipped if it fails").Envar(c.EnvName("KOPIA_PRE_MAINTENANCE_HOOK")).StringVar(&c.preMaintenanceHook)
+	app.Flag("post-maintenance-hook", "Command to run after automatic maintenance").Envar(c.EnvName("KOPIA_POST_MAINTENANCE_HOOK")).StringVar(&c.postMaintenanceHook)
+	app.Flag("maintenance-hook-timeout", "Maximum duration of a maintenance hook command").Default("5m").Envar(c.EnvName("KOPIA_MAINTENANCE_HOOK_TIMEOUT")).DurationVar(&c.maintenanceHookTimeout)
+	app.Flag("retry-open", "Number of times to retry opening the repository after a transient failure").Envar(c.EnvName("KOPIA_RETRY_OPEN")).IntVar(&c.retryOpen)
+	app.Flag("command-timeout", "Abort the command if it does not finish within the provided duration (0 = no timeout)").Envar(c.EnvName("KOPIA_COMMAND_TIMEOUT")).DurationVar(&c.commandTimeout)
+	app.Flag("quiet", "Suppress progress and informational messages, only show warnings and errors").Short('q').Envar(c.EnvName("KOPIA_QUIET")).BoolVar(&c.quiet)
+	app.Flag("fail-on-warnings", "Exit with an error if any warnings were logged").Envar(c.EnvName("KOPIA_FAIL_ON_WARNINGS")).BoolVar(&c.failOnWarnings)
 
 	// hidden flags to control auto-update behavior.
 	app.Flag("initial-update-check-delay", "Initial delay before first time update check").Default("24h").Hidden().Envar(c.EnvName("KOPIA_INITIAL_UPDATE_CHECK_DELAY")).DurationVar(&c.initialUpdateCheckDelay)
 	app.Flag("update-check-interval", "Interval between update checks").Default("168h").Hidden().Envar(c.EnvName("KOPIA_UPDATE_CHECK_INTERVAL")).DurationVar(&c.updateCheckInterval)
 	app.Flag("update-available-notify-interval", "Interval between update notifications").Default("1h").Hidden().Envar(c.EnvName("KOPIA_UPDATE_NOTIFY_INTERVAL")).DurationVar(&c.updateAvailableNotifyInterval)
 	app.Flag("config-file", "Specify the config file to use").Default("repository.config").Envar(c.EnvName("KOPIA_CONFIG_PATH")).StringVar(&c.configPath)
+	app.Flag("profile", "Use the named connection profile, stored as a section of the file specified by --config-file").Envar(c.EnvName("KOPIA_PROFILE")).StringVar(&c.configProfile)
 	app.Flag("trace-storage", "Enables tracing of storage operations.").Default("true").Hidden().BoolVar(&c.traceStorage)
+	app.Flag("upload-limit", "Limit the upload speed to the provided number of bytes per second (0 = use repository setting)").PlaceHolder("BYTES_PER_SEC").Default("0").Envar(c.EnvName("KOPIA_UPLOAD_LIMIT")).BytesVar(&c.uploadLimit)
+	app.Flag("download-limit", "Limit the download speed to the provided number of bytes per second (0 = use repository setting)").PlaceHolder("BYTES_PER_SEC").Default("0").Envar(c.EnvName("KOPIA_DOWNLOAD_LIMIT")).BytesVar(&c.downloadLimit)
 	app.Flag("timezone", "Format time according to specified time zone (local, utc, original or time zone name)").Hidden().StringVar(&timeZone)
 	app.Flag("password", "Repository password.").Envar(c.EnvName("KOPIA_PASSWORD")).Short('p').StringVar(&c.password)
+	app.Flag("password-command", "Run the provided shell command and use its output as repository password.").Envar(c.EnvName("KOPIA_PASSWORD_COMMAND")).StringVar(&c.passwordCommand)
+	app.Flag("password-command-timeout", "Maximum duration of the command provided with --password-command.").Default("1m").Envar(c.EnvName("KOPIA_PASSWORD_COMMAND_TIMEOUT")).DurationVar(&c.passwordCommandTimeout)
+	app.Flag("password-prompt-attempts", "Number of times to repeat interactive password prompt when no password is entered.").Hidden().Default("5").Envar(c.EnvName("KOPIA_PASSWORD_PROMPT_ATTEMPTS")).IntVar(&c.passwordPromptAttempts)
+	app.Flag("password-file", "Read repository password from the first line of the provided file.").Envar(c.EnvName("KOPIA_PASSWORD_FILE")).StringVar(&c.passwordFile)
 	app.Flag("persist-credentials", "Persist credentials").Default("true").Envar(c.EnvName("KOPIA_PERSIST_CREDENTIALS_ON_CONNECT")).BoolVar(&c.persistCredentials)
 	app.Flag("disable-internal-log", "Disable internal log").Hidden().Envar(c.EnvName("KOPIA_DISABLE_INTERNAL_LOG")).BoolVar(&c.disableInternalLog)
 	app.Flag("advanced-commands", "Enable advanced (and potentially dangerous) commands.").Hidden().Envar(c.EnvName("KOPIA_ADVANCED_COMMANDS")).StringVar(&c.AdvancedCommands)
@@ -306,6 +360,15 @@ func (c *App) setup(app *kingpin.Application) {
 	c.pf.setup(app)
 	c.progress.setup(c, app)
 
+	app.PreAction(func(_ *kingpin.ParseContext) error {
+		if c.quiet {
+			c.progress.enableProgress = false
+		}
+
+		//nolint:wrapcheck
+		return repo.ValidateProfileName(c.configProfile)
+	})
+
 	c.blob.setup(c, app)
 	c.benchmark.setup(c, app)
 	c.cache.setup(c, app)
@@ -381,7 +444,8 @@ func (c *App) Attach(app *kingpin.Application) {
 	c.setup(app)
 }
 
-// safetyFlagVar defines c --safety=none|full flag that sets the SafetyParameters.
+// safetyFlagVar defines c --safety=none|full flag that sets the SafetyParameters and
+// --safety-* flags that override individual parameters of the selected safety level.
 func safetyFlagVar(cmd *kingpin.CmdClause, result *maintenance.SafetyParameters) {
 	var str string
 
@@ -392,6 +456,16 @@ func safetyFlagVar(cmd *kingpin.CmdClause, result *maintenance.SafetyParameters)
 		"full": maintenance.SafetyFull,
 	}
 
+	// overrides provided on the command line, re-applied whenever the safety level is selected,
+	// so that they take precedence regardless of the order of flags.
+	overrides := map[string]func(sp *maintenance.SafetyParameters){}
+
+	applyOverrides := func() {
+		for _, o := range overrides {
+			o(result)
+		}
+	}
+
 	cmd.Flag("safety", "Safety level").Default("full").PreAction(func(_ *kingpin.ParseContext) error {
 		r, ok := safetyByName[str]
 		if !ok {
@@ -400,8 +474,54 @@ func safetyFlagVar(cmd *kingpin.CmdClause, result *maintenance.SafetyParameters)
 
 		*result = r
 
+		applyOverrides()
+
 		return nil
 	}).EnumVar(&str, "full", "none")
+
+	durationOverrides := []struct {
+		name  string
+		help  string
+		field func(sp *maintenance.SafetyParameters) *time.Duration
+	}{
+		{"safety-min-content-age", "Override minimum age of unreferenced contents subject to snapshot GC", func(sp *maintenance.SafetyParameters) *time.Duration { return &sp.MinContentAgeSubjectToGC }},
+		{"safety-blob-delete-min-age", "Override minimum age of unreferenced blobs to be deleted", func(sp *maintenance.SafetyParameters) *time.Duration { return &sp.BlobDeleteMinAge }},
+		{"safety-session-expiration-age", "Override age after which incomplete session blobs are deleted", func(sp *maintenance.SafetyParameters) *time.Duration { return &sp.SessionExpirationAge }},
+		{"safety-rewrite-min-age", "Override minimum age of contents to be rewritten", func(sp *maintenance.SafetyParameters) *time.Duration { return &sp.RewriteMinAge }},
+		{"safety-margin-between-snapshot-gc", "Override minimum time between snapshot GC cycles", func(sp *maintenance.SafetyParameters) *time.Duration { return &sp.MarginBetweenSnapshotGC }},
+		{"safety-drop-content-margin", "Override extra margin before dropping deleted contents from the index", func(sp *maintenance.SafetyParameters) *time.Duration { return &sp.DropContentFromIndexExtraMargin }},
+		{"safety-rewrite-to-delete-delay", "Override minimum time between content rewrite and deletion of orphaned blobs", func(sp *maintenance.SafetyParameters) *time.Duration { return &sp.MinRewriteToOrphanDeletionDelay }},
+	}
+
+	for _, o := range durationOverrides {
+		var v time.Duration
+
+		cmd.Flag(o.name, o.help).PlaceHolder("DURATION").PreAction(func(_ *kingpin.ParseContext) error {
+			if v < 0 {
+				return errors.Errorf("--%v must not be negative", o.name)
+			}
+
+			overrides[o.name] = func(sp *maintenance.SafetyParameters) {
+				*o.field(sp) = v
+			}
+
+			applyOverrides()
+
+			return nil
+		}).DurationVar(&v)
+	}
+
+	var requireTwoGCCycles bool
+
+	cmd.Flag("safety-require-two-gc-cycles", "Override whether two snapshot GC cycles are required before dropping deleted contents").PreAction(func(_ *kingpin.ParseContext) error {
+		overrides["safety-require-two-gc-cycles"] = func(sp *maintenance.SafetyParameters) {
+			sp.RequireTwoGCCycles = requireTwoGCCycles
+		}
+
+		applyOverrides()
+
+		return nil
+	}).BoolVar(&requireTwoGCCycles)
 }
 
 func (c *App) currentActionName() string {
@@ -411,12 +531,14 @@ func (c *App) currentActionName() string {
 func (c *App) noRepositoryAction(act func(ctx context.Context) error) func(ctx *kingpin.ParseContext) error {
 	return func(kpc *kingpin.ParseContext) error {
 		return c.runAppWithContext(kpc.SelectedCommand, func(ctx context.Context) error {
-			return c.pf.withProfiling(func() error {
-				if c.dumpAllocatorStats {
-					defer gather.DumpStats(ctx)
-				}
-
-				return act(ctx)
+			return c.withCommandTimeout(ctx, func(ctx context.Context) error {
+				return c.pf.withProfiling(func() error {
+					if c.dumpAllocatorStats {
+						defer gather.DumpStats(ctx)
+					}
+
+					return act(ctx)
+				})
 			})
 		})
 	}
@@ -461,7 +583,7 @@ func (c *App) directRepositoryWriteAction(act func(ctx context.Context, rep repo
 	return c.maybeRepositoryAction(assertDirectRepository(func(ctx context.Context, rep repo.DirectRepository) error {
 		return repo.DirectWriteSession(ctx, rep, repo.WriteSessionOptions{
 			Purpose:  "cli:" + c.currentActionName(),
-			OnUpload: c.progress.UploadedBytes,
+			OnUpload: c.onRepositoryUpload,
 		}, func(ctx context.Context, dw repo.DirectRepositoryWriter) error { return act(ctx, dw) })
 	}), repositoryAccessMode{
 		mustBeConnected:    true,
@@ -491,20 +613,27 @@ func (c *App) repositoryWriterAction(act func(ctx context.Context, rep repo.Repo
 	return c.maybeRepositoryAction(func(ctx context.Context, rep repo.Repository) error {
 		return repo.WriteSession(ctx, rep, repo.WriteSessionOptions{
 			Purpose:  "cli:" + c.currentActionName(),
-			OnUpload: c.progress.UploadedBytes,
+			OnUpload: c.onRepositoryUpload,
 		}, func(ctx context.Context, w repo.RepositoryWriter) error {
 			return act(ctx, w)
 		})
 	}, repositoryAccessMode{
-		mustBeConnected: true,
+		mustBeConnected:            true,
+		maintenanceOnlyAfterWrites: true,
 	})
 }
 
+// onRepositoryUpload is invoked after each upload to the repository performed by the command.
+func (c *App) onRepositoryUpload(numBytes int64) {
+	c.wroteRepository.Store(true)
+	c.progress.UploadedBytes(numBytes)
+}
+
 func (c *App) runAppWithContext(command *kingpin.CmdClause, cb func(ctx context.Context) error) error {
 	ctx := c.rootctx
 
 	if c.loggerFactory != nil {
-		ctx = logging.WithLogger(ctx, c.loggerFactory)
+		ctx = logging.WithLogger(ctx, c.warningCountingLoggerFactory(c.loggerFactory))
 	}
 
 	for _, r := range c.trackReleasable {
@@ -515,14 +644,16 @@ func (c *App) runAppWithContext(command *kingpin.CmdClause, cb func(ctx context.
 		return errors.Wrap(err, "unable to start metrics")
 	}
 
+	cctx, stopHandlingInterrupts := c.handleInterrupts(ctx)
+
 	err := func() error {
 		if command == nil {
 			defer c.runOnExit()
 
-			return cb(ctx)
+			return cb(cctx)
 		}
 
-		tctx, span := tracer.Start(ctx, command.FullCommand(), trace.WithSpanKind(trace.SpanKindClient))
+		tctx, span := tracer.Start(cctx, command.FullCommand(), trace.WithSpanKind(trace.SpanKindClient))
 		defer span.End()
 
 		defer c.runOnExit()
@@ -530,6 +661,16 @@ func (c *App) runAppWithContext(command *kingpin.CmdClause, cb func(ctx context.
 		return cb(tctx)
 	}()
 
+	stopHandlingInterrupts()
+
+	if err != nil && errors.Is(context.Cause(cctx), errInterrupted) {
+		err = errors.Wrap(err, "command was interrupted")
+	}
+
+	if err == nil {
+		err = c.checkWarnings()
+	}
+
 	c.observability.stopMetrics(ctx)
 
 	if err != nil {
@@ -551,22 +692,44 @@ func (c *App) runAppWithContext(command *kingpin.CmdClause, cb func(ctx context.
 type repositoryAccessMode struct {
 	mustBeConnected    bool
 	disableMaintenance bool
+
+	// only run automatic maintenance if the action has written to the repository.
+	maintenanceOnlyAfterWrites bool
 }
 
 func (c *App) baseActionWithContext(act func(ctx context.Context) error) func(ctx *kingpin.ParseContext) error {
 	return func(kpc *kingpin.ParseContext) error {
 		return c.runAppWithContext(kpc.SelectedCommand, func(ctx context.Context) error {
-			return c.pf.withProfiling(func() error {
-				if c.dumpAllocatorStats {
-					defer gather.DumpStats(ctx)
-				}
-
-				return act(ctx)
+			return c.withCommandTimeout(ctx, func(ctx context.Context) error {
+				return c.pf.withProfiling(func() error {
+					if c.dumpAllocatorStats {
+						defer gather.DumpStats(ctx)
+					}
+
+					return act(ctx)
+				})
 			})
 		})
 	}
 }
 
+// withCommandTimeout runs the provided function with a context that is canceled after --command-timeout, if provided.
+func (c *App) withCommandTimeout(ctx context.Context, cb func(ctx context.Context) error) error {
+	if c.commandTimeout <= 0 {
+		return cb(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.commandTimeout)
+	defer cancel()
+
+	err := cb(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errors.Wrapf(err, "command timed out after %v", c.commandTimeout)
+	}
+
+	return err
+}
+
 func (c *App) maybeRepositoryAction(act func(ctx context.Context, rep repo.Repository) error, mode repositoryAccessMode) func(ctx *kingpin.ParseContext) error {
 	return c.baseActionWithContext(func(ctx context.Context) error {
 		rep, err := c.openRepository(ctx, mode.mustBeConnected)
@@ -579,7 +742,9 @@ func (c *App) maybeRepositoryAction(act func(ctx context.Context, rep repo.Repos
 		err = act(ctx, rep)
 
 		if rep != nil && err == nil && !mode.disableMaintenance {
-			if merr := c.maybeRunMaintenance(ctx, rep); merr != nil {
+			if mode.maintenanceOnlyAfterWrites && !c.wroteRepository.Load() {
+				c.maintenanceSkipped(ctx, "the command did not write to the repository")
+			} else if merr := c.maybeRunMaintenance(ctx, rep); merr != nil {
 				log(ctx).Errorf("error running maintenance: %v", merr)
 			}
 		}
@@ -596,7 +761,8 @@ func (c *App) maybeRepositoryAction(act func(ctx context.Context, rep repo.Repos
 		}
 
 		if rep != nil && mode.mustBeConnected {
-			if cerr := rep.Close(ctx); cerr != nil {
+			// close the repository even if the command has timed out.
+			if cerr := rep.Close(context.WithoutCancel(ctx)); cerr != nil {
 				return errors.Wrap(cerr, "unable to close repository")
 			}
 		}
@@ -629,35 +795,64 @@ func (c *App) repositoryHintAction(act func(ctx context.Context, rep repo.Reposi
 
 func (c *App) maybeRunMaintenance(ctx context.Context, rep repo.Repository) error {
 	if !c.enableAutomaticMaintenance {
+		c.maintenanceSkipped(ctx, "automatic maintenance is disabled")
 		return nil
 	}
 
 	if rep.ClientOptions().ReadOnly {
+		c.maintenanceSkipped(ctx, "the repository is connected in read-only mode")
 		return nil
 	}
 
 	dr, ok := rep.(repo.DirectRepository)
 	if !ok {
+		c.maintenanceSkipped(ctx, "the repository is not directly connected")
 		return nil
 	}
 
-	err := repo.DirectWriteSession(ctx, dr, repo.WriteSessionOptions{
+	mctx := ctx
+
+	// stop starting new tasks after the deadline, but don't interrupt the task in progress.
+	if d := c.maxAutoMaintenanceDuration; d > 0 {
+		mctx = maintenance.WithDeadline(ctx, clock.Now().Add(d))
+	}
+
+	err := repo.DirectWriteSession(mctx, dr, repo.WriteSessionOptions{
 		Purpose:  "maybeRunMaintenance",
 		OnUpload: c.progress.UploadedBytes,
 	}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
-		return snapshotmaintenance.Run(ctx, w, maintenance.ModeAuto, false, maintenance.SafetyFull)
+		return snapshotmaintenance.RunWithHooks(ctx, w, maintenance.ModeAuto, false, maintenance.SafetyFull, c.maintenanceHooks())
 	})
 
+	if errors.Is(err, maintenance.ErrDeadlineReached) {
+		log(ctx).Infof("Automatic maintenance was stopped after %v, it will continue next time.", c.maxAutoMaintenanceDuration)
+		return nil
+	}
+
 	var noe maintenance.NotOwnedError
 
 	if errors.As(err, &noe) {
 		// do not report the NotOwnedError to the user since this is automatic maintenance.
+		c.maintenanceSkipped(ctx, "maintenance is owned by %v", noe.Owner)
 		return nil
 	}
 
 	return errors.Wrap(err, "error running maintenance")
 }
 
+// maintenanceSkipped logs the reason automatic maintenance was skipped, at info level when
+// --verbose-auto-maintenance is set and at debug level otherwise.
+func (c *App) maintenanceSkipped(ctx context.Context, reason string, args ...any) {
+	msg := "Skipping automatic maintenance because " + fmt.Sprintf(reason, args...)
+
+	if c.verboseAutoMaintenance {
+		log(ctx).Info(msg)
+		return
+	}
+
+	log(ctx).Debug(msg)
+}
+
 func (c *App) advancedCommand(ctx context.Context) {
 	if c.AdvancedCommands != "enabled" {
 		_, _ = errorColor.Fprintf(c.stderrWriter, `
@@ -0,0 +1,23 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	// command finishing before the deadline is not affected.
+	env.RunAndExpectSuccess(t, "snapshot", "list", "--command-timeout=1m")
+
+	// watching never finishes on its own and is interrupted by the timeout.
+	_, stderr := env.RunAndExpectFailure(t, "content", "verify", "--watch", "--interval=1h", "--command-timeout=2s")
+	mustGetLineContaining(t, stderr, "command timed out after 2s")
+
+	// the repository remains usable after the timed out command.
+	env.RunAndExpectSuccess(t, "snapshot", "list")
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+func TestSafetyFlagVar(t *testing.T) {
+	cases := []struct {
+		args    []string
+		want    func(sp *maintenance.SafetyParameters)
+		wantErr bool
+	}{
+		{
+			args: nil,
+			want: func(*maintenance.SafetyParameters) {},
+		},
+		{
+			args: []string{"--safety-min-content-age=3h", "--safety-blob-delete-min-age=1h"},
+			want: func(sp *maintenance.SafetyParameters) {
+				sp.MinContentAgeSubjectToGC = 3 * time.Hour
+				sp.BlobDeleteMinAge = time.Hour
+			},
+		},
+		{
+			// overrides take precedence over the safety level regardless of the order.
+			args: []string{"--safety-rewrite-min-age=5m", "--safety=none", "--no-safety-require-two-gc-cycles"},
+			want: func(sp *maintenance.SafetyParameters) {
+				*sp = maintenance.SafetyNone
+				sp.RewriteMinAge = 5 * time.Minute
+			},
+		},
+		{
+			args: []string{"--safety=none", "--safety-require-two-gc-cycles", "--safety-session-expiration-age=2h"},
+			want: func(sp *maintenance.SafetyParameters) {
+				*sp = maintenance.SafetyNone
+				sp.RequireTwoGCCycles = true
+				sp.SessionExpirationAge = 2 * time.Hour
+			},
+		},
+		{
+			args:    []string{"--safety-min-content-age=-1h"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		var got maintenance.SafetyParameters
+
+		app := kingpin.New("test", "")
+		safetyFlagVar(app.Command("cmd", ""), &got)
+
+		_, err := app.Parse(append([]string{"cmd"}, tc.args...))
+		if tc.wantErr {
+			require.Error(t, err, tc.args)
+			continue
+		}
+
+		require.NoError(t, err, tc.args)
+
+		want := maintenance.SafetyFull
+		tc.want(&want)
+
+		require.Equal(t, want, got, tc.args)
+	}
+}
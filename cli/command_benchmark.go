@@ -12,6 +12,7 @@ type commandBenchmark struct {
 	encryption  commandBenchmarkEncryption
 	splitters   commandBenchmarkSplitters
 	ecc         commandBenchmarkEcc
+	storage     commandBenchmarkStorage
 }
 
 func (c *commandBenchmark) setup(svc appServices, parent commandParent) {
@@ -23,6 +24,7 @@ func (c *commandBenchmark) setup(svc appServices, parent commandParent) {
 	c.hashing.setup(svc, cmd)
 	c.encryption.setup(svc, cmd)
 	c.ecc.setup(svc, cmd)
+	c.storage.setup(svc, cmd)
 }
 
 type cryptoBenchResult struct {
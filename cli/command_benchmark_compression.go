@@ -5,11 +5,14 @@
 	"context"
 	"hash/fnv"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
 
+	atunits "github.com/alecthomas/units"
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/gather"
@@ -32,13 +35,23 @@ type commandBenchmarkCompression struct {
 	operations   string
 	algorithms   string
 
+	allFilesIn           string
+	filesPerExtension    int
+	maxBytesPerExtension atunits.Base2Bytes
+	minThroughputMB      float64
+
 	out textOutput
+	jo  jsonOutput
 }
 
 func (c *commandBenchmarkCompression) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("compression", "Run compression benchmarks")
 	cmd.Flag("repeat", "Number of repetitions").Default("0").IntVar(&c.repeat)
-	cmd.Flag("data-file", "Use data from the given file").Required().ExistingFileVar(&c.dataFile)
+	cmd.Flag("data-file", "Use data from the given file or directory, concatenating all files in a directory").ExistingFileOrDirVar(&c.dataFile)
+	cmd.Flag("all-files-in", "Benchmark using a sample of files in the given directory, grouped by file extension").ExistingDirVar(&c.allFilesIn)
+	cmd.Flag("files-per-extension", "Maximum number of files sampled for each extension with --all-files-in").Default("10").IntVar(&c.filesPerExtension)
+	cmd.Flag("max-bytes-per-extension", "Maximum number of bytes sampled for each extension with --all-files-in").Default("16MB").BytesVar(&c.maxBytesPerExtension)
+	cmd.Flag("min-throughput-mb", "Minimum acceptable compression throughput in MB/s when recommending algorithms with --all-files-in").Default("50").Float64Var(&c.minThroughputMB)
 	cmd.Flag("by-size", "Sort results by size").BoolVar(&c.bySize)
 	cmd.Flag("by-alloc", "Sort results by allocated bytes").BoolVar(&c.byAllocated)
 	cmd.Flag("parallel", "Number of parallel goroutines").Default("1").IntVar(&c.parallel)
@@ -49,10 +62,72 @@ func (c *commandBenchmarkCompression) setup(svc appServices, parent commandParen
 	cmd.Flag("algorithms", "Comma-separated list of algorithms to benchmark").StringVar(&c.algorithms)
 	cmd.Action(svc.noRepositoryAction(c.run))
 	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
 }
 
-func (c *commandBenchmarkCompression) readInputFile(ctx context.Context) ([]byte, error) {
-	f, err := os.Open(c.dataFile)
+// readInput reads the benchmark data from the file or directory provided with --data-file.
+func (c *commandBenchmarkCompression) readInput(ctx context.Context) ([]byte, error) {
+	st, err := os.Stat(c.dataFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "stat error")
+	}
+
+	if !st.IsDir() {
+		return c.readInputFile(ctx, c.dataFile)
+	}
+
+	return c.readInputDirectory(ctx, c.dataFile)
+}
+
+// readInputDirectory concatenates the contents of regular files in the provided directory,
+// up to the maximum amount of data used by the benchmark.
+func (c *commandBenchmarkCompression) readInputDirectory(ctx context.Context, dir string) ([]byte, error) {
+	var (
+		data  []byte
+		files int
+	)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log(ctx).Debugf("skipping %v: %v", path, err)
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		remaining := int64(defaultCompressedDataByMethod - len(data))
+		if remaining <= 0 {
+			return filepath.SkipAll
+		}
+
+		b, err := readFilePrefix(path, remaining)
+		if err != nil {
+			log(ctx).Debugf("unable to read %v: %v", path, err)
+			return nil
+		}
+
+		data = append(data, b...)
+		files++
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error walking directory")
+	}
+
+	if len(data) >= defaultCompressedDataByMethod {
+		log(ctx).Infof("NOTICE: The provided input directory is too big, using first %v.", units.BytesStringBase2(int64(len(data))))
+	}
+
+	log(ctx).Infof("Read %v from %v files in %q.", units.BytesString(len(data)), files, dir)
+
+	return data, nil
+}
+
+func (c *commandBenchmarkCompression) readInputFile(ctx context.Context, fname string) ([]byte, error) {
+	f, err := os.Open(fname) //nolint:gosec
 	if err != nil {
 		return nil, errors.Wrap(err, "error opening input file")
 	}
@@ -109,13 +184,22 @@ func (c *commandBenchmarkCompression) shouldIncludeAlgorithm(name compression.Na
 func (c *commandBenchmarkCompression) run(ctx context.Context) error {
 	var benchmarkCompression, benchmarkDecompression bool
 
-	data, err := c.readInputFile(ctx)
+	if (c.dataFile == "") == (c.allFilesIn == "") {
+		return errors.New("exactly one of --data-file or --all-files-in must be provided")
+	}
+
+	if c.allFilesIn != "" {
+		return c.runCorpus(ctx)
+	}
+
+	data, err := c.readInput(ctx)
 	if err != nil {
 		return err
 	}
 
+	// empty input can't be benchmarked and would result in invalid compression ratios.
 	if len(data) == 0 {
-		return errors.New("empty data file")
+		return errors.Errorf("no data to benchmark in %v", c.dataFile)
 	}
 
 	repeatCount := c.repeat
@@ -168,7 +252,7 @@ func (c *commandBenchmarkCompression) run(ctx context.Context) error {
 func (c *commandBenchmarkCompression) runCompression(ctx context.Context, data []byte, repeatCount int, algorithms map[compression.Name]compression.Compressor) error {
 	var results []compressionBechmarkResult
 
-	log(ctx).Infof("Compressing input file %q (%v) using %v compression methods.", c.dataFile, units.BytesString(len(data)), len(algorithms))
+	log(ctx).Infof("Compressing input %q (%v) using %v compression methods.", c.dataFile, units.BytesString(len(data)), len(algorithms))
 
 	for name, comp := range algorithms {
 		log(ctx).Infof("Benchmarking compressor '%v'...", name)
@@ -235,7 +319,7 @@ func (c *commandBenchmarkCompression) runCompression(ctx context.Context, data [
 	}
 
 	c.sortResults(results)
-	c.printResults(results)
+	c.printResults(results, len(data))
 
 	return nil
 }
@@ -243,7 +327,7 @@ func (c *commandBenchmarkCompression) runCompression(ctx context.Context, data [
 func (c *commandBenchmarkCompression) runDecompression(ctx context.Context, data []byte, repeatCount int, algorithms map[compression.Name]compression.Compressor) error {
 	var results []compressionBechmarkResult
 
-	log(ctx).Infof("Decompressing input file %q (%v) using %v compression methods.", c.dataFile, units.BytesString(len(data)), len(algorithms))
+	log(ctx).Infof("Decompressing input %q (%v) using %v compression methods.", c.dataFile, units.BytesString(len(data)), len(algorithms))
 
 	var compressedInput gather.WriteBuffer
 	defer compressedInput.Close()
@@ -304,7 +388,7 @@ func (c *commandBenchmarkCompression) runDecompression(ctx context.Context, data
 	}
 
 	c.sortResults(results)
-	c.printResults(results)
+	c.printResults(results, len(data))
 
 	return nil
 }
@@ -326,9 +410,9 @@ func (c *commandBenchmarkCompression) sortResults(results []compressionBechmarkR
 	}
 }
 
-func (c *commandBenchmarkCompression) printResults(results []compressionBechmarkResult) {
-	c.out.printStdout("     %-26v %-12v %-12v %v\n", "Compression", "Compressed", "Throughput", "Allocs   Memory Usage")
-	c.out.printStdout("------------------------------------------------------------------------------------------------\n")
+func (c *commandBenchmarkCompression) printResults(results []compressionBechmarkResult, inputLength int) {
+	c.out.printStdout("     %-26v %-12v %-7v %-12v %v\n", "Compression", "Compressed", "Ratio", "Throughput", "Allocs   Memory Usage")
+	c.out.printStdout("--------------------------------------------------------------------------------------------------------\n")
 
 	for ndx, r := range results {
 		maybeDeprecated := ""
@@ -336,10 +420,11 @@ func (c *commandBenchmarkCompression) printResults(results []compressionBechmark
 			maybeDeprecated = " (deprecated)"
 		}
 
-		c.out.printStdout("%3d. %-26v %-12v %-12v/s %-8v %v%v",
+		c.out.printStdout("%3d. %-26v %-12v %-7.3f %-12v/s %-8v %v%v",
 			ndx,
 			r.compression,
 			units.BytesString(r.compressedSize),
+			float64(r.compressedSize)/float64(inputLength),
 			units.BytesString(r.throughput),
 			r.allocations,
 			units.BytesString(r.allocBytes),
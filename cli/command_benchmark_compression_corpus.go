@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo/compression"
+)
+
+// noExtension is the name of the group of files without extension.
+const noExtension = "(none)"
+
+// corpusCompressionResult describes the result of compressing a group of files using a single algorithm.
+type corpusCompressionResult struct {
+	Compression     compression.Name `json:"compression"`
+	CompressedBytes int64            `json:"compressedBytes"`
+	Ratio           float64          `json:"ratio"`
+	Throughput      float64          `json:"throughput"`
+}
+
+// extensionCompressionResults describes the results of compressing files with the same extension.
+type extensionCompressionResults struct {
+	Extension   string                    `json:"extension"`
+	Files       int                       `json:"files"`
+	Bytes       int64                     `json:"bytes"`
+	Recommended compression.Name          `json:"recommended"`
+	Results     []corpusCompressionResult `json:"results"`
+}
+
+type corpusCompressionBenchmark struct {
+	Extensions []extensionCompressionResults `json:"extensions"`
+	Overall    extensionCompressionResults   `json:"overall"`
+}
+
+// corpusSample holds contents of files sampled for a single extension.
+type corpusSample struct {
+	files [][]byte
+	bytes int64
+}
+
+func (c *commandBenchmarkCompression) runCorpus(ctx context.Context) error {
+	samples, err := c.sampleCorpus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(samples) == 0 {
+		return errors.Errorf("no files found in %v", c.allFilesIn)
+	}
+
+	var algorithms []compression.Name
+
+	for name := range compression.ByName {
+		if c.shouldIncludeAlgorithm(name) {
+			algorithms = append(algorithms, name)
+		}
+	}
+
+	sort.Slice(algorithms, func(i, j int) bool { return algorithms[i] < algorithms[j] })
+
+	exts := make([]string, 0, len(samples))
+	for ext := range samples {
+		exts = append(exts, ext)
+	}
+
+	sort.Strings(exts)
+
+	var result corpusCompressionBenchmark
+
+	overall := map[compression.Name]*corpusCompressionResult{}
+	overallDuration := map[compression.Name]float64{}
+
+	result.Overall.Extension = "overall"
+
+	for _, ext := range exts {
+		s := samples[ext]
+
+		log(ctx).Infof("Benchmarking %v files with extension %v (%v) using %v compression methods...", len(s.files), ext, units.BytesString(s.bytes), len(algorithms))
+
+		er := extensionCompressionResults{Extension: ext, Files: len(s.files), Bytes: s.bytes}
+
+		for _, name := range algorithms {
+			r, seconds, err := c.compressCorpusSample(name, s)
+			if err != nil {
+				return err
+			}
+
+			er.Results = append(er.Results, r)
+
+			if overall[name] == nil {
+				overall[name] = &corpusCompressionResult{Compression: name}
+			}
+
+			overall[name].CompressedBytes += r.CompressedBytes
+			overallDuration[name] += seconds
+		}
+
+		er.Recommended = c.recommendAlgorithm(er.Results)
+		result.Extensions = append(result.Extensions, er)
+
+		result.Overall.Files += er.Files
+		result.Overall.Bytes += er.Bytes
+	}
+
+	for _, name := range algorithms {
+		r := *overall[name]
+		r.Ratio = float64(r.CompressedBytes) / float64(max(result.Overall.Bytes, 1))
+
+		if d := overallDuration[name]; d > 0 {
+			r.Throughput = float64(result.Overall.Bytes) / d
+		}
+
+		result.Overall.Results = append(result.Overall.Results, r)
+	}
+
+	result.Overall.Recommended = c.recommendAlgorithm(result.Overall.Results)
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(result))
+		return nil
+	}
+
+	c.printCorpusResults(result)
+
+	return nil
+}
+
+// sampleCorpus walks the --all-files-in directory and reads a sample of files for each extension.
+func (c *commandBenchmarkCompression) sampleCorpus(ctx context.Context) (map[string]*corpusSample, error) {
+	samples := map[string]*corpusSample{}
+	maxBytes := int64(c.maxBytesPerExtension)
+
+	err := filepath.WalkDir(c.allFilesIn, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log(ctx).Debugf("skipping %v: %v", path, err)
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if ext == "" {
+			ext = noExtension
+		}
+
+		s := samples[ext]
+		if s == nil {
+			s = &corpusSample{}
+			samples[ext] = s
+		}
+
+		if len(s.files) >= c.filesPerExtension || s.bytes >= maxBytes {
+			return nil
+		}
+
+		data, err := readFilePrefix(path, maxBytes-s.bytes)
+		if err != nil {
+			log(ctx).Debugf("unable to read %v: %v", path, err)
+			return nil
+		}
+
+		if len(data) == 0 {
+			return nil
+		}
+
+		s.files = append(s.files, data)
+		s.bytes += int64(len(data))
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error walking directory")
+	}
+
+	for ext, s := range samples {
+		if len(s.files) == 0 {
+			delete(samples, ext)
+		}
+	}
+
+	return samples, nil
+}
+
+func readFilePrefix(path string, maxBytes int64) ([]byte, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening file")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes))
+
+	return data, errors.Wrap(err, "error reading file")
+}
+
+// compressCorpusSample compresses all files in the sample using the provided algorithm and returns
+// the result along with the number of seconds it took to compress the sample once.
+func (c *commandBenchmarkCompression) compressCorpusSample(name compression.Name, s *corpusSample) (corpusCompressionResult, float64, error) {
+	comp := compression.ByName[name]
+	repeat := max(c.repeat, 1)
+
+	var (
+		compressed bytes.Buffer
+		total      int64
+	)
+
+	tt := timetrack.Start()
+
+	for i := range repeat {
+		for _, f := range s.files {
+			compressed.Reset()
+
+			if err := comp.Compress(&compressed, bytes.NewReader(f)); err != nil {
+				return corpusCompressionResult{}, 0, errors.Wrapf(err, "compression %q failed", name)
+			}
+
+			if i == 0 {
+				total += int64(compressed.Len())
+			}
+		}
+	}
+
+	dur, perSecond := tt.Completed(float64(s.bytes) * float64(repeat))
+
+	return corpusCompressionResult{
+		Compression:     name,
+		CompressedBytes: total,
+		Ratio:           float64(total) / float64(max(s.bytes, 1)),
+		Throughput:      perSecond,
+	}, dur.Seconds() / float64(repeat), nil
+}
+
+// recommendAlgorithm returns the algorithm with the best compression ratio among algorithms whose throughput is acceptable,
+// or the fastest algorithm if none of them is fast enough.
+func (c *commandBenchmarkCompression) recommendAlgorithm(results []corpusCompressionResult) compression.Name {
+	var best, fastest *corpusCompressionResult
+
+	for i := range results {
+		r := &results[i]
+
+		if fastest == nil || r.Throughput > fastest.Throughput {
+			fastest = r
+		}
+
+		if r.Throughput < c.minThroughputMB*1e6 {
+			continue
+		}
+
+		if best == nil || r.Ratio < best.Ratio {
+			best = r
+		}
+	}
+
+	switch {
+	case best != nil:
+		return best.Compression
+	case fastest != nil:
+		return fastest.Compression
+	default:
+		return ""
+	}
+}
+
+func (c *commandBenchmarkCompression) printCorpusResults(result corpusCompressionBenchmark) {
+	c.out.printStdout("%-12v %6v %10v  %-26v %7v %v\n", "Extension", "Files", "Size", "Recommended", "Ratio", "Throughput")
+	c.out.printStdout("------------------------------------------------------------------------------------------------\n")
+
+	for _, er := range append(result.Extensions, result.Overall) {
+		var rec corpusCompressionResult
+
+		for _, r := range er.Results {
+			if r.Compression == er.Recommended {
+				rec = r
+			}
+		}
+
+		c.out.printStdout("%-12v %6v %10v  %-26v %6.1f%% %v/s\n",
+			er.Extension,
+			er.Files,
+			units.BytesString(er.Bytes),
+			er.Recommended,
+			100*rec.Ratio, //nolint:mnd
+			units.BytesString(rec.Throughput),
+		)
+	}
+}
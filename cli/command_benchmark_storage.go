@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	atunits "github.com/alecthomas/units"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// prefix of blobs written by the storage benchmark, followed by a random UUID.
+const storageBenchmarkBlobPrefix = "zbench-"
+
+type commandBenchmarkStorage struct {
+	blobSize  atunits.Base2Bytes
+	blobCount int
+	listCount int
+	parallel  int
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandBenchmarkStorage) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("storage", "Measure latency and throughput of the repository storage")
+	cmd.Flag("blob-size", "Size of each test blob").Default("4MB").BytesVar(&c.blobSize)
+	cmd.Flag("blob-count", "Number of test blobs to write, read and delete").Default("20").IntVar(&c.blobCount)
+	cmd.Flag("list-count", "Number of times to list the test blobs").Default("5").IntVar(&c.listCount)
+	cmd.Flag("parallel", "Number of parallel goroutines").Default("1").IntVar(&c.parallel)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+// storageBenchmarkResult summarizes the timings of a single storage operation.
+type storageBenchmarkResult struct {
+	Operation      string        `json:"operation"`
+	Count          int           `json:"count"`
+	Bytes          int64         `json:"bytes,omitempty"`
+	Duration       time.Duration `json:"duration"`
+	OpsPerSecond   float64       `json:"opsPerSecond"`
+	BytesPerSecond float64       `json:"bytesPerSecond,omitempty"`
+	P50            time.Duration `json:"p50"`
+	P95            time.Duration `json:"p95"`
+	P99            time.Duration `json:"p99"`
+}
+
+func (c *commandBenchmarkStorage) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	if c.blobCount <= 0 || c.parallel <= 0 {
+		return errors.New("--blob-count and --parallel must be positive")
+	}
+
+	st := rep.BlobStorage()
+	prefix := blob.ID(storageBenchmarkBlobPrefix + uuid.NewString())
+
+	// clean up even if the benchmark was canceled.
+	defer c.cleanup(context.WithoutCancel(ctx), st, prefix)
+
+	data := make([]byte, c.blobSize)
+	if _, err := rand.Read(data); err != nil {
+		return errors.Wrap(err, "unable to generate test data")
+	}
+
+	ids := make([]blob.ID, c.blobCount)
+	for i := range ids {
+		ids[i] = blob.ID(fmt.Sprintf("%v-%06d", prefix, i))
+	}
+
+	log(ctx).Infof("Benchmarking storage using %v blobs of %v (parallelism %v)", c.blobCount, units.BytesString(int64(len(data))), c.parallel)
+
+	var results []storageBenchmarkResult
+
+	r, err := c.measure(ctx, "write", ids, int64(len(data)), func(ctx context.Context, id blob.ID) error {
+		return errors.Wrap(st.PutBlob(ctx, id, gather.FromSlice(data), blob.PutOptions{}), "error writing blob")
+	})
+	if err != nil {
+		return err
+	}
+
+	results = append(results, r)
+
+	r, err = c.measure(ctx, "read", ids, int64(len(data)), func(ctx context.Context, id blob.ID) error {
+		var tmp gather.WriteBuffer
+		defer tmp.Close()
+
+		return errors.Wrap(st.GetBlob(ctx, id, 0, -1, &tmp), "error reading blob")
+	})
+	if err != nil {
+		return err
+	}
+
+	results = append(results, r)
+
+	listIDs := make([]blob.ID, max(c.listCount, 1))
+	for i := range listIDs {
+		listIDs[i] = prefix
+	}
+
+	r, err = c.measure(ctx, "list", listIDs, 0, func(ctx context.Context, p blob.ID) error {
+		bms, err := blob.ListAllBlobs(ctx, st, p)
+		if err != nil {
+			return errors.Wrap(err, "error listing blobs")
+		}
+
+		if len(bms) != len(ids) {
+			return errors.Errorf("unexpected number of blobs listed: %v, want %v", len(bms), len(ids))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	results = append(results, r)
+
+	r, err = c.measure(ctx, "delete", ids, 0, func(ctx context.Context, id blob.ID) error {
+		return errors.Wrap(st.DeleteBlob(ctx, id), "error deleting blob")
+	})
+	if err != nil {
+		return err
+	}
+
+	results = append(results, r)
+
+	c.printResults(results)
+
+	return nil
+}
+
+// measure runs the provided operation once for each argument using c.parallel workers and returns its timings.
+func (c *commandBenchmarkStorage) measure(ctx context.Context, op string, args []blob.ID, bytesPerOp int64, run func(ctx context.Context, arg blob.ID) error) (storageBenchmarkResult, error) {
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+	)
+
+	argsCh := make(chan blob.ID, len(args))
+	for _, a := range args {
+		argsCh <- a
+	}
+
+	close(argsCh)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	tt := timetrack.Start()
+
+	for range c.parallel {
+		eg.Go(func() error {
+			for a := range argsCh {
+				timer := timetrack.StartTimer()
+
+				if err := run(ctx, a); err != nil {
+					return err
+				}
+
+				dt := timer.Elapsed()
+
+				mu.Lock()
+				latencies = append(latencies, dt)
+				mu.Unlock()
+			}
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return storageBenchmarkResult{}, errors.Wrapf(err, "%v benchmark failed", op)
+	}
+
+	dur, opsPerSecond := tt.Completed(float64(len(args)))
+
+	slices.Sort(latencies)
+
+	r := storageBenchmarkResult{
+		Operation:    op,
+		Count:        len(args),
+		Bytes:        bytesPerOp * int64(len(args)),
+		Duration:     dur,
+		OpsPerSecond: opsPerSecond,
+		P50:          sortedPercentile(latencies, 50),
+		P95:          sortedPercentile(latencies, 95),
+		P99:          sortedPercentile(latencies, 99),
+	}
+
+	if dur > 0 {
+		r.BytesPerSecond = float64(r.Bytes) / dur.Seconds()
+	}
+
+	return r, nil
+}
+
+func (c *commandBenchmarkStorage) printResults(results []storageBenchmarkResult) {
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(results))
+		return
+	}
+
+	c.out.printStdout("%-8v %6v %12v %14v %10v %10v %10v\n", "Op", "Count", "Ops/s", "Throughput", "p50", "p95", "p99")
+	c.out.printStdout("-----------------------------------------------------------------------------\n")
+
+	for _, r := range results {
+		throughput := "-"
+		if r.Bytes > 0 {
+			throughput = units.BytesPerSecondsString(r.BytesPerSecond)
+		}
+
+		c.out.printStdout("%-8v %6v %12.1f %14v %10v %10v %10v\n",
+			r.Operation, r.Count, r.OpsPerSecond, throughput,
+			r.P50.Round(time.Microsecond), r.P95.Round(time.Microsecond), r.P99.Round(time.Microsecond))
+	}
+}
+
+// cleanup removes any test blobs left behind, for example when the benchmark fails midway.
+func (c *commandBenchmarkStorage) cleanup(ctx context.Context, st blob.Storage, prefix blob.ID) {
+	bms, err := blob.ListAllBlobs(ctx, st, prefix)
+	if err != nil {
+		log(ctx).Errorf("unable to list benchmark blobs for cleanup: %v", err)
+		return
+	}
+
+	var ids []blob.ID
+
+	for _, bm := range bms {
+		ids = append(ids, bm.BlobID)
+	}
+
+	if err := blob.DeleteMultiple(ctx, st, ids, max(c.parallel, 1)); err != nil {
+		log(ctx).Errorf("unable to delete benchmark blobs with prefix %v: %v", prefix, err)
+	}
+}
+
+// sortedPercentile returns the nearest-rank percentile of the provided sorted values.
+func sortedPercentile[T any](sorted []T, percent int) T {
+	if len(sorted) == 0 {
+		var zero T
+		return zero
+	}
+
+	rank := (len(sorted)*percent + 99) / 100 //nolint:mnd
+
+	return sorted[max(rank, 1)-1]
+}
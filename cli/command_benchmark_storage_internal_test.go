@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedPercentile(t *testing.T) {
+	require.Equal(t, uint32(0), sortedPercentile[uint32](nil, 50))
+
+	sizes := []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	require.Equal(t, uint32(5), sortedPercentile(sizes, 50))
+	require.Equal(t, uint32(10), sortedPercentile(sizes, 95))
+	require.Equal(t, uint32(1), sortedPercentile(sizes, 0))
+	require.Equal(t, uint32(10), sortedPercentile(sizes, 100))
+	require.Equal(t, uint32(7), sortedPercentile([]uint32{7}, 50))
+}
@@ -2,10 +2,15 @@
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -58,3 +63,99 @@ func TestCommandBenchmarkCompression(t *testing.T) {
 	e.RunAndExpectSuccess(t, "benchmark", "compression", "--data-file", testFile, "--repeat=2", "--verify-stable", "--print-options")
 	e.RunAndExpectSuccess(t, "benchmark", "compression", "--data-file", testFile, "--repeat=2", "--by-size")
 }
+
+func TestCommandBenchmarkCompressionInput(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "subdir"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), bytes.Repeat([]byte("hello world "), 1000), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "subdir", "b.txt"), bytes.Repeat([]byte("foo bar "), 1000), 0o600))
+
+	lines := e.RunAndExpectSuccess(t, "benchmark", "compression", "--data-file", dir, "--algorithms=gzip", "--repeat=1", "--operations=compress")
+	require.Contains(t, lines[0], "Ratio")
+	require.Contains(t, lines[2], "gzip")
+
+	e.RunAndExpectSuccess(t, "benchmark", "compression", "--data-file", filepath.Join(dir, "a.txt"), "--algorithms=gzip", "--repeat=1")
+	e.RunAndExpectFailure(t, "benchmark", "compression", "--data-file", dir, "--all-files-in", dir)
+	e.RunAndExpectFailure(t, "benchmark", "compression")
+
+	// empty input is rejected.
+	emptyDir := testutil.TempDirectory(t)
+	e.RunAndExpectFailure(t, "benchmark", "compression", "--data-file", emptyDir)
+
+	emptyFile := filepath.Join(emptyDir, "empty.txt")
+	require.NoError(t, os.WriteFile(emptyFile, nil, 0o600))
+	e.RunAndExpectFailure(t, "benchmark", "compression", "--data-file", emptyFile)
+}
+
+func TestCommandBenchmarkCompressionAllFilesIn(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "subdir"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.txt"), bytes.Repeat([]byte("hello world "), 1000), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "subdir", "b.TXT"), bytes.Repeat([]byte("foo bar "), 1000), 0o600))
+
+	rnd := make([]byte, 10000)
+	rand.Read(rnd)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.bin"), rnd, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "noext"), rnd, 0o600))
+
+	e.RunAndExpectFailure(t, "benchmark", "compression")
+
+	lines := e.RunAndExpectSuccess(t, "benchmark", "compression", "--all-files-in", dir, "--algorithms=gzip,zstd", "--min-throughput-mb=0")
+	require.Contains(t, lines[len(lines)-1], "overall")
+
+	var result struct {
+		Extensions []struct {
+			Extension   string `json:"extension"`
+			Files       int    `json:"files"`
+			Recommended string `json:"recommended"`
+		} `json:"extensions"`
+		Overall struct {
+			Files int `json:"files"`
+		} `json:"overall"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(strings.Join(e.RunAndExpectSuccess(t, "benchmark", "compression", "--all-files-in", dir, "--algorithms=gzip,zstd", "--files-per-extension=1", "--json"), "\n")), &result))
+	require.Len(t, result.Extensions, 3)
+	require.Equal(t, "(none)", result.Extensions[0].Extension)
+	require.Equal(t, ".bin", result.Extensions[1].Extension)
+	require.Equal(t, ".txt", result.Extensions[2].Extension)
+	require.Equal(t, 1, result.Extensions[2].Files)
+	require.NotEmpty(t, result.Extensions[2].Recommended)
+	require.Equal(t, 3, result.Overall.Files)
+}
+
+func TestCommandBenchmarkStorage(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	blobsBefore := e.RunAndExpectSuccess(t, "blob", "list")
+
+	lines := e.RunAndExpectSuccess(t, "benchmark", "storage", "--blob-count=3", "--blob-size=1KB", "--parallel=2")
+	require.Len(t, lines, 6)
+
+	var results []map[string]any
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "benchmark", "storage", "--blob-count=3", "--blob-size=1KB", "--list-count=2", "--json"), &results)
+	require.Len(t, results, 4)
+	require.Equal(t, "write", results[0]["operation"])
+	require.Equal(t, "list", results[2]["operation"])
+	require.InDelta(t, 2, results[2]["count"], 0)
+	require.LessOrEqual(t, results[1]["p50"], results[1]["p99"])
+
+	// test blobs are removed.
+	require.Equal(t, blobsBefore, e.RunAndExpectSuccess(t, "blob", "list"))
+}
@@ -5,6 +5,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/maintenance"
@@ -12,6 +13,7 @@
 
 type commandBlobGC struct {
 	delete   string
+	dryRun   bool
 	parallel int
 	prefix   string
 	safety   maintenance.SafetyParameters
@@ -22,6 +24,7 @@ type commandBlobGC struct {
 func (c *commandBlobGC) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("gc", "Garbage-collect unused blobs")
 	cmd.Flag("delete", "Whether to delete unused blobs").StringVar(&c.delete)
+	cmd.Flag("dry-run", "Only report unused blobs and reclaimable space, do not delete anything").BoolVar(&c.dryRun)
 	cmd.Flag("parallel", "Number of parallel blob scans").Default("16").IntVar(&c.parallel)
 	cmd.Flag("prefix", "Only GC blobs with given prefix").StringVar(&c.prefix)
 	safetyFlagVar(cmd, &c.safety)
@@ -33,18 +36,29 @@ func (c *commandBlobGC) setup(svc appServices, parent commandParent) {
 func (c *commandBlobGC) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
 	c.svc.advancedCommand(ctx)
 
+	if c.dryRun && c.delete == "yes" {
+		return errors.New("--dry-run cannot be combined with --delete=yes")
+	}
+
 	opts := maintenance.DeleteUnreferencedBlobsOptions{
-		DryRun:   c.delete != "yes",
+		DryRun:   c.dryRun || c.delete != "yes",
 		Parallel: c.parallel,
 		Prefix:   blob.ID(c.prefix),
 	}
 
-	n, err := maintenance.DeleteUnreferencedBlobs(ctx, rep, opts, c.safety)
+	n, size, err := maintenance.DeleteUnreferencedBlobsWithListingCheck(ctx, rep, opts, c.safety)
 	if err != nil {
 		return errors.Wrap(err, "error deleting unreferenced blobs")
 	}
 
-	if opts.DryRun && n > 0 {
+	if !opts.DryRun {
+		log(ctx).Infof("Deleted %v unreferenced blobs older than %v (%v reclaimed).", n, c.safety.BlobDeleteMinAge, units.BytesString(size))
+		return nil
+	}
+
+	log(ctx).Infof("Found %v unreferenced blobs older than %v (%v reclaimable).", n, c.safety.BlobDeleteMinAge, units.BytesString(size))
+
+	if n > 0 && !c.dryRun {
 		log(ctx).Info("Pass --delete=yes to delete.")
 	}
 
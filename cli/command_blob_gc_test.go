@@ -0,0 +1,51 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestBlobGC(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	ctx := testlogging.Context(t)
+
+	st, err := filesystem.New(ctx, &filesystem.Options{Path: env.RepoDir}, false)
+	require.NoError(t, err)
+
+	defer st.Close(ctx)
+
+	const orphanBlobID blob.ID = "pdeadbeef1"
+
+	require.NoError(t, st.PutBlob(ctx, orphanBlobID, gather.FromSlice([]byte("hello world")), blob.PutOptions{}))
+
+	env.RunAndExpectFailure(t, "blob", "gc", "--advanced-commands=enabled", "--dry-run", "--delete=yes")
+	env.RunAndExpectFailure(t, "blob", "gc", "--advanced-commands=enabled", "--safety-blob-delete-min-age=-1h")
+
+	// the blob is too new to be deleted with the default grace period.
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "blob", "gc", "--advanced-commands=enabled", "--delete=yes")
+	mustGetLineContaining(t, stderr, "Deleted 0 unreferenced blobs")
+
+	_, stderr = env.RunAndExpectSuccessWithErrOut(t, "blob", "gc", "--advanced-commands=enabled", "--safety-blob-delete-min-age=0", "--dry-run")
+	mustGetLineContaining(t, stderr, "Found 1 unreferenced blobs older than 0s (11 B reclaimable)")
+
+	_, err = st.GetMetadata(ctx, orphanBlobID)
+	require.NoError(t, err)
+
+	_, stderr = env.RunAndExpectSuccessWithErrOut(t, "blob", "gc", "--advanced-commands=enabled", "--safety-blob-delete-min-age=0", "--delete=yes")
+	mustGetLineContaining(t, stderr, "Deleted 1 unreferenced blobs older than 0s (11 B reclaimed)")
+
+	_, err = st.GetMetadata(ctx, orphanBlobID)
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+}
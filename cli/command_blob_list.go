@@ -2,8 +2,12 @@
 
 import (
 	"context"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/kopia/kopia/internal/epoch"
 	"github.com/kopia/kopia/internal/repodiag"
 	"github.com/kopia/kopia/repo"
@@ -17,6 +21,10 @@ type commandBlobList struct {
 	blobListMinSize       int64
 	blobListMaxSize       int64
 	dataOnly              bool
+	regex                 string
+	countOnly             bool
+
+	re *regexp.Regexp
 
 	jo  jsonOutput
 	out textOutput
@@ -29,12 +37,34 @@ func (c *commandBlobList) setup(svc appServices, parent commandParent) {
 	cmd.Flag("min-size", "Minimum size").Int64Var(&c.blobListMinSize)
 	cmd.Flag("max-size", "Maximum size").Int64Var(&c.blobListMaxSize)
 	cmd.Flag("data-only", "Only list data blobs").BoolVar(&c.dataOnly)
+	cmd.Flag("regex", "Only list blobs whose ID matches the provided regular expression").StringVar(&c.regex)
+	cmd.Flag("count-only", "Only print the number and total size of blobs for each prefix category").BoolVar(&c.countOnly)
 	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
 }
 
+// blobCategoryStats holds the number and total size of blobs in a prefix category.
+type blobCategoryStats struct {
+	Prefix    string `json:"prefix"`
+	Count     int64  `json:"count"`
+	TotalSize int64  `json:"totalSize"`
+}
+
 func (c *commandBlobList) run(ctx context.Context, rep repo.DirectRepository) error {
+	if c.regex != "" {
+		re, err := regexp.Compile(c.regex)
+		if err != nil {
+			return errors.Wrap(err, "invalid --regex")
+		}
+
+		c.re = re
+	}
+
+	if c.countOnly {
+		return c.runCountOnly(ctx, rep)
+	}
+
 	var jl jsonList
 
 	jl.begin(&c.jo)
@@ -56,6 +86,83 @@ func (c *commandBlobList) run(ctx context.Context, rep repo.DirectRepository) er
 	})
 }
 
+func (c *commandBlobList) runCountOnly(ctx context.Context, rep repo.DirectRepository) error {
+	stats := map[string]*blobCategoryStats{}
+
+	if err := rep.BlobReader().ListBlobs(ctx, blob.ID(c.blobListPrefix), func(b blob.Metadata) error {
+		if !c.shouldInclude(b) {
+			return nil
+		}
+
+		cat := blobCategory(b.BlobID)
+
+		st := stats[cat]
+		if st == nil {
+			st = &blobCategoryStats{Prefix: cat}
+			stats[cat] = st
+		}
+
+		st.Count++
+		st.TotalSize += b.Length
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error listing blobs")
+	}
+
+	var (
+		result []*blobCategoryStats
+		total  blobCategoryStats
+	)
+
+	for _, st := range stats {
+		result = append(result, st)
+		total.Count += st.Count
+		total.TotalSize += st.TotalSize
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Prefix < result[j].Prefix
+	})
+
+	if c.jo.jsonOutput {
+		var jl jsonList
+
+		jl.begin(&c.jo)
+		defer jl.end()
+
+		for _, st := range result {
+			jl.emit(st)
+		}
+
+		return nil
+	}
+
+	for _, st := range result {
+		c.out.printStdout("%-10v %10v %v\n", st.Prefix, st.Count, st.TotalSize)
+	}
+
+	c.out.printStdout("%-10v %10v %v\n", "total", total.Count, total.TotalSize)
+
+	return nil
+}
+
+// blobCategory returns the prefix category of the provided blob ID, which is the first character
+// of the ID except for well-known multi-character prefixes.
+func blobCategory(id blob.ID) string {
+	for _, p := range []string{"kopia.", repodiag.LogBlobPrefix} {
+		if strings.HasPrefix(string(id), p) {
+			return p
+		}
+	}
+
+	if id == "" {
+		return ""
+	}
+
+	return string(id[0:1])
+}
+
 func (c *commandBlobList) shouldInclude(b blob.Metadata) bool {
 	if c.dataOnly {
 		if strings.HasPrefix(string(b.BlobID), indexblob.V0IndexBlobPrefix) {
@@ -89,5 +196,9 @@ func (c *commandBlobList) shouldInclude(b blob.Metadata) bool {
 		}
 	}
 
+	if c.re != nil && !c.re.MatchString(string(b.BlobID)) {
+		return false
+	}
+
 	return true
 }
@@ -0,0 +1,58 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+type blobCategoryStats struct {
+	Prefix    string `json:"prefix"`
+	Count     int64  `json:"count"`
+	TotalSize int64  `json:"totalSize"`
+}
+
+func TestBlobListRegexAndCountOnly(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	all := env.RunAndExpectSuccess(t, "blob", "list")
+
+	packs := env.RunAndExpectSuccess(t, "blob", "list", "--regex", "^[pq]")
+	require.NotEmpty(t, packs)
+	require.Less(t, len(packs), len(all))
+
+	for _, l := range packs {
+		require.True(t, strings.HasPrefix(l, "p") || strings.HasPrefix(l, "q"), l)
+	}
+
+	env.RunAndExpectFailure(t, "blob", "list", "--regex", "[")
+
+	var stats []blobCategoryStats
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "blob", "list", "--count-only", "--json"), &stats)
+
+	var total int64
+
+	for _, st := range stats {
+		require.Positive(t, st.Count)
+		require.Positive(t, st.TotalSize)
+
+		total += st.Count
+	}
+
+	require.EqualValues(t, len(all), total)
+
+	lines := env.RunAndExpectSuccess(t, "blob", "list", "--count-only", "--prefix", "kopia.")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "kopia. "), lines[0])
+	require.True(t, strings.HasPrefix(lines[1], "total "), lines[1])
+}
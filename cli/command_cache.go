@@ -5,7 +5,9 @@ type commandCache struct {
 	info     commandCacheInfo
 	prefetch commandCachePrefetch
 	set      commandCacheSetParams
+	stats    commandCacheStats
 	sync     commandCacheSync
+	warm     commandCacheWarm
 }
 
 func (c *commandCache) setup(svc appServices, parent commandParent) {
@@ -15,5 +17,7 @@ func (c *commandCache) setup(svc appServices, parent commandParent) {
 	c.info.setup(svc, cmd)
 	c.prefetch.setup(svc, cmd)
 	c.set.setup(svc, cmd)
+	c.stats.setup(svc, cmd)
 	c.sync.setup(svc, cmd)
+	c.warm.setup(svc, cmd)
 }
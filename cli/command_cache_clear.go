@@ -27,7 +27,7 @@ func (c *commandCacheClear) setup(svc appServices, parent commandParent) {
 }
 
 func (c *commandCacheClear) run(ctx context.Context, rep repo.Repository) error {
-	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName())
+	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName(), c.svc.configProfileName())
 	if err != nil {
 		return errors.Wrap(err, "error getting caching options")
 	}
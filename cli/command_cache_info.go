@@ -31,7 +31,7 @@ func (c *commandCacheInfo) setup(svc appServices, parent commandParent) {
 }
 
 func (c *commandCacheInfo) run(ctx context.Context, _ repo.Repository) error {
-	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName())
+	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName(), c.svc.configProfileName())
 	if err != nil {
 		return errors.Wrap(err, "error getting cache options")
 	}
@@ -12,6 +12,14 @@
 	"github.com/kopia/kopia/repo/content"
 )
 
+const (
+	// defaultCacheSizeMB is the desired size of content and metadata caches of new repository connections.
+	defaultCacheSizeMB = 5000
+
+	// defaultMaxListCacheDuration is the duration of the blob list cache of new repository connections.
+	defaultMaxListCacheDuration = 30 * time.Second
+)
+
 type cacheSizeFlags struct {
 	contentCacheSizeMB      int64
 	contentCacheSizeLimitMB int64
@@ -40,6 +48,7 @@ func (c *cacheSizeFlags) setup(cmd *kingpin.CmdClause) {
 
 type commandCacheSetParams struct {
 	directory string
+	reset     bool
 
 	cacheSizeFlags
 
@@ -60,19 +69,34 @@ func (c *commandCacheSetParams) setup(svc appServices, parent commandParent) {
 	c.cacheSizeFlags.setup(cmd)
 
 	cmd.Flag("cache-directory", "Directory where to store cache files").StringVar(&c.directory)
+	cmd.Flag("reset", "Reset cache sizes, limits and durations to the defaults of new repository connections before applying other changes").BoolVar(&c.reset)
 
 	cmd.Action(svc.repositoryWriterAction(c.run))
 	c.svc = svc
 }
 
 func (c *commandCacheSetParams) run(ctx context.Context, _ repo.RepositoryWriter) error {
-	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName())
+	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName(), c.svc.configProfileName())
 	if err != nil {
 		return errors.Wrap(err, "error getting caching options")
 	}
 
 	changed := 0
 
+	if c.reset {
+		log(ctx).Info("resetting cache sizes, limits and durations to defaults")
+
+		opts.ContentCacheSizeBytes = defaultCacheSizeMB * 1e6
+		opts.ContentCacheSizeLimitBytes = 0
+		opts.MetadataCacheSizeBytes = defaultCacheSizeMB * 1e6
+		opts.MetadataCacheSizeLimitBytes = 0
+		opts.MaxListCacheDuration = content.DurationSeconds(defaultMaxListCacheDuration.Seconds())
+		opts.MinContentSweepAge = 0
+		opts.MinMetadataSweepAge = 0
+		opts.MinIndexSweepAge = 0
+		changed++
+	}
+
 	if v := c.directory; v != "" {
 		log(ctx).Infof("setting cache directory to %v", v)
 		opts.CacheDirectory = v
@@ -136,5 +160,5 @@ func (c *commandCacheSetParams) run(ctx context.Context, _ repo.RepositoryWriter
 	}
 
 	//nolint:wrapcheck
-	return repo.SetCachingOptions(ctx, c.svc.repositoryConfigFileName(), opts)
+	return repo.SetCachingOptions(ctx, c.svc.repositoryConfigFileName(), c.svc.configProfileName(), opts)
 }
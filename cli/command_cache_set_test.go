@@ -46,6 +46,18 @@ func TestCacheSet(t *testing.T) {
 	require.Contains(t, mustGetLineContaining(t, out, "min sweep age: 24h0m0s"), "metadata")
 
 	require.Contains(t, mustGetLineContaining(t, out, "55s"), "blob-list")
+
+	// reset restores the defaults of new connections, other flags are applied on top of them.
+	env.RunAndExpectSuccess(t,
+		"cache", "set",
+		"--reset",
+		"--metadata-min-sweep-age=2h",
+	)
+
+	out = env.RunAndExpectSuccess(t, "cache", "info")
+	require.Contains(t, mustGetLineContaining(t, out, "soft limit: 5 GB, hard limit: none, min sweep age: 10m0s"), "contents")
+	require.Contains(t, mustGetLineContaining(t, out, "soft limit: 5 GB, hard limit: none, min sweep age: 2h0m0s"), "metadata")
+	require.Contains(t, mustGetLineContaining(t, out, "30s"), "blob-list")
 }
 
 func mustGetLineContaining(t *testing.T, lines []string, containing string) string {
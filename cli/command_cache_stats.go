@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/cache"
+	"github.com/kopia/kopia/internal/metrics"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+)
+
+// cacheIDToSubdirectory maps IDs of caches that report metrics to their subdirectories, when the two differ.
+//
+//nolint:gochecknoglobals
+var cacheIDToSubdirectory = map[string]string{
+	"cache-storage": "server-contents",
+}
+
+type commandCacheStats struct {
+	svc appServices
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandCacheStats) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("stats", "Displays size of each cache along with hit and miss counts accumulated since the cache was cleared")
+	cmd.Action(svc.repositoryReaderAction(c.run))
+
+	c.svc = svc
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+}
+
+// cacheStatsEntry describes the contents and effectiveness of a single cache.
+type cacheStatsEntry struct {
+	Name      string  `json:"name"`
+	Path      string  `json:"path,omitempty"`
+	FileCount int     `json:"fileCount"`
+	TotalSize int64   `json:"totalSize"`
+	HitRate   float64 `json:"hitRate"`
+
+	cache.Stats
+}
+
+func (c *commandCacheStats) run(ctx context.Context, rep repo.Repository) error {
+	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName(), c.svc.configProfileName())
+	if err != nil {
+		return errors.Wrap(err, "error getting cache options")
+	}
+
+	counters := map[string]cache.Stats{}
+
+	if opts.CacheDirectory != "" {
+		// statistics of previous processes, which are saved when they close the repository.
+		counters, err = cache.ReadStatsFile(opts.CacheDirectory)
+		if err != nil {
+			return errors.Wrap(err, "error reading cache statistics")
+		}
+	}
+
+	if mp, ok := rep.(interface{ Metrics() *metrics.Registry }); ok {
+		for cacheID, st := range cache.StatsFromMetrics(mp.Metrics().Snapshot(false)) {
+			counters[cacheID] = counters[cacheID].Add(st)
+		}
+	}
+
+	result, err := cacheStatsEntries(opts.CacheDirectory, counters)
+	if err != nil {
+		return err
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(result))
+		return nil
+	}
+
+	c.out.printStdout("%-16v %8v %10v %8v %8v %10v\n", "Cache", "Files", "Size", "Hits", "Misses", "Hit Rate")
+
+	for _, e := range result {
+		c.out.printStdout("%-16v %8v %10v %8v %8v %9.1f%%\n", e.Name, e.FileCount, units.BytesString(e.TotalSize), e.HitCount, e.MissCount, e.HitRate*100) //nolint:mnd
+	}
+
+	c.out.printStderr("Hit and miss counts include all processes that used the cache since it was last cleared.\n")
+
+	return nil
+}
+
+// cacheStatsEntries returns statistics of all caches found in the cache directory or reported in the provided counters.
+func cacheStatsEntries(cacheDir string, counters map[string]cache.Stats) ([]cacheStatsEntry, error) {
+	byName := map[string]*cacheStatsEntry{}
+
+	if cacheDir != "" {
+		entries, err := os.ReadDir(cacheDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, "unable to scan cache directory")
+		}
+
+		for _, ent := range entries {
+			if !ent.IsDir() {
+				continue
+			}
+
+			subdir := filepath.Join(cacheDir, ent.Name())
+
+			fileCount, totalFileSize, err := scanCacheDir(subdir)
+			if err != nil {
+				return nil, err
+			}
+
+			byName[ent.Name()] = &cacheStatsEntry{
+				Name:      ent.Name(),
+				Path:      subdir,
+				FileCount: fileCount,
+				TotalSize: totalFileSize,
+			}
+		}
+	}
+
+	for cacheID, st := range counters {
+		name := cacheID
+		if sd, ok := cacheIDToSubdirectory[cacheID]; ok {
+			name = sd
+		}
+
+		e := byName[name]
+		if e == nil {
+			e = &cacheStatsEntry{Name: name}
+			byName[name] = e
+		}
+
+		e.Stats = st
+		e.HitRate = st.HitRate()
+	}
+
+	result := []cacheStatsEntry{}
+
+	for _, e := range byName {
+		result = append(result, *e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
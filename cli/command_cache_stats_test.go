@@ -0,0 +1,45 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestCacheStats(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	var stats []map[string]any
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "cache", "stats", "--json"), &stats)
+
+	byName := map[string]map[string]any{}
+	for _, s := range stats {
+		byName[s["name"].(string)] = s
+	}
+
+	require.Contains(t, byName, "contents")
+	require.Contains(t, byName, "metadata")
+
+	require.Positive(t, byName["metadata"]["fileCount"])
+	require.Positive(t, byName["metadata"]["totalSize"])
+
+	// counters of previous commands are saved in the cache directory.
+	var lookups float64
+
+	for _, s := range stats {
+		lookups += s["hitCount"].(float64) + s["missCount"].(float64)
+	}
+
+	require.Positive(t, lookups)
+
+	out := env.RunAndExpectSuccess(t, "cache", "stats")
+	require.Contains(t, out[0], "Hit Rate")
+	mustGetLineContaining(t, out, "metadata")
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// number of file objects passed to a single PrefetchObjects() call.
+const cacheWarmBatchSize = 1000
+
+type commandCacheWarm struct {
+	targets      []string
+	metadataOnly bool
+	hint         string
+	parallel     int
+
+	out textOutput
+}
+
+func (c *commandCacheWarm) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("warm", "Populates the local cache with data of the provided snapshots or directories")
+	cmd.Arg("target", "Snapshot ID, object ID with optional path or path of a snapshot source (latest snapshot is used)").Required().StringsVar(&c.targets)
+	cmd.Flag("metadata-only", "Only cache directory listings and file metadata, not file contents").BoolVar(&c.metadataOnly)
+	cmd.Flag("hint", "Prefetch hint").StringVar(&c.hint)
+	cmd.Flag("parallel", "Parallelism").Default("8").IntVar(&c.parallel)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+
+	c.out.setup(svc)
+}
+
+// cacheWarmer walks snapshot trees and prefetches the objects it encounters in batches.
+type cacheWarmer struct {
+	rep          repo.Repository
+	hint         string
+	metadataOnly bool
+
+	dirs       atomic.Int64
+	files      atomic.Int64
+	fileBytes  atomic.Int64
+	contents   atomic.Int64
+	throttle   timetrack.Throttle
+	prefetchMu sync.Mutex
+
+	mu sync.Mutex
+	// +checklocks:mu
+	pending []object.ID
+}
+
+func (c *commandCacheWarm) run(ctx context.Context, rep repo.Repository) error {
+	w := &cacheWarmer{
+		rep:          rep,
+		hint:         c.hint,
+		metadataOnly: c.metadataOnly,
+	}
+
+	tw, err := snapshotfs.NewTreeWalker(ctx, snapshotfs.TreeWalkerOptions{
+		EntryCallback: w.processEntry,
+		Parallelism:   c.parallel,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create tree walker")
+	}
+
+	defer tw.Close(ctx)
+
+	for _, target := range c.targets {
+		e, err := cacheWarmTargetEntry(ctx, rep, target)
+		if err != nil {
+			return err
+		}
+
+		log(ctx).Infof("Warming cache for %v...", target)
+
+		if err := tw.Process(ctx, e, target); err != nil {
+			return errors.Wrapf(err, "error walking %v", target)
+		}
+	}
+
+	if err := w.flush(ctx, w.takePending(0)); err != nil {
+		return err
+	}
+
+	if c.metadataOnly {
+		c.out.printStdout("Cached metadata of %v directories and %v files.\n", w.dirs.Load(), w.files.Load())
+	} else {
+		c.out.printStdout("Cached %v directories and %v files (%v, %v contents).\n",
+			w.dirs.Load(), w.files.Load(), units.BytesString(w.fileBytes.Load()), w.contents.Load())
+	}
+
+	return nil
+}
+
+// cacheWarmTargetEntry resolves the provided snapshot ID or object ID with path, falling back to
+// the latest snapshot of the snapshot source with the provided path.
+func cacheWarmTargetEntry(ctx context.Context, rep repo.Repository, target string) (fs.Entry, error) {
+	e, err := snapshotfs.FilesystemEntryFromIDWithPath(ctx, rep, target, false)
+	if err == nil {
+		return e, nil
+	}
+
+	si, perr := snapshot.ParseSourceInfo(target, rep.ClientOptions().Hostname, rep.ClientOptions().Username)
+	if perr != nil {
+		return nil, errors.Wrapf(err, "unable to find %v", target)
+	}
+
+	manifests, lerr := snapshot.ListSnapshots(ctx, rep, si)
+	if lerr != nil {
+		return nil, errors.Wrapf(lerr, "error listing snapshots of %v", si)
+	}
+
+	if len(manifests) == 0 {
+		return nil, errors.Wrapf(err, "unable to find %v", target)
+	}
+
+	latest := snapshot.SortByTime(manifests, true)[0]
+
+	root, err := snapshotfs.SnapshotRoot(rep, latest)
+
+	return root, errors.Wrapf(err, "unable to get root of snapshot %v", latest.ID)
+}
+
+// processEntry is invoked by the tree walker for each entry, directories have already been read at this point.
+func (w *cacheWarmer) processEntry(ctx context.Context, e fs.Entry, oid object.ID, _ string) error {
+	if w.throttle.ShouldOutput(time.Second) {
+		log(ctx).Infof("Processed %v directories and %v files (%v)...", w.dirs.Load(), w.files.Load(), units.BytesString(w.fileBytes.Load()))
+	}
+
+	if e.IsDir() {
+		w.dirs.Add(1)
+		return nil
+	}
+
+	w.files.Add(1)
+
+	if w.metadataOnly {
+		return nil
+	}
+
+	w.fileBytes.Add(e.Size())
+
+	w.mu.Lock()
+	w.pending = append(w.pending, oid)
+	w.mu.Unlock()
+
+	return w.flush(ctx, w.takePending(cacheWarmBatchSize))
+}
+
+// takePending returns pending objects once there are at least minCount of them.
+func (w *cacheWarmer) takePending(minCount int) []object.ID {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 || len(w.pending) < minCount {
+		return nil
+	}
+
+	p := w.pending
+	w.pending = nil
+
+	return p
+}
+
+func (w *cacheWarmer) flush(ctx context.Context, oids []object.ID) error {
+	if len(oids) == 0 {
+		return nil
+	}
+
+	// prefetching batches concurrently would compete for the same pack blobs.
+	w.prefetchMu.Lock()
+	defer w.prefetchMu.Unlock()
+
+	cids, err := w.rep.PrefetchObjects(ctx, oids, w.hint)
+	if err != nil {
+		return errors.Wrap(err, "error prefetching")
+	}
+
+	w.contents.Add(int64(len(cids)))
+
+	return nil
+}
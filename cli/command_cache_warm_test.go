@@ -0,0 +1,44 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/clitestutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestCacheWarm(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--cache-directory", testutil.TempDirectory(t))
+
+	srcDir := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("hello"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "sub", "b.txt"), []byte("world!"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	snapshots := clitestutil.ListSnapshotsAndExpectSuccess(t, env, srcDir)
+	require.Len(t, snapshots, 1)
+	require.Len(t, snapshots[0].Snapshots, 1)
+
+	env.RunAndExpectSuccess(t, "cache", "clear")
+
+	require.Equal(t, []string{"Cached metadata of 2 directories and 2 files."},
+		env.RunAndExpectSuccess(t, "cache", "warm", "--metadata-only", snapshots[0].Snapshots[0].SnapshotID))
+
+	// snapshot source path resolves to the latest snapshot.
+	require.Equal(t, []string{"Cached 2 directories and 2 files (11 B, 2 contents)."},
+		env.RunAndExpectSuccess(t, "cache", "warm", srcDir))
+
+	require.Equal(t, []string{"Cached 1 directories and 1 files (6 B, 1 contents)."},
+		env.RunAndExpectSuccess(t, "cache", "warm", snapshots[0].Snapshots[0].ObjectID+"/sub"))
+
+	env.RunAndExpectFailure(t, "cache", "warm", "no-such-snapshot")
+}
@@ -3,6 +3,7 @@
 type commandContent struct {
 	delete  commandContentDelete
 	list    commandContentList
+	owners  commandContentOwners
 	rewrite commandContentRewrite
 	show    commandContentShow
 	stats   commandContentStats
@@ -14,6 +15,7 @@ func (c *commandContent) setup(svc appServices, parent commandParent) {
 
 	c.delete.setup(svc, cmd)
 	c.list.setup(svc, cmd)
+	c.owners.setup(svc, cmd)
 	c.rewrite.setup(svc, cmd)
 	c.show.setup(svc, cmd)
 	c.stats.setup(svc, cmd)
@@ -6,6 +6,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/stats"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/compression"
@@ -21,6 +22,7 @@ type commandContentList struct {
 	compression    bool
 
 	contentRange contentRangeFlags
+	contentAge   contentAgeFlags
 	jo           jsonOutput
 	out          textOutput
 }
@@ -34,6 +36,7 @@ func (c *commandContentList) setup(svc appServices, parent commandParent) {
 	cmd.Flag("summary", "Summarize the list").Short('s').BoolVar(&c.summary)
 	cmd.Flag("human", "Human-readable output").Short('h').BoolVar(&c.human)
 	c.contentRange.setup(cmd)
+	c.contentAge.setup(cmd)
 	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
@@ -47,6 +50,8 @@ func (c *commandContentList) run(ctx context.Context, rep repo.DirectRepository)
 
 	var totalSize stats.CountSum
 
+	now := clock.Now()
+
 	err := rep.ContentReader().IterateContents(
 		ctx,
 		content.IterateOptions{
@@ -58,6 +63,10 @@ func(b content.Info) error {
 				return nil
 			}
 
+			if !c.contentAge.matches(b, now) {
+				return nil
+			}
+
 			totalSize.Add(int64(b.PackedLength))
 
 			switch {
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandContentOwners struct {
+	ids   []string
+	limit int
+
+	out textOutput
+	jo  jsonOutput
+}
+
+// contentOwner describes an object in a snapshot that references a particular content.
+type contentOwner struct {
+	ContentID  content.ID          `json:"contentID"`
+	ObjectID   object.ID           `json:"objectID"`
+	Path       string              `json:"path"`
+	Source     snapshot.SourceInfo `json:"source"`
+	SnapshotID manifest.ID         `json:"snapshotID"`
+}
+
+// contentOwnersFinder walks snapshot trees and records objects that reference any of the target contents.
+type contentOwnersFinder struct {
+	rep     repo.Repository
+	targets map[content.ID]bool
+	limit   int
+
+	// reverse index of objects that have already been examined, cached for the duration of the invocation
+	// so that objects shared between snapshots are only read once.
+	objectTargets map[object.ID][]content.ID
+
+	// references found in subtrees of directories that have been fully walked, so that directories
+	// shared between snapshots are only walked once.
+	subtreeMatches map[object.ID][]subtreeMatch
+
+	owners map[content.ID][]contentOwner
+}
+
+// subtreeMatch is a reference to a target content found in a directory subtree.
+type subtreeMatch struct {
+	contentID content.ID
+	objectID  object.ID
+	relPath   string // path relative to the directory
+}
+
+func (c *commandContentOwners) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("owners", "Show objects and snapshots referencing the provided contents.")
+
+	cmd.Arg("id", "IDs of contents to find owners of").Required().StringsVar(&c.ids)
+	cmd.Flag("limit", "Maximum number of references to report for each content (0 = unlimited)").IntVar(&c.limit)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
+}
+
+func (c *commandContentOwners) run(ctx context.Context, rep repo.DirectRepository) error {
+	contentIDs, err := toContentIDs(c.ids)
+	if err != nil {
+		return err
+	}
+
+	f := &contentOwnersFinder{
+		rep:            rep,
+		targets:        map[content.ID]bool{},
+		limit:          c.limit,
+		objectTargets:  map[object.ID][]content.ID{},
+		subtreeMatches: map[object.ID][]subtreeMatch{},
+		owners:         map[content.ID][]contentOwner{},
+	}
+
+	for _, cid := range contentIDs {
+		f.targets[cid] = true
+	}
+
+	manifestIDs, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to list snapshots")
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, manifestIDs)
+	if err != nil {
+		return errors.Wrap(err, "unable to load snapshots")
+	}
+
+	log(ctx).Infof("Searching %v snapshots for references to %v contents...", len(manifests), len(contentIDs))
+
+	for _, man := range manifests {
+		if f.done() {
+			break
+		}
+
+		if man.RootEntry == nil {
+			continue
+		}
+
+		root, err := snapshotfs.SnapshotRoot(rep, man)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get root of snapshot %v", man.ID)
+		}
+
+		if _, _, err := f.processEntry(ctx, man, root, ""); err != nil {
+			return errors.Wrapf(err, "error processing snapshot %v", man.ID)
+		}
+	}
+
+	return c.output(contentIDs, f.owners)
+}
+
+func (c *commandContentOwners) output(contentIDs []content.ID, owners map[content.ID][]contentOwner) error {
+	if c.jo.jsonOutput {
+		var result []contentOwner
+
+		for _, cid := range contentIDs {
+			result = append(result, owners[cid]...)
+		}
+
+		c.out.printStdout("%s\n", c.jo.jsonBytes(result))
+
+		return nil
+	}
+
+	for _, cid := range contentIDs {
+		if len(owners[cid]) == 0 {
+			c.out.printStdout("%v is not referenced by any snapshot\n", cid)
+			continue
+		}
+
+		for _, o := range owners[cid] {
+			c.out.printStdout("%v %v %v %v\n", cid, o.SnapshotID, o.ObjectID, o.Path)
+		}
+	}
+
+	return nil
+}
+
+// done returns true when the reference limit has been reached for all target contents.
+func (f *contentOwnersFinder) done() bool {
+	if f.limit <= 0 {
+		return false
+	}
+
+	for cid := range f.targets {
+		if len(f.owners[cid]) < f.limit {
+			return false
+		}
+	}
+
+	return true
+}
+
+// processEntry records references to target contents by the provided entry and its subtree and returns them
+// relative to the entry. The returned bool is false if the walk was stopped early because the limit has been reached.
+func (f *contentOwnersFinder) processEntry(ctx context.Context, man *snapshot.Manifest, e fs.Entry, entryPath string) ([]subtreeMatch, bool, error) {
+	hoid, ok := e.(object.HasObjectID)
+	if !ok {
+		return nil, true, nil
+	}
+
+	oid := hoid.ObjectID()
+
+	dir, isDir := e.(fs.Directory)
+
+	if cached, ok := f.subtreeMatches[oid]; ok && isDir {
+		for _, m := range cached {
+			f.addOwner(man, m.contentID, m.objectID, path.Join(entryPath, m.relPath))
+		}
+
+		return cached, true, nil
+	}
+
+	matched, err := f.matchingTargets(ctx, oid)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "error reading object %v", oid)
+	}
+
+	var result []subtreeMatch
+
+	for _, cid := range matched {
+		f.addOwner(man, cid, oid, entryPath)
+
+		result = append(result, subtreeMatch{cid, oid, ""})
+	}
+
+	if !isDir {
+		return result, true, nil
+	}
+
+	complete := true
+
+	if err := fs.IterateEntries(ctx, dir, func(ctx context.Context, child fs.Entry) error {
+		if f.done() {
+			complete = false
+			return nil
+		}
+
+		childMatches, childComplete, err := f.processEntry(ctx, man, child, path.Join(entryPath, child.Name()))
+		if err != nil {
+			return err
+		}
+
+		for _, m := range childMatches {
+			m.relPath = path.Join(child.Name(), m.relPath)
+			result = append(result, m)
+		}
+
+		complete = complete && childComplete
+
+		return nil
+	}); err != nil {
+		return nil, false, err //nolint:wrapcheck
+	}
+
+	if complete {
+		f.subtreeMatches[oid] = result
+	}
+
+	return result, complete, nil
+}
+
+// addOwner records the reference to the provided content unless the limit has been reached.
+func (f *contentOwnersFinder) addOwner(man *snapshot.Manifest, cid content.ID, oid object.ID, entryPath string) {
+	if f.limit > 0 && len(f.owners[cid]) >= f.limit {
+		return
+	}
+
+	f.owners[cid] = append(f.owners[cid], contentOwner{
+		ContentID:  cid,
+		ObjectID:   oid,
+		Path:       fmt.Sprintf("%v@%v/%v", man.Source, formatTimestamp(man.StartTime.ToTime()), entryPath),
+		Source:     man.Source,
+		SnapshotID: man.ID,
+	})
+}
+
+// matchingTargets returns the target contents that back the provided object.
+func (f *contentOwnersFinder) matchingTargets(ctx context.Context, oid object.ID) ([]content.ID, error) {
+	if m, ok := f.objectTargets[oid]; ok {
+		return m, nil
+	}
+
+	cids, err := f.rep.VerifyObject(ctx, oid)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to determine object contents")
+	}
+
+	var matched []content.ID
+
+	for _, cid := range cids {
+		if f.targets[cid] {
+			matched = append(matched, cid)
+		}
+	}
+
+	f.objectTargets[oid] = matched
+
+	return matched, nil
+}
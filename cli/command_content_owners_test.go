@@ -0,0 +1,67 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestContentOwnersSharedSubtrees(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "shared.txt"), []byte("shared contents"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte("v1"), 0o600))
+
+	// the first two snapshots share the root directory, the third one only shares 'sub'.
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir, "--force-hash=100")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte("v2"), 0o600))
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var manifests []cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "list", "--json"), &manifests)
+	require.Len(t, manifests, 3)
+
+	// object IDs of small files are the IDs of their contents.
+	fileID := strings.Fields(env.RunAndExpectSuccess(t, "ls", "-o", string(manifests[0].ID)+"/sub")[0])[0]
+
+	var owners []struct {
+		ContentID  string              `json:"contentID"`
+		ObjectID   string              `json:"objectID"`
+		Path       string              `json:"path"`
+		Source     snapshot.SourceInfo `json:"source"`
+		SnapshotID string              `json:"snapshotID"`
+	}
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "content", "owners", fileID, "--json"), &owners)
+	require.Len(t, owners, 3)
+
+	var snapshotIDs []string
+
+	for _, o := range owners {
+		require.Equal(t, fileID, o.ContentID)
+		require.Equal(t, fileID, o.ObjectID)
+		require.True(t, strings.HasSuffix(o.Path, "/sub/shared.txt"), o.Path)
+
+		snapshotIDs = append(snapshotIDs, o.SnapshotID)
+	}
+
+	require.ElementsMatch(t, []string{string(manifests[0].ID), string(manifests[1].ID), string(manifests[2].ID)}, snapshotIDs)
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "content", "owners", fileID, "--json", "--limit=2"), &owners)
+	require.Len(t, owners, 2)
+}
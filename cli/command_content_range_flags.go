@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"time"
+
 	"github.com/alecthomas/kingpin/v2"
 
 	"github.com/kopia/kopia/repo/content"
@@ -30,3 +32,29 @@ func (c *contentRangeFlags) contentIDRange() content.IDRange {
 
 	return index.PrefixRange(content.IDPrefix(c.contentIDPrefix))
 }
+
+// contentAgeFlags filters contents based on their timestamps.
+type contentAgeFlags struct {
+	newerThan time.Duration
+	olderThan time.Duration
+}
+
+func (c *contentAgeFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("newer-than", "Only include contents written within the specified duration").PlaceHolder("DURATION").DurationVar(&c.newerThan)
+	cmd.Flag("older-than", "Only include contents written before the specified duration").PlaceHolder("DURATION").DurationVar(&c.olderThan)
+}
+
+// matches returns true if the provided content satisfies age filters relative to the provided time.
+func (c *contentAgeFlags) matches(ci content.Info, now time.Time) bool {
+	ts := ci.Timestamp()
+
+	if c.newerThan > 0 && ts.Before(now.Add(-c.newerThan)) {
+		return false
+	}
+
+	if c.olderThan > 0 && ts.After(now.Add(-c.olderThan)) {
+		return false
+	}
+
+	return true
+}
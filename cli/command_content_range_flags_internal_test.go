@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/repo/content"
+)
+
+func TestContentAgeFlags(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	infoWrittenAgo := func(d time.Duration) content.Info {
+		return content.Info{TimestampSeconds: now.Add(-d).Unix()}
+	}
+
+	cases := []struct {
+		name      string
+		flags     contentAgeFlags
+		age       time.Duration
+		wantMatch bool
+	}{
+		{name: "no filters", age: 100 * time.Hour, wantMatch: true},
+		{name: "newer than, recent", flags: contentAgeFlags{newerThan: time.Hour}, age: 30 * time.Minute, wantMatch: true},
+		{name: "newer than, old", flags: contentAgeFlags{newerThan: time.Hour}, age: 2 * time.Hour},
+		{name: "older than, recent", flags: contentAgeFlags{olderThan: time.Hour}, age: 30 * time.Minute},
+		{name: "older than, old", flags: contentAgeFlags{olderThan: time.Hour}, age: 2 * time.Hour, wantMatch: true},
+		{name: "between, inside", flags: contentAgeFlags{newerThan: 3 * time.Hour, olderThan: time.Hour}, age: 2 * time.Hour, wantMatch: true},
+		{name: "between, too recent", flags: contentAgeFlags{newerThan: 3 * time.Hour, olderThan: time.Hour}, age: 30 * time.Minute},
+		{name: "between, too old", flags: contentAgeFlags{newerThan: 3 * time.Hour, olderThan: time.Hour}, age: 4 * time.Hour},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.wantMatch, tc.flags.matches(infoWrittenAgo(tc.age), now))
+		})
+	}
+}
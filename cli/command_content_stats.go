@@ -2,6 +2,9 @@
 
 import (
 	"context"
+	"fmt"
+	"math/bits"
+	"sort"
 	"strconv"
 
 	"github.com/pkg/errors"
@@ -13,15 +16,21 @@
 )
 
 type commandContentStats struct {
-	raw          bool
-	contentRange contentRangeFlags
-	out          textOutput
+	raw            bool
+	byCompression  bool
+	includeDeleted bool
+	contentRange   contentRangeFlags
+	jo             jsonOutput
+	out            textOutput
 }
 
 func (c *commandContentStats) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("stats", "Content statistics")
 	cmd.Flag("raw", "Raw numbers").Short('r').BoolVar(&c.raw)
+	cmd.Flag("by-compression", "Report statistics for each compression algorithm, sorted by saved bytes").BoolVar(&c.byCompression)
+	cmd.Flag("include-deleted", "Include deleted contents").BoolVar(&c.includeDeleted)
 	c.contentRange.setup(cmd)
+	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
 }
@@ -30,9 +39,86 @@ type contentStatsTotals struct {
 	originalSize int64
 	packedSize   int64
 	count        int64
+	deleted      int64
+	packedSizes  sizeQuantiles
+}
+
+// sizeQuantileSubBuckets is the number of buckets each power of two is divided into by sizeQuantiles,
+// so that estimated quantiles are within 1/sizeQuantileSubBuckets of the exact values.
+const sizeQuantileSubBuckets = 16
+
+// sizeQuantiles estimates quantiles of observed sizes in constant memory by counting them in logarithmic buckets.
+// Sizes below 2*sizeQuantileSubBuckets are counted exactly.
+type sizeQuantiles struct {
+	counts [(32 - 3) * sizeQuantileSubBuckets]int64
+	total  int64
+	max    uint32
+}
+
+func sizeQuantileBucket(v uint32) int {
+	if v < sizeQuantileSubBuckets {
+		return int(v)
+	}
+
+	exp := bits.Len32(v) - 1
+
+	return (exp-3)*sizeQuantileSubBuckets + int((v>>(exp-4))%sizeQuantileSubBuckets)
+}
+
+// sizeQuantileBucketUpperBound returns the largest value counted in the provided bucket.
+func sizeQuantileBucketUpperBound(b int) uint64 {
+	if b < sizeQuantileSubBuckets {
+		return uint64(b) //nolint:gosec
+	}
+
+	shift := b/sizeQuantileSubBuckets - 1
+	lower := uint64(sizeQuantileSubBuckets+b%sizeQuantileSubBuckets) << shift //nolint:gosec
+
+	return lower + 1<<shift - 1
+}
+
+func (q *sizeQuantiles) add(v uint32) {
+	q.counts[sizeQuantileBucket(v)]++
+	q.total++
+	q.max = max(q.max, v)
+}
+
+// percentile returns the estimated nearest-rank percentile of the observed sizes.
+func (q *sizeQuantiles) percentile(percent int) int64 {
+	if q.total == 0 {
+		return 0
+	}
+
+	rank := max((q.total*int64(percent)+99)/100, 1) //nolint:mnd
+
+	var seen int64
+
+	for b, cnt := range q.counts {
+		if seen += cnt; seen >= rank {
+			return int64(min(sizeQuantileBucketUpperBound(b), uint64(q.max))) //nolint:gosec
+		}
+	}
+
+	return int64(q.max)
+}
+
+// contentCompressionStats summarizes contents stored using a single compression algorithm.
+type contentCompressionStats struct {
+	Compression   string  `json:"compression"`
+	Count         int64   `json:"count"`
+	OriginalBytes int64   `json:"originalBytes"`
+	StoredBytes   int64   `json:"storedBytes"`
+	SavedBytes    int64   `json:"savedBytes"`
+	Ratio         float64 `json:"ratio"` // stored bytes divided by original bytes
+	MedianStored  int64   `json:"medianStoredBytes"`
+	P95Stored     int64   `json:"p95StoredBytes"`
 }
 
 func (c *commandContentStats) run(ctx context.Context, rep repo.DirectRepository) error {
+	if c.jo.jsonOutput && !c.byCompression {
+		return errors.New("--json is only supported with --by-compression")
+	}
+
 	var (
 		sizeThreshold uint32 = 10
 		sizeBuckets   []uint32
@@ -55,7 +141,16 @@ func (c *commandContentStats) run(ctx context.Context, rep repo.DirectRepository
 		}
 	}
 
+	if c.byCompression {
+		return c.outputByCompression(compressionStatsFromTotals(byCompressionTotal), sizeToString)
+	}
+
 	c.out.printStdout("Count: %v\n", grandTotal.count)
+
+	if c.includeDeleted {
+		c.out.printStdout("Deleted: %v\n", grandTotal.deleted)
+	}
+
 	c.out.printStdout("Total Bytes: %v\n", sizeToString(grandTotal.originalSize))
 
 	if grandTotal.packedSize < grandTotal.originalSize {
@@ -91,6 +186,9 @@ func (c *commandContentStats) run(ctx context.Context, rep repo.DirectRepository
 	}
 
 	c.out.printStdout("Average: %v\n", sizeToString(grandTotal.originalSize/grandTotal.count))
+
+	c.out.printStdout("Median Packed: %v\n", sizeToString(grandTotal.packedSizes.percentile(50)))          //nolint:mnd
+	c.out.printStdout("95th Percentile Packed: %v\n", sizeToString(grandTotal.packedSizes.percentile(95))) //nolint:mnd
 	c.out.printStdout("Histogram:\n\n")
 
 	var lastSize uint32
@@ -109,6 +207,66 @@ func (c *commandContentStats) run(ctx context.Context, rep repo.DirectRepository
 	return nil
 }
 
+func (c *commandContentStats) outputByCompression(stats []contentCompressionStats, sizeToString func(int64) string) error {
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(stats))
+		return nil
+	}
+
+	for _, st := range stats {
+		c.out.printStdout("%-22v count: %v size: %v stored: %v saved: %v ratio: %.2f\n",
+			st.Compression, st.Count,
+			sizeToString(st.OriginalBytes),
+			sizeToString(st.StoredBytes),
+			sizeToString(st.SavedBytes),
+			st.Ratio)
+	}
+
+	return nil
+}
+
+// compressionStatsFromTotals converts per-compression totals into a list sorted by saved bytes, largest first.
+func compressionStatsFromTotals(byCompressionTotal map[compression.HeaderID]*contentStatsTotals) []contentCompressionStats {
+	result := []contentCompressionStats{}
+
+	for hdrID, bct := range byCompressionTotal {
+		cname := string(compression.HeaderIDToName[hdrID])
+
+		switch {
+		case hdrID == content.NoCompression:
+			cname = "none"
+		case cname == "":
+			cname = fmt.Sprintf("unknown-%x", hdrID)
+		}
+
+		st := contentCompressionStats{
+			Compression:   cname,
+			Count:         bct.count,
+			OriginalBytes: bct.originalSize,
+			StoredBytes:   bct.packedSize,
+			SavedBytes:    bct.originalSize - bct.packedSize,
+			MedianStored:  bct.packedSizes.percentile(50), //nolint:mnd
+			P95Stored:     bct.packedSizes.percentile(95), //nolint:mnd
+		}
+
+		if bct.originalSize > 0 {
+			st.Ratio = float64(bct.packedSize) / float64(bct.originalSize)
+		}
+
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SavedBytes != result[j].SavedBytes {
+			return result[i].SavedBytes > result[j].SavedBytes
+		}
+
+		return result[i].Compression < result[j].Compression
+	})
+
+	return result
+}
+
 func (c *commandContentStats) calculateStats(ctx context.Context, rep repo.DirectRepository, sizeBuckets []uint32) (
 	grandTotal contentStatsTotals,
 	byCompressionTotal map[compression.HeaderID]*contentStatsTotals,
@@ -127,13 +285,20 @@ func (c *commandContentStats) calculateStats(ctx context.Context, rep repo.Direc
 	err = rep.ContentReader().IterateContents(
 		ctx,
 		content.IterateOptions{
-			Range: c.contentRange.contentIDRange(),
+			Range:          c.contentRange.contentIDRange(),
+			IncludeDeleted: c.includeDeleted,
 		},
 		func(b content.Info) error {
 			grandTotal.packedSize += int64(b.PackedLength)
 			grandTotal.originalSize += int64(b.OriginalLength)
 			grandTotal.count++
 
+			if b.Deleted {
+				grandTotal.deleted++
+			}
+
+			grandTotal.packedSizes.add(b.PackedLength)
+
 			bct := byCompressionTotal[b.CompressionHeaderID]
 			if bct == nil {
 				bct = &contentStatsTotals{}
@@ -143,6 +308,7 @@ func(b content.Info) error {
 			bct.packedSize += int64(b.PackedLength)
 			bct.originalSize += int64(b.OriginalLength)
 			bct.count++
+			bct.packedSizes.add(b.PackedLength)
 
 			for s := range countMap {
 				if b.PackedLength < s {
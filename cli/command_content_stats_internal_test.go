@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/repo/content"
+)
+
+func TestSizeQuantiles(t *testing.T) {
+	var q sizeQuantiles
+
+	require.EqualValues(t, 0, q.percentile(50))
+
+	// small sizes are counted exactly.
+	for i := range uint32(10) {
+		q.add(i + 1)
+	}
+
+	require.EqualValues(t, 5, q.percentile(50))
+	require.EqualValues(t, 10, q.percentile(95))
+	require.EqualValues(t, 1, q.percentile(0))
+	require.EqualValues(t, 10, q.percentile(100))
+
+	// large sizes are estimated within 1/sizeQuantileSubBuckets of the exact value.
+	q = sizeQuantiles{}
+
+	for i := range uint32(1000) {
+		q.add(1000 + i*1000)
+	}
+
+	q.add(math.MaxUint32)
+
+	for _, tc := range []struct {
+		percent int
+		exact   float64
+	}{
+		{50, 501000},
+		{95, 951000},
+	} {
+		got := float64(q.percentile(tc.percent))
+		require.GreaterOrEqual(t, got, tc.exact, tc.percent)
+		require.LessOrEqual(t, got, tc.exact*(1+1.0/sizeQuantileSubBuckets), tc.percent)
+	}
+
+	require.EqualValues(t, math.MaxUint32, q.percentile(100))
+}
+
+func TestCompressionStatsFromTotals(t *testing.T) {
+	require.Empty(t, compressionStatsFromTotals(nil))
+
+	totals := map[compression.HeaderID]*contentStatsTotals{
+		content.NoCompression:         {originalSize: 1000, packedSize: 1000, count: 2},
+		compression.HeaderZstdDefault: {originalSize: 4000, packedSize: 1000, count: 4},
+		0xfeed:                        {originalSize: 2000, packedSize: 1500, count: 1},
+		0xbeef:                        {},
+	}
+
+	for _, bct := range totals {
+		if bct.count > 0 {
+			bct.packedSizes.add(uint32(bct.packedSize / bct.count)) //nolint:gosec
+		}
+	}
+
+	stats := compressionStatsFromTotals(totals)
+	require.Len(t, stats, 4)
+
+	// sorted by saved bytes, largest first, ties are broken by name.
+	require.Equal(t, "zstd", stats[0].Compression)
+	require.EqualValues(t, 4, stats[0].Count)
+	require.EqualValues(t, 3000, stats[0].SavedBytes)
+	require.InDelta(t, 0.25, stats[0].Ratio, 1e-9)
+	require.EqualValues(t, 250, stats[0].MedianStored)
+
+	require.Equal(t, "unknown-feed", stats[1].Compression)
+	require.EqualValues(t, 500, stats[1].SavedBytes)
+
+	require.Equal(t, "none", stats[2].Compression)
+	require.EqualValues(t, 0, stats[2].SavedBytes)
+	require.InDelta(t, 1.0, stats[2].Ratio, 1e-9)
+
+	// no division by zero for algorithms without any data.
+	require.Equal(t, "unknown-beef", stats[3].Compression)
+	require.Zero(t, stats[3].Ratio)
+}
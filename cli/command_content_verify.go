@@ -2,61 +2,212 @@
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"math"
 	"math/rand"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	atunits "github.com/alecthomas/units"
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/timetrack"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/index"
+	"github.com/kopia/kopia/repo/object"
 )
 
 type commandContentVerify struct {
 	contentVerifyParallel       int
+	parallelPerBlob             int
 	contentVerifyFull           bool
 	contentVerifyIncludeDeleted bool
 	contentVerifyPercent        float64
 	progressInterval            time.Duration
+	slowReadThreshold           time.Duration
+	slowReadReportCount         int
+	includeManifestReferences   bool
+	blobAgeMin                  time.Duration
+	validateTombstones          bool
+	blobUsageMapFile            string
+	blobUsageMapFormat          string
+	indexGeneration             string
+	onlyFormat                  int
+	writeAttestation            string
+	attestAgainst               string
+	watch                       bool
+	watchInterval               time.Duration
+	checkpointFile              string
+	checkpointInterval          int
+	maxErrors                   int32
+	samplePercent               float64
+	sampleSeed                  string
+	contentIDs                  []string
+	contentIDsFile              string
+	readRetries                 int
+	readRetryDelay              time.Duration
+	blobMapMemoryLimit          atunits.Base2Bytes
+
+	// hashes of verified contents, non-nil when writing or comparing attestation.
+	attest *attestationTracker
+
+	// non-nil when writing checkpoints, resume is the checkpoint verification resumed from.
+	checkpointer *verifyCheckpointer
+	resume       *contentVerifyCheckpoint
+
+	// contents provided with --content-id or --content-ids-file that are present in the index.
+	explicitContents []content.Info
+
+	// non-nil when displaying progress bar.
+	progress *cliVerifyProgress
+
+	// contents successfully read after retrying transient errors.
+	retriedReadCount atomic.Int32
+
+	// contents with missing blobs ignored because they were written within blobAgeMin.
+	settlingCount atomic.Int32
+	// time used as a reference for blobAgeMin, captured before listing blobs.
+	verifyStartTime time.Time
+
+	// testing hook: blob IDs to treat as missing from the blob map.
+	simulateMissingBlobIDs []string
 
 	contentRange contentRangeFlags
+
+	svc appServices
+	out textOutput
+	jo  jsonOutput
 }
 
 func (c *commandContentVerify) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("verify", "Verify that each content is backed by a valid blob")
 
 	cmd.Flag("parallel", "Parallelism").Default("16").IntVar(&c.contentVerifyParallel)
+	cmd.Flag("parallel-per-blob", "Group contents by pack blob and verify this many contents of each blob concurrently, with --parallel controlling the number of blobs processed concurrently, reading each pack blob once when downloading all contents (0 = don't group)").PlaceHolder("N").IntVar(&c.parallelPerBlob)
 	cmd.Flag("full", "Full verification (including download)").BoolVar(&c.contentVerifyFull)
 	cmd.Flag("include-deleted", "Include deleted contents").BoolVar(&c.contentVerifyIncludeDeleted)
 	cmd.Flag("download-percent", "Download a percentage of files [0.0 .. 100.0]").Float64Var(&c.contentVerifyPercent)
 	cmd.Flag("progress-interval", "Progress output interval").Default("3s").DurationVar(&c.progressInterval)
+	cmd.Flag("read-retries", "Number of times to retry reading a content after transient errors").Default("3").IntVar(&c.readRetries)
+	cmd.Flag("read-retry-delay", "Delay before the first retry of reading a content, growing exponentially with each retry").Default("1s").DurationVar(&c.readRetryDelay)
+	cmd.Flag("report-slow-reads", "Log contents whose download takes longer than the provided duration").PlaceHolder("DURATION").DurationVar(&c.slowReadThreshold)
+	cmd.Flag("report-slow-reads-count", "Number of slowest reads to summarize at the end of verification").Default("10").IntVar(&c.slowReadReportCount)
+	cmd.Flag("include-manifest-references", "Also verify objects referenced by manifests").BoolVar(&c.includeManifestReferences)
+	cmd.Flag("validate-tombstones", "Validate that deleted contents are well-formed instead of verifying them like live contents (implies --include-deleted)").BoolVar(&c.validateTombstones)
+	cmd.Flag("write-blob-usage-map", "Write live and dead bytes of each pack blob to the provided file").PlaceHolder("FILE").StringVar(&c.blobUsageMapFile)
+	cmd.Flag("blob-usage-map-format", "Format of the blob usage map").Default(blobUsageFormatCSV).EnumVar(&c.blobUsageMapFormat, blobUsageFormatCSV, blobUsageFormatJSON)
+	cmd.Flag("content-id", "Only verify the provided content (can be repeated)").PlaceHolder("CONTENTID").StringsVar(&c.contentIDs)
+	cmd.Flag("content-ids-file", "Only verify contents listed in the provided file, one content ID per line").PlaceHolder("FILE").StringVar(&c.contentIDsFile)
+	cmd.Flag("blob-map-memory-limit", "Store the map of blobs on disk if it would use more than the provided amount of memory (0 = unlimited)").Default("0").BytesVar(&c.blobMapMemoryLimit)
+	cmd.Flag("index-generation", "Only verify contents present in the provided index blob instead of the merged index").PlaceHolder("BLOBID").StringVar(&c.indexGeneration)
+	c.onlyFormat = -1
+	cmd.Flag("only-format", "Only verify contents with the provided format version").PlaceHolder("BYTE").IntVar(&c.onlyFormat)
+	cmd.Flag("write-attestation", "After successful full verification, write signed attestation of hashes of all verified contents to the provided file").PlaceHolder("FILE").StringVar(&c.writeAttestation)
+	cmd.Flag("attest-against", "Compare hashes of verified contents against attestation previously written with --write-attestation").PlaceHolder("FILE").StringVar(&c.attestAgainst)
+	cmd.Flag("blob-age-min", "Do not report missing blobs for contents written within the provided duration").PlaceHolder("DURATION").DurationVar(&c.blobAgeMin)
+	cmd.Flag("sample-percent", "Only verify a sample of contents deterministically selected using --sample-seed [0.0 .. 100.0]").PlaceHolder("PERCENT").Float64Var(&c.samplePercent)
+	cmd.Flag("sample-seed", "Seed used to select the sample of contents, random if not provided").PlaceHolder("SEED").StringVar(&c.sampleSeed)
+	cmd.Flag("max-errors", "Stop verification after finding this many errors (0 = unlimited)").PlaceHolder("N").Int32Var(&c.maxErrors)
+	cmd.Flag("checkpoint-file", "Periodically save verification progress to the provided file and resume from it if it exists").PlaceHolder("FILE").StringVar(&c.checkpointFile)
+	cmd.Flag("checkpoint-interval", "Number of verified contents between checkpoints").Default("10000").IntVar(&c.checkpointInterval)
+	cmd.Flag("watch", "Keep running and repeat verification on the interval specified with --interval").BoolVar(&c.watch)
+	cmd.Flag("interval", "Interval between starts of verifications in --watch mode").Default("24h").DurationVar(&c.watchInterval)
+	cmd.Flag("simulate-missing", "Simulate missing blob (for rehearsing recovery procedures only)").Hidden().PlaceHolder("BLOBID").StringsVar(&c.simulateMissingBlobIDs)
 	c.contentRange.setup(cmd)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
 }
 
 func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepository) error {
-	blobMap := map[blob.ID]blob.Metadata{}
+	if c.watch {
+		return c.runWatch(ctx, rep)
+	}
+
+	_, err := c.verifyOnce(ctx, rep)
+
+	return err
+}
+
+// contentVerifyResult summarizes a single verification run.
+type contentVerifyResult struct {
+	Verified int32
+	Errors   int32
+}
+
+func (c *commandContentVerify) verifyOnce(ctx context.Context, rep repo.DirectRepository) (contentVerifyResult, error) {
 	downloadPercent := c.contentVerifyPercent
 
 	if c.contentVerifyFull {
 		downloadPercent = 100.0
 	}
 
-	blobMap, err := blob.ReadBlobMap(ctx, rep.BlobReader())
+	if c.onlyFormat < -1 || c.onlyFormat > math.MaxUint8 {
+		return contentVerifyResult{}, errors.Errorf("invalid format version %v", c.onlyFormat)
+	}
+
+	if c.hasExplicitContentIDs() && (c.indexGeneration != "" || c.checkpointFile != "") {
+		return contentVerifyResult{}, errors.New("--content-id and --content-ids-file can't be used with --index-generation or --checkpoint-file")
+	}
+
+	if c.writeAttestation != "" || c.attestAgainst != "" {
+		if downloadPercent < 100 { //nolint:mnd
+			return contentVerifyResult{}, errors.New("attestation requires --full verification")
+		}
+
+		c.attest = newAttestationTracker()
+	}
+
+	if err := c.initSample(ctx); err != nil {
+		return contentVerifyResult{}, err
+	}
+
+	c.verifyStartTime = rep.Time()
+	c.settlingCount.Store(0)
+	c.retriedReadCount.Store(0)
+
+	if err := c.loadCheckpoint(); err != nil {
+		return contentVerifyResult{}, err
+	}
+
+	if err := c.validateBlobUsageMapFlags(); err != nil {
+		return contentVerifyResult{}, err
+	}
+
+	blobMap, err := readBlobMetadataMap(ctx, rep.BlobReader(), int64(c.blobMapMemoryLimit))
 	if err != nil {
-		return errors.Wrap(err, "unable to read blob map")
+		return contentVerifyResult{}, err
+	}
+
+	defer blobMap.close(ctx)
+
+	for _, bid := range c.simulateMissingBlobIDs {
+		log(ctx).Warnf("SIMULATION: treating blob %v as missing", bid)
+		blobMap.remove(blob.ID(bid))
 	}
 
 	var (
 		verifiedCount atomic.Int32
 		successCount  atomic.Int32
-		errorCount    atomic.Int32
 		totalCount    atomic.Int32
+
+		// errors found before resuming from a checkpoint, all other errors are recorded in failures.
+		resumedErrors int32
+		failures      = &verifyFailureTracker{}
+		suspicious    = &verifyFailureTracker{}
 	)
 
+	errorCount := func() int32 {
+		return resumedErrors + failures.count()
+	}
+
 	subctx, cancel := context.WithCancel(ctx)
 
 	var wg sync.WaitGroup
@@ -67,64 +218,613 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 		wg.Wait()
 	}()
 
-	// start a goroutine that will populate totalCount
-	wg.Add(1)
+	if c.checkpointFile != "" {
+		if c.resume != nil {
+			log(ctx).Infof("Resuming verification after content %v, %v contents verified and %v errors found before.", c.resume.LastContentID, c.resume.VerifiedCount, c.resume.ErrorCount)
 
-	go func() {
-		defer wg.Done()
-		c.getTotalContentCount(subctx, rep, &totalCount)
-	}()
+			verifiedCount.Store(c.resume.VerifiedCount)
+			successCount.Store(c.resume.VerifiedCount - c.resume.ErrorCount)
+			resumedErrors = c.resume.ErrorCount
+		}
+
+		c.checkpointer = newVerifyCheckpointer(c.checkpointFile, c.checkpointInterval, c.contentRange.contentIDRange(), func() (int32, int32) {
+			return verifiedCount.Load(), errorCount()
+		})
+	}
+
+	if c.hasExplicitContentIDs() {
+		found, unknownCount, err := c.resolveExplicitContents(ctx, rep, failures)
+		if err != nil {
+			return contentVerifyResult{}, err
+		}
+
+		c.explicitContents = found
+
+		verifiedCount.Add(unknownCount)
+	}
+
+	iterate, err := c.contentIterator(ctx, rep, downloadPercent)
+	if err != nil {
+		return contentVerifyResult{}, err
+	}
 
-	log(ctx).Info("Verifying all contents...")
+	if c.indexGeneration == "" && !c.hasExplicitContentIDs() {
+		// start a goroutine that will populate totalCount
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			c.getTotalContentCount(subctx, rep, &totalCount)
+		}()
+	}
+
+	var slow *slowReadTracker
+	if c.slowReadThreshold > 0 {
+		slow = &slowReadTracker{threshold: c.slowReadThreshold, maxReported: c.slowReadReportCount}
+	}
+
+	c.progress = newVerifyProgress(c.svc.getProgress().progressFlags, c.jo.jsonOutput, downloadPercent > 0)
+
+	// live bytes of each pack blob are always tracked to detect pack blobs whose contents claim more bytes than the blob has.
+	usage := newBlobUsageTracker()
+
+	switch {
+	case c.hasExplicitContentIDs():
+		// number of provided contents was already logged.
+	case c.indexGeneration != "":
+		log(ctx).Infof("Verifying contents in index blob %v...", c.indexGeneration)
+	default:
+		log(ctx).Info("Verifying all contents...")
+	}
 
 	rep.DisableIndexRefresh()
 
 	throttle := new(timetrack.Throttle)
 	est := timetrack.Start()
 
-	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{
-		Range:          c.contentRange.contentIDRange(),
-		Parallel:       c.contentVerifyParallel,
-		IncludeDeleted: c.contentVerifyIncludeDeleted,
-	}, func(ci content.Info) error {
-		if err := c.contentVerify(ctx, rep.ContentReader(), ci, blobMap, downloadPercent); err != nil {
-			log(ctx).Errorf("error %v", err)
-			errorCount.Add(1)
+	if err := iterate(totalCount.Store, func(ci content.Info) error {
+		usage.record(ci)
+
+		if !c.matchesFormat(ci) || !c.inSample(ci) {
+			return nil
+		}
+
+		if err := c.verifyContentOrTombstone(ctx, rep.ContentReader(), ci, blobMap, downloadPercent, slow); err != nil {
+			var bme *blobMapLookupError
+			if errors.As(err, &bme) {
+				return err
+			}
+
+			if errors.Is(err, errSuspiciouslySmallContent) {
+				log(ctx).Warnf("suspicious %v", err)
+				suspicious.recordSuspicious(ci, err)
+			} else {
+				log(ctx).Errorf("error %v", err)
+				failures.record(ci, err)
+			}
 		} else {
 			successCount.Add(1)
 		}
 
 		verifiedCount.Add(1)
 
-		if throttle.ShouldOutput(c.progressInterval) {
+		if c.maxErrors > 0 && errorCount() >= c.maxErrors {
+			return errVerifyErrorLimitReached
+		}
+
+		if c.progress != nil {
+			c.progress.setCounters(verifiedCount.Load(), errorCount(), totalCount.Load())
+		} else if throttle.ShouldOutput(c.progressInterval) {
 			timings, ok := est.Estimate(float64(verifiedCount.Load()), float64(totalCount.Load()))
 			if ok {
 				log(ctx).Infof("  Verified %v of %v contents (%.1f%%), %v errors, remaining %v, ETA %v",
 					verifiedCount.Load(),
 					totalCount.Load(),
 					timings.PercentComplete,
-					errorCount.Load(),
+					errorCount(),
 					timings.Remaining,
 					formatTimestamp(timings.EstimatedEndTime),
 				)
 			} else {
-				log(ctx).Infof("  Verified %v contents, %v errors, estimating...", verifiedCount.Load(), errorCount.Load())
+				log(ctx).Infof("  Verified %v contents, %v errors, estimating...", verifiedCount.Load(), errorCount())
 			}
 		}
 
 		return nil
 	}); err != nil {
-		return errors.Wrap(err, "iterate contents")
+		c.progress.finish()
+
+		if !errors.Is(err, errVerifyErrorLimitReached) {
+			return contentVerifyResult{}, errors.Wrap(err, "iterate contents")
+		}
+
+		result := contentVerifyResult{Verified: verifiedCount.Load(), Errors: errorCount()}
+
+		log(ctx).Errorf("Verification aborted after reaching the limit of %v errors, verified %v contents.", c.maxErrors, result.Verified)
+		c.maybePrintJSONSummary(result, successCount.Load(), resumedErrors, failures, suspicious, true)
+
+		return result, errors.Errorf("verification aborted after reaching the limit of %v errors", c.maxErrors)
+	}
+
+	c.progress.finish()
+	c.checkpointer.finish(ctx)
+
+	log(ctx).Infof("Finished verifying %v contents, found %v errors.", verifiedCount.Load(), errorCount())
+
+	if c.onlyFormat >= 0 {
+		log(ctx).Infof("%v contents matched format version %v.", verifiedCount.Load(), c.onlyFormat)
+	}
+
+	if c.includeManifestReferences {
+		c.verifyManifestReferences(ctx, rep, blobMap, downloadPercent, slow, failures, suspicious)
+	}
+
+	if sc := c.settlingCount.Load(); sc > 0 {
+		log(ctx).Infof("Ignored %v recently-written contents whose blobs were not yet listed.", sc)
+	}
+
+	if rc := c.retriedReadCount.Load(); rc > 0 {
+		log(ctx).Infof("Read %v contents successfully after retrying transient errors.", rc)
+	}
+
+	if sc := suspicious.count(); sc > 0 {
+		log(ctx).Warnf("Found %v suspiciously small contents with zero packed length.", sc)
+	}
+
+	slow.report(ctx)
+
+	oversubscribed, err := usage.oversubscribed(blobMap)
+	if err != nil {
+		return contentVerifyResult{}, err
+	}
+
+	reportOversubscribedBlobs(ctx, oversubscribed, failures)
+
+	if c.blobUsageMapFile != "" {
+		if err := usage.writeToFile(ctx, c.blobUsageMapFile, c.blobUsageMapFormat, blobMap); err != nil {
+			return contentVerifyResult{}, err
+		}
+	}
+
+	if err := c.processAttestation(ctx, rep, errorCount(), failures); err != nil {
+		return contentVerifyResult{}, err
+	}
+
+	result := contentVerifyResult{Verified: verifiedCount.Load(), Errors: errorCount()}
+
+	c.maybePrintJSONSummary(result, successCount.Load(), resumedErrors, failures, suspicious, false)
+
+	if result.Errors == 0 {
+		return result, nil
+	}
+
+	return result, errors.Errorf("encountered %v errors", result.Errors)
+}
+
+// maybePrintJSONSummary prints summary of verification to stdout when --json is specified.
+func (c *commandContentVerify) maybePrintJSONSummary(result contentVerifyResult, successCount, resumedErrors int32, failures, suspicious *verifyFailureTracker, aborted bool) {
+	if !c.jo.jsonOutput {
+		return
+	}
+
+	sorted := failures.sortedFailures()
+
+	c.out.printStdout("%s\n", c.jo.jsonBytes(contentVerifySummary{
+		VerifiedCount:     result.Verified,
+		SuccessCount:      successCount,
+		ErrorCount:        int32(len(sorted)), //nolint:gosec
+		ResumedErrorCount: resumedErrors,
+		RetriedCount:      c.retriedReadCount.Load(),
+		Aborted:           aborted,
+		SampleSeed:        c.sampleSeedIfSampling(),
+		Failures:          sorted,
+		SuspiciousCount:   suspicious.count(),
+		Suspicious:        suspicious.sortedFailures(),
+	}))
+}
+
+// processAttestation compares hashes of verified contents against --attest-against and writes --write-attestation
+// if verification found no errors. Differences from the attested state are recorded as failures.
+func (c *commandContentVerify) processAttestation(ctx context.Context, rep repo.DirectRepository, errorCount int32, failures *verifyFailureTracker) error {
+	if c.attest == nil {
+		return nil
+	}
+
+	key := rep.DeriveKey([]byte(attestationKeyPurpose), sha256.Size)
+	hashes := c.attest.contentHashes()
+
+	if c.attestAgainst != "" {
+		a, err := readContentAttestation(c.attestAgainst, key)
+		if err != nil {
+			return err
+		}
+
+		d := diffAttestation(a.Contents, hashes)
+
+		for _, id := range d.Added {
+			recordAttestationDiff(ctx, failures, id, "added")
+		}
+
+		for _, id := range d.Removed {
+			recordAttestationDiff(ctx, failures, id, "removed")
+		}
+
+		for _, id := range d.Changed {
+			recordAttestationDiff(ctx, failures, id, "modified")
+		}
+
+		log(ctx).Infof("Compared %v contents against attestation from %v: %v added, %v removed, %v modified.",
+			len(hashes), formatTimestamp(a.CreateTime), len(d.Added), len(d.Removed), len(d.Changed))
+	}
+
+	if c.writeAttestation != "" {
+		if errorCount > 0 {
+			log(ctx).Warnf("Not writing attestation because verification found errors.")
+		} else {
+			a := newContentAttestation(hashes, rep.Time(), key)
+			if err := writeContentAttestation(c.writeAttestation, a); err != nil {
+				return err
+			}
+
+			log(ctx).Infof("Wrote attestation of %v contents to %v, root hash %v.", a.ContentCount, c.writeAttestation, a.RootHash)
+		}
+	}
+
+	return nil
+}
+
+func recordAttestationDiff(ctx context.Context, failures *verifyFailureTracker, id, change string) {
+	err := errors.Errorf("content %v was %v since attestation", id, change)
+
+	log(ctx).Errorf("%v", err)
+
+	cid, _ := content.ParseID(id)
+
+	failures.recordFailure(contentVerifyFailure{
+		ContentID: cid,
+		Reason:    verifyFailureAttestation,
+		Error:     err.Error(),
+	})
+}
+
+// verifyManifestReferences verifies contents of objects referenced by manifests and records errors in failures.
+func (c *commandContentVerify) verifyManifestReferences(ctx context.Context, rep repo.DirectRepository, blobMap blobMetadataMap, downloadPercent float64, slow *slowReadTracker, failures, suspicious *verifyFailureTracker) {
+	log(ctx).Info("Verifying objects referenced by manifests...")
+
+	var errorCount int
+
+	fail := func(ci content.Info, err error) {
+		log(ctx).Errorf("%v", err)
+
+		if verifyFailureReason(err, "") == "" {
+			err = verifyError(verifyFailureManifestRef, err)
+		}
+
+		failures.record(ci, err)
+
+		errorCount++
+	}
+
+	manifests, err := rep.FindManifests(ctx, nil)
+	if err != nil {
+		fail(content.Info{}, errors.Wrap(err, "error listing manifests"))
+		return
+	}
+
+	var manifestCount, objectCount, contentCount int
+
+	for _, m := range manifests {
+		var payload json.RawMessage
+
+		if _, err := rep.GetManifest(ctx, m.ID, &payload); err != nil {
+			fail(content.Info{}, errors.Wrapf(err, "error reading manifest %v", m.ID))
+
+			continue
+		}
+
+		refs := manifestObjectReferences(payload)
+		if len(refs) > 0 {
+			manifestCount++
+		}
+
+		for _, oid := range refs {
+			objectCount++
+
+			cids, err := rep.VerifyObject(ctx, oid)
+			if err != nil {
+				fail(content.Info{}, errors.Wrapf(err, "object %v referenced by manifest %v is invalid", oid, m.ID))
+
+				continue
+			}
+
+			for _, cid := range cids {
+				contentCount++
+
+				ci, err := rep.ContentReader().ContentInfo(ctx, cid)
+				if err == nil {
+					err = c.contentVerify(ctx, rep.ContentReader(), ci, blobMap, downloadPercent, slow)
+				}
+
+				if errors.Is(err, errSuspiciouslySmallContent) {
+					log(ctx).Warnf("suspicious %v", err)
+					suspicious.recordSuspicious(ci, err)
+
+					continue
+				}
+
+				if err != nil {
+					fail(content.Info{ContentID: cid, PackBlobID: ci.PackBlobID}, errors.Wrapf(err, "content %v of object %v referenced by manifest %v", cid, oid, m.ID))
+				}
+			}
+		}
+	}
+
+	log(ctx).Infof("Verified %v objects (%v contents) referenced by %v of %v manifests, found %v errors.",
+		objectCount, contentCount, manifestCount, len(manifests), errorCount)
+}
+
+// manifestObjectReferences returns IDs of objects referenced by "obj" fields anywhere in the manifest payload.
+func manifestObjectReferences(payload []byte) []object.ID {
+	var (
+		v      any
+		result []object.ID
+	)
+
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil
+	}
+
+	var walk func(v any)
+
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if s, ok := child.(string); ok && k == "obj" {
+					if oid, err := object.ParseID(s); err == nil {
+						result = append(result, oid)
+					}
+
+					continue
+				}
+
+				walk(child)
+			}
+
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+
+	walk(v)
+
+	return result
+}
+
+func (c *commandContentVerify) iterateOptions() content.IterateOptions {
+	opts := content.IterateOptions{
+		Range:          c.contentRange.contentIDRange(),
+		Parallel:       c.contentVerifyParallel,
+		IncludeDeleted: c.contentVerifyIncludeDeleted || c.validateTombstones,
 	}
 
-	log(ctx).Infof("Finished verifying %v contents, found %v errors.", verifiedCount.Load(), errorCount.Load())
+	if c.checkpointer != nil {
+		// contents are returned sequentially in ID order and dispatched to workers by the checkpointer.
+		opts.Parallel = 1
 
-	ec := errorCount.Load()
-	if ec == 0 {
+		if c.resume != nil {
+			opts.Range = c.resume.resumeRange()
+		}
+	}
+
+	return opts
+}
+
+// validateBlobUsageMapFlags ensures that the blob usage map is only written when all contents are iterated,
+// since pack blobs whose contents were not iterated would be reported as entirely dead.
+func (c *commandContentVerify) validateBlobUsageMapFlags() error {
+	if c.blobUsageMapFile == "" {
 		return nil
 	}
 
-	return errors.Errorf("encountered %v errors", ec)
+	if c.hasExplicitContentIDs() || c.indexGeneration != "" || c.contentRange.contentIDRange() != index.AllIDs {
+		return errors.New("--write-blob-usage-map can't be used with --content-id, --content-ids-file, --index-generation or content ID range flags")
+	}
+
+	if c.resume != nil {
+		return errors.Errorf("--write-blob-usage-map can't be used when resuming from checkpoint %v", c.checkpointFile)
+	}
+
+	return nil
+}
+
+// loadCheckpoint validates flags used with --checkpoint-file and loads the checkpoint to resume from, if any.
+func (c *commandContentVerify) loadCheckpoint() error {
+	c.checkpointer = nil
+	c.resume = nil
+
+	if c.checkpointFile == "" {
+		return nil
+	}
+
+	if c.parallelPerBlob > 0 || c.indexGeneration != "" || c.attest != nil {
+		return errors.New("--checkpoint-file can't be used with --parallel-per-blob, --index-generation or attestations")
+	}
+
+	cp, err := readContentVerifyCheckpoint(c.checkpointFile)
+	if err != nil || cp == nil {
+		return err
+	}
+
+	if r := c.contentRange.contentIDRange(); cp.RangeStart != r.StartID || cp.RangeEnd != r.EndID {
+		return errors.Errorf("checkpoint %v was written for a different range of contents", c.checkpointFile)
+	}
+
+	c.resume = cp
+
+	return nil
+}
+
+// contentIterator returns a function that iterates the contents to verify, either from the merged index,
+// from a single index blob when --index-generation is provided or contents provided with --content-id, optionally grouping contents
+// by pack blob when --parallel-per-blob is provided.
+func (c *commandContentVerify) contentIterator(ctx context.Context, rep repo.DirectRepository, downloadPercent float64) (contentIteratorFunc, error) {
+	if c.parallelPerBlob <= 0 {
+		iterate, err := c.unbatchedContentIterator(ctx, rep)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.checkpointer != nil {
+			return c.checkpointer.iterate(iterate, c.contentVerifyParallel), nil
+		}
+
+		return iterate, nil
+	}
+
+	groups, err := c.contentGroupIterator(ctx, rep)
+	if err != nil {
+		return nil, err
+	}
+
+	var beforePack func([]content.Info)
+
+	if downloadPercent >= 100 { //nolint:mnd
+		// all contents of each pack will be downloaded, fetch the entire pack into the cache once
+		// instead of reading it once for each content.
+		beforePack = func(contents []content.Info) {
+			ids := make([]content.ID, 0, len(contents))
+			for _, ci := range contents {
+				ids = append(ids, ci.ContentID)
+			}
+
+			rep.PrefetchContents(ctx, ids, "blobs")
+		}
+	}
+
+	log(ctx).Infof("Verifying contents grouped by pack blob: %v blobs at a time, %v contents per blob in parallel.", c.contentVerifyParallel, c.parallelPerBlob)
+
+	return batchByPackBlob(groups, c.contentVerifyParallel, c.parallelPerBlob, beforePack), nil
+}
+
+// contentGroupIterator returns a function that iterates the contents to verify in groups that contain all
+// contents of the pack blobs they reference. Contents of the merged index are returned one index blob at a time,
+// so that only entries of a single index blob are kept in memory.
+func (c *commandContentVerify) contentGroupIterator(ctx context.Context, rep repo.DirectRepository) (contentGroupIteratorFunc, error) {
+	if c.hasExplicitContentIDs() {
+		return singleContentGroup(c.explicitContents), nil
+	}
+
+	opts := c.iterateOptions()
+
+	if c.indexGeneration != "" {
+		matching, err := c.indexBlobContents(ctx, rep, blob.ID(c.indexGeneration), opts, false)
+		if err != nil {
+			return nil, err
+		}
+
+		return singleContentGroup(matching), nil
+	}
+
+	indexBlobs, err := rep.IndexBlobs(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing index blobs")
+	}
+
+	return func(_ func(int32), cb func([]content.Info) error) error {
+		for _, ib := range indexBlobs {
+			matching, err := c.indexBlobContents(ctx, rep, ib.BlobID, opts, true)
+			if err != nil {
+				return err
+			}
+
+			if err := cb(matching); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+// indexBlobContents returns the entries of the provided index blob that match the provided options. When onlyCurrent is true,
+// entries superseded by entries in other index blobs are skipped, so that each content of the merged index is returned once.
+func (c *commandContentVerify) indexBlobContents(ctx context.Context, rep repo.DirectRepository, indexBlobID blob.ID, opts content.IterateOptions, onlyCurrent bool) ([]content.Info, error) {
+	_, entries, err := readIndexBlobEntries(ctx, rep, indexBlobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []content.Info
+
+	for _, ci := range entries {
+		if ci.Deleted && !opts.IncludeDeleted {
+			continue
+		}
+
+		if !opts.Range.Contains(ci.ContentID) {
+			continue
+		}
+
+		if onlyCurrent {
+			current, err := rep.ContentReader().ContentInfo(ctx, ci.ContentID)
+			if err != nil || !isCurrentIndexEntry(ci, current) {
+				continue
+			}
+		}
+
+		matching = append(matching, ci)
+	}
+
+	log(ctx).Debugf("Index blob %v has %v entries, %v of them selected for verification.", indexBlobID, len(entries), len(matching))
+
+	return matching, nil
+}
+
+// unbatchedContentIterator returns a function that iterates contents from the merged index, from the index
+// blob provided with --index-generation or contents provided with --content-id, in the order in which they are read.
+func (c *commandContentVerify) unbatchedContentIterator(ctx context.Context, rep repo.DirectRepository) (contentIteratorFunc, error) {
+	opts := c.iterateOptions()
+
+	if c.hasExplicitContentIDs() {
+		return func(setTotal func(int32), cb func(content.Info) error) error {
+			setTotal(int32(len(c.explicitContents))) //nolint:gosec
+
+			for _, ci := range c.explicitContents {
+				if err := cb(ci); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}, nil
+	}
+
+	if c.indexGeneration == "" {
+		return func(_ func(int32), cb func(content.Info) error) error {
+			//nolint:wrapcheck
+			return rep.ContentReader().IterateContents(ctx, opts, cb)
+		}, nil
+	}
+
+	matching, err := c.indexBlobContents(ctx, rep, blob.ID(c.indexGeneration), opts, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(setTotal func(int32), cb func(content.Info) error) error {
+		setTotal(int32(len(matching))) //nolint:gosec
+
+		for _, ci := range matching {
+			if err := cb(ci); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
 }
 
 func (c *commandContentVerify) getTotalContentCount(ctx context.Context, rep repo.DirectRepository, totalCount *atomic.Int32) {
@@ -133,11 +833,15 @@ func (c *commandContentVerify) getTotalContentCount(ctx context.Context, rep rep
 	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{
 		Range:          c.contentRange.contentIDRange(),
 		IncludeDeleted: c.contentVerifyIncludeDeleted,
-	}, func(_ content.Info) error {
+	}, func(ci content.Info) error {
 		if err := ctx.Err(); err != nil {
 			return errors.Wrap(err, "context error")
 		}
 
+		if !c.matchesFormat(ci) || !c.inSample(ci) {
+			return nil
+		}
+
 		tc++
 		return nil
 	}); err != nil {
@@ -148,24 +852,234 @@ func (c *commandContentVerify) getTotalContentCount(ctx context.Context, rep rep
 	totalCount.Store(tc)
 }
 
-func (c *commandContentVerify) contentVerify(ctx context.Context, r content.Reader, ci content.Info, blobMap map[blob.ID]blob.Metadata, downloadPercent float64) error {
-	bi, ok := blobMap[ci.PackBlobID]
+func (c *commandContentVerify) contentVerify(ctx context.Context, r content.Reader, ci content.Info, blobMap blobMetadataMap, downloadPercent float64, slow *slowReadTracker) error {
+	bi, ok, err := blobMap.get(ci.PackBlobID)
+	if err != nil {
+		return err
+	}
+
 	if !ok {
-		return errors.Errorf("content %v depends on missing blob %v", ci.ContentID, ci.PackBlobID)
+		if c.isSettling(ci) {
+			log(ctx).Debugf("content %v was written recently, ignoring missing blob %v", ci.ContentID, ci.PackBlobID)
+			c.settlingCount.Add(1)
+
+			return nil
+		}
+
+		return verifyError(verifyFailureMissingBlob, errors.Errorf("content %v depends on missing blob %v", ci.ContentID, ci.PackBlobID))
 	}
 
-	if int64(ci.PackOffset+ci.PackedLength) > bi.Length {
-		return errors.Errorf("content %v out of bounds of its pack blob %v", ci.ContentID, ci.PackBlobID)
+	if err := verifyContentBounds(ci, bi); err != nil {
+		return err
 	}
 
 	//nolint:gosec
 	if 100*rand.Float64() < downloadPercent {
-		if _, err := r.GetContent(ctx, ci.ContentID); err != nil {
-			return errors.Wrapf(err, "content %v is invalid", ci.ContentID)
+		timer := timetrack.StartTimer()
+
+		data, err := c.getContentWithRetries(ctx, r, ci.ContentID)
+		if err != nil {
+			return &contentVerifyError{
+				reason:    verifyFailureDownload,
+				transient: isTransientReadError(err),
+				err:       errors.Wrapf(err, "content %v is invalid", ci.ContentID),
+			}
 		}
 
+		c.attest.record(ci.ContentID, data)
+		c.progress.downloaded(int64(len(data)))
+
+		slow.record(ctx, ci, timer.Elapsed())
+
 		return nil
 	}
 
 	return nil
 }
+
+// verifyContentOrTombstone verifies the provided content, validating deleted contents as tombstones when requested.
+func (c *commandContentVerify) verifyContentOrTombstone(ctx context.Context, r content.Reader, ci content.Info, blobMap blobMetadataMap, downloadPercent float64, slow *slowReadTracker) error {
+	if ci.Deleted && c.validateTombstones {
+		if err := verifyTombstone(ci, blobMap, c.verifyStartTime); err != nil {
+			return verifyError(verifyFailureReason(err, verifyFailureInvalidTombstone), err)
+		}
+
+		return nil
+	}
+
+	return c.contentVerify(ctx, r, ci, blobMap, downloadPercent, slow)
+}
+
+// maxTombstoneClockSkew is the maximum amount of time a deletion timestamp may be ahead of the local clock.
+const maxTombstoneClockSkew = 5 * time.Minute
+
+// verifyTombstone validates that a deleted content entry is well-formed.
+// Unlike live contents, the pack blob of a deleted content may have already been garbage-collected,
+// but if it still exists, the entry must point at a valid region within it.
+func verifyTombstone(ci content.Info, blobMap blobMetadataMap, now time.Time) error {
+	if !ci.Deleted {
+		return errors.Errorf("content %v is not marked as deleted", ci.ContentID)
+	}
+
+	if ci.TimestampSeconds <= 0 {
+		return errors.Errorf("deleted content %v has invalid timestamp %v", ci.ContentID, ci.TimestampSeconds)
+	}
+
+	if ts := ci.Timestamp(); ts.After(now.Add(maxTombstoneClockSkew)) {
+		return errors.Errorf("deleted content %v has timestamp in the future: %v", ci.ContentID, formatTimestamp(ts))
+	}
+
+	if !isPackBlob(ci.PackBlobID) {
+		return errors.Errorf("deleted content %v has invalid pack blob reference %q", ci.ContentID, ci.PackBlobID)
+	}
+
+	bi, ok, err := blobMap.get(ci.PackBlobID)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		// pack blob has already been garbage-collected.
+		return nil
+	}
+
+	if err := verifyContentBounds(ci, bi); err != nil {
+		if errors.Is(err, errSuspiciouslySmallContent) {
+			return errors.Wrap(err, "deleted content")
+		}
+
+		return errors.Wrap(err, "deleted content claims invalid region of its pack blob")
+	}
+
+	return nil
+}
+
+// matchesFormat returns true if the content has the format version requested with --only-format.
+func (c *commandContentVerify) matchesFormat(ci content.Info) bool {
+	return c.onlyFormat < 0 || int(ci.FormatVersion) == c.onlyFormat
+}
+
+// initSample validates --sample-percent and picks the seed used to select the sample if not provided.
+// The seed is kept for subsequent verifications in --watch mode, so they all verify the same sample.
+func (c *commandContentVerify) initSample(ctx context.Context) error {
+	if c.samplePercent == 0 {
+		return nil
+	}
+
+	if c.samplePercent < 0 || c.samplePercent > 100 {
+		return errors.Errorf("invalid sample percentage %v, must be in range (0.0 .. 100.0]", c.samplePercent)
+	}
+
+	if c.sampleSeed == "" {
+		seed, err := newSampleSeed()
+		if err != nil {
+			return err
+		}
+
+		c.sampleSeed = seed
+	}
+
+	log(ctx).Infof("Verifying %v%% sample of contents selected using seed %v (use --sample-seed=%v to verify the same sample).", c.samplePercent, c.sampleSeed, c.sampleSeed)
+
+	return nil
+}
+
+// inSample returns true if the content belongs to the sample requested with --sample-percent.
+func (c *commandContentVerify) inSample(ci content.Info) bool {
+	return c.samplePercent == 0 || inContentSample(c.sampleSeed, ci.ContentID, c.samplePercent)
+}
+
+func (c *commandContentVerify) sampleSeedIfSampling() string {
+	if c.samplePercent == 0 {
+		return ""
+	}
+
+	return c.sampleSeed
+}
+
+// isSettling returns true if the content was written within --blob-age-min of the start of verification,
+// in which case its blob may not have appeared in the blob listing yet.
+func (c *commandContentVerify) isSettling(ci content.Info) bool {
+	if c.blobAgeMin <= 0 {
+		return false
+	}
+
+	return c.verifyStartTime.Sub(ci.Timestamp()) < c.blobAgeMin
+}
+
+type slowRead struct {
+	contentID  content.ID
+	packBlobID blob.ID
+	duration   time.Duration
+}
+
+// slowReadTracker logs content reads slower than the threshold and keeps track of the slowest ones.
+// All methods are safe to call on nil tracker, in which case they do nothing.
+type slowReadTracker struct {
+	threshold   time.Duration
+	maxReported int
+
+	mu sync.Mutex
+	// +checklocks:mu
+	slowest []slowRead // sorted by duration, descending
+	// +checklocks:mu
+	count int
+}
+
+func (t *slowReadTracker) record(ctx context.Context, ci content.Info, dur time.Duration) {
+	if t == nil || dur < t.threshold {
+		return
+	}
+
+	log(ctx).Warnf("slow read of content %v from pack blob %v took %v", ci.ContentID, ci.PackBlobID, dur)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.count++
+
+	t.slowest = append(t.slowest, slowRead{ci.ContentID, ci.PackBlobID, dur})
+	sort.Slice(t.slowest, func(i, j int) bool {
+		return t.slowest[i].duration > t.slowest[j].duration
+	})
+
+	if n := max(t.maxReported, 0); len(t.slowest) > n {
+		t.slowest = t.slowest[:n]
+	}
+}
+
+func (t *slowReadTracker) report(ctx context.Context) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	log(ctx).Infof("Found %v reads slower than %v.", t.count, t.threshold)
+
+	for _, r := range t.slowest {
+		log(ctx).Infof("  %v content %v pack blob %v", r.duration, r.contentID, r.packBlobID)
+	}
+}
+
+var (
+	errSuspiciouslySmallContent = errors.New("zero packed length")
+	errVerifyErrorLimitReached  = errors.New("error limit reached")
+)
+
+// verifyContentBounds verifies that the content occupies a non-empty region within its pack blob.
+// Contents with zero packed length are reported as suspiciously small instead of as failures.
+func verifyContentBounds(ci content.Info, bi blob.Metadata) error {
+	if ci.PackedLength == 0 {
+		// even empty contents have non-zero packed length due to encryption overhead.
+		return errors.Wrapf(errSuspiciouslySmallContent, "content %v at offset %v of pack blob %v", ci.ContentID, ci.PackOffset, ci.PackBlobID)
+	}
+
+	// compute the end offset using int64 to avoid uint32 overflow.
+	if end := int64(ci.PackOffset) + int64(ci.PackedLength); end > bi.Length {
+		return verifyError(verifyFailureOutOfBounds, errors.Errorf("content %v out of bounds of its pack blob %v (end offset %v, blob length %v)", ci.ContentID, ci.PackBlobID, end, bi.Length))
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// attestationKeyPurpose is the purpose used to derive the key signing integrity attestations from the repository master key.
+const attestationKeyPurpose = "content-verify-attestation"
+
+// contentAttestation records hashes of verified contents, allowing later verification runs to prove
+// that the verified state of the repository has not changed.
+type contentAttestation struct {
+	CreateTime   time.Time `json:"createTime"`
+	ContentCount int       `json:"contentCount"`
+	// hash over sorted content ID to content hash pairs.
+	RootHash string `json:"rootHash"`
+	// HMAC of the root hash using a key derived from the repository master key.
+	Signature string            `json:"signature"`
+	Contents  map[string]string `json:"contents"`
+}
+
+// attestationDiff describes differences between contents recorded in an attestation and the current state.
+type attestationDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d attestationDiff) count() int {
+	return len(d.Added) + len(d.Removed) + len(d.Changed)
+}
+
+// attestationTracker accumulates hashes of verified contents.
+// All methods are safe to call on nil tracker, in which case they do nothing.
+type attestationTracker struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	hashes map[string]string
+}
+
+func newAttestationTracker() *attestationTracker {
+	return &attestationTracker{hashes: map[string]string{}}
+}
+
+func (t *attestationTracker) record(cid content.ID, data []byte) {
+	if t == nil {
+		return
+	}
+
+	h := sha256.Sum256(data)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.hashes[cid.String()] = hex.EncodeToString(h[:])
+}
+
+func (t *attestationTracker) contentHashes() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.hashes
+}
+
+// attestationRootHash computes a deterministic hash over sorted content ID to content hash pairs.
+func attestationRootHash(hashes map[string]string) string {
+	ids := make([]string, 0, len(hashes))
+	for id := range hashes {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	h := sha256.New()
+
+	for _, id := range ids {
+		h.Write([]byte(id + ":" + hashes[id] + "\n")) //nolint:errcheck
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func signAttestation(key []byte, rootHash string) string {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(rootHash)) //nolint:errcheck
+
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func newContentAttestation(hashes map[string]string, now time.Time, key []byte) *contentAttestation {
+	root := attestationRootHash(hashes)
+
+	return &contentAttestation{
+		CreateTime:   now,
+		ContentCount: len(hashes),
+		RootHash:     root,
+		Signature:    signAttestation(key, root),
+		Contents:     hashes,
+	}
+}
+
+func writeContentAttestation(fname string, a *contentAttestation) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(a); err != nil {
+		return errors.Wrap(err, "unable to marshal attestation")
+	}
+
+	return errors.Wrap(atomicfile.Write(fname, &buf), "error writing attestation")
+}
+
+// readContentAttestation reads the attestation file and ensures it has not been modified since it was written.
+func readContentAttestation(fname string, key []byte) (*contentAttestation, error) {
+	b, err := os.ReadFile(fname) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read attestation")
+	}
+
+	a := &contentAttestation{}
+	if err := json.Unmarshal(b, a); err != nil {
+		return nil, errors.Wrap(err, "unable to parse attestation")
+	}
+
+	if attestationRootHash(a.Contents) != a.RootHash || len(a.Contents) != a.ContentCount {
+		return nil, errors.New("attestation contents do not match its root hash")
+	}
+
+	if !hmac.Equal([]byte(signAttestation(key, a.RootHash)), []byte(a.Signature)) {
+		return nil, errors.New("invalid attestation signature, the attestation was modified or created for a different repository")
+	}
+
+	return a, nil
+}
+
+// diffAttestation returns contents that were added, removed or changed compared to the attested hashes.
+func diffAttestation(attested, current map[string]string) attestationDiff {
+	var d attestationDiff
+
+	for id, h := range current {
+		switch ah, ok := attested[id]; {
+		case !ok:
+			d.Added = append(d.Added, id)
+		case ah != h:
+			d.Changed = append(d.Changed, id)
+		}
+	}
+
+	for id := range attested {
+		if _, ok := current[id]; !ok {
+			d.Removed = append(d.Removed, id)
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+
+	return d
+}
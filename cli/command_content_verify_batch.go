@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// contentIteratorFunc iterates contents to verify, invoking cb for each of them, possibly in parallel.
+// When the total number of contents is known upfront, it is reported to setTotal before iteration.
+type contentIteratorFunc func(setTotal func(int32), cb func(content.Info) error) error
+
+// contentGroupIteratorFunc iterates contents to verify in groups, such that all contents of a pack blob
+// are in the same group. When the total number of contents is known upfront, it is reported to setTotal before iteration.
+type contentGroupIteratorFunc func(setTotal func(int32), cb func(contents []content.Info) error) error
+
+// singleContentGroup returns a group iterator that returns all provided contents as a single group.
+func singleContentGroup(contents []content.Info) contentGroupIteratorFunc {
+	return func(setTotal func(int32), cb func([]content.Info) error) error {
+		setTotal(int32(len(contents))) //nolint:gosec
+
+		return cb(contents)
+	}
+}
+
+// batchByPackBlob returns an iterator that groups contents of each group returned by the provided iterator
+// by pack blob, so that each pack blob is processed as a unit instead of its contents being interleaved
+// with contents of other blobs. Only a single group is kept in memory at a time.
+//
+// Before contents of a pack blob are processed, beforePack is invoked with all of them.
+//
+// Up to 'parallel' pack blobs are processed at a time (bounding I/O parallelism) and for each of them
+// up to 'parallelPerBlob' contents are processed concurrently (bounding CPU parallelism), so
+// up to parallel*parallelPerBlob callbacks may be running at the same time.
+func batchByPackBlob(groups contentGroupIteratorFunc, parallel, parallelPerBlob int, beforePack func(contents []content.Info)) contentIteratorFunc {
+	return func(setTotal func(int32), cb func(content.Info) error) error {
+		return groups(setTotal, func(contents []content.Info) error {
+			return processContentGroup(contents, parallel, parallelPerBlob, beforePack, cb)
+		})
+	}
+}
+
+// processContentGroup groups the provided contents by pack blob and processes them.
+func processContentGroup(contents []content.Info, parallel, parallelPerBlob int, beforePack func([]content.Info), cb func(content.Info) error) error {
+	byPack := map[blob.ID][]content.Info{}
+
+	for _, ci := range contents {
+		byPack[ci.PackBlobID] = append(byPack[ci.PackBlobID], ci)
+	}
+
+	packs := make([]blob.ID, 0, len(byPack))
+	for id := range byPack {
+		packs = append(packs, id)
+	}
+
+	sort.Slice(packs, func(i, j int) bool { return packs[i] < packs[j] })
+
+	packCh := make(chan blob.ID, len(packs))
+	for _, id := range packs {
+		packCh <- id
+	}
+
+	close(packCh)
+
+	var eg errgroup.Group
+
+	for range max(parallel, 1) {
+		eg.Go(func() error {
+			for id := range packCh {
+				if beforePack != nil {
+					beforePack(byPack[id])
+				}
+
+				if err := processPackBlobContents(byPack[id], parallelPerBlob, cb); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	//nolint:wrapcheck
+	return eg.Wait()
+}
+
+// processPackBlobContents invokes cb for the provided contents of a single pack blob using up to 'parallel' goroutines.
+func processPackBlobContents(contents []content.Info, parallel int, cb func(content.Info) error) error {
+	sort.Slice(contents, func(i, j int) bool { return contents[i].PackOffset < contents[j].PackOffset })
+
+	ch := make(chan content.Info, len(contents))
+	for _, ci := range contents {
+		ch <- ci
+	}
+
+	close(ch)
+
+	var eg errgroup.Group
+
+	for range max(parallel, 1) {
+		eg.Go(func() error {
+			for ci := range ch {
+				if err := cb(ci); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	//nolint:wrapcheck
+	return eg.Wait()
+}
+
+// isCurrentIndexEntry returns true if the index entry is the one returned by the merged index for its content.
+func isCurrentIndexEntry(ci, current content.Info) bool {
+	return ci.PackBlobID == current.PackBlobID &&
+		ci.PackOffset == current.PackOffset &&
+		ci.Deleted == current.Deleted &&
+		ci.TimestampSeconds == current.TimestampSeconds
+}
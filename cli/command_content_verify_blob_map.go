@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/internal/tempfile"
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+const (
+	// estimated number of bytes of memory used by each entry of in-memory blob map.
+	estimatedBlobMapEntrySize = 160
+
+	// maximum size of a single memory segment of the on-disk blob map.
+	maxBlobMapSegmentSize = 16 << 20
+
+	// length of keys of the on-disk blob map.
+	blobMapKeyLength = 20
+
+	blobListingProgressInterval = 10000
+)
+
+// blobMetadataMap provides metadata of blobs listed before verification.
+type blobMetadataMap interface {
+	get(id blob.ID) (blob.Metadata, bool, error)
+	forEach(cb func(bm blob.Metadata) error) error
+	remove(id blob.ID)
+	close(ctx context.Context)
+}
+
+// memoryBlobMap is a blobMetadataMap kept entirely in memory.
+type memoryBlobMap map[blob.ID]blob.Metadata
+
+func (m memoryBlobMap) get(id blob.ID) (blob.Metadata, bool, error) {
+	bm, ok := m[id]
+	return bm, ok, nil
+}
+
+func (m memoryBlobMap) forEach(cb func(bm blob.Metadata) error) error {
+	for _, bm := range m {
+		if err := cb(bm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m memoryBlobMap) remove(id blob.ID) {
+	delete(m, id)
+}
+
+func (m memoryBlobMap) close(_ context.Context) {}
+
+// blobMapLookupError is returned when the blob map cannot be read. Unlike verification failures
+// of individual contents, it stops the verification, since a blob that could not be looked up
+// must not be reported as missing.
+type blobMapLookupError struct {
+	err error
+}
+
+func (e *blobMapLookupError) Error() string { return e.err.Error() }
+func (e *blobMapLookupError) Unwrap() error { return e.err }
+
+// diskBlobMap is a blobMetadataMap stored in a hash map that spills over to memory-mapped files
+// and a temporary file with metadata of all blobs in listing order, which keeps memory usage bounded.
+type diskBlobMap struct {
+	m *bigmap.Map
+	f *os.File
+	w *bufio.Writer
+
+	removed map[blob.ID]bool
+}
+
+func newDiskBlobMap(ctx context.Context, memoryLimit int64) (*diskBlobMap, error) {
+	segmentSize := min(memoryLimit, maxBlobMapSegmentSize)
+
+	m, err := bigmap.NewMapWithOptions(ctx, &bigmap.Options{
+		MemorySegmentSize: segmentSize,
+		NumMemorySegments: int(max(memoryLimit/segmentSize, 1)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create blob map")
+	}
+
+	f, err := tempfile.Create("")
+	if err != nil {
+		m.Close(ctx)
+
+		return nil, errors.Wrap(err, "unable to create blob map file")
+	}
+
+	return &diskBlobMap{
+		m:       m,
+		f:       f,
+		w:       bufio.NewWriter(f),
+		removed: map[blob.ID]bool{},
+	}, nil
+}
+
+func diskBlobMapKey(id blob.ID) []byte {
+	h := sha256.Sum256([]byte(id))
+	return h[:blobMapKeyLength]
+}
+
+func (m *diskBlobMap) add(ctx context.Context, bm blob.Metadata) error {
+	var v [16]byte
+
+	binary.BigEndian.PutUint64(v[0:8], uint64(bm.Length))                //nolint:gosec
+	binary.BigEndian.PutUint64(v[8:16], uint64(bm.Timestamp.UnixNano())) //nolint:gosec
+
+	m.m.PutIfAbsent(ctx, diskBlobMapKey(bm.BlobID), v[:])
+
+	var lenBuf [binary.MaxVarintLen64]byte
+
+	n := binary.PutUvarint(lenBuf[:], uint64(len(bm.BlobID)))
+
+	m.w.Write(lenBuf[:n])              //nolint:errcheck
+	m.w.WriteString(string(bm.BlobID)) //nolint:errcheck
+	_, err := m.w.Write(v[:])
+
+	return errors.Wrap(err, "error writing blob map file")
+}
+
+// finishListing flushes metadata written to the file, must be called after all blobs have been added.
+func (m *diskBlobMap) finishListing() error {
+	return errors.Wrap(m.w.Flush(), "error writing blob map file")
+}
+
+func (m *diskBlobMap) get(id blob.ID) (blob.Metadata, bool, error) {
+	if m.removed[id] {
+		return blob.Metadata{}, false, nil
+	}
+
+	// the map does not use the context for lookups.
+	v, ok, err := m.m.Get(context.Background(), nil, diskBlobMapKey(id))
+	if err != nil {
+		return blob.Metadata{}, false, &blobMapLookupError{errors.Wrapf(err, "unable to look up blob %v in blob map", id)}
+	}
+
+	if !ok {
+		return blob.Metadata{}, false, nil
+	}
+
+	if len(v) != 16 { //nolint:mnd
+		return blob.Metadata{}, false, &blobMapLookupError{errors.Errorf("invalid blob map entry for blob %v", id)}
+	}
+
+	return blob.Metadata{
+		BlobID:    id,
+		Length:    int64(binary.BigEndian.Uint64(v[0:8])),                //nolint:gosec
+		Timestamp: time.Unix(0, int64(binary.BigEndian.Uint64(v[8:16]))), //nolint:gosec
+	}, true, nil
+}
+
+func (m *diskBlobMap) forEach(cb func(bm blob.Metadata) error) error {
+	r := bufio.NewReader(io.NewSectionReader(m.f, 0, 1<<62)) //nolint:mnd
+
+	for {
+		idLen, err := binary.ReadUvarint(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "error reading blob map file")
+		}
+
+		buf := make([]byte, idLen+16) //nolint:mnd
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return errors.Wrap(err, "error reading blob map file")
+		}
+
+		id := blob.ID(buf[:idLen])
+		if m.removed[id] {
+			continue
+		}
+
+		v := buf[idLen:]
+
+		if err := cb(blob.Metadata{
+			BlobID:    id,
+			Length:    int64(binary.BigEndian.Uint64(v[0:8])),                //nolint:gosec
+			Timestamp: time.Unix(0, int64(binary.BigEndian.Uint64(v[8:16]))), //nolint:gosec
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *diskBlobMap) remove(id blob.ID) {
+	m.removed[id] = true
+}
+
+func (m *diskBlobMap) close(ctx context.Context) {
+	m.m.Close(ctx)
+	m.f.Close() //nolint:errcheck
+}
+
+// readBlobMetadataMap lists all blobs, storing their metadata in memory or, if it would use more than the
+// provided amount of memory, on disk. Zero memory limit means the map is always kept in memory.
+func readBlobMetadataMap(ctx context.Context, br blob.Reader, memoryLimit int64) (blobMetadataMap, error) {
+	if memoryLimit <= 0 {
+		m, err := blob.ReadBlobMap(ctx, br)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read blob map")
+		}
+
+		return memoryBlobMap(m), nil
+	}
+
+	var (
+		mem   = memoryBlobMap{}
+		disk  *diskBlobMap
+		count int
+		timer = timetrack.StartTimer()
+	)
+
+	log(ctx).Info("Listing blobs...")
+
+	if err := br.ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		count++
+		if count%blobListingProgressInterval == 0 {
+			log(ctx).Infof("  %v blobs...", count)
+		}
+
+		if disk != nil {
+			return disk.add(ctx, bm)
+		}
+
+		mem[bm.BlobID] = bm
+
+		if int64(len(mem))*estimatedBlobMapEntrySize <= memoryLimit {
+			return nil
+		}
+
+		log(ctx).Infof("Blob map exceeds memory limit of %v, storing it on disk.", units.BytesString(memoryLimit))
+
+		d, err := newDiskBlobMap(ctx, memoryLimit)
+		if err != nil {
+			return err
+		}
+
+		disk = d
+
+		for _, bm := range mem {
+			if err := disk.add(ctx, bm); err != nil {
+				return err
+			}
+		}
+
+		mem = nil
+
+		return nil
+	}); err != nil {
+		if disk != nil {
+			disk.close(ctx)
+		}
+
+		// never return partial results, since callers would treat blobs that were not listed as missing.
+		return nil, errors.Wrapf(err, "unable to list blobs, listing was interrupted after %v blobs", count)
+	}
+
+	dur := timer.Elapsed()
+
+	log(ctx).Infof("Listed %v blobs in %v (%.0f blobs/s).", count, dur.Round(time.Millisecond), float64(count)/max(dur.Seconds(), 1e-3))
+
+	if disk == nil {
+		return mem, nil
+	}
+
+	if err := disk.finishListing(); err != nil {
+		disk.close(ctx)
+
+		return nil, err
+	}
+
+	return disk, nil
+}
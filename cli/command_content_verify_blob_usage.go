@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+const (
+	blobUsageFormatJSON = "json"
+	blobUsageFormatCSV  = "csv"
+)
+
+// blobUsage describes how much of a pack blob is occupied by live contents.
+type blobUsage struct {
+	BlobID      blob.ID `json:"blobID"`
+	Length      int64   `json:"length"`
+	LiveBytes   int64   `json:"liveBytes"`
+	DeadBytes   int64   `json:"deadBytes"`
+	Utilization float64 `json:"utilization"`
+}
+
+// blobUsageTracker accumulates the number of live bytes in each pack blob.
+// All methods are safe to call on nil tracker, in which case they do nothing.
+type blobUsageTracker struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	liveBytes map[blob.ID]int64
+}
+
+func newBlobUsageTracker() *blobUsageTracker {
+	return &blobUsageTracker{liveBytes: map[blob.ID]int64{}}
+}
+
+func (t *blobUsageTracker) record(ci content.Info) {
+	if t == nil || ci.Deleted {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.liveBytes[ci.PackBlobID] += int64(ci.PackedLength)
+}
+
+// usage returns usage of all pack blobs in the blob map, sorted by the number of dead bytes, descending.
+func (t *blobUsageTracker) usage(blobMap blobMetadataMap) ([]blobUsage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var result []blobUsage
+
+	if err := blobMap.forEach(func(bm blob.Metadata) error {
+		if !isPackBlob(bm.BlobID) {
+			return nil
+		}
+
+		bu := blobUsage{
+			BlobID:    bm.BlobID,
+			Length:    bm.Length,
+			LiveBytes: t.liveBytes[bm.BlobID],
+		}
+
+		bu.DeadBytes = max(bu.Length-bu.LiveBytes, 0)
+
+		if bu.Length > 0 {
+			bu.Utilization = float64(bu.LiveBytes) / float64(bu.Length)
+		}
+
+		result = append(result, bu)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DeadBytes != result[j].DeadBytes {
+			return result[i].DeadBytes > result[j].DeadBytes
+		}
+
+		return result[i].BlobID < result[j].BlobID
+	})
+
+	return result, nil
+}
+
+// oversubscribed returns usage of pack blobs whose live contents claim more bytes than the length
+// of the blob, which indicates index inconsistency even when each content individually fits in the blob.
+// Pack blobs also contain a random preamble and padding, so their length always exceeds the sum of contents.
+func (t *blobUsageTracker) oversubscribed(blobMap blobMetadataMap) ([]blobUsage, error) {
+	usage, err := t.usage(blobMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []blobUsage
+
+	for _, bu := range usage {
+		if bu.LiveBytes > bu.Length {
+			result = append(result, bu)
+		}
+	}
+
+	return result, nil
+}
+
+// reportOversubscribedBlobs logs oversubscribed pack blobs and records them in failures.
+func reportOversubscribedBlobs(ctx context.Context, oversubscribed []blobUsage, failures *verifyFailureTracker) {
+	for _, bu := range oversubscribed {
+		err := errors.Errorf("pack blob %v is oversubscribed: its contents claim %v bytes, blob length is %v", bu.BlobID, bu.LiveBytes, bu.Length)
+
+		log(ctx).Errorf("%v", err)
+
+		failures.recordFailure(contentVerifyFailure{
+			PackBlobID: bu.BlobID,
+			Reason:     verifyFailureOversubscribed,
+			Error:      err.Error(),
+		})
+	}
+
+	if len(oversubscribed) > 0 {
+		log(ctx).Infof("Found %v pack blobs whose contents claim more bytes than the blob length.", len(oversubscribed))
+	}
+}
+
+func (t *blobUsageTracker) writeToFile(ctx context.Context, fname, format string, blobMap blobMetadataMap) error {
+	if t == nil {
+		return nil
+	}
+
+	usage, err := t.usage(blobMap)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(fname) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to create blob usage map file")
+	}
+
+	if err := writeBlobUsage(f, format, usage); err != nil {
+		f.Close() //nolint:errcheck
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "error closing blob usage map file")
+	}
+
+	var totalDead int64
+
+	for _, bu := range usage {
+		totalDead += bu.DeadBytes
+	}
+
+	log(ctx).Infof("Wrote usage of %v pack blobs to %v, %v not used by live contents.", len(usage), fname, units.BytesString(totalDead))
+
+	return nil
+}
+
+func writeBlobUsage(w io.Writer, format string, usage []blobUsage) error {
+	if format == blobUsageFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return errors.Wrap(enc.Encode(usage), "error writing JSON")
+	}
+
+	cw := csv.NewWriter(w)
+
+	//nolint:errcheck
+	cw.Write([]string{"blobID", "length", "liveBytes", "deadBytes", "utilization"})
+
+	for _, bu := range usage {
+		//nolint:errcheck
+		cw.Write([]string{
+			string(bu.BlobID),
+			strconv.FormatInt(bu.Length, 10),
+			strconv.FormatInt(bu.LiveBytes, 10),
+			strconv.FormatInt(bu.DeadBytes, 10),
+			strconv.FormatFloat(bu.Utilization, 'f', 4, 64),
+		})
+	}
+
+	cw.Flush()
+
+	return errors.Wrap(cw.Error(), "error writing CSV")
+}
+
+func isPackBlob(id blob.ID) bool {
+	for _, prefix := range content.PackBlobIDPrefixes {
+		if strings.HasPrefix(string(id), string(prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
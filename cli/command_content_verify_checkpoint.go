@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// contentVerifyCheckpoint is persisted periodically during verification with --checkpoint-file,
+// so that interrupted verification can be resumed.
+type contentVerifyCheckpoint struct {
+	// range of content IDs being verified, resuming is only possible for the same range.
+	RangeStart content.IDPrefix `json:"rangeStart"`
+	RangeEnd   content.IDPrefix `json:"rangeEnd"`
+
+	// all contents up to and including this one have been verified.
+	LastContentID content.ID `json:"lastContentID"`
+
+	// counts of verified contents and errors at the time the checkpoint was written, may include
+	// contents after LastContentID which will be verified again after resuming.
+	VerifiedCount int32 `json:"verifiedCount"`
+	ErrorCount    int32 `json:"errorCount"`
+
+	UpdateTime time.Time `json:"updateTime"`
+}
+
+// resumeRange returns the range of contents that remain to be verified.
+func (cp *contentVerifyCheckpoint) resumeRange() content.IDRange {
+	if cp.LastContentID == content.EmptyID {
+		return content.IDRange{StartID: cp.RangeStart, EndID: cp.RangeEnd}
+	}
+
+	return content.IDRange{
+		// the smallest ID prefix greater than the last verified content ID.
+		StartID: content.IDPrefix(cp.LastContentID.String() + "\x00"),
+		EndID:   cp.RangeEnd,
+	}
+}
+
+func readContentVerifyCheckpoint(fname string) (*contentVerifyCheckpoint, error) {
+	b, err := os.ReadFile(fname) //nolint:gosec
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read checkpoint")
+	}
+
+	cp := &contentVerifyCheckpoint{}
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, errors.Wrap(err, "unable to parse checkpoint")
+	}
+
+	return cp, nil
+}
+
+func writeContentVerifyCheckpoint(fname string, cp *contentVerifyCheckpoint) error {
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(cp); err != nil {
+		return errors.Wrap(err, "unable to marshal checkpoint")
+	}
+
+	return errors.Wrap(atomicfile.Write(fname, &buf), "error writing checkpoint")
+}
+
+// verifyCheckpointer tracks completion of contents dispatched in ID order and periodically writes the checkpoint
+// with the highest content ID such that it and all contents before it have been verified.
+// All methods are safe to call on nil checkpointer.
+type verifyCheckpointer struct {
+	fname    string
+	interval int
+	counts   func() (verified, errors int32)
+
+	mu sync.Mutex
+	// +checklocks:mu
+	cp contentVerifyCheckpoint
+	// +checklocks:mu
+	completed map[int64]content.ID // completed contents with sequence numbers after watermark
+	// +checklocks:mu
+	watermark int64 // sequence number of the last content such that it and all contents before it were completed
+	// +checklocks:mu
+	sinceWrite int
+	// +checklocks:mu
+	writeErr error
+}
+
+func newVerifyCheckpointer(fname string, interval int, r content.IDRange, counts func() (int32, int32)) *verifyCheckpointer {
+	return &verifyCheckpointer{
+		fname:     fname,
+		interval:  max(interval, 1),
+		counts:    counts,
+		cp:        contentVerifyCheckpoint{RangeStart: r.StartID, RangeEnd: r.EndID},
+		completed: map[int64]content.ID{},
+	}
+}
+
+// iterate returns an iterator which invokes cb for contents returned by the provided iterator using up to 'parallel'
+// goroutines, recording their completion. The provided iterator must return contents sequentially in ID order.
+func (v *verifyCheckpointer) iterate(iterate contentIteratorFunc, parallel int) contentIteratorFunc {
+	type seqContent struct {
+		seq int64
+		ci  content.Info
+	}
+
+	return func(setTotal func(int32), cb func(content.Info) error) error {
+		ch := make(chan seqContent, parallel)
+
+		eg, egctx := errgroup.WithContext(context.Background())
+
+		for range max(parallel, 1) {
+			eg.Go(func() error {
+				for sc := range ch {
+					if err := cb(sc.ci); err != nil {
+						return err
+					}
+
+					v.done(sc.seq, sc.ci.ContentID)
+				}
+
+				return nil
+			})
+		}
+
+		var seq int64
+
+		err := iterate(setTotal, func(ci content.Info) error {
+			seq++
+
+			select {
+			case ch <- seqContent{seq, ci}:
+				return nil
+
+			case <-egctx.Done():
+				// one of the workers failed.
+				return egctx.Err()
+			}
+		})
+
+		close(ch)
+
+		if werr := eg.Wait(); werr != nil {
+			return werr //nolint:wrapcheck
+		}
+
+		return err
+	}
+}
+
+// done records completion of the content with the provided sequence number and writes the checkpoint every 'interval' contents.
+func (v *verifyCheckpointer) done(seq int64, cid content.ID) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.completed[seq] = cid
+
+	for {
+		next, ok := v.completed[v.watermark+1]
+		if !ok {
+			break
+		}
+
+		delete(v.completed, v.watermark+1)
+		v.watermark++
+		v.cp.LastContentID = next
+	}
+
+	v.sinceWrite++
+	if v.sinceWrite < v.interval {
+		return
+	}
+
+	v.sinceWrite = 0
+	v.cp.VerifiedCount, v.cp.ErrorCount = v.counts()
+	v.cp.UpdateTime = clock.Now()
+
+	if err := writeContentVerifyCheckpoint(v.fname, &v.cp); err != nil && v.writeErr == nil {
+		v.writeErr = err
+	}
+}
+
+// finish removes the checkpoint after verification has completed.
+func (v *verifyCheckpointer) finish(ctx context.Context) {
+	if v == nil {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.writeErr != nil {
+		log(ctx).Warnf("Unable to write verification checkpoint: %v", v.writeErr)
+	}
+
+	if err := os.Remove(v.fname); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log(ctx).Warnf("Unable to remove verification checkpoint: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// verifyFailureUnknownContent is reported for content IDs provided with --content-id or --content-ids-file
+// which are not present in the index.
+const verifyFailureUnknownContent = "unknown-content"
+
+// hasExplicitContentIDs returns true if contents to verify were provided with --content-id or --content-ids-file.
+func (c *commandContentVerify) hasExplicitContentIDs() bool {
+	return len(c.contentIDs) > 0 || c.contentIDsFile != ""
+}
+
+// explicitContentIDs returns content IDs provided with --content-id and --content-ids-file, without duplicates.
+func (c *commandContentVerify) explicitContentIDs() ([]content.ID, error) {
+	ids := append([]string(nil), c.contentIDs...)
+
+	if c.contentIDsFile != "" {
+		fromFile, err := readContentIDsFile(c.contentIDsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, fromFile...)
+	}
+
+	var (
+		result []content.ID
+		seen   = map[content.ID]bool{}
+	)
+
+	for _, s := range ids {
+		cid, err := content.ParseID(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid content ID %q", s)
+		}
+
+		if seen[cid] {
+			continue
+		}
+
+		seen[cid] = true
+
+		result = append(result, cid)
+	}
+
+	return result, nil
+}
+
+// readContentIDsFile reads content IDs from the provided file, one per line, ignoring empty lines and comments starting with '#'.
+func readContentIDsFile(fname string) ([]string, error) {
+	f, err := os.Open(fname) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open content IDs file")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	var result []string
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		result = append(result, l)
+	}
+
+	return result, errors.Wrap(s.Err(), "error reading content IDs file")
+}
+
+// resolveExplicitContents looks up contents provided with --content-id or --content-ids-file and
+// reports the ones that are not present in the index as errors.
+func (c *commandContentVerify) resolveExplicitContents(ctx context.Context, rep repo.DirectRepository, failures *verifyFailureTracker) (found []content.Info, unknownCount int32, err error) {
+	cids, err := c.explicitContentIDs()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, cid := range cids {
+		ci, err := rep.ContentReader().ContentInfo(ctx, cid)
+		if errors.Is(err, content.ErrContentNotFound) {
+			err = verifyError(verifyFailureUnknownContent, errors.Errorf("content %v not found", cid))
+
+			log(ctx).Errorf("error %v", err)
+			failures.record(content.Info{ContentID: cid}, err)
+
+			unknownCount++
+
+			continue
+		}
+
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "unable to get info of content %v", cid)
+		}
+
+		found = append(found, ci)
+	}
+
+	log(ctx).Infof("Verifying %v of %v provided contents, %v not found.", len(found), len(cids), unknownCount)
+
+	return found, unknownCount, nil
+}
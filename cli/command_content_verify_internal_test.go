@@ -0,0 +1,707 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+func TestVerifyContentBounds(t *testing.T) {
+	bm := blob.Metadata{BlobID: "p1234", Length: 1000}
+
+	cases := []struct {
+		name           string
+		offset         uint32
+		length         uint32
+		wantErr        bool
+		wantSuspicious bool
+	}{
+		{name: "valid", offset: 0, length: 100},
+		{name: "ends at blob end", offset: 900, length: 100},
+		{name: "zero length", offset: 100, length: 0, wantErr: true, wantSuspicious: true},
+		{name: "zero length at blob end", offset: 1000, length: 0, wantErr: true, wantSuspicious: true},
+		{name: "offset equals blob length", offset: 1000, length: 1, wantErr: true},
+		{name: "past blob end", offset: 950, length: 100, wantErr: true},
+		{name: "offset plus length overflows uint32", offset: math.MaxUint32 - 10, length: 100, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyContentBounds(content.Info{
+				PackBlobID:   bm.BlobID,
+				PackOffset:   tc.offset,
+				PackedLength: tc.length,
+			}, bm)
+
+			if !tc.wantErr {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Equal(t, tc.wantSuspicious, errors.Is(err, errSuspiciouslySmallContent))
+
+			// suspicious contents are not verification failures.
+			require.Equal(t, !tc.wantSuspicious, verifyFailureReason(err, "") != "")
+		})
+	}
+}
+
+func TestContentVerifyZeroLengthIsSuspicious(t *testing.T) {
+	ctx := testlogging.Context(t)
+	blobMap := memoryBlobMap{"p1234": {BlobID: "p1234", Length: 1000}}
+	ci := content.Info{ContentID: mustParseContentID(t, "abcdef0123456789abcdef0123456789"), PackBlobID: "p1234", PackOffset: 100}
+
+	var c commandContentVerify
+
+	err := c.contentVerify(ctx, nil, ci, blobMap, 100, nil)
+	require.ErrorIs(t, err, errSuspiciouslySmallContent)
+
+	var suspicious verifyFailureTracker
+
+	suspicious.recordSuspicious(ci, err)
+	require.EqualValues(t, 1, suspicious.count())
+	require.Equal(t, verifySuspiciousZeroLength, suspicious.sortedFailures()[0].Reason)
+}
+
+func TestSlowReadTracker(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	// nil tracker is a no-op
+	var nilTracker *slowReadTracker
+
+	nilTracker.record(ctx, content.Info{}, time.Hour)
+	nilTracker.report(ctx)
+
+	tr := &slowReadTracker{threshold: 10 * time.Millisecond, maxReported: 2}
+
+	for i, d := range []time.Duration{5, 30, 10, 20, 1} {
+		tr.record(ctx, content.Info{PackBlobID: blob.ID(fmt.Sprintf("p%v", i))}, d*time.Millisecond)
+	}
+
+	tr.report(ctx)
+
+	require.Equal(t, 3, tr.count)
+	require.Len(t, tr.slowest, 2)
+	require.Equal(t, blob.ID("p1"), tr.slowest[0].packBlobID)
+	require.Equal(t, blob.ID("p3"), tr.slowest[1].packBlobID)
+}
+
+func TestManifestObjectReferences(t *testing.T) {
+	require.Empty(t, manifestObjectReferences([]byte(`not json`)))
+	require.Empty(t, manifestObjectReferences([]byte(`{"policy":{"obj":"not-an-object-id!"}}`)))
+
+	refs := manifestObjectReferences([]byte(`{
+		"source": {"host":"h","userName":"u","path":"/p"},
+		"rootEntry": {"name":"p","obj":"k1234567890abcdef1234567890abcdef"},
+		"other": [{"nested": {"obj":"Ixabcdef1234567890abcdef1234567890"}}],
+		"obj-like": "abc"
+	}`))
+
+	var got []string
+	for _, r := range refs {
+		got = append(got, r.String())
+	}
+
+	require.ElementsMatch(t, []string{"k1234567890abcdef1234567890abcdef", "Ixabcdef1234567890abcdef1234567890"}, got)
+}
+
+func TestContentVerifyBlobAgeMin(t *testing.T) {
+	ctx := testlogging.Context(t)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	c := &commandContentVerify{blobAgeMin: time.Minute, verifyStartTime: now}
+
+	recent := content.Info{PackBlobID: "p1234", TimestampSeconds: now.Add(-30 * time.Second).Unix()}
+	old := content.Info{PackBlobID: "p1234", TimestampSeconds: now.Add(-2 * time.Minute).Unix()}
+
+	// recently-written content with missing blob is ignored.
+	require.NoError(t, c.contentVerify(ctx, nil, recent, memoryBlobMap{}, 0, nil))
+	require.EqualValues(t, 1, c.settlingCount.Load())
+
+	// older content with missing blob is reported.
+	require.ErrorContains(t, c.contentVerify(ctx, nil, old, memoryBlobMap{}, 0, nil), "missing blob")
+
+	// without the flag, all missing blobs are reported.
+	c2 := &commandContentVerify{verifyStartTime: now}
+	require.Error(t, c2.contentVerify(ctx, nil, recent, memoryBlobMap{}, 0, nil))
+}
+
+// failingBlobMap is a blobMetadataMap whose lookups always fail.
+type failingBlobMap struct {
+	memoryBlobMap
+}
+
+func (failingBlobMap) get(id blob.ID) (blob.Metadata, bool, error) {
+	return blob.Metadata{}, false, &blobMapLookupError{errors.Errorf("lookup of %v failed", id)}
+}
+
+func TestContentVerifyBlobMapLookupError(t *testing.T) {
+	ctx := testlogging.Context(t)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	c := &commandContentVerify{verifyStartTime: now, validateTombstones: true}
+
+	var bme *blobMapLookupError
+
+	// lookup errors are not reported as missing blobs.
+	err := c.contentVerify(ctx, nil, content.Info{PackBlobID: "p1234"}, failingBlobMap{}, 0, nil)
+	require.ErrorAs(t, err, &bme)
+	require.NotContains(t, err.Error(), "missing blob")
+
+	err = c.verifyContentOrTombstone(ctx, nil, content.Info{PackBlobID: "p1234", Deleted: true, TimestampSeconds: now.Unix()}, failingBlobMap{}, 0, nil)
+	require.ErrorAs(t, err, &bme)
+}
+
+func TestBlobUsageTracker(t *testing.T) {
+	blobMap := memoryBlobMap{
+		"p1": {BlobID: "p1", Length: 1000},
+		"p2": {BlobID: "p2", Length: 500},
+		"q1": {BlobID: "q1", Length: 100},
+		"n1": {BlobID: "n1", Length: 100},
+	}
+
+	tr := newBlobUsageTracker()
+	tr.record(content.Info{PackBlobID: "p1", PackedLength: 900})
+	tr.record(content.Info{PackBlobID: "p2", PackedLength: 100})
+	tr.record(content.Info{PackBlobID: "p2", PackedLength: 100})
+	tr.record(content.Info{PackBlobID: "p2", PackedLength: 200, Deleted: true})
+	tr.record(content.Info{PackBlobID: "q1", PackedLength: 100})
+
+	require.Equal(t, []blobUsage{
+		{BlobID: "p2", Length: 500, LiveBytes: 200, DeadBytes: 300, Utilization: 0.4},
+		{BlobID: "p1", Length: 1000, LiveBytes: 900, DeadBytes: 100, Utilization: 0.9},
+		{BlobID: "q1", Length: 100, LiveBytes: 100, DeadBytes: 0, Utilization: 1},
+	}, mustGetBlobUsage(t, tr, blobMap))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeBlobUsage(&buf, blobUsageFormatCSV, mustGetBlobUsage(t, tr, blobMap)))
+	require.Equal(t, "blobID,length,liveBytes,deadBytes,utilization\np2,500,200,300,0.4000\np1,1000,900,100,0.9000\nq1,100,100,0,1.0000\n", buf.String())
+
+	var parsed []blobUsage
+
+	buf.Reset()
+	require.NoError(t, writeBlobUsage(&buf, blobUsageFormatJSON, mustGetBlobUsage(t, tr, blobMap)))
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &parsed))
+	require.Equal(t, mustGetBlobUsage(t, tr, blobMap), parsed)
+
+	// nil tracker is a no-op
+	var nilTracker *blobUsageTracker
+
+	nilTracker.record(content.Info{PackBlobID: "p1", PackedLength: 1})
+	require.NoError(t, nilTracker.writeToFile(testlogging.Context(t), "", blobUsageFormatCSV, blobMap))
+}
+
+func mustGetBlobUsage(t *testing.T, tr *blobUsageTracker, blobMap blobMetadataMap) []blobUsage {
+	t.Helper()
+
+	usage, err := tr.usage(blobMap)
+	require.NoError(t, err)
+
+	return usage
+}
+
+func TestOversubscribedBlobs(t *testing.T) {
+	blobMap := memoryBlobMap{
+		"p1": {BlobID: "p1", Length: 1000},
+		"p2": {BlobID: "p2", Length: 500},
+	}
+
+	tr := newBlobUsageTracker()
+
+	// each content fits in its blob, but together they claim more than the blob length.
+	tr.record(content.Info{PackBlobID: "p1", PackOffset: 0, PackedLength: 600})
+	tr.record(content.Info{PackBlobID: "p1", PackOffset: 300, PackedLength: 600})
+	tr.record(content.Info{PackBlobID: "p2", PackedLength: 400})
+	tr.record(content.Info{PackBlobID: "p2", PackedLength: 400, Deleted: true})
+
+	over, err := tr.oversubscribed(blobMap)
+	require.NoError(t, err)
+	require.Equal(t, []blobUsage{
+		{BlobID: "p1", Length: 1000, LiveBytes: 1200, DeadBytes: 0, Utilization: 1.2},
+	}, over)
+
+	var failures verifyFailureTracker
+
+	reportOversubscribedBlobs(testlogging.Context(t), nil, &failures)
+	require.EqualValues(t, 0, failures.count())
+
+	reportOversubscribedBlobs(testlogging.Context(t), over, &failures)
+	require.EqualValues(t, 1, failures.count())
+
+	f := failures.sortedFailures()[0]
+	require.Equal(t, blob.ID("p1"), f.PackBlobID)
+	require.Equal(t, verifyFailureOversubscribed, f.Reason)
+}
+
+func TestBatchByPackBlob(t *testing.T) {
+	var input []content.Info
+
+	for i := range 30 {
+		input = append(input, content.Info{
+			ContentID:  mustParseContentID(t, fmt.Sprintf("%032x", i)),
+			PackBlobID: blob.ID(fmt.Sprintf("p%v", i%3)),
+			PackOffset: uint32(i), //nolint:gosec
+		})
+	}
+
+	// contents are returned in two groups, the second one sharing no pack blobs with the first.
+	groups := func(setTotal func(int32), cb func([]content.Info) error) error {
+		setTotal(int32(len(input)))
+
+		var first, second []content.Info
+
+		for _, ci := range input {
+			if ci.PackBlobID == "p2" {
+				second = append(second, ci)
+			} else {
+				first = append(first, ci)
+			}
+		}
+
+		if err := cb(first); err != nil {
+			return err
+		}
+
+		return cb(second)
+	}
+
+	var (
+		mu          sync.Mutex
+		total       int32
+		seen        = map[content.ID]int{}
+		activePacks = map[blob.ID]int{}
+		maxPacks    int
+		prefetched  = map[blob.ID]int{}
+	)
+
+	beforePack := func(contents []content.Info) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		require.Len(t, contents, 10)
+		prefetched[contents[0].PackBlobID]++
+	}
+
+	require.NoError(t, batchByPackBlob(groups, 2, 4, beforePack)(func(n int32) { total = n }, func(ci content.Info) error {
+		mu.Lock()
+		require.Equal(t, 1, prefetched[ci.PackBlobID], "pack must be prefetched once before its contents")
+		seen[ci.ContentID]++
+		activePacks[ci.PackBlobID]++
+		maxPacks = max(maxPacks, len(activePacks))
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		if activePacks[ci.PackBlobID]--; activePacks[ci.PackBlobID] == 0 {
+			delete(activePacks, ci.PackBlobID)
+		}
+		mu.Unlock()
+
+		return nil
+	}))
+
+	require.EqualValues(t, len(input), total)
+	require.Len(t, seen, len(input))
+
+	for cid, cnt := range seen {
+		require.Equal(t, 1, cnt, cid)
+	}
+
+	require.LessOrEqual(t, maxPacks, 2)
+	require.Equal(t, map[blob.ID]int{"p0": 1, "p1": 1, "p2": 1}, prefetched)
+
+	// errors returned by the callback are propagated.
+	someErr := errors.New("some error")
+
+	require.ErrorIs(t, batchByPackBlob(groups, 2, 4, nil)(func(int32) {}, func(content.Info) error {
+		return someErr
+	}), someErr)
+}
+
+func TestIsCurrentIndexEntry(t *testing.T) {
+	current := content.Info{PackBlobID: "p1", PackOffset: 10, TimestampSeconds: 100}
+
+	require.True(t, isCurrentIndexEntry(current, current))
+
+	superseded := current
+	superseded.PackBlobID = "p0"
+	require.False(t, isCurrentIndexEntry(superseded, current))
+
+	deleted := current
+	deleted.Deleted = true
+	require.False(t, isCurrentIndexEntry(deleted, current))
+
+	older := current
+	older.TimestampSeconds = 50
+	require.False(t, isCurrentIndexEntry(older, current))
+}
+
+func TestVerifyTombstone(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	blobMap := memoryBlobMap{
+		"p1234": {BlobID: "p1234", Length: 1000},
+	}
+
+	valid := content.Info{
+		ContentID:        mustParseContentID(t, "abcdef01"),
+		Deleted:          true,
+		TimestampSeconds: now.Add(-time.Hour).Unix(),
+		PackBlobID:       "p1234",
+		PackOffset:       100,
+		PackedLength:     50,
+	}
+
+	cases := []struct {
+		name    string
+		modify  func(ci *content.Info)
+		wantErr string
+	}{
+		{name: "valid", modify: func(ci *content.Info) {}},
+		{name: "pack blob already deleted", modify: func(ci *content.Info) { ci.PackBlobID = "pdeleted" }},
+		{name: "special pack blob", modify: func(ci *content.Info) { ci.PackBlobID = "q1234"; ci.PackedLength = 0 }},
+		{name: "not deleted", modify: func(ci *content.Info) { ci.Deleted = false }, wantErr: "not marked as deleted"},
+		{name: "zero timestamp", modify: func(ci *content.Info) { ci.TimestampSeconds = 0 }, wantErr: "invalid timestamp"},
+		{name: "future timestamp", modify: func(ci *content.Info) { ci.TimestampSeconds = now.Add(time.Hour).Unix() }, wantErr: "in the future"},
+		{name: "small clock skew", modify: func(ci *content.Info) { ci.TimestampSeconds = now.Add(time.Minute).Unix() }},
+		{name: "missing pack reference", modify: func(ci *content.Info) { ci.PackBlobID = "" }, wantErr: "invalid pack blob reference"},
+		{name: "non-pack blob reference", modify: func(ci *content.Info) { ci.PackBlobID = "xn0_abc" }, wantErr: "invalid pack blob reference"},
+		{name: "out of bounds", modify: func(ci *content.Info) { ci.PackOffset = 990 }, wantErr: "invalid region"},
+		{name: "zero length", modify: func(ci *content.Info) { ci.PackedLength = 0 }, wantErr: "zero packed length"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ci := valid
+			tc.modify(&ci)
+
+			err := verifyTombstone(ci, blobMap, now)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func mustParseContentID(t *testing.T, s string) content.ID {
+	t.Helper()
+
+	cid, err := content.ParseID(s)
+	require.NoError(t, err)
+
+	return cid
+}
+
+func TestContentAttestation(t *testing.T) {
+	key := []byte("some-key")
+	fname := filepath.Join(t.TempDir(), "attestation.json")
+
+	tr := newAttestationTracker()
+	tr.record(mustParseContentID(t, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), []byte("a"))
+	tr.record(mustParseContentID(t, "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"), []byte("b"))
+
+	a := newContentAttestation(tr.contentHashes(), time.Now(), key)
+	require.Equal(t, 2, a.ContentCount)
+	require.NoError(t, writeContentAttestation(fname, a))
+
+	a2, err := readContentAttestation(fname, key)
+	require.NoError(t, err)
+	require.Equal(t, a.RootHash, a2.RootHash)
+	require.Empty(t, diffAttestation(a2.Contents, tr.contentHashes()).count())
+
+	// the root hash does not depend on the order in which contents were recorded.
+	tr2 := newAttestationTracker()
+	tr2.record(mustParseContentID(t, "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"), []byte("b2"))
+	tr2.record(mustParseContentID(t, "cccccccccccccccccccccccccccccccc"), []byte("c"))
+	tr2.record(mustParseContentID(t, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), []byte("a"))
+
+	require.Equal(t, attestationDiff{
+		Added:   []string{"cccccccccccccccccccccccccccccccc"},
+		Changed: []string{"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"},
+	}, diffAttestation(a2.Contents, tr2.contentHashes()))
+
+	require.Equal(t, attestationDiff{
+		Removed: []string{"cccccccccccccccccccccccccccccccc"},
+		Changed: []string{"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"},
+	}, diffAttestation(tr2.contentHashes(), a2.Contents))
+
+	// attestation can't be verified with a different key.
+	_, err = readContentAttestation(fname, []byte("other-key"))
+	require.ErrorContains(t, err, "invalid attestation signature")
+
+	// modified attestation is detected.
+	a2.Contents["aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"] = a2.Contents["bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"]
+	require.NoError(t, writeContentAttestation(fname, a2))
+
+	_, err = readContentAttestation(fname, key)
+	require.ErrorContains(t, err, "do not match its root hash")
+
+	// nil tracker is a no-op
+	var nilTracker *attestationTracker
+
+	nilTracker.record(mustParseContentID(t, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), []byte("a"))
+}
+
+func TestVerifyCheckpointer(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	var ids []content.ID
+
+	for i := range 100 {
+		cid, err := content.IDFromHash("", []byte{byte(i), 1, 2, 3})
+		require.NoError(t, err)
+
+		ids = append(ids, cid)
+	}
+
+	var verified atomic.Int32
+
+	v := newVerifyCheckpointer(fname, 7, content.IDRange{StartID: "", EndID: "z"}, func() (int32, int32) {
+		return verified.Load(), 0
+	})
+
+	iterate := func(_ func(int32), cb func(content.Info) error) error {
+		for _, cid := range ids {
+			if err := cb(content.Info{ContentID: cid}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// fail on content #50, all previous contents have been completed.
+	require.Error(t, v.iterate(iterate, 4)(nil, func(ci content.Info) error {
+		if ci.ContentID == ids[50] {
+			return errors.New("interrupted")
+		}
+
+		verified.Add(1)
+
+		return nil
+	}))
+
+	cp, err := readContentVerifyCheckpoint(fname)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	require.Less(t, cp.LastContentID.String(), ids[50].String())
+	require.Positive(t, cp.VerifiedCount)
+
+	// resumed range includes contents after the last verified one, but not the last one.
+	r := cp.resumeRange()
+	require.Equal(t, content.IDPrefix("z"), r.EndID)
+	require.False(t, r.Contains(cp.LastContentID))
+	require.True(t, r.Contains(ids[50]))
+	require.True(t, r.Contains(ids[99]))
+
+	v.finish(testlogging.Context(t))
+	require.NoFileExists(t, fname)
+
+	cp, err = readContentVerifyCheckpoint(fname)
+	require.NoError(t, err)
+	require.Nil(t, cp)
+
+	// checkpoint without any verified contents resumes from the beginning of the range.
+	require.Equal(t, content.IDRange{StartID: "k", EndID: "l"}, (&contentVerifyCheckpoint{RangeStart: "k", RangeEnd: "l"}).resumeRange())
+}
+
+func TestInContentSample(t *testing.T) {
+	var cids []content.ID
+
+	for i := range 10000 {
+		cids = append(cids, mustParseContentID(t, fmt.Sprintf("%032x", i)))
+	}
+
+	sample := func(seed string, percent float64) map[content.ID]bool {
+		result := map[content.ID]bool{}
+
+		for _, cid := range cids {
+			if inContentSample(seed, cid, percent) {
+				result[cid] = true
+			}
+		}
+
+		return result
+	}
+
+	s1 := sample("seed1", 10)
+
+	// same seed selects the same sample.
+	require.Equal(t, s1, sample("seed1", 10))
+
+	// approximately the requested percentage of contents is selected.
+	require.InDelta(t, 1000, len(s1), 150)
+
+	// different seed selects a different sample.
+	require.NotEqual(t, s1, sample("seed2", 10))
+
+	// smaller percentage with the same seed selects a subset.
+	for cid := range sample("seed1", 5) {
+		require.True(t, s1[cid])
+	}
+
+	require.Len(t, sample("seed1", 100), len(cids))
+	require.Empty(t, sample("seed1", 0))
+}
+
+func TestVerifyProgressLine(t *testing.T) {
+	p := &cliVerifyProgress{showDownloaded: true}
+
+	p.verifiedCount.Store(5)
+	p.errorCount.Store(1)
+	p.downloaded(2000)
+	require.Equal(t, "Verified 5 contents, 1 errors, downloaded 2 KB, estimating...", p.line())
+
+	p.totalCount.Store(10)
+	require.Contains(t, p.line(), "Verified 5 of 10 contents")
+	require.Contains(t, p.line(), "1 errors, downloaded 2 KB.")
+
+	// all methods are safe to call on nil progress.
+	var np *cliVerifyProgress
+
+	np.setCounters(1, 0, 1)
+	np.downloaded(100)
+	np.finish()
+}
+
+type flakyContentReader struct {
+	content.Reader
+
+	failuresLeft int
+	err          error
+	calls        int
+}
+
+func (r *flakyContentReader) GetContent(_ context.Context, _ content.ID) ([]byte, error) {
+	r.calls++
+
+	if r.failuresLeft != 0 {
+		r.failuresLeft--
+		return nil, r.err
+	}
+
+	return []byte("data"), nil
+}
+
+func TestContentVerifyReadRetries(t *testing.T) {
+	ctx := testlogging.Context(t)
+	cid := mustParseContentID(t, "abcdef0123456789abcdef0123456789")
+	ci := content.Info{ContentID: cid, PackBlobID: "p1234", PackOffset: 0, PackedLength: 10}
+	blobMap := memoryBlobMap{"p1234": {BlobID: "p1234", Length: 100}}
+	errTransient := errors.Wrap(syscall.ECONNRESET, "service unavailable")
+
+	c := &commandContentVerify{readRetries: 3, readRetryDelay: time.Millisecond}
+
+	// transient errors are retried.
+	r := &flakyContentReader{failuresLeft: 2, err: errTransient}
+	require.NoError(t, c.contentVerify(ctx, r, ci, blobMap, 100, nil))
+	require.Equal(t, 3, r.calls)
+	require.EqualValues(t, 1, c.retriedReadCount.Load())
+
+	// content fails after exhausting retries.
+	r = &flakyContentReader{failuresLeft: -1, err: errTransient}
+	err := c.contentVerify(ctx, r, ci, blobMap, 100, nil)
+	require.ErrorIs(t, err, errTransient)
+	require.Equal(t, 4, r.calls)
+	require.True(t, isTransientVerifyFailure(err))
+	require.Equal(t, verifyFailureDownload, verifyFailureReason(err, verifyFailureOther))
+
+	// permanent errors are not retried.
+	r = &flakyContentReader{failuresLeft: -1, err: blob.ErrBlobNotFound}
+	err = c.contentVerify(ctx, r, ci, blobMap, 100, nil)
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+	require.Equal(t, 1, r.calls)
+	require.False(t, isTransientVerifyFailure(err))
+
+	// unrecognized errors, such as decryption failures, are permanent.
+	errCorrupted := errors.New("error getting cached content: decrypt: unable to decrypt content: cipher: message authentication failed")
+	r = &flakyContentReader{failuresLeft: -1, err: errCorrupted}
+	err = c.contentVerify(ctx, r, ci, blobMap, 100, nil)
+	require.ErrorIs(t, err, errCorrupted)
+	require.Equal(t, 1, r.calls)
+	require.False(t, isTransientVerifyFailure(err))
+}
+
+func TestReadBlobMetadataMapOnDisk(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	for i := range 1000 {
+		require.NoError(t, st.PutBlob(ctx, blob.ID(fmt.Sprintf("p%05x", i)), gather.FromSlice(make([]byte, i)), blob.PutOptions{}))
+	}
+
+	mem, err := readBlobMetadataMap(ctx, st, 0)
+	require.NoError(t, err)
+
+	defer mem.close(ctx)
+
+	// the limit only allows storing 100 blobs in memory.
+	disk, err := readBlobMetadataMap(ctx, st, 100*estimatedBlobMapEntrySize)
+	require.NoError(t, err)
+
+	defer disk.close(ctx)
+
+	require.IsType(t, memoryBlobMap{}, mem)
+	require.IsType(t, &diskBlobMap{}, disk)
+
+	for _, id := range []blob.ID{"p00000", "p00123", "p003e7"} {
+		want, ok, err := mem.get(id)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		got, ok, err := disk.get(id)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, want.Length, got.Length)
+		require.True(t, want.Timestamp.Equal(got.Timestamp))
+	}
+
+	_, ok, err := disk.get("p99999")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	disk.remove("p00123")
+
+	_, ok, err = disk.get("p00123")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	var ids []blob.ID
+
+	require.NoError(t, disk.forEach(func(bm blob.Metadata) error {
+		want, ok, err := mem.get(bm.BlobID)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, want.Length, bm.Length)
+
+		ids = append(ids, bm.BlobID)
+
+		return nil
+	}))
+
+	require.Len(t, ids, 999)
+	require.NotContains(t, ids, blob.ID("p00123"))
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/kopia/kopia/internal/retry"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// isTransientReadError returns true if reading the content failed due to an error that may go away when retried,
+// such as network or storage backend availability errors. Unrecognized errors, such as decryption failures
+// caused by corrupted pack data, are permanent.
+func isTransientReadError(err error) bool {
+	return isTransientStorageError(err)
+}
+
+// getContentWithRetries reads the content, retrying transient errors up to --read-retries times
+// with exponential backoff starting at --read-retry-delay.
+func (c *commandContentVerify) getContentWithRetries(ctx context.Context, r content.Reader, cid content.ID) ([]byte, error) {
+	attempts := 0
+
+	data, err := retry.WithExponentialBackoffInitialDelay(ctx, c.readRetryDelay, max(c.readRetries, 0)+1, "reading content "+cid.String(), func() ([]byte, error) {
+		attempts++
+
+		//nolint:wrapcheck
+		return r.GetContent(ctx, cid)
+	}, isTransientReadError)
+
+	if err == nil && attempts > 1 {
+		log(ctx).Debugf("content %v was read successfully after %v attempts", cid, attempts)
+		c.retriedReadCount.Add(1)
+	}
+
+	return data, err //nolint:wrapcheck
+}
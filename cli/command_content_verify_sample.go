@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/content"
+)
+
+// sampleSeedLength is the number of random bytes in a generated sample seed.
+const sampleSeedLength = 8
+
+// newSampleSeed returns a random seed used to select a sample of contents when --sample-seed is not provided.
+func newSampleSeed() (string, error) {
+	b := make([]byte, sampleSeedLength)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "unable to generate sample seed")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// inContentSample returns true if the content belongs to the sample of the provided percentage of all contents
+// selected using the provided seed. The selection only depends on the seed and content ID, so that
+// repeated verifications using the same seed select the same contents.
+func inContentSample(seed string, cid content.ID, percent float64) bool {
+	if percent >= 100 { //nolint:mnd
+		return true
+	}
+
+	h := sha256.New()
+	h.Write([]byte(seed))
+	h.Write([]byte{0})
+	h.Write([]byte(cid.String()))
+
+	v := binary.BigEndian.Uint64(h.Sum(nil))
+
+	return float64(v)/math.MaxUint64*100 < percent
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// Reasons of content verification failures reported in JSON summary.
+const (
+	verifyFailureMissingBlob      = "missing-blob"
+	verifyFailureOutOfBounds      = "out-of-bounds"
+	verifyFailureDownload         = "download-failed"
+	verifyFailureInvalidTombstone = "invalid-tombstone"
+	verifyFailureOversubscribed   = "oversubscribed-blob"
+	verifyFailureManifestRef      = "invalid-manifest-reference"
+	verifyFailureAttestation      = "attestation-mismatch"
+	verifyFailureOther            = "other"
+)
+
+// Reasons of suspicious contents reported in JSON summary, which are not counted as errors.
+const (
+	verifySuspiciousZeroLength = "zero-length"
+)
+
+// contentVerifyError is an error of content verification with a reason reported in JSON summary.
+type contentVerifyError struct {
+	reason string
+	// transient is true if the content could not be read due to errors that persisted after retries,
+	// but may indicate a problem with the storage backend and not with the content.
+	transient bool
+	err       error
+}
+
+func (e *contentVerifyError) Error() string { return e.err.Error() }
+func (e *contentVerifyError) Unwrap() error { return e.err }
+
+func verifyError(reason string, err error) error {
+	return &contentVerifyError{reason: reason, err: err}
+}
+
+// isTransientVerifyFailure returns true if the content verification failed due to transient errors.
+func isTransientVerifyFailure(err error) bool {
+	var cve *contentVerifyError
+
+	return errors.As(err, &cve) && cve.transient
+}
+
+// verifyFailureReason returns the reason of content verification failure.
+func verifyFailureReason(err error, defaultReason string) string {
+	var cve *contentVerifyError
+
+	if errors.As(err, &cve) {
+		return cve.reason
+	}
+
+	return defaultReason
+}
+
+// contentVerifyFailure describes a content which failed verification.
+type contentVerifyFailure struct {
+	ContentID  content.ID `json:"contentID"`
+	PackBlobID blob.ID    `json:"packBlobID"`
+	Reason     string     `json:"reason"`
+	Transient  bool       `json:"transient,omitempty"`
+	Error      string     `json:"error"`
+}
+
+// contentVerifySummary is printed to stdout when content verify is invoked with --json.
+// ErrorCount is always the number of Failures, errors found before resuming from a checkpoint
+// are not listed and are reported separately in ResumedErrorCount.
+type contentVerifySummary struct {
+	VerifiedCount     int32                  `json:"verifiedCount"`
+	SuccessCount      int32                  `json:"successCount"`
+	ErrorCount        int32                  `json:"errorCount"`
+	ResumedErrorCount int32                  `json:"resumedErrorCount,omitempty"`
+	RetriedCount      int32                  `json:"retriedCount,omitempty"`
+	Aborted           bool                   `json:"aborted,omitempty"`
+	SampleSeed        string                 `json:"sampleSeed,omitempty"`
+	Failures          []contentVerifyFailure `json:"failures"`
+	SuspiciousCount   int32                  `json:"suspiciousCount"`
+	Suspicious        []contentVerifyFailure `json:"suspicious"`
+}
+
+// verifyFailureTracker collects all failures found during verification and is the source of the number of errors.
+// It is also used to collect suspicious contents, which are reported separately and are not errors.
+type verifyFailureTracker struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	failures []contentVerifyFailure
+}
+
+func (t *verifyFailureTracker) record(ci content.Info, err error) {
+	t.recordFailure(contentVerifyFailure{
+		ContentID:  ci.ContentID,
+		PackBlobID: ci.PackBlobID,
+		Reason:     verifyFailureReason(err, verifyFailureOther),
+		Transient:  isTransientVerifyFailure(err),
+		Error:      err.Error(),
+	})
+}
+
+// recordSuspicious records a content that is suspicious, but not known to be invalid.
+func (t *verifyFailureTracker) recordSuspicious(ci content.Info, err error) {
+	t.recordFailure(contentVerifyFailure{
+		ContentID:  ci.ContentID,
+		PackBlobID: ci.PackBlobID,
+		Reason:     verifySuspiciousZeroLength,
+		Error:      err.Error(),
+	})
+}
+
+func (t *verifyFailureTracker) recordFailure(f contentVerifyFailure) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.failures = append(t.failures, f)
+}
+
+// count returns the number of recorded failures.
+func (t *verifyFailureTracker) count() int32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return int32(len(t.failures)) //nolint:gosec
+}
+
+// sortedFailures returns recorded failures sorted by content ID and pack blob ID.
+func (t *verifyFailureTracker) sortedFailures() []contentVerifyFailure {
+	result := []contentVerifyFailure{}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result = append(result, t.failures...)
+
+	sort.Slice(result, func(i, j int) bool {
+		if ci, cj := result[i].ContentID.String(), result[j].ContentID.String(); ci != cj {
+			return ci < cj
+		}
+
+		return result[i].PackBlobID < result[j].PackBlobID
+	})
+
+	return result
+}
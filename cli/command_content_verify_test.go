@@ -2,13 +2,17 @@
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -24,17 +28,312 @@ func (s *formatSpecificTestSuite) TestContentVerify(t *testing.T) {
 	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
 	env.RunAndExpectSuccess(t, "content", "verify", "--download-percent=30")
 
+	_, verifyStderr := env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--include-manifest-references")
+	mustGetLineContaining(t, verifyStderr, "referenced by 1 of")
+
+	_, verifyStderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--only-format=99")
+	mustGetLineContaining(t, verifyStderr, "0 contents matched format version 99")
+
+	_, verifyStderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", fmt.Sprintf("--only-format=%v", s.formatVersion))
+	require.NotContains(t, mustGetLineContaining(t, verifyStderr, "contents matched format version"), "0 contents")
+
+	// verify contents of a single index blob.
+	indexBlobID := strings.Fields(env.RunAndExpectSuccess(t, "index", "list")[0])[0]
+	_, verifyStderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--index-generation="+indexBlobID)
+	mustGetLineContaining(t, verifyStderr, "Verifying contents in index blob "+indexBlobID)
+	env.RunAndExpectFailure(t, "content", "verify", "--index-generation=no-such-blob")
+
+	_, verifyStderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--full", "--sample-percent=50", "--sample-seed=abc")
+	mustGetLineContaining(t, verifyStderr, "sample of contents selected using seed abc")
+	sampled := mustGetLineContaining(t, verifyStderr, "Finished verifying")
+
+	_, verifyStderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--sample-percent=50", "--sample-seed=abc")
+	require.Equal(t, sampled, mustGetLineContaining(t, verifyStderr, "Finished verifying"))
+
+	_, verifyStderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--sample-percent=1")
+	mustGetLineContaining(t, verifyStderr, "to verify the same sample")
+	env.RunAndExpectFailure(t, "content", "verify", "--sample-percent=101")
+
+	_, verifyStderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--full", "--parallel=2", "--parallel-per-blob=3")
+	mustGetLineContaining(t, verifyStderr, "2 blobs at a time, 3 contents per blob in parallel")
+
 	// delete one of 'p' blobs.
 	blobIDToDelete := strings.Split(env.RunAndExpectSuccess(t, "blob", "list", "--prefix=p")[0], " ")[0]
+
+	// simulate the blob being missing before actually deleting it.
+	_, verifyStderr, err := env.Run(t, true, "content", "verify", "--simulate-missing="+blobIDToDelete)
+	require.Error(t, err)
+	mustGetLineContaining(t, verifyStderr, "missing blob "+blobIDToDelete)
+
 	blobList := env.RunAndExpectSuccess(t, "blob", "list")
 	t.Logf("blob list: %v", strings.Join(blobList, "\n"))
 	env.RunAndExpectSuccess(t, "blob", "delete", blobIDToDelete)
 
-	_, verifyStderr, err := env.Run(t, true, "content", "verify")
+	_, verifyStderr, err = env.Run(t, true, "content", "verify")
 	require.Error(t, err)
 
 	// this fails if not found
 	mustGetLineContaining(t, verifyStderr, "missing blob "+blobIDToDelete)
 
+	// missing blob is also found when the blob map is stored on disk.
+	_, verifyStderr, err = env.Run(t, true, "content", "verify", "--blob-map-memory-limit=1KB")
+	require.Error(t, err)
+	mustGetLineContaining(t, verifyStderr, "storing it on disk")
+	mustGetLineContaining(t, verifyStderr, "missing blob "+blobIDToDelete)
+
+	_, verifyStderr, err = env.Run(t, true, "content", "verify", "--max-errors=1")
+	require.Error(t, err)
+	mustGetLineContaining(t, verifyStderr, "Verification aborted after reaching the limit of 1 errors")
+
+	// JSON summary is the only output on stdout.
+	verifyStdout, _, err := env.Run(t, true, "content", "verify", "--json")
+	require.Error(t, err)
+
+	var summary struct {
+		VerifiedCount int `json:"verifiedCount"`
+		SuccessCount  int `json:"successCount"`
+		ErrorCount    int `json:"errorCount"`
+		Failures      []struct {
+			ContentID  string `json:"contentID"`
+			PackBlobID string `json:"packBlobID"`
+			Reason     string `json:"reason"`
+		} `json:"failures"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(strings.Join(verifyStdout, "\n")), &summary))
+	require.NotEmpty(t, summary.Failures)
+	require.Equal(t, summary.VerifiedCount, summary.SuccessCount+summary.ErrorCount)
+	require.Len(t, summary.Failures, summary.ErrorCount)
+
+	for _, f := range summary.Failures {
+		require.Equal(t, "missing-blob", f.Reason)
+		require.Equal(t, blobIDToDelete, f.PackBlobID)
+	}
+
 	env.RunAndExpectFailure(t, "content", "verify", "--full")
 }
+
+func TestContentVerifyAttestation(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("hello world"), 0o600))
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	attestation := filepath.Join(testutil.TempDirectory(t), "attestation.json")
+
+	env.RunAndExpectFailure(t, "content", "verify", "--write-attestation", attestation)
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--full", "--write-attestation", attestation)
+	mustGetLineContaining(t, stderr, "Wrote attestation of")
+
+	_, stderr = env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--full", "--attest-against", attestation)
+	mustGetLineContaining(t, stderr, "0 added, 0 removed, 0 modified")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file2.txt"), []byte("new file"), 0o600))
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	_, stderr, err := env.Run(t, true, "content", "verify", "--full", "--attest-against", attestation)
+	require.Error(t, err)
+	mustGetLineContaining(t, stderr, "was added since attestation")
+}
+
+func TestContentVerifyWatch(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	env.RunAndExpectFailure(t, "content", "verify", "--watch", "--interval=0")
+
+	var cycles []string
+
+	wait, interrupt := env.RunAndProcessStderrInt(t, func(line string) bool {
+		if strings.Contains(line, "Verification #") {
+			cycles = append(cycles, line)
+		}
+
+		return len(cycles) < 2
+	}, nil, "content", "verify", "--watch", "--interval=100ms")
+
+	interrupt(os.Interrupt)
+	require.NoError(t, wait())
+
+	require.Contains(t, cycles[0], "Verification #1 finished")
+	require.Contains(t, cycles[1], "Verification #2 finished")
+
+	// termination stops the watch promptly, without waiting for the next cycle.
+	wait, interrupt = env.RunAndProcessStderrInt(t, func(line string) bool {
+		return !strings.Contains(line, "Verification #1 finished")
+	}, nil, "content", "verify", "--watch", "--interval=1h")
+
+	t0 := clock.Now()
+
+	interrupt(os.Interrupt)
+	require.NoError(t, wait())
+	require.Less(t, clock.Now().Sub(t0), time.Minute)
+}
+
+func TestContentVerifyCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+
+	for i := range 10 {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%v.txt", i)), []byte(fmt.Sprintf("file %v", i)), 0o600))
+	}
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	checkpointFile := filepath.Join(testutil.TempDirectory(t), "checkpoint.json")
+
+	// checkpoint is removed after verification finishes.
+	env.RunAndExpectSuccess(t, "content", "verify", "--checkpoint-file", checkpointFile, "--checkpoint-interval=1")
+	require.NoFileExists(t, checkpointFile)
+
+	contentIDs := env.RunAndExpectSuccess(t, "content", "list")
+	require.Greater(t, len(contentIDs), 5)
+
+	// simulate interrupted verification which verified the first 5 contents.
+	require.NoError(t, os.WriteFile(checkpointFile, []byte(fmt.Sprintf(
+		`{"rangeStart":"","rangeEnd":"{","lastContentID":%q,"verifiedCount":5,"errorCount":0}`, contentIDs[4])), 0o600))
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--checkpoint-file", checkpointFile)
+	mustGetLineContaining(t, stderr, "Resuming verification after content "+contentIDs[4])
+	mustGetLineContaining(t, stderr, fmt.Sprintf("Finished verifying %v contents", len(contentIDs)))
+	require.NoFileExists(t, checkpointFile)
+
+	// checkpoint for a different range of contents is rejected.
+	require.NoError(t, os.WriteFile(checkpointFile, []byte(`{"rangeStart":"k","rangeEnd":"k{"}`), 0o600))
+	env.RunAndExpectFailure(t, "content", "verify", "--checkpoint-file", checkpointFile)
+}
+
+func TestContentVerifyBlobUsageMapRequiresAllContents(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	usageFile := filepath.Join(testutil.TempDirectory(t), "usage.csv")
+	contentIDs := env.RunAndExpectSuccess(t, "content", "list")
+	indexBlobID := strings.Fields(env.RunAndExpectSuccess(t, "index", "list")[0])[0]
+
+	// blobs whose contents are not iterated would be reported as entirely dead.
+	env.RunAndExpectFailure(t, "content", "verify", "--write-blob-usage-map", usageFile, "--prefix=k")
+	env.RunAndExpectFailure(t, "content", "verify", "--write-blob-usage-map", usageFile, "--prefixed")
+	env.RunAndExpectFailure(t, "content", "verify", "--write-blob-usage-map", usageFile, "--content-id", contentIDs[0])
+	env.RunAndExpectFailure(t, "content", "verify", "--write-blob-usage-map", usageFile, "--index-generation", indexBlobID)
+	require.NoFileExists(t, usageFile)
+
+	checkpointFile := filepath.Join(testutil.TempDirectory(t), "checkpoint.json")
+	require.NoError(t, os.WriteFile(checkpointFile, []byte(fmt.Sprintf(
+		`{"rangeStart":"","rangeEnd":"{","lastContentID":%q,"verifiedCount":1,"errorCount":0}`, contentIDs[0])), 0o600))
+	env.RunAndExpectFailure(t, "content", "verify", "--write-blob-usage-map", usageFile, "--checkpoint-file", checkpointFile)
+	require.NoFileExists(t, usageFile)
+
+	env.RunAndExpectSuccess(t, "content", "verify", "--write-blob-usage-map", usageFile)
+	require.FileExists(t, usageFile)
+}
+
+func TestContentVerifyContentIDs(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("hello world"), 0o600))
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	contentIDs := env.RunAndExpectSuccess(t, "content", "list")
+	require.GreaterOrEqual(t, len(contentIDs), 2)
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "content", "verify", "--full", "--content-id", contentIDs[0], "--content-id", contentIDs[1])
+	mustGetLineContaining(t, stderr, "Verifying 2 of 2 provided contents, 0 not found.")
+	mustGetLineContaining(t, stderr, "Finished verifying 2 contents, found 0 errors.")
+
+	idsFile := filepath.Join(testutil.TempDirectory(t), "ids.txt")
+	require.NoError(t, os.WriteFile(idsFile, []byte("# suspicious contents\n"+contentIDs[0]+"\n\nabcdef0123456789abcdef0123456789\n"), 0o600))
+
+	stdout, stderr, err := env.Run(t, true, "content", "verify", "--content-ids-file", idsFile, "--json")
+	require.Error(t, err)
+	mustGetLineContaining(t, stderr, "Verifying 1 of 2 provided contents, 1 not found.")
+
+	var summary struct {
+		VerifiedCount int `json:"verifiedCount"`
+		ErrorCount    int `json:"errorCount"`
+		Failures      []struct {
+			ContentID string `json:"contentID"`
+			Reason    string `json:"reason"`
+		} `json:"failures"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(strings.Join(stdout, "\n")), &summary))
+	require.Equal(t, 2, summary.VerifiedCount)
+	require.Equal(t, 1, summary.ErrorCount)
+	require.Len(t, summary.Failures, 1)
+	require.Equal(t, "abcdef0123456789abcdef0123456789", summary.Failures[0].ContentID)
+	require.Equal(t, "unknown-content", summary.Failures[0].Reason)
+
+	env.RunAndExpectFailure(t, "content", "verify", "--content-id", "not-a-content-id")
+}
+
+func TestContentVerifyCorruptedContentIsNotTransient(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), bytes.Repeat([]byte("hello world"), 1000), 0o600))
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	// flip bytes in the middle of each data pack blob.
+	var corrupted int
+
+	require.NoError(t, filepath.Walk(env.RepoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasPrefix(path, filepath.Join(env.RepoDir, "p")+string(filepath.Separator)) {
+			return err
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		for i := len(b) / 2; i < len(b)/2+10; i++ {
+			b[i] ^= 0xff
+		}
+
+		corrupted++
+
+		return os.WriteFile(path, b, 0o600)
+	}))
+	require.Positive(t, corrupted)
+
+	env.RunAndExpectSuccess(t, "cache", "clear")
+
+	stdout, _, err := env.Run(t, true, "content", "verify", "--full", "--json", "--read-retry-delay=1ms")
+	require.Error(t, err)
+
+	var summary struct {
+		ErrorCount   int              `json:"errorCount"`
+		RetriedCount int              `json:"retriedCount"`
+		Failures     []map[string]any `json:"failures"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(strings.Join(stdout, "\n")), &summary))
+	require.NotEmpty(t, summary.Failures)
+	require.Zero(t, summary.RetriedCount)
+
+	for _, f := range summary.Failures {
+		require.NotContains(t, f, "transient")
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/repo"
+)
+
+// metrics describing the most recent verification in --watch mode, exposed using --metrics-listen-addr.
+//
+//nolint:gochecknoglobals
+var (
+	contentVerifyRunsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kopia_content_verify_runs_total",
+		Help: "Number of content verifications completed in watch mode",
+	})
+	contentVerifyLastRunTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kopia_content_verify_last_run_timestamp_seconds",
+		Help: "Time when the most recent content verification finished",
+	})
+	contentVerifyLastRunDuration = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kopia_content_verify_last_run_duration_seconds",
+		Help: "Duration of the most recent content verification",
+	})
+	contentVerifyLastRunContents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kopia_content_verify_last_run_contents",
+		Help: "Number of contents verified by the most recent content verification",
+	})
+	contentVerifyLastRunErrors = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kopia_content_verify_last_run_errors",
+		Help: "Number of errors found by the most recent content verification",
+	})
+	contentVerifyLastRunSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kopia_content_verify_last_run_success",
+		Help: "1 if the most recent content verification succeeded, 0 otherwise",
+	})
+)
+
+// runWatch repeats verification every --interval until the process is terminated.
+// Verifications never overlap, if one takes longer than the interval, the next one starts immediately after it.
+func (c *commandContentVerify) runWatch(ctx context.Context, rep repo.DirectRepository) error {
+	if c.watchInterval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+
+	// termination cancels the verification in progress, so that the watch stops promptly.
+	var stopped atomic.Bool
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.svc.onTerminate(func() {
+		stopped.Store(true)
+		cancel()
+	})
+
+	for cycle := 1; ; cycle++ {
+		start := clock.Now()
+
+		if cycle > 1 {
+			// pick up contents written since the previous verification.
+			if err := rep.Refresh(ctx); err != nil {
+				log(ctx).Errorf("unable to refresh repository: %v", err)
+			}
+		}
+
+		result, err := c.verifyOnce(ctx, rep)
+		dur := clock.Now().Sub(start)
+
+		if stopped.Load() {
+			log(ctx).Infof("Stopping verification, verification #%v was interrupted.", cycle)
+			return nil
+		}
+
+		recordContentVerifyMetrics(result, err, dur)
+
+		if err != nil {
+			log(ctx).Errorf("Verification #%v failed after %v: %v", cycle, dur.Round(time.Millisecond), err)
+		} else {
+			log(ctx).Infof("Verification #%v finished in %v: verified %v contents, found %v errors.", cycle, dur.Round(time.Millisecond), result.Verified, result.Errors)
+		}
+
+		wait := c.watchInterval - dur
+		if wait <= 0 {
+			log(ctx).Warnf("Verification took longer than --interval=%v, starting next verification immediately.", c.watchInterval)
+
+			wait = 0
+		} else {
+			log(ctx).Infof("Next verification at %v.", formatTimestamp(start.Add(c.watchInterval)))
+		}
+
+		select {
+		case <-ctx.Done():
+			if stopped.Load() {
+				log(ctx).Info("Stopping verification.")
+				return nil
+			}
+
+			return errors.Wrap(ctx.Err(), "verification interrupted")
+
+		case <-time.After(wait):
+		}
+	}
+}
+
+func recordContentVerifyMetrics(result contentVerifyResult, err error, dur time.Duration) {
+	contentVerifyRunsTotal.Inc()
+	contentVerifyLastRunTimestamp.SetToCurrentTime()
+	contentVerifyLastRunDuration.Set(dur.Seconds())
+	contentVerifyLastRunContents.Set(float64(result.Verified))
+	contentVerifyLastRunErrors.Set(float64(result.Errors))
+
+	if err != nil {
+		contentVerifyLastRunSuccess.Set(0)
+	} else {
+		contentVerifyLastRunSuccess.Set(1)
+	}
+}
@@ -2,6 +2,8 @@
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -17,16 +19,25 @@ type commandDiff struct {
 	diffSecondObjectPath string
 	diffCompareFiles     bool
 	diffCommandCommand   string
+	diffFormat           string
 
 	out textOutput
 }
 
+// Supported values of 'diff --format'.
+const (
+	diffFormatText    = "text"
+	diffFormatJSON    = "json"
+	diffFormatUnified = "unified"
+)
+
 func (c *commandDiff) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("diff", "Displays differences between two repository objects (files or directories)").Alias("compare")
 	cmd.Arg("object-path1", "First object/path").Required().StringVar(&c.diffFirstObjectPath)
 	cmd.Arg("object-path2", "Second object/path").Required().StringVar(&c.diffSecondObjectPath)
 	cmd.Flag("files", "Compare files by launching diff command for all pairs of (old,new)").Short('f').BoolVar(&c.diffCompareFiles)
 	cmd.Flag("diff-command", "Displays differences between two repository objects (files or directories)").Default(defaultDiffCommand()).Envar(svc.EnvName("KOPIA_DIFF")).StringVar(&c.diffCommandCommand)
+	cmd.Flag("format", "Output format").Default(diffFormatText).EnumVar(&c.diffFormat, diffFormatText, diffFormatJSON, diffFormatUnified)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 
 	c.out.setup(svc)
@@ -50,6 +61,10 @@ func (c *commandDiff) run(ctx context.Context, rep repo.Repository) error {
 		return errors.New("arguments do diff must both be directories or both non-directories")
 	}
 
+	if c.diffCompareFiles && c.diffFormat != diffFormatText {
+		return errors.Errorf("--files can't be used with --format=%v", c.diffFormat)
+	}
+
 	d, err := diff.NewComparer(c.out.stdout())
 	if err != nil {
 		return errors.Wrap(err, "error creating comparer")
@@ -62,11 +77,65 @@ func (c *commandDiff) run(ctx context.Context, rep repo.Repository) error {
 		d.DiffArguments = parts[1:]
 	}
 
-	if isDir1 {
-		return errors.Wrap(d.Compare(ctx, ent1, ent2), "error comparing directories")
+	if !isDir1 {
+		return errors.New("comparing files not implemented yet")
+	}
+
+	switch c.diffFormat {
+	case diffFormatJSON:
+		changes := []diff.Change{}
+
+		d.OnChange = func(ch diff.Change) {
+			changes = append(changes, ch)
+		}
+
+		if err := d.Compare(ctx, ent1, ent2); err != nil {
+			return errors.Wrap(err, "error comparing directories")
+		}
+
+		e := json.NewEncoder(c.out.stdout())
+		e.SetIndent("", "  ")
+
+		return errors.Wrap(e.Encode(changes), "error writing JSON")
+
+	case diffFormatUnified:
+		c.out.printStdout("--- %v\n+++ %v\n", c.diffFirstObjectPath, c.diffSecondObjectPath)
+
+		d.OnChange = func(ch diff.Change) {
+			printUnifiedDiffChange(&c.out, ch)
+		}
 	}
 
-	return errors.New("comparing files not implemented yet")
+	return errors.Wrap(d.Compare(ctx, ent1, ent2), "error comparing directories")
+}
+
+// printUnifiedDiffChange prints the change as lines of a unified diff of directory tree listings,
+// where directories have a trailing slash and files are followed by their size.
+func printUnifiedDiffChange(out *textOutput, ch diff.Change) {
+	line := func(typ string, size *int64) string {
+		if typ == "directory" {
+			return ch.Path + "/"
+		}
+
+		if size == nil {
+			return ch.Path
+		}
+
+		return fmt.Sprintf("%v\t%v bytes", ch.Path, *size)
+	}
+
+	oldType := ch.Type
+	if ch.OldType != "" {
+		oldType = ch.OldType
+	}
+
+	if ch.Kind != diff.ChangeAdded {
+		out.printStdout("-%v\n", line(oldType, ch.OldSize))
+	}
+
+	if ch.Kind != diff.ChangeRemoved {
+		out.printStdout("+%v\n", line(ch.Type, ch.NewSize))
+	}
 }
 
 func defaultDiffCommand() string {
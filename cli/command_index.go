@@ -7,6 +7,7 @@ type commandIndex struct {
 	list     commandIndexList
 	optimize commandIndexOptimize
 	recover  commandIndexRecover
+	status   commandIndexStatus
 }
 
 func (c *commandIndex) setup(svc appServices, parent commandParent) {
@@ -17,4 +18,5 @@ func (c *commandIndex) setup(svc appServices, parent commandParent) {
 	c.list.setup(svc, cmd)
 	c.optimize.setup(svc, cmd)
 	c.recover.setup(svc, cmd)
+	c.status.setup(svc, cmd)
 }
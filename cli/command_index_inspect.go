@@ -151,26 +151,36 @@ type indexBlobPlusContentInfo struct {
 func (c *commandIndexInspect) inspectSingleIndexBlob(ctx context.Context, rep repo.DirectRepository, blobID blob.ID, output chan indexBlobPlusContentInfo) error {
 	log(ctx).Debugf("Inspecting blob %v...", blobID)
 
+	bm, entries, err := readIndexBlobEntries(ctx, rep, blobID)
+	if err != nil {
+		return err
+	}
+
+	for _, ent := range entries {
+		output <- indexBlobPlusContentInfo{bm, ent}
+	}
+
+	return nil
+}
+
+// readIndexBlobEntries returns the metadata and content entries stored in a single index blob.
+func readIndexBlobEntries(ctx context.Context, rep repo.DirectRepository, blobID blob.ID) (blob.Metadata, []content.Info, error) {
 	bm, err := rep.BlobReader().GetMetadata(ctx, blobID)
 	if err != nil {
-		return errors.Wrapf(err, "unable to get metadata for %v", blobID)
+		return bm, nil, errors.Wrapf(err, "unable to get metadata for %v", blobID)
 	}
 
 	var data gather.WriteBuffer
 	defer data.Close()
 
 	if err = rep.BlobReader().GetBlob(ctx, blobID, 0, -1, &data); err != nil {
-		return errors.Wrapf(err, "unable to get data for %v", blobID)
+		return bm, nil, errors.Wrapf(err, "unable to get data for %v", blobID)
 	}
 
 	entries, err := content.ParseIndexBlob(blobID, data.Bytes(), rep.ContentReader().ContentFormat())
 	if err != nil {
-		return errors.Wrapf(err, "unable to recover index from %v", blobID)
+		return bm, nil, errors.Wrapf(err, "unable to recover index from %v", blobID)
 	}
 
-	for _, ent := range entries {
-		output <- indexBlobPlusContentInfo{bm, ent}
-	}
-
-	return nil
+	return bm, entries, nil
 }
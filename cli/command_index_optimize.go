@@ -4,6 +4,9 @@
 	"context"
 	"time"
 
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/content/indexblob"
 )
@@ -46,6 +49,34 @@ func (c *commandIndexOptimize) runOptimizeCommand(ctx context.Context, rep repo.
 		opt.DropDeletedBefore = rep.Time().Add(-age)
 	}
 
-	//nolint:wrapcheck
-	return rep.ContentManager().CompactIndexes(ctx, opt)
+	before, beforeCount, err := totalIndexBlobSize(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	if err := rep.ContentManager().CompactIndexes(ctx, opt); err != nil {
+		return errors.Wrap(err, "error compacting indexes")
+	}
+
+	after, afterCount, err := totalIndexBlobSize(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	log(ctx).Infof("Index blobs: %v in %v blobs (was %v in %v blobs).", units.BytesString(after), afterCount, units.BytesString(before), beforeCount)
+
+	return nil
+}
+
+func totalIndexBlobSize(ctx context.Context, rep repo.DirectRepository) (total int64, count int, err error) {
+	blobs, err := rep.IndexBlobs(ctx, false)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "error listing index blobs")
+	}
+
+	for _, b := range blobs {
+		total += b.Length
+	}
+
+	return total, len(blobs), nil
 }
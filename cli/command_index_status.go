@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/internal/epoch"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/indexblob"
+)
+
+type commandIndexStatus struct {
+	parallel int
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandIndexStatus) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("status", "Report index fragmentation and estimate the benefit of index compaction")
+	cmd.Flag("parallel", "Parallelism").Default("8").IntVar(&c.parallel)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+// indexStatus summarizes the active index blobs and the expected effect of compacting them.
+type indexStatus struct {
+	IndexBlobs      int   `json:"indexBlobs"`
+	IndexBytes      int64 `json:"indexBytes"`
+	SmallestBlob    int64 `json:"smallestBlobBytes"`
+	LargestBlob     int64 `json:"largestBlobBytes"`
+	SupersededBlobs int   `json:"supersededBlobs"`
+	SupersededBytes int64 `json:"supersededBytes"`
+
+	TotalEntries     int64 `json:"totalEntries"`
+	UniqueContents   int64 `json:"uniqueContents"`
+	RedundantEntries int64 `json:"redundantEntries"`
+
+	// Fragmentation is the fraction of index entries that are shadowed by another entry for the same content.
+	Fragmentation float64 `json:"fragmentation"`
+
+	// estimated index blobs once maintenance has compacted everything the compaction policy allows.
+	EstimatedCompactedBlobs int   `json:"estimatedCompactedBlobs"`
+	EstimatedCompactedBytes int64 `json:"estimatedCompactedBytes"`
+	EstimatedSavedBytes     int64 `json:"estimatedSavedBytes"`
+}
+
+func (c *commandIndexStatus) run(ctx context.Context, rep repo.DirectRepository) error {
+	active, err := rep.IndexBlobs(ctx, false)
+	if err != nil {
+		return errors.Wrap(err, "error listing index blobs")
+	}
+
+	all, err := rep.IndexBlobs(ctx, true)
+	if err != nil {
+		return errors.Wrap(err, "error listing index blobs")
+	}
+
+	st, err := c.computeStatus(ctx, rep, active)
+	if err != nil {
+		return err
+	}
+
+	st.SupersededBlobs = len(all) - len(active)
+
+	for _, b := range all {
+		st.SupersededBytes += b.Length
+	}
+
+	st.SupersededBytes -= st.IndexBytes
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(st))
+		return nil
+	}
+
+	c.out.printStdout("Active index blobs:    %v (%v, smallest %v, largest %v)\n",
+		st.IndexBlobs, units.BytesString(st.IndexBytes), units.BytesString(st.SmallestBlob), units.BytesString(st.LargestBlob))
+	c.out.printStdout("Superseded blobs:      %v (%v)\n", st.SupersededBlobs, units.BytesString(st.SupersededBytes))
+	c.out.printStdout("Index entries:         %v for %v unique contents\n", st.TotalEntries, st.UniqueContents)
+	c.out.printStdout("Fragmentation:         %.1f%% (%v redundant entries)\n", st.Fragmentation*100, st.RedundantEntries) //nolint:mnd
+	c.out.printStdout("After compaction:      ~%v blob(s), ~%v (saves ~%v per repository open)\n",
+		st.EstimatedCompactedBlobs, units.BytesString(st.EstimatedCompactedBytes), units.BytesString(st.EstimatedSavedBytes))
+
+	return nil
+}
+
+func (c *commandIndexStatus) computeStatus(ctx context.Context, rep repo.DirectRepository, active []indexblob.Metadata) (*indexStatus, error) {
+	st := &indexStatus{
+		IndexBlobs: len(active),
+	}
+
+	for i, b := range active {
+		st.IndexBytes += b.Length
+
+		if i == 0 || b.Length < st.SmallestBlob {
+			st.SmallestBlob = b.Length
+		}
+
+		if b.Length > st.LargestBlob {
+			st.LargestBlob = b.Length
+		}
+	}
+
+	totalEntries, err := c.countIndexEntries(ctx, rep, active)
+	if err != nil {
+		return nil, err
+	}
+
+	// the merged index returns a single entry for each content, so counting them does not require
+	// keeping track of content IDs seen in individual index blobs.
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(content.Info) error {
+		st.UniqueContents++
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	st.TotalEntries = totalEntries
+	st.RedundantEntries = max(st.TotalEntries-st.UniqueContents, 0)
+
+	if st.TotalEntries > 0 {
+		st.Fragmentation = float64(st.RedundantEntries) / float64(st.TotalEntries)
+	}
+
+	em, hasEpochManager, err := rep.ContentReader().EpochManager(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "epoch manager")
+	}
+
+	if !hasEpochManager {
+		// without epoch manager, compaction merges all active blobs into one and keeps only the latest entry for each content,
+		// so the index size shrinks roughly in proportion to the number of redundant entries.
+		st.EstimatedCompactedBlobs = min(st.IndexBlobs, 1)
+		st.EstimatedCompactedBytes = int64(float64(st.IndexBytes) * (1 - st.Fragmentation))
+	} else {
+		cs, err := em.Current(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to determine current epoch")
+		}
+
+		mp, err := rep.ContentReader().ContentFormat().GetMutableParameters(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get mutable parameters")
+		}
+
+		st.EstimatedCompactedBlobs, st.EstimatedCompactedBytes = estimateEpochCompaction(cs, mp.EpochParameters, 1-st.Fragmentation)
+	}
+
+	st.EstimatedSavedBytes = st.IndexBytes - st.EstimatedCompactedBytes
+
+	return st, nil
+}
+
+// countIndexEntries returns the total number of entries in the provided index blobs.
+func (c *commandIndexStatus) countIndexEntries(ctx context.Context, rep repo.DirectRepository, active []indexblob.Metadata) (int64, error) {
+	var total atomic.Int64
+
+	indexesCh := make(chan indexblob.Metadata, len(active))
+	for _, bm := range active {
+		indexesCh <- bm
+	}
+
+	close(indexesCh)
+
+	var eg errgroup.Group
+
+	for range max(c.parallel, 1) {
+		eg.Go(func() error {
+			for bm := range indexesCh {
+				_, entries, err := readIndexBlobEntries(ctx, rep, bm.BlobID)
+				if err != nil {
+					return err
+				}
+
+				total.Add(int64(len(entries)))
+			}
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return 0, errors.Wrap(err, "error reading index blobs")
+	}
+
+	return total.Load(), nil
+}
+
+// epochStatusUnsettledEpochs mirrors the number of most recent epochs that the epoch manager never compacts.
+const epochStatusUnsettledEpochs = 2
+
+// estimateEpochCompaction estimates the number and total size of index blobs once maintenance has compacted all epochs
+// eligible for compaction under the epoch manager policy: each settled epoch is compacted into a single blob and, once enough
+// settled epochs accumulate past the last range checkpoint, they are merged into a new range checkpoint. Blobs of unsettled epochs
+// are kept as is. Merged blobs are assumed to shrink to keepRatio of their size, as redundant entries are dropped.
+func estimateEpochCompaction(cs epoch.CurrentSnapshot, p epoch.Parameters, keepRatio float64) (blobs int, bytes int64) {
+	latestSettled := cs.WriteEpoch - epochStatusUnsettledEpochs
+
+	firstNonRangeCompacted := 0
+
+	for _, r := range cs.LongestRangeCheckpointSets {
+		blobs += len(r.Blobs)
+		bytes += blob.TotalLength(r.Blobs)
+		firstNonRangeCompacted = r.MaxEpoch + 1
+	}
+
+	compactRange := latestSettled >= 0 && latestSettled-firstNonRangeCompacted >= p.FullCheckpointFrequency
+
+	merged := func(n int64) int64 {
+		return int64(float64(n) * keepRatio)
+	}
+
+	var rangeBytes int64
+
+	for e := firstNonRangeCompacted; e <= cs.WriteEpoch; e++ {
+		uncompacted := cs.UncompactedEpochSets[e]
+		singleEpoch := cs.SingleEpochCompactionSets[e]
+
+		epochBlobs := uncompacted
+		if singleEpoch != nil {
+			epochBlobs = singleEpoch
+		}
+
+		switch {
+		case e > latestSettled:
+			blobs += len(uncompacted)
+			bytes += blob.TotalLength(uncompacted)
+
+		case compactRange:
+			rangeBytes += blob.TotalLength(epochBlobs)
+
+		case singleEpoch != nil:
+			blobs += len(singleEpoch)
+			bytes += blob.TotalLength(singleEpoch)
+
+		case len(uncompacted) > 0:
+			blobs++
+			bytes += merged(blob.TotalLength(uncompacted))
+		}
+	}
+
+	if rangeBytes > 0 {
+		blobs++
+		bytes += merged(rangeBytes)
+	}
+
+	return blobs, bytes
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/epoch"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+func TestEstimateEpochCompaction(t *testing.T) {
+	blobs := func(lengths ...int64) []blob.Metadata {
+		var result []blob.Metadata
+
+		for _, l := range lengths {
+			result = append(result, blob.Metadata{Length: l})
+		}
+
+		return result
+	}
+
+	p := epoch.Parameters{FullCheckpointFrequency: 3}
+
+	// epochs 0 and 1 are settled, 2 and 3 are not compacted.
+	cs := epoch.CurrentSnapshot{
+		WriteEpoch: 3,
+		UncompactedEpochSets: map[int][]blob.Metadata{
+			0: blobs(100, 100),
+			1: blobs(100, 100, 100),
+			2: blobs(10, 20),
+			3: blobs(30),
+		},
+		SingleEpochCompactionSets: map[int][]blob.Metadata{
+			0: blobs(150),
+		},
+	}
+
+	n, bytes := estimateEpochCompaction(cs, p, 0.5)
+	require.Equal(t, 1+1+2+1, n)
+	require.EqualValues(t, 150+150+10+20+30, bytes)
+
+	// once enough epochs are settled after the last range checkpoint, they are merged into a new one.
+	cs = epoch.CurrentSnapshot{
+		WriteEpoch: 6,
+		LongestRangeCheckpointSets: []*epoch.RangeMetadata{
+			{MinEpoch: 0, MaxEpoch: 0, Blobs: blobs(1000)},
+		},
+		UncompactedEpochSets: map[int][]blob.Metadata{
+			1: blobs(100, 100),
+			2: blobs(100),
+			3: blobs(100),
+			4: blobs(100, 100),
+			5: blobs(10),
+			6: blobs(20),
+		},
+		SingleEpochCompactionSets: map[int][]blob.Metadata{
+			1: blobs(100),
+		},
+	}
+
+	n, bytes = estimateEpochCompaction(cs, p, 0.5)
+	require.Equal(t, 1+1+1+1, n)
+	require.EqualValues(t, 1000+(100+100+100+200)/2+10+20, bytes)
+}
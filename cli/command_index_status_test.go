@@ -0,0 +1,41 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestIndexStatus(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	someContentID := env.RunAndExpectSuccess(t, "content", "list")[0]
+
+	var before map[string]float64
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "index", "status", "--json"), &before)
+	require.Positive(t, before["indexBlobs"])
+	require.Equal(t, before["totalEntries"], before["uniqueContents"])
+	require.Zero(t, before["redundantEntries"])
+
+	// rewriting a content makes it appear in a second index blob.
+	env.RunAndExpectSuccess(t, "content", "rewrite", someContentID, "--safety=none")
+
+	var after map[string]float64
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "index", "status", "--json"), &after)
+	require.InDelta(t, 1, after["redundantEntries"], 0)
+	require.Equal(t, before["uniqueContents"], after["uniqueContents"])
+	require.Positive(t, after["fragmentation"])
+	// both index blobs belong to the current epoch, which the epoch manager does not compact.
+	require.InDelta(t, after["indexBlobs"], after["estimatedCompactedBlobs"], 0)
+	require.InDelta(t, after["indexBytes"], after["estimatedCompactedBytes"], 0)
+
+	lines := env.RunAndExpectSuccess(t, "index", "status")
+	require.Contains(t, lines[0], "Active index blobs:")
+}
@@ -10,6 +10,7 @@
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
@@ -19,8 +20,17 @@ type commandList struct {
 	showOID      bool
 	errorSummary bool
 	path         string
+	objectID     string
 
 	out textOutput
+	jo  jsonOutput
+	jl  jsonList
+}
+
+// listedEntryJSON is the JSON representation of a single entry emitted by 'list --json'.
+type listedEntryJSON struct {
+	Path  string             `json:"path"`
+	Entry *snapshot.DirEntry `json:"entry"`
 }
 
 func (c *commandList) setup(svc appServices, parent commandParent) {
@@ -30,29 +40,76 @@ func (c *commandList) setup(svc appServices, parent commandParent) {
 	cmd.Flag("recursive", "Recursive output").Short('r').BoolVar(&c.recursive)
 	cmd.Flag("show-object-id", "Show object IDs").Short('o').BoolVar(&c.showOID)
 	cmd.Flag("error-summary", "Emit error summary").Default("true").BoolVar(&c.errorSummary)
-	cmd.Arg("object-path", "Path").Required().StringVar(&c.path)
+	cmd.Flag("object", "List directory object with the provided raw object ID").StringVar(&c.objectID)
+	cmd.Arg("object-path", "Path").StringVar(&c.path)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 
 	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
 }
 
 func (c *commandList) run(ctx context.Context, rep repo.Repository) error {
-	dir, err := snapshotfs.FilesystemDirectoryFromIDWithPath(ctx, rep, c.path, false)
+	if (c.path == "") == (c.objectID == "") {
+		return errors.New("exactly one of object path or --object must be provided")
+	}
+
+	var (
+		dir         fs.Directory
+		displayPath = c.path
+		err         error
+	)
+
+	if c.objectID != "" {
+		displayPath = c.objectID
+
+		dir, err = c.directoryFromObjectID(ctx, rep, c.objectID)
+	} else {
+		dir, err = snapshotfs.FilesystemDirectoryFromIDWithPath(ctx, rep, c.path, false)
+	}
+
 	if err != nil {
 		return errors.Wrap(err, "unable to get filesystem directory entry")
 	}
 
 	var prefix string
-	if !c.long {
-		prefix = c.path
+	if !c.long || c.jo.jsonOutput {
+		prefix = displayPath
 		if !strings.HasSuffix(prefix, "/") {
 			prefix += "/"
 		}
 	}
 
+	c.jl.begin(&c.jo)
+	defer c.jl.end()
+
 	return c.listDirectory(ctx, dir, prefix, "")
 }
 
+// directoryFromObjectID returns the directory stored in the provided raw object,
+// failing if the object is not a directory.
+func (c *commandList) directoryFromObjectID(ctx context.Context, rep repo.Repository, objectID string) (fs.Directory, error) {
+	oid, err := object.ParseID(objectID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid object ID %v", objectID)
+	}
+
+	if !snapshotfs.IsDirectoryID(oid) {
+		return nil, errors.Errorf("object %v is not a directory", oid)
+	}
+
+	dir := snapshotfs.DirectoryEntry(rep, oid, nil)
+
+	// make sure the object can be read as a directory before listing it.
+	iter, err := dir.Iterate(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "object %v is not a valid directory", oid)
+	}
+
+	iter.Close()
+
+	return dir, nil
+}
+
 func (c *commandList) listDirectory(ctx context.Context, d fs.Directory, prefix, indent string) error {
 	iter, err := d.Iterate(ctx)
 	if err != nil {
@@ -73,7 +130,7 @@ func (c *commandList) listDirectory(ctx context.Context, d fs.Directory, prefix,
 		return err //nolint:wrapcheck
 	}
 
-	if dws, ok := d.(fs.DirectoryWithSummary); ok && c.errorSummary {
+	if dws, ok := d.(fs.DirectoryWithSummary); ok && c.errorSummary && !c.jo.jsonOutput {
 		if ds, _ := dws.Summary(ctx); ds != nil && ds.FatalErrorCount > 0 {
 			errorColor.Fprintf(c.out.stderr(), "\nNOTE: Encountered %v errors while snapshotting this directory:\n\n", ds.FatalErrorCount) //nolint:errcheck
 
@@ -92,6 +149,14 @@ func (c *commandList) printDirectoryEntry(ctx context.Context, e fs.Entry, prefi
 		return errors.New("entry without object ID")
 	}
 
+	if c.jo.jsonOutput {
+		if hde, ok := e.(snapshot.HasDirEntry); ok {
+			c.jl.emit(listedEntryJSON{Path: prefix + e.Name(), Entry: hde.DirEntry()})
+		}
+
+		return c.maybeListSubdirectory(ctx, e, prefix, indent)
+	}
+
 	objectID := hoid.ObjectID()
 	oid := objectID.String()
 	col := defaultColor
@@ -128,6 +193,10 @@ func (c *commandList) printDirectoryEntry(ctx context.Context, e fs.Entry, prefi
 
 	col.Fprintln(c.out.stdout(), info) //nolint:errcheck
 
+	return c.maybeListSubdirectory(ctx, e, prefix, indent)
+}
+
+func (c *commandList) maybeListSubdirectory(ctx context.Context, e fs.Entry, prefix, indent string) error {
 	if c.recursive {
 		if subdir, ok := e.(fs.Directory); ok {
 			if listerr := c.listDirectory(ctx, subdir, prefix+e.Name()+"/", indent+"  "); listerr != nil {
@@ -0,0 +1,127 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestListObject(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("hello"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "subdir", "file2.txt"), []byte("world"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var manifests []cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "list", "--json"), &manifests)
+	require.Len(t, manifests, 1)
+
+	rootID := manifests[0].RootObjectID().String()
+
+	require.ElementsMatch(t, []string{"subdir", "file1.txt"}, env.RunAndExpectSuccess(t, "ls", "--object", rootID))
+	require.ElementsMatch(t, []string{
+		rootID + "/subdir/",
+		rootID + "/subdir/file2.txt",
+		rootID + "/file1.txt",
+	}, env.RunAndExpectSuccess(t, "ls", "--object", rootID, "-r"))
+
+	// listing by raw object ID and by path produce the same output.
+	require.ElementsMatch(t,
+		env.RunAndExpectSuccess(t, "ls", rootID, "-l"),
+		env.RunAndExpectSuccess(t, "ls", "--object", rootID, "-l"))
+
+	fileID := strings.Fields(mustGetLineContaining(t, env.RunAndExpectSuccess(t, "ls", "--object", rootID, "-o"), "file1.txt"))[0]
+
+	// --object only accepts valid directory objects and can't be combined with a path.
+	env.RunAndExpectFailure(t, "ls")
+	env.RunAndExpectFailure(t, "ls", "--object", rootID, rootID)
+	env.RunAndExpectFailure(t, "ls", "--object", "not-an-object-id")
+	env.RunAndExpectFailure(t, "ls", "--object", fileID)
+}
+
+func TestListJSON(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("hello"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "subdir", "file2.txt"), []byte("world!"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var manifests []cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "list", "--json"), &manifests)
+	require.Len(t, manifests, 1)
+
+	rootID := manifests[0].RootObjectID().String()
+
+	type listedEntry struct {
+		Path  string `json:"path"`
+		Entry struct {
+			Name     string `json:"name"`
+			Type     string `json:"type"`
+			ObjectID string `json:"obj"`
+			Size     int64  `json:"size"`
+		} `json:"entry"`
+	}
+
+	byPath := map[string]listedEntry{}
+
+	parse := func(lines []string) []listedEntry {
+		t.Helper()
+
+		var result []listedEntry
+
+		require.NoError(t, json.Unmarshal([]byte(strings.Join(lines, "\n")), &result))
+
+		for _, e := range result {
+			byPath[e.Path] = e
+		}
+
+		return result
+	}
+
+	entries := parse(env.RunAndExpectSuccess(t, "ls", rootID, "--json"))
+	require.Len(t, entries, 2)
+
+	subdir := byPath[rootID+"/subdir"]
+	require.Equal(t, "subdir", subdir.Entry.Name)
+	require.Equal(t, "d", subdir.Entry.Type)
+
+	file1 := byPath[rootID+"/file1.txt"]
+	require.Equal(t, "file1.txt", file1.Entry.Name)
+	require.Equal(t, "f", file1.Entry.Type)
+	require.EqualValues(t, 5, file1.Entry.Size)
+	require.NotEmpty(t, file1.Entry.ObjectID)
+
+	// recursive listing includes entries of subdirectories, --long does not affect JSON output.
+	entries = parse(env.RunAndExpectSuccess(t, "ls", "--object", rootID, "--json", "-r", "-l"))
+	require.Len(t, entries, 3)
+
+	file2 := byPath[rootID+"/subdir/file2.txt"]
+	require.Equal(t, "file2.txt", file2.Entry.Name)
+	require.EqualValues(t, 6, file2.Entry.Size)
+
+	// the object ID of the listed entry can be used to read its contents.
+	require.Equal(t, []string{"world!"}, env.RunAndExpectSuccess(t, "show", file2.Entry.ObjectID))
+}
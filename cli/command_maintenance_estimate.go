@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// measureDownloadBytes is the maximum number of bytes downloaded to measure download throughput.
+const measureDownloadBytes = 4 << 20
+
+// estimateShortPackPercent mirrors the threshold below which maintenance considers packs short and rewrites their contents.
+const estimateShortPackPercent = 60
+
+var maintenanceEstimateCaveats = []string{
+	"Blob GC only deletes unreferenced blobs older than the safety margin, so recently written blobs may be reclaimed by a later cycle.",
+	"Snapshot GC may mark additional contents as unreferenced, which is only reclaimed by subsequent maintenance cycles.",
+	"Duration is extrapolated from listing times and a single partial blob download and may vary with storage load.",
+}
+
+type commandMaintenanceEstimate struct {
+	jo  jsonOutput
+	out textOutput
+}
+
+// MaintenanceEstimate describes predicted duration and reclaimable space of the next full maintenance.
+type MaintenanceEstimate struct {
+	PackBlobs             int   `json:"packBlobs"`
+	PackBytes             int64 `json:"packBytes"`
+	LiveContentBytes      int64 `json:"liveContentBytes"`
+	DeadContentBytes      int64 `json:"deadContentBytes"`
+	UnreferencedPackBlobs int   `json:"unreferencedPackBlobs"`
+	UnreferencedPackBytes int64 `json:"unreferencedPackBytes"`
+	ShortPackBlobs        int   `json:"shortPackBlobs"`
+	ShortPackLiveBytes    int64 `json:"shortPackLiveBytes"`
+	ShortPackDeadBytes    int64 `json:"shortPackDeadBytes"`
+
+	IndexBlobs              int   `json:"indexBlobs"`
+	IndexBytes              int64 `json:"indexBytes"`
+	IndexEntries            int   `json:"indexEntries"`
+	UniqueIndexEntries      int   `json:"uniqueIndexEntries"`
+	CompactedIndexBytes     int64 `json:"compactedIndexBytes"`
+	ReclaimableBlobGCBytes  int64 `json:"reclaimableBlobGCBytes"`
+	ReclaimableRewriteBytes int64 `json:"reclaimableRewriteBytes"`
+	ReclaimableIndexBytes   int64 `json:"reclaimableIndexBytes"`
+	TotalReclaimableBytes   int64 `json:"totalReclaimableBytes"`
+	DownloadBytesPerSecond  int64 `json:"measuredDownloadBytesPerSecond"`
+
+	EstimatedDuration time.Duration `json:"estimatedDuration"`
+	Caveats           []string      `json:"caveats"`
+}
+
+func (c *commandMaintenanceEstimate) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("estimate", "Estimate duration and reclaimable space of the next full maintenance without making any changes.")
+	c.jo.setup(svc, cmd)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+	c.out.setup(svc)
+}
+
+func (c *commandMaintenanceEstimate) run(ctx context.Context, rep repo.DirectRepository) error {
+	est := &MaintenanceEstimate{Caveats: maintenanceEstimateCaveats}
+
+	log(ctx).Info("Listing blobs...")
+
+	timer := timetrack.StartTimer()
+
+	blobMap, err := blob.ReadBlobMap(ctx, rep.BlobReader())
+	if err != nil {
+		return errors.Wrap(err, "unable to read blob map")
+	}
+
+	listDuration := timer.Elapsed()
+
+	log(ctx).Info("Analyzing contents...")
+
+	timer = timetrack.StartTimer()
+	usage := newBlobUsageTracker()
+
+	var metadataLiveBytes int64
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		usage.record(ci)
+
+		est.UniqueIndexEntries++
+
+		if !ci.Deleted && ci.ContentID.HasPrefix() {
+			metadataLiveBytes += int64(ci.PackedLength)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error iterating contents")
+	}
+
+	iterateDuration := timer.Elapsed()
+
+	packUsage, err := usage.usage(memoryBlobMap(blobMap))
+	if err != nil {
+		return err
+	}
+
+	if err := c.estimatePacks(ctx, rep, est, packUsage); err != nil {
+		return err
+	}
+
+	if err := c.estimateIndexes(ctx, rep, est); err != nil {
+		return err
+	}
+
+	downloadRate, opLatency := c.measureStorage(ctx, rep, blobMap)
+	est.DownloadBytesPerSecond = int64(downloadRate)
+
+	// maintenance lists blobs and iterates contents several times, reads metadata contents during snapshot GC,
+	// reads and writes contents of short packs and indexes and deletes unreferenced blobs.
+	const listingPasses = 3
+
+	est.EstimatedDuration = listingPasses*(listDuration+iterateDuration) +
+		time.Duration(est.UnreferencedPackBlobs+est.IndexBlobs)*opLatency
+
+	if downloadRate > 0 {
+		transferBytes := metadataLiveBytes + 2*est.ShortPackLiveBytes + 2*est.IndexBytes //nolint:mnd
+		est.EstimatedDuration += time.Duration(float64(transferBytes) / downloadRate * float64(time.Second))
+	}
+
+	est.TotalReclaimableBytes = est.ReclaimableBlobGCBytes + est.ReclaimableRewriteBytes + est.ReclaimableIndexBytes
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(est))
+		return nil
+	}
+
+	c.printEstimate(est)
+
+	return nil
+}
+
+func (c *commandMaintenanceEstimate) estimatePacks(ctx context.Context, rep repo.DirectRepository, est *MaintenanceEstimate, usage []blobUsage) error {
+	mp, err := rep.ContentReader().ContentFormat().GetMutableParameters(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to get mutable parameters")
+	}
+
+	shortPackThreshold := int64(mp.MaxPackSize * estimateShortPackPercent / 100) //nolint:mnd
+
+	for _, bu := range usage {
+		est.PackBlobs++
+		est.PackBytes += bu.Length
+		est.LiveContentBytes += bu.LiveBytes
+		est.DeadContentBytes += bu.DeadBytes
+
+		switch {
+		case bu.LiveBytes == 0:
+			est.UnreferencedPackBlobs++
+			est.UnreferencedPackBytes += bu.Length
+
+		case bu.Length < shortPackThreshold:
+			est.ShortPackBlobs++
+			est.ShortPackLiveBytes += bu.LiveBytes
+			est.ShortPackDeadBytes += bu.DeadBytes
+		}
+	}
+
+	est.ReclaimableBlobGCBytes = est.UnreferencedPackBytes
+
+	// rewriting contents of short packs drops their dead bytes, but only if there is more than one of them.
+	if est.ShortPackBlobs > 1 {
+		est.ReclaimableRewriteBytes = est.ShortPackDeadBytes
+	}
+
+	return nil
+}
+
+func (c *commandMaintenanceEstimate) estimateIndexes(ctx context.Context, rep repo.DirectRepository, est *MaintenanceEstimate) error {
+	indexBlobs, err := rep.IndexBlobs(ctx, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to list index blobs")
+	}
+
+	for _, ib := range indexBlobs {
+		_, entries, err := readIndexBlobEntries(ctx, rep, ib.BlobID)
+		if err != nil {
+			return err
+		}
+
+		est.IndexBlobs++
+		est.IndexBytes += ib.Length
+		est.IndexEntries += len(entries)
+	}
+
+	est.CompactedIndexBytes = est.IndexBytes
+
+	// compaction merges index blobs, dropping entries superseded by newer ones.
+	if est.IndexBlobs > 1 && est.IndexEntries > 0 {
+		est.CompactedIndexBytes = est.IndexBytes * int64(min(est.UniqueIndexEntries, est.IndexEntries)) / int64(est.IndexEntries)
+		est.ReclaimableIndexBytes = est.IndexBytes - est.CompactedIndexBytes
+	}
+
+	return nil
+}
+
+// measureStorage downloads up to measureDownloadBytes of the largest pack blob to measure download throughput in bytes per second
+// and latency of storage operations. Zero values are returned if measurement was not possible.
+func (c *commandMaintenanceEstimate) measureStorage(ctx context.Context, rep repo.DirectRepository, blobMap map[blob.ID]blob.Metadata) (bytesPerSecond float64, opLatency time.Duration) {
+	var largest blob.Metadata
+
+	for _, bm := range blobMap {
+		if isPackBlob(bm.BlobID) && bm.Length > largest.Length {
+			largest = bm
+		}
+	}
+
+	if largest.BlobID == "" {
+		return 0, 0
+	}
+
+	timer := timetrack.StartTimer()
+
+	if _, err := rep.BlobReader().GetMetadata(ctx, largest.BlobID); err != nil {
+		log(ctx).Debugf("unable to get metadata of %v: %v", largest.BlobID, err)
+		return 0, 0
+	}
+
+	opLatency = timer.Elapsed()
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	timer = timetrack.StartTimer()
+
+	if err := rep.BlobReader().GetBlob(ctx, largest.BlobID, 0, min(largest.Length, measureDownloadBytes), &tmp); err != nil {
+		log(ctx).Debugf("unable to download %v: %v", largest.BlobID, err)
+		return 0, opLatency
+	}
+
+	dur := timer.Elapsed()
+	if dur <= 0 {
+		return 0, opLatency
+	}
+
+	return float64(tmp.Length()) / dur.Seconds(), opLatency
+}
+
+func (c *commandMaintenanceEstimate) printEstimate(est *MaintenanceEstimate) {
+	c.out.printStdout("Pack blobs:            %v (%v), live contents %v, dead contents %v\n",
+		est.PackBlobs, units.BytesString(est.PackBytes), units.BytesString(est.LiveContentBytes), units.BytesString(est.DeadContentBytes))
+	c.out.printStdout("Index blobs:           %v (%v), %v entries, %v unique\n",
+		est.IndexBlobs, units.BytesString(est.IndexBytes), est.IndexEntries, est.UniqueIndexEntries)
+	c.out.printStdout("\nReclaimable space:\n")
+	c.out.printStdout("  Blob GC:             %v in %v unreferenced pack blobs\n", units.BytesString(est.ReclaimableBlobGCBytes), est.UnreferencedPackBlobs)
+	c.out.printStdout("  Content rewrite:     %v in %v short pack blobs\n", units.BytesString(est.ReclaimableRewriteBytes), est.ShortPackBlobs)
+	c.out.printStdout("  Index compaction:    %v\n", units.BytesString(est.ReclaimableIndexBytes))
+	c.out.printStdout("  Total:               %v\n", units.BytesString(est.TotalReclaimableBytes))
+	c.out.printStdout("\nEstimated duration:    %v", est.EstimatedDuration.Round(time.Second))
+
+	if est.DownloadBytesPerSecond > 0 {
+		c.out.printStdout(" (measured download speed %v/s)", units.BytesString(est.DownloadBytesPerSecond))
+	}
+
+	c.out.printStdout("\n\nNOTE: These are estimates:\n  %v\n", strings.Join(est.Caveats, "\n  "))
+}
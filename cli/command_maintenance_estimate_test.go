@@ -0,0 +1,41 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestMaintenanceEstimate(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("hello world"), 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file2.txt"), []byte("another file"), 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var est cli.MaintenanceEstimate
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "estimate", "--json"), &est)
+	require.Positive(t, est.PackBlobs)
+	require.Positive(t, est.LiveContentBytes)
+	require.GreaterOrEqual(t, est.IndexBlobs, 1)
+	require.GreaterOrEqual(t, est.IndexEntries, est.UniqueIndexEntries)
+	require.Equal(t, est.ReclaimableBlobGCBytes+est.ReclaimableRewriteBytes+est.ReclaimableIndexBytes, est.TotalReclaimableBytes)
+	require.NotEmpty(t, est.Caveats)
+
+	lines := e.RunAndExpectSuccess(t, "maintenance", "estimate")
+	require.Contains(t, mustGetLineContaining(t, lines, "Estimated duration:"), "measured download speed")
+	mustGetLineContaining(t, lines, "NOTE: These are estimates")
+}
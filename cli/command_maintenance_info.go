@@ -76,6 +76,10 @@ func (c *commandMaintenanceInfo) run(ctx context.Context, rep repo.DirectReposit
 		c.out.printStdout("List parallelism: %v\n", p.ListParallelism)
 	}
 
+	if p.MaxBlobListingDropPercent != 0 {
+		c.out.printStdout("Max blob listing drop: %v%%\n", p.MaxBlobListingDropPercent)
+	}
+
 	c.out.printStdout("Recent Maintenance Runs:\n")
 
 	for run, timings := range s.Runs {
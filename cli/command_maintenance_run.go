@@ -3,6 +3,9 @@
 import (
 	"context"
 
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
@@ -11,25 +14,112 @@
 type commandMaintenanceRun struct {
 	maintenanceRunFull  bool
 	maintenanceRunForce bool
+	fullBlobGC          bool
+	dryRun              bool
 	safety              maintenance.SafetyParameters
+
+	jo  jsonOutput
+	out textOutput
+	svc appServices
 }
 
 func (c *commandMaintenanceRun) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("run", "Run repository maintenance")
 	cmd.Flag("full", "Full maintenance").BoolVar(&c.maintenanceRunFull)
 	cmd.Flag("force", "Run maintenance even if not owned (unsafe)").Hidden().BoolVar(&c.maintenanceRunForce)
+	cmd.Flag("full-blob-gc", "Only run full blob garbage collection now, regardless of maintenance schedule (advanced)").BoolVar(&c.fullBlobGC)
+	cmd.Flag("dry-run", "Only report what maintenance would compact, delete or rewrite, without making any changes").BoolVar(&c.dryRun)
 	safetyFlagVar(cmd, &c.safety)
+	c.jo.setup(svc, cmd)
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
 }
 
 func (c *commandMaintenanceRun) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	if c.fullBlobGC {
+		if c.dryRun {
+			return errors.New("--dry-run can't be used with --full-blob-gc, use 'kopia blob gc' without --delete instead")
+		}
+
+		return c.runFullBlobGC(ctx, rep)
+	}
+
 	mode := maintenance.ModeQuick
 
 	if c.maintenanceRunFull {
 		mode = maintenance.ModeFull
 	}
 
+	if c.dryRun {
+		return c.runDryRun(ctx, rep, mode)
+	}
+
 	//nolint:wrapcheck
 	return snapshotmaintenance.Run(ctx, rep, mode, c.maintenanceRunForce, c.safety)
 }
+
+// runFullBlobGC deletes unreferenced blobs immediately instead of waiting for the maintenance schedule.
+// Blobs are still protected by the safety parameters, but deleting them ahead of schedule shortens
+// the window other clients have to commit their indexes, so this is gated behind --advanced-commands.
+func (c *commandMaintenanceRun) runFullBlobGC(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	c.svc.advancedCommand(ctx)
+
+	// run under quick maintenance mode, so that next full maintenance is not postponed.
+	//
+	//nolint:wrapcheck
+	return maintenance.RunExclusive(ctx, rep, maintenance.ModeQuick, c.maintenanceRunForce,
+		func(ctx context.Context, runParams maintenance.RunParameters) error {
+			cnt, size, err := maintenance.RunFullBlobGC(ctx, runParams, c.safety)
+			if err != nil {
+				return errors.Wrap(err, "error running full blob garbage collection")
+			}
+
+			log(ctx).Infof("Reclaimed %v by deleting %v unreferenced blobs.", units.BytesString(size), cnt)
+
+			return nil
+		})
+}
+
+// runDryRun runs maintenance in a mode which only reports the changes it would make.
+func (c *commandMaintenanceRun) runDryRun(ctx context.Context, rep repo.DirectRepositoryWriter, mode maintenance.Mode) error {
+	r, err := snapshotmaintenance.RunDryRun(ctx, rep, mode, c.safety)
+	if err != nil {
+		return errors.Wrap(err, "maintenance dry run failed")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(r))
+		return nil
+	}
+
+	c.out.printStdout("Dry run of %v maintenance, no changes were made.\n", r.Mode)
+
+	if r.Mode == maintenance.ModeFull {
+		c.out.printStdout("  Snapshot GC would mark %v unreferenced contents as deleted (%v).\n", r.UnreferencedContents, units.BytesString(r.UnreferencedContentBytes))
+	}
+
+	c.out.printStdout("  Would rewrite %v contents from short packs (%v).\n", r.RewrittenContents, units.BytesString(r.RewrittenContentBytes))
+
+	if r.Mode == maintenance.ModeFull {
+		c.out.printStdout("  Would drop %v deleted contents from the index.\n", r.DroppedContents)
+	}
+
+	c.out.printStdout("  Would delete %v unreferenced blobs (%v).\n", r.DeletedBlobs, units.BytesString(r.DeletedBlobBytes))
+
+	if r.ExtendedRetentionBlobs > 0 {
+		c.out.printStdout("  Would extend retention time of %v blobs.\n", r.ExtendedRetentionBlobs)
+	}
+
+	c.out.printStdout("  Would delete %v logs (%v).\n", r.DeletedLogs, units.BytesString(r.DeletedLogBytes))
+
+	for _, t := range r.SkippedTasks {
+		c.out.printStdout("  Not estimated: %v\n", t)
+	}
+
+	c.out.printStdout("Estimated reclaimed space: %v\n", units.BytesString(r.ReclaimedBytes()))
+
+	return nil
+}
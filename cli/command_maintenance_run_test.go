@@ -0,0 +1,65 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestMaintenanceRunDryRun(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir, "--disable-internal-log")
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1"), []byte(strings.Repeat("some data", 1000)), 0o600))
+
+	var snap snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", dir, "--json", "--disable-internal-log"), &snap)
+
+	// avoid create and delete in the same second.
+	time.Sleep(2 * time.Second)
+	e.RunAndExpectSuccess(t, "snapshot", "delete", string(snap.ID), "--delete", "--disable-internal-log")
+
+	var before, after cli.MaintenanceInfo
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &before)
+	blobsBefore := e.RunAndExpectSuccess(t, "blob", "list")
+
+	var r maintenance.DryRunReport
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none", "--dry-run", "--json", "--disable-internal-log"), &r)
+	require.Equal(t, maintenance.ModeFull, r.Mode)
+	require.Positive(t, r.UnreferencedContents)
+	require.Positive(t, r.UnreferencedContentBytes)
+
+	out := e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none", "--dry-run", "--disable-internal-log")
+	mustGetLineContaining(t, out, "no changes were made")
+	mustGetLineContaining(t, out, "Snapshot GC would mark")
+	mustGetLineContaining(t, out, "Estimated reclaimed space")
+
+	// neither blobs nor maintenance schedule have changed.
+	require.Equal(t, blobsBefore, e.RunAndExpectSuccess(t, "blob", "list"))
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &after)
+	require.Equal(t, before.Schedule, after.Schedule)
+
+	e.RunAndExpectFailure(t, "maintenance", "run", "--full-blob-gc", "--dry-run")
+
+	// actual maintenance changes the repository.
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none", "--disable-internal-log")
+	require.NotEqual(t, blobsBefore, e.RunAndExpectSuccess(t, "blob", "list"))
+}
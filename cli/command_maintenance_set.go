@@ -27,6 +27,8 @@ type commandMaintenanceSet struct {
 	extendObjectLocks []bool // optional boolean
 
 	listParallelism int
+
+	maxBlobListingDropPercent int
 }
 
 func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
@@ -42,6 +44,7 @@ func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
 	c.maxTotalRetainedLogSizeMB = -1
 
 	c.listParallelism = -1
+	c.maxBlobListingDropPercent = -1
 
 	cmd.Flag("owner", "Set maintenance owner user@hostname").StringVar(&c.maintenanceSetOwner)
 
@@ -60,6 +63,7 @@ func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
 	cmd.Flag("extend-object-locks", "Extend retention period of locked objects as part of full maintenance.").BoolListVar(&c.extendObjectLocks)
 
 	cmd.Flag("list-parallelism", "Override list parallelism.").IntVar(&c.listParallelism)
+	cmd.Flag("max-blob-listing-drop-percent", "Refuse to delete unreferenced blobs if the number of entries in listed index blobs dropped by more than this percentage since the last blob GC (0 = default, 100 = disable check).").IntVar(&c.maxBlobListingDropPercent)
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
 }
@@ -100,6 +104,13 @@ func (c *commandMaintenanceSet) setDeleteUnreferencedBlobsParams(ctx context.Con
 
 		log(ctx).Infof("Setting list parallelism to %v.", v)
 	}
+
+	if v := c.maxBlobListingDropPercent; v != -1 {
+		p.MaxBlobListingDropPercent = v
+		*changed = true
+
+		log(ctx).Infof("Setting maximum blob listing drop to %v%%.", v)
+	}
 }
 
 func (c *commandMaintenanceSet) setMaintenanceOwnerFromFlags(ctx context.Context, p *maintenance.Params, rep repo.DirectRepositoryWriter, changed *bool) {
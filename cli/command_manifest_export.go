@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+// exportedManifest is a single manifest item in the export file.
+type exportedManifest struct {
+	ID      manifest.ID       `json:"id"`
+	Labels  map[string]string `json:"labels"`
+	Payload json.RawMessage   `json:"payload"`
+}
+
+// manifestExcludeFlags defines flags for excluding manifests from export and import.
+type manifestExcludeFlags struct {
+	excludeTypes  []string
+	excludeLabels []string
+
+	// parsed --exclude-label values
+	excludeLabelPairs [][2]string
+}
+
+func (c *manifestExcludeFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("exclude-type", "Exclude manifests of the provided type").StringsVar(&c.excludeTypes)
+	cmd.Flag("exclude-label", "Exclude manifests with the provided label (key:value)").StringsVar(&c.excludeLabels)
+}
+
+func (c *manifestExcludeFlags) parse() error {
+	c.excludeLabelPairs = nil
+
+	for _, kv := range c.excludeLabels {
+		p := strings.Index(kv, ":")
+		if p <= 0 {
+			return errors.Errorf("invalid exclude label %q, must be key:value", kv)
+		}
+
+		c.excludeLabelPairs = append(c.excludeLabelPairs, [2]string{kv[0:p], kv[p+1:]})
+	}
+
+	return nil
+}
+
+// isExcluded returns true if manifest with the provided labels matches any of the exclusions.
+func (c *manifestExcludeFlags) isExcluded(labels map[string]string) bool {
+	for _, t := range c.excludeTypes {
+		if labels[manifest.TypeLabelKey] == t {
+			return true
+		}
+	}
+
+	for _, kv := range c.excludeLabelPairs {
+		if v, ok := labels[kv[0]]; ok && v == kv[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+type commandManifestExport struct {
+	outputFile string
+	exclude    manifestExcludeFlags
+
+	out textOutput
+}
+
+func (c *commandManifestExport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("export", "Export manifest items to a file")
+	cmd.Flag("output", "Output file (defaults to stdout)").Short('o').StringVar(&c.outputFile)
+	c.exclude.setup(cmd)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+	c.out.setup(svc)
+}
+
+func (c *commandManifestExport) run(ctx context.Context, rep repo.Repository) error {
+	if err := c.exclude.parse(); err != nil {
+		return err
+	}
+
+	items, err := rep.FindManifests(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to find manifests")
+	}
+
+	result := []exportedManifest{}
+	excluded := 0
+
+	for _, it := range items {
+		if c.exclude.isExcluded(it.Labels) {
+			excluded++
+			continue
+		}
+
+		var payload json.RawMessage
+
+		if _, err := rep.GetManifest(ctx, it.ID, &payload); err != nil {
+			return errors.Wrapf(err, "error getting manifest %v", it.ID)
+		}
+
+		result = append(result, exportedManifest{it.ID, it.Labels, payload})
+	}
+
+	var w io.Writer = c.out.stdout()
+
+	if c.outputFile != "" {
+		f, err := os.Create(c.outputFile) //nolint:gosec
+		if err != nil {
+			return errors.Wrap(err, "unable to create output file")
+		}
+
+		defer f.Close() //nolint:errcheck
+
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(result); err != nil {
+		return errors.Wrap(err, "error writing manifests")
+	}
+
+	log(ctx).Infof("Exported %v manifests, excluded %v.", len(result), excluded)
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestManifestExportImport(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	e.RunAndExpectSuccess(t, "policy", "set", "--global", "--keep-latest=5")
+
+	exportFile := filepath.Join(testutil.TempDirectory(t), "manifests.json")
+
+	e.RunAndExpectFailure(t, "manifest", "export", "--output", exportFile, "--exclude-label=nocolon")
+	e.RunAndExpectSuccess(t, "manifest", "export", "--output", exportFile, "--exclude-type=snapshot", "--exclude-label=type:maintenance")
+
+	var exported []struct {
+		ID      manifest.ID       `json:"id"`
+		Labels  map[string]string `json:"labels"`
+		Payload json.RawMessage   `json:"payload"`
+	}
+
+	b, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &exported))
+	require.NotEmpty(t, exported)
+
+	for _, m := range exported {
+		require.NotEqual(t, "snapshot", m.Labels[manifest.TypeLabelKey])
+		require.NotEqual(t, "maintenance", m.Labels[manifest.TypeLabelKey])
+	}
+
+	// import everything except policies into a new repository.
+	e2 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e2.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e2.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e2.RepoDir)
+
+	before := e2.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:policy")
+
+	e2.RunAndExpectSuccess(t, "manifest", "import", exportFile, "--exclude-type=policy")
+	require.Equal(t, before, e2.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:policy"))
+
+	// by default manifests whose labels match existing ones are skipped, so the global policy is kept.
+	e2.RunAndExpectSuccess(t, "manifest", "import", exportFile)
+	require.Equal(t, before, e2.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:policy"))
+	require.NotEqual(t, 5, globalKeepLatest(t, e2))
+
+	e2.RunAndExpectFailure(t, "manifest", "import", exportFile, "--existing=no-such-mode")
+
+	// replacing swaps the existing global policy for the imported one, importing again does not add duplicates.
+	for range 2 {
+		e2.RunAndExpectSuccess(t, "manifest", "import", exportFile, "--existing=replace")
+		require.Len(t, e2.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:policy"), len(before))
+		require.Equal(t, 5, globalKeepLatest(t, e2))
+	}
+}
+
+func TestManifestImportTwice(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	exportFile := filepath.Join(testutil.TempDirectory(t), "manifests.json")
+	e.RunAndExpectSuccess(t, "manifest", "export", "--output", exportFile, "--exclude-label=type:maintenance")
+
+	e2 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e2.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e2.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e2.RepoDir)
+
+	e2.RunAndExpectSuccess(t, "manifest", "import", exportFile)
+	afterFirst := e2.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:snapshot")
+	require.Len(t, afterFirst, 1)
+
+	e2.RunAndExpectSuccess(t, "manifest", "import", exportFile)
+	require.Equal(t, afterFirst, e2.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:snapshot"))
+
+	e2.RunAndExpectSuccess(t, "manifest", "import", exportFile, "--existing=replace")
+	require.Len(t, e2.RunAndExpectSuccess(t, "manifest", "list", "--filter=type:snapshot"), 1)
+}
+
+func globalKeepLatest(t *testing.T, e *testenv.CLITest) int {
+	t.Helper()
+
+	var pol policy.Policy
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "show", "--global", "--json"), &pol)
+
+	return pol.RetentionPolicy.KeepLatest.OrDefault(0)
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+const (
+	manifestImportExistingSkip    = "skip"
+	manifestImportExistingReplace = "replace"
+)
+
+type commandManifestImport struct {
+	inputFile string
+	existing  string
+	exclude   manifestExcludeFlags
+}
+
+func (c *commandManifestImport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("import", "Import manifest items from a file produced by 'manifest export'")
+	cmd.Arg("file", "Input file").Required().ExistingFileVar(&c.inputFile)
+	cmd.Flag("existing", "What to do with manifests that already exist in the repository with the same labels").
+		Default(manifestImportExistingSkip).EnumVar(&c.existing, manifestImportExistingSkip, manifestImportExistingReplace)
+	c.exclude.setup(cmd)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandManifestImport) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	if err := c.exclude.parse(); err != nil {
+		return err
+	}
+
+	b, err := os.ReadFile(c.inputFile) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to read input file")
+	}
+
+	var items []exportedManifest
+
+	if err := json.Unmarshal(b, &items); err != nil {
+		return errors.Wrap(err, "invalid manifest export file")
+	}
+
+	var imported, excluded, skipped, replaced int
+
+	// manifests created by this import, which are never matched against later items.
+	importedIDs := map[manifest.ID]bool{}
+
+	for _, it := range items {
+		if c.exclude.isExcluded(it.Labels) {
+			excluded++
+			continue
+		}
+
+		existing, err := findManifestsWithSameLabels(ctx, rep, it.Labels, importedIDs)
+		if err != nil {
+			return err
+		}
+
+		if len(existing) > 0 {
+			if c.existing == manifestImportExistingSkip {
+				log(ctx).Debugf("skipping manifest %v, already exists as %v", it.ID, existing)
+
+				skipped++
+
+				continue
+			}
+
+			for _, id := range existing {
+				if err := rep.DeleteManifest(ctx, id); err != nil {
+					return errors.Wrapf(err, "error deleting manifest %v", id)
+				}
+			}
+
+			replaced += len(existing)
+		}
+
+		id, err := rep.PutManifest(ctx, it.Labels, it.Payload)
+		if err != nil {
+			return errors.Wrapf(err, "error importing manifest %v", it.ID)
+		}
+
+		log(ctx).Debugf("imported manifest %v as %v", it.ID, id)
+
+		importedIDs[id] = true
+		imported++
+	}
+
+	log(ctx).Infof("Imported %v manifests (replacing %v existing), skipped %v existing, excluded %v.", imported, replaced, skipped, excluded)
+
+	return nil
+}
+
+// findManifestsWithSameLabels returns IDs of manifests that have exactly the provided labels, except the ignored ones.
+func findManifestsWithSameLabels(ctx context.Context, rep repo.Repository, labels map[string]string, ignored map[manifest.ID]bool) ([]manifest.ID, error) {
+	entries, err := rep.FindManifests(ctx, labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find existing manifests")
+	}
+
+	var result []manifest.ID
+
+	for _, e := range entries {
+		if !ignored[e.ID] && maps.Equal(e.Labels, labels) {
+			result = append(result, e.ID)
+		}
+	}
+
+	return result, nil
+}
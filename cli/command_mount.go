@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/skratchdot/open-golang/open"
@@ -22,6 +23,12 @@ type commandMount struct {
 	mountFuseAllowOther         bool
 	mountFuseAllowNonEmptyMount bool
 	mountPreferWebDAV           bool
+	mountNFS                    bool
+	mountNFSPort                int
+	mountNFSAllowLocalUsers     bool
+	mountReadOnly               bool
+	mountEntryCacheTTL          time.Duration
+	mountAttrCacheTTL           time.Duration
 	maxCachedEntries            int
 	maxCachedDirectories        int
 
@@ -39,6 +46,13 @@ func (c *commandMount) setup(svc appServices, parent commandParent) {
 	cmd.Flag("fuse-allow-other", "Allows other users to access the file system.").BoolVar(&c.mountFuseAllowOther)
 	cmd.Flag("fuse-allow-non-empty-mount", "Allows the mounting over a non-empty directory. The files in it will be shadowed by the freshly created mount.").BoolVar(&c.mountFuseAllowNonEmptyMount)
 	cmd.Flag("webdav", "Use WebDAV to mount the repository object regardless of fuse availability.").BoolVar(&c.mountPreferWebDAV)
+	cmd.Flag("nfs", "Serve the repository object as a read-only loopback NFSv3 export, which must be mounted using the printed command.").BoolVar(&c.mountNFS)
+	cmd.Flag("nfs-port", "TCP port to serve the NFS export on (0=random).").Default("0").IntVar(&c.mountNFSPort)
+	cmd.Flag("nfs-allow-local-users", "Acknowledge that the NFS export is unauthenticated and can be mounted by any user of this machine, required with --nfs.").BoolVar(&c.mountNFSAllowLocalUsers)
+
+	cmd.Flag("read-only", "Mount read-only, causing all modifications to fail with EROFS (FUSE only).").Default("true").BoolVar(&c.mountReadOnly)
+	cmd.Flag("entry-cache-ttl", "How long the kernel caches directory entries, including missing ones (FUSE only).").Default(mount.DefaultCacheTimeout.String()).DurationVar(&c.mountEntryCacheTTL)
+	cmd.Flag("attr-cache-ttl", "How long the kernel caches file attributes (FUSE only).").Default(mount.DefaultCacheTimeout.String()).DurationVar(&c.mountAttrCacheTTL)
 
 	cmd.Flag("max-cached-entries", "Limit the number of cached directory entries").Default("100000").IntVar(&c.maxCachedEntries)
 	cmd.Flag("max-cached-dirs", "Limit the number of cached directories").Default("100").IntVar(&c.maxCachedDirectories)
@@ -54,7 +68,40 @@ func (c *commandMount) newFSCache() cachefs.DirectoryCacher {
 	})
 }
 
+func (c *commandMount) mountDirectory(ctx context.Context, entry fs.Directory) (mount.Controller, error) {
+	if !c.mountNFS {
+		//nolint:wrapcheck
+		return mount.Directory(ctx, entry, c.mountPoint,
+			mount.Options{
+				FuseAllowOther:         c.mountFuseAllowOther,
+				FuseAllowNonEmptyMount: c.mountFuseAllowNonEmptyMount,
+				PreferWebDAV:           c.mountPreferWebDAV,
+				ReadOnly:               c.mountReadOnly,
+				EntryCacheTimeout:      &c.mountEntryCacheTTL,
+				AttrCacheTimeout:       &c.mountAttrCacheTTL,
+			})
+	}
+
+	if !c.mountNFSAllowLocalUsers {
+		return nil, errors.New("the NFS export is unauthenticated and allows any local user to read the mounted snapshots, pass --nfs-allow-local-users to confirm")
+	}
+
+	ctrl, err := mount.DirectoryNFS(ctx, entry, c.mountPoint, c.mountNFSPort)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to start NFS server")
+	}
+
+	log(ctx).Infof("Serving NFS export on 127.0.0.1:%v, attach it by running:\n\n  %v\n", ctrl.Port(), ctrl.NFSMountCommand())
+	log(ctx).Infof("Before pressing Ctrl-C, detach the export by running:\n\n  %v\n", ctrl.NFSUnmountCommand())
+
+	return ctrl, nil
+}
+
 func (c *commandMount) run(ctx context.Context, rep repo.Repository) error {
+	if c.mountEntryCacheTTL < 0 || c.mountAttrCacheTTL < 0 {
+		return errors.New("cache TTLs must not be negative")
+	}
+
 	var entry fs.Directory
 
 	if c.mountObjectID == "all" {
@@ -76,13 +123,7 @@ func (c *commandMount) run(ctx context.Context, rep repo.Repository) error {
 	//nolint:forcetypeassert
 	entry = cachefs.Wrap(entry, c.newFSCache()).(fs.Directory)
 
-	ctrl, mountErr := mount.Directory(ctx, entry, c.mountPoint,
-		mount.Options{
-			FuseAllowOther:         c.mountFuseAllowOther,
-			FuseAllowNonEmptyMount: c.mountFuseAllowNonEmptyMount,
-			PreferWebDAV:           c.mountPreferWebDAV,
-		})
-
+	ctrl, mountErr := c.mountDirectory(ctx, entry)
 	if mountErr != nil {
 		return errors.Wrap(mountErr, "mount error")
 	}
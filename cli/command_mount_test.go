@@ -0,0 +1,18 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestMountNFSRequiresAllowLocalUsers(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	_, stderr := env.RunAndExpectFailure(t, "mount", "--nfs", "all", t.TempDir())
+	mustGetLineContaining(t, stderr, "pass --nfs-allow-local-users to confirm")
+}
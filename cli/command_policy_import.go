@@ -3,11 +3,14 @@
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"slices"
+	"strings"
 
 	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
 
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/snapshot"
@@ -19,8 +22,10 @@ type commandPolicyImport struct {
 	filePath            string
 	allowUnknownFields  bool
 	deleteOtherPolicies bool
+	dryRun              bool
 
 	svc appServices
+	out textOutput
 }
 
 func (c *commandPolicyImport) setup(svc appServices, parent commandParent) {
@@ -28,9 +33,11 @@ func (c *commandPolicyImport) setup(svc appServices, parent commandParent) {
 	cmd.Flag("from-file", "File path to import from").StringVar(&c.filePath)
 	cmd.Flag("allow-unknown-fields", "Allow unknown fields in the policy file").BoolVar(&c.allowUnknownFields)
 	cmd.Flag("delete-other-policies", "Delete all other policies, keeping only those that got imported").BoolVar(&c.deleteOtherPolicies)
+	cmd.Flag("dry-run", "Show differences against current policies without applying them").BoolVar(&c.dryRun)
 
 	c.policyTargetFlags.setup(cmd)
 	c.svc = svc
+	c.out.setup(svc)
 
 	cmd.Action(svc.repositoryWriterAction(c.run))
 }
@@ -84,7 +91,9 @@ func (c *commandPolicyImport) run(ctx context.Context, rep repo.RepositoryWriter
 
 	importedSources := make([]string, 0, len(policies))
 
-	for ts, newPolicy := range policies {
+	for _, ts := range sortedStringKeys(policies) {
+		newPolicy := policies[ts]
+
 		target, err := snapshot.ParseSourceInfo(ts, rep.ClientOptions().Hostname, rep.ClientOptions().Username)
 		if err != nil {
 			return errors.Wrapf(err, "unable to parse source info: %q", ts)
@@ -96,13 +105,21 @@ func (c *commandPolicyImport) run(ctx context.Context, rep repo.RepositoryWriter
 		// used for deleteOtherPolicies
 		importedSources = append(importedSources, ts)
 
+		if c.dryRun {
+			if err := c.showPolicyDiff(ctx, rep, target, newPolicy); err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		if err := policy.SetPolicy(ctx, rep, target, newPolicy); err != nil {
 			return errors.Wrapf(err, "can't save policy for %v", target)
 		}
 	}
 
 	if c.deleteOtherPolicies {
-		err := deleteOthers(ctx, rep, importedSources)
+		err := deleteOthers(ctx, rep, importedSources, c.dryRun, &c.out)
 		if err != nil {
 			return err
 		}
@@ -111,7 +128,7 @@ func (c *commandPolicyImport) run(ctx context.Context, rep repo.RepositoryWriter
 	return nil
 }
 
-func deleteOthers(ctx context.Context, rep repo.RepositoryWriter, importedSources []string) error {
+func deleteOthers(ctx context.Context, rep repo.RepositoryWriter, importedSources []string, dryRun bool, out *textOutput) error {
 	ps, err := policy.ListPolicies(ctx, rep)
 	if err != nil {
 		return errors.Wrap(err, "failed to list policies")
@@ -119,6 +136,11 @@ func deleteOthers(ctx context.Context, rep repo.RepositoryWriter, importedSource
 
 	for _, p := range ps {
 		if !slices.Contains(importedSources, p.Target().String()) {
+			if dryRun {
+				out.printStdout("Policy for %v would be deleted.\n", p.Target())
+				continue
+			}
+
 			if err := policy.RemovePolicy(ctx, rep, p.Target()); err != nil {
 				return errors.Wrapf(err, "can't delete policy for %v", p.Target())
 			}
@@ -127,3 +149,105 @@ func deleteOthers(ctx context.Context, rep repo.RepositoryWriter, importedSource
 
 	return nil
 }
+
+// showPolicyDiff prints the changes that importing the provided policy would make to the policy currently defined for the target.
+func (c *commandPolicyImport) showPolicyDiff(ctx context.Context, rep repo.Repository, target snapshot.SourceInfo, newPolicy *policy.Policy) error {
+	oldValues := map[string]string{}
+	status := "changed"
+
+	oldPolicy, err := policy.GetDefinedPolicy(ctx, rep, target)
+
+	switch {
+	case errors.Is(err, policy.ErrPolicyNotFound):
+		status = "new"
+	case err != nil:
+		return errors.Wrapf(err, "can't get policy for %v", target)
+	default:
+		if oldValues, err = flattenPolicyJSON(oldPolicy); err != nil {
+			return err
+		}
+	}
+
+	newValues, err := flattenPolicyJSON(newPolicy)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+
+	for _, k := range sortedStringKeys(newValues) {
+		oldValue, ok := oldValues[k]
+
+		switch {
+		case !ok:
+			lines = append(lines, fmt.Sprintf("  + %v: %v", k, newValues[k]))
+		case oldValue != newValues[k]:
+			lines = append(lines, fmt.Sprintf("  ~ %v: %v -> %v", k, oldValue, newValues[k]))
+		}
+	}
+
+	for _, k := range sortedStringKeys(oldValues) {
+		if _, ok := newValues[k]; !ok {
+			lines = append(lines, fmt.Sprintf("  - %v: %v", k, oldValues[k]))
+		}
+	}
+
+	if len(lines) == 0 {
+		c.out.printStdout("Policy for %v is unchanged.\n", target)
+		return nil
+	}
+
+	c.out.printStdout("Policy for %v (%v):\n", target, status)
+
+	for _, l := range lines {
+		c.out.printStdout("%v\n", l)
+	}
+
+	return nil
+}
+
+// flattenPolicyJSON returns JSON-encoded values of all fields set in the policy keyed by their dotted JSON path.
+func flattenPolicyJSON(p *policy.Policy) (map[string]string, error) {
+	m, err := policyAsJSONMap(p)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+
+	var walk func(prefix string, v any) error
+
+	walk = func(prefix string, v any) error {
+		if obj, ok := v.(map[string]any); ok && len(obj) > 0 {
+			for k, child := range obj {
+				if err := walk(strings.TrimPrefix(prefix+"."+k, "."), child); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return errors.Wrap(err, "unable to serialize policy value")
+		}
+
+		result[prefix] = string(b)
+
+		return nil
+	}
+
+	if err := walk("", m); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func sortedStringKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+
+	return keys
+}
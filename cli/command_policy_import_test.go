@@ -8,6 +8,7 @@
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/snapshot"
@@ -167,6 +168,55 @@ func TestImportPolicy(t *testing.T) {
 	e.RunAndExpectFailure(t, "policy", "import", "--from-file", policyFilePath)
 }
 
+func TestImportPolicyDryRun(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir, "--override-username=user", "--override-hostname=host")
+
+	td := testutil.TempDirectory(t)
+	policyFilePath := path.Join(td, "policy.json")
+	id := snapshot.SourceInfo{Host: "host", UserName: "user", Path: filepath.ToSlash(td)}.String()
+
+	e.RunAndExpectSuccess(t, "policy", "set", "--global", "--keep-latest=5")
+	e.RunAndExpectSuccess(t, "policy", "set", "user@host", "--keep-daily=3")
+
+	var before map[string]*policy.Policy
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "export"), &before)
+
+	specifiedPolicies := map[string]*policy.Policy{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "export", "(global)"), &specifiedPolicies)
+
+	newKeepLatest := policy.OptionalInt(7)
+	specifiedPolicies["(global)"].RetentionPolicy.KeepLatest = &newKeepLatest
+	specifiedPolicies["(global)"].RetentionPolicy.KeepDaily = nil
+	specifiedPolicies[id] = &policy.Policy{
+		SplitterPolicy: policy.SplitterPolicy{Algorithm: "FIXED-8M"},
+	}
+
+	data, err := json.Marshal(specifiedPolicies)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(policyFilePath, data, 0o600))
+
+	lines := e.RunAndExpectSuccess(t, "policy", "import", "--from-file", policyFilePath, "--dry-run", "--delete-other-policies")
+	require.Contains(t, lines, "Policy for (global) (changed):")
+	require.Contains(t, lines, "  ~ retention.keepLatest: 5 -> 7")
+	require.Contains(t, lines, "  - retention.keepDaily: 7")
+	require.Contains(t, lines, "Policy for "+id+" (new):")
+	require.Contains(t, lines, `  + splitter.algorithm: "FIXED-8M"`)
+	require.Contains(t, lines, "Policy for user@host would be deleted.")
+
+	// nothing was changed.
+	assertPoliciesEqual(t, e, before)
+
+	// importing policies that match current ones is reported as no-op.
+	e.RunAndExpectSuccess(t, "policy", "import", "--from-file", policyFilePath)
+	lines = e.RunAndExpectSuccess(t, "policy", "import", "--from-file", policyFilePath, "--dry-run")
+	require.Contains(t, lines, "Policy for (global) is unchanged.")
+	require.Contains(t, lines, "Policy for "+id+" is unchanged.")
+}
+
 func assertPoliciesEqual(t *testing.T, e *testenv.CLITest, expected map[string]*policy.Policy) {
 	t.Helper()
 	var policies map[string]*policy.Policy
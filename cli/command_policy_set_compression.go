@@ -2,6 +2,8 @@
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pkg/errors"
@@ -22,6 +24,10 @@ type policyCompressionFlags struct {
 	policySetAddNeverCompress    []string
 	policySetRemoveNeverCompress []string
 	policySetClearNeverCompress  bool
+
+	policySetAddCompressExt    []string
+	policySetRemoveCompressExt []string
+	policySetClearCompressExt  bool
 }
 
 type policyMetadataCompressionFlags struct {
@@ -70,6 +76,11 @@ func (c *policyCompressionFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("add-never-compress", "List of extensions to add to the never compress list").PlaceHolder("PATTERN").StringsVar(&c.policySetAddNeverCompress)
 	cmd.Flag("remove-never-compress", "List of extensions to remove from the never compress list").PlaceHolder("PATTERN").StringsVar(&c.policySetRemoveNeverCompress)
 	cmd.Flag("clear-never-compress", "Clear list of extensions in the never compress list").BoolVar(&c.policySetClearNeverCompress)
+
+	// Per-extension compression algorithms.
+	cmd.Flag("add-compress-ext", "Use the specified compression algorithm (or 'none') for files with the given extension or matching a glob pattern").PlaceHolder("PATTERN=ALGORITHM").StringsVar(&c.policySetAddCompressExt)
+	cmd.Flag("remove-compress-ext", "Remove per-extension compression algorithm").PlaceHolder("PATTERN").StringsVar(&c.policySetRemoveCompressExt)
+	cmd.Flag("clear-compress-ext", "Clear all per-extension compression algorithms").BoolVar(&c.policySetClearCompressExt)
 }
 
 func (c *policyCompressionFlags) setCompressionPolicyFromFlags(ctx context.Context, p *policy.CompressionPolicy, changeCount *int) error {
@@ -95,11 +106,106 @@ func (c *policyCompressionFlags) setCompressionPolicyFromFlags(ctx context.Conte
 		}
 	}
 
-	applyPolicyStringList(ctx, "only-compress extensions",
+	applyCompressionExtensionList(ctx, "only-compress extensions",
 		&p.OnlyCompress, c.policySetAddOnlyCompress, c.policySetRemoveOnlyCompress, c.policySetClearOnlyCompress, changeCount)
 
-	applyPolicyStringList(ctx, "never-compress extensions",
+	applyCompressionExtensionList(ctx, "never-compress extensions",
 		&p.NeverCompress, c.policySetAddNeverCompress, c.policySetRemoveNeverCompress, c.policySetClearNeverCompress, changeCount)
 
+	return c.applyExtensionCompressors(ctx, p, changeCount)
+}
+
+// applyExtensionCompressors performs read-modify-write of the per-extension compression algorithms.
+func (c *policyCompressionFlags) applyExtensionCompressors(ctx context.Context, p *policy.CompressionPolicy, changeCount *int) error {
+	if c.policySetClearCompressExt {
+		*changeCount++
+
+		log(ctx).Info(" - removing all per-extension compression algorithms")
+
+		p.ExtensionCompressors = nil
+	}
+
+	for _, v := range c.policySetAddCompressExt {
+		pattern, algorithm, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(pattern) == "" {
+			return errors.Errorf("invalid per-extension compression %q, must be PATTERN=ALGORITHM", v)
+		}
+
+		algorithm = strings.TrimSpace(algorithm)
+		if algorithm == inheritPolicyString || !slices.Contains(supportedCompressionAlgorithms(), algorithm) {
+			return errors.Errorf("unsupported compression algorithm %q for %q", algorithm, pattern)
+		}
+
+		pattern = normalizeCompressionPattern(pattern)
+
+		*changeCount++
+
+		log(ctx).Infof(" - compressing files matching %q using %v", pattern, algorithm)
+
+		if p.ExtensionCompressors == nil {
+			p.ExtensionCompressors = map[string]compression.Name{}
+		}
+
+		p.ExtensionCompressors[pattern] = compression.Name(algorithm)
+	}
+
+	for _, pattern := range c.policySetRemoveCompressExt {
+		pattern = normalizeCompressionPattern(pattern)
+
+		*changeCount++
+
+		log(ctx).Infof(" - removing per-extension compression algorithm for %q", pattern)
+
+		delete(p.ExtensionCompressors, pattern)
+	}
+
+	if len(p.ExtensionCompressors) == 0 {
+		p.ExtensionCompressors = nil
+	}
+
 	return nil
 }
+
+// normalizeCompressionPattern adds a leading dot to extensions, leaving glob patterns intact.
+func normalizeCompressionPattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+
+	if policy.IsCompressionGlobPattern(pattern) {
+		return pattern
+	}
+
+	return normalizeCompressionExtensions([]string{pattern})[0]
+}
+
+// applyCompressionExtensionList performs read-modify-write of the provided extension list
+// and reports the resulting list when it was changed.
+func applyCompressionExtensionList(ctx context.Context, desc string, val *[]string, add, remove []string, clearList bool, changeCount *int) {
+	before := *changeCount
+
+	applyPolicyStringList(ctx, desc, val, normalizeCompressionExtensions(add), normalizeCompressionExtensions(remove), clearList, changeCount)
+
+	if *changeCount != before {
+		log(ctx).Infof(" - %v: [%v]", desc, strings.Join(*val, " "))
+	}
+}
+
+// normalizeCompressionExtensions ensures that each extension starts with a dot, since
+// the compression policy matches against the result of filepath.Ext().
+func normalizeCompressionExtensions(exts []string) []string {
+	var result []string
+
+	for _, e := range exts {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+
+		result = append(result, e)
+	}
+
+	return result
+}
@@ -0,0 +1,40 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSetSchedulingPolicyCron(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	td := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--snapshot-cron", "0 2 * * *;30 14 * * 1-5 # weekdays")
+
+	var pol policy.Policy
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "show", td, "--json"), &pol)
+	require.Equal(t, []string{"0 2 * * *", "30 14 * * 1-5 # weekdays"}, pol.SchedulingPolicy.Cron)
+
+	// invalid expressions are rejected and the policy is left unchanged.
+	e.RunAndExpectFailure(t, "policy", "set", td, "--snapshot-cron", "0 25 * * *")
+	e.RunAndExpectFailure(t, "policy", "set", td, "--snapshot-time-crontab", "not a cron expression")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "show", td, "--json"), &pol)
+	require.Equal(t, []string{"0 2 * * *", "30 14 * * 1-5 # weekdays"}, pol.SchedulingPolicy.Cron)
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--snapshot-cron", "inherit")
+
+	pol = policy.Policy{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "show", td, "--json"), &pol)
+	require.Empty(t, pol.SchedulingPolicy.Cron)
+}
@@ -8,6 +8,7 @@
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/snapshot/policy"
 )
 
@@ -475,3 +476,143 @@ func TestSetSchedulingPolicyFromFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestSetCompressionPolicyExtensionsFromFlags(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	for _, tc := range []struct {
+		name           string
+		startingPolicy *policy.CompressionPolicy
+		addNever       []string
+		removeNever    []string
+		addOnly        []string
+		removeOnly     []string
+		expResult      *policy.CompressionPolicy
+		expChangeCount int
+	}{
+		{
+			name:           "No flags provided",
+			startingPolicy: &policy.CompressionPolicy{NeverCompress: []string{".jpg"}},
+			expResult:      &policy.CompressionPolicy{NeverCompress: []string{".jpg"}},
+			expChangeCount: 0,
+		},
+		{
+			name:           "Add never-compress extensions with and without dot",
+			startingPolicy: &policy.CompressionPolicy{NeverCompress: []string{".jpg"}},
+			addNever:       []string{"zip", ".mp4"},
+			expResult:      &policy.CompressionPolicy{NeverCompress: []string{".jpg", ".mp4", ".zip"}},
+			expChangeCount: 2,
+		},
+		{
+			name:           "Remove never-compress extension without dot",
+			startingPolicy: &policy.CompressionPolicy{NeverCompress: []string{".jpg", ".zip"}},
+			removeNever:    []string{"jpg"},
+			expResult:      &policy.CompressionPolicy{NeverCompress: []string{".zip"}},
+			expChangeCount: 1,
+		},
+		{
+			name:           "Add and remove only-compress extensions",
+			startingPolicy: &policy.CompressionPolicy{OnlyCompress: []string{".log"}},
+			addOnly:        []string{"txt", " "},
+			removeOnly:     []string{".log"},
+			expResult:      &policy.CompressionPolicy{OnlyCompress: []string{".txt"}},
+			expChangeCount: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			changeCount := 0
+
+			var pcf policyCompressionFlags
+
+			pcf.policySetAddNeverCompress = tc.addNever
+			pcf.policySetRemoveNeverCompress = tc.removeNever
+			pcf.policySetAddOnlyCompress = tc.addOnly
+			pcf.policySetRemoveOnlyCompress = tc.removeOnly
+
+			require.NoError(t, pcf.setCompressionPolicyFromFlags(ctx, tc.startingPolicy, &changeCount))
+			require.Equal(t, tc.expResult, tc.startingPolicy)
+			require.Equal(t, tc.expChangeCount, changeCount)
+		})
+	}
+}
+
+func TestSetCompressionPolicyExtensionCompressorsFromFlags(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	for _, tc := range []struct {
+		name           string
+		startingPolicy *policy.CompressionPolicy
+		add            []string
+		remove         []string
+		clear          bool
+		expResult      *policy.CompressionPolicy
+		expErr         string
+		expChangeCount int
+	}{
+		{
+			name:           "Add extensions and glob patterns",
+			startingPolicy: &policy.CompressionPolicy{},
+			add:            []string{"jpg=none", ".log=zstd", "*.tar.*=gzip"},
+			expResult: &policy.CompressionPolicy{ExtensionCompressors: map[string]compression.Name{
+				".jpg":    "none",
+				".log":    "zstd",
+				"*.tar.*": "gzip",
+			}},
+			expChangeCount: 3,
+		},
+		{
+			name:           "Replace and remove",
+			startingPolicy: &policy.CompressionPolicy{ExtensionCompressors: map[string]compression.Name{".jpg": "none", ".log": "zstd"}},
+			add:            []string{"log=gzip"},
+			remove:         []string{"jpg"},
+			expResult:      &policy.CompressionPolicy{ExtensionCompressors: map[string]compression.Name{".log": "gzip"}},
+			expChangeCount: 2,
+		},
+		{
+			name:           "Removing last entry clears the map",
+			startingPolicy: &policy.CompressionPolicy{ExtensionCompressors: map[string]compression.Name{".jpg": "none"}},
+			remove:         []string{".jpg"},
+			expResult:      &policy.CompressionPolicy{},
+			expChangeCount: 1,
+		},
+		{
+			name:           "Clear",
+			startingPolicy: &policy.CompressionPolicy{ExtensionCompressors: map[string]compression.Name{".jpg": "none"}},
+			clear:          true,
+			expResult:      &policy.CompressionPolicy{},
+			expChangeCount: 1,
+		},
+		{
+			name:           "Missing algorithm",
+			startingPolicy: &policy.CompressionPolicy{},
+			add:            []string{"jpg"},
+			expErr:         "must be PATTERN=ALGORITHM",
+		},
+		{
+			name:           "Unknown algorithm",
+			startingPolicy: &policy.CompressionPolicy{},
+			add:            []string{"jpg=no-such-algorithm"},
+			expErr:         "unsupported compression algorithm",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			changeCount := 0
+
+			var pcf policyCompressionFlags
+
+			pcf.policySetAddCompressExt = tc.add
+			pcf.policySetRemoveCompressExt = tc.remove
+			pcf.policySetClearCompressExt = tc.clear
+
+			err := pcf.setCompressionPolicyFromFlags(ctx, tc.startingPolicy, &changeCount)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expResult, tc.startingPolicy)
+			require.Equal(t, tc.expChangeCount, changeCount)
+		})
+	}
+}
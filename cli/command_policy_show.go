@@ -16,13 +16,15 @@
 
 type commandPolicyShow struct {
 	policyTargetFlags
-	jo  jsonOutput
-	out textOutput
+	effective bool
+	jo        jsonOutput
+	out       textOutput
 }
 
 func (c *commandPolicyShow) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("show", "Show snapshot policy.").Alias("get")
 	c.policyTargetFlags.setup(cmd)
+	cmd.Flag("effective", "Show each field of the resolved policy along with the policy level that supplied it").BoolVar(&c.effective)
 	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.repositoryReaderAction(c.run))
@@ -35,11 +37,26 @@ func (c *commandPolicyShow) run(ctx context.Context, rep repo.Repository) error
 	}
 
 	for _, target := range targets {
-		effective, definition, _, err := policy.GetEffectivePolicy(ctx, rep, target)
+		effective, definition, sources, err := policy.GetEffectivePolicy(ctx, rep, target)
 		if err != nil {
 			return errors.Wrapf(err, "can't get effective policy for %q", target)
 		}
 
+		if c.effective {
+			fields, err := effectivePolicyFields(effective, definition, sources)
+			if err != nil {
+				return err
+			}
+
+			if c.jo.jsonOutput {
+				c.out.printStdout("%s\n", c.jo.jsonBytes(fields))
+			} else {
+				printEffectivePolicyFields(&c.out, target, fields)
+			}
+
+			continue
+		}
+
 		if c.jo.jsonOutput {
 			c.out.printStdout("%s\n", c.jo.jsonBytes(effective))
 		} else {
@@ -339,7 +356,7 @@ func appendSchedulingPolicyRows(rows []policyTableRow, p *policy.Policy, def *po
 func appendCompressionPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
 	if p.CompressionPolicy.CompressorName == "" || p.CompressionPolicy.CompressorName == "none" {
 		rows = append(rows, policyTableRow{"Compression disabled.", "", ""})
-		return rows
+		return appendExtensionCompressorRows(rows, p, def)
 	}
 
 	rows = append(rows,
@@ -387,6 +404,23 @@ func appendCompressionPolicyRows(rows []policyTableRow, p *policy.Policy, def *p
 		rows = append(rows, policyTableRow{"  Compress files of all sizes.", "", ""})
 	}
 
+	return appendExtensionCompressorRows(rows, p, def)
+}
+
+func appendExtensionCompressorRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
+	if len(p.CompressionPolicy.ExtensionCompressors) == 0 {
+		return rows
+	}
+
+	rows = append(rows, policyTableRow{
+		"  Per-extension compression:", "",
+		definitionPointToString(p.Target(), def.CompressionPolicy.ExtensionCompressors),
+	})
+
+	for _, pattern := range sortedStringKeys(p.CompressionPolicy.ExtensionCompressors) {
+		rows = append(rows, policyTableRow{"    " + pattern, string(p.CompressionPolicy.ExtensionCompressors[pattern]), ""})
+	}
+
 	return rows
 }
 
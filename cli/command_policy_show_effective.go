@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+// Levels of the policy hierarchy reported by 'policy show --effective'.
+const (
+	policyLevelDefault = "default"
+	policyLevelGlobal  = "global"
+	policyLevelHost    = "host"
+	policyLevelUser    = "user"
+	policyLevelPath    = "path"
+)
+
+// effectivePolicyField describes the resolved value of a single policy field and where it came from.
+type effectivePolicyField struct {
+	Field  string               `json:"field"`
+	Value  json.RawMessage      `json:"value"`
+	Level  string               `json:"level"`
+	Source *snapshot.SourceInfo `json:"source,omitempty"`
+}
+
+// effectivePolicyFields flattens the effective policy into a list of fields that have a value, annotating each
+// with the policy level which supplied it. Sources must be the policies that were merged, most specific first.
+func effectivePolicyFields(effective *policy.Policy, def *policy.Definition, sources []*policy.Policy) ([]effectivePolicyField, error) {
+	values, err := policyAsJSONMap(effective)
+	if err != nil {
+		return nil, err
+	}
+
+	// fields of the global policy that were explicitly set, everything else comes from built-in defaults.
+	globalValues := map[string]any{}
+
+	for _, p := range sources {
+		if p.Target() == policy.GlobalPolicySourceInfo {
+			if globalValues, err = policyAsJSONMap(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var result []effectivePolicyField
+
+	for _, d := range flattenPolicyDefinition(nil, reflect.ValueOf(*def)) {
+		v, ok := lookupJSONPath(values, d.path)
+		if !ok {
+			continue
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to serialize policy value")
+		}
+
+		f := effectivePolicyField{
+			Field: strings.Join(d.path, "."),
+			Value: b,
+			Level: policyLevel(d.source),
+		}
+
+		if f.Level == policyLevelGlobal {
+			if _, ok := lookupJSONPath(globalValues, d.path); !ok {
+				f.Level = policyLevelDefault
+			}
+		}
+
+		if f.Level != policyLevelDefault {
+			src := d.source
+			f.Source = &src
+		}
+
+		result = append(result, f)
+	}
+
+	return result, nil
+}
+
+type policyDefinitionField struct {
+	path   []string
+	source snapshot.SourceInfo
+}
+
+// flattenPolicyDefinition walks the (nested) definition struct and returns all leaf fields along with their JSON paths,
+// which match the JSON paths of corresponding policy fields.
+func flattenPolicyDefinition(prefix []string, v reflect.Value) []policyDefinitionField {
+	var result []policyDefinitionField
+
+	for i := range v.NumField() {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := append(append([]string(nil), prefix...), name)
+		fv := v.Field(i)
+
+		if si, ok := fv.Interface().(snapshot.SourceInfo); ok {
+			result = append(result, policyDefinitionField{path, si})
+			continue
+		}
+
+		if fv.Kind() == reflect.Struct {
+			result = append(result, flattenPolicyDefinition(path, fv)...)
+		}
+	}
+
+	return result
+}
+
+func policyAsJSONMap(p *policy.Policy) (map[string]any, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to serialize policy")
+	}
+
+	var m map[string]any
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrap(err, "unable to deserialize policy")
+	}
+
+	return m, nil
+}
+
+func lookupJSONPath(m map[string]any, path []string) (any, bool) {
+	var v any = m
+
+	for _, p := range path {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if v, ok = obj[p]; !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func policyLevel(si snapshot.SourceInfo) string {
+	switch {
+	case si == policy.GlobalPolicySourceInfo:
+		return policyLevelGlobal
+	case si.Path != "":
+		return policyLevelPath
+	case si.UserName != "":
+		return policyLevelUser
+	default:
+		return policyLevelHost
+	}
+}
+
+func printEffectivePolicyFields(out *textOutput, target snapshot.SourceInfo, fields []effectivePolicyField) {
+	var rows []policyTableRow
+
+	for _, f := range fields {
+		def := f.Level
+		if f.Source != nil {
+			def += " (" + f.Source.String() + ")"
+		}
+
+		rows = append(rows, policyTableRow{f.Field, string(f.Value), def})
+	}
+
+	out.printStdout("Effective policy for %v:\n\n%v\n", target, alignedPolicyTableRows(rows))
+}
@@ -0,0 +1,55 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+type effectivePolicyField struct {
+	Field  string               `json:"field"`
+	Value  any                  `json:"value"`
+	Level  string               `json:"level"`
+	Source *snapshot.SourceInfo `json:"source"`
+}
+
+func TestPolicyShowEffective(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	td := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--keep-latest=7")
+
+	var fields []effectivePolicyField
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "policy", "show", td, "--effective", "--json"), &fields)
+
+	byName := map[string]effectivePolicyField{}
+	for _, f := range fields {
+		byName[f.Field] = f
+	}
+
+	keepLatest := byName["retention.keepLatest"]
+	require.EqualValues(t, 7, keepLatest.Value)
+	require.Equal(t, "path", keepLatest.Level)
+	require.NotNil(t, keepLatest.Source)
+	require.Equal(t, td, keepLatest.Source.Path)
+
+	require.Equal(t, "global", byName["retention.keepDaily"].Level)
+
+	// define a value at the user@host level.
+	userHost := snapshot.SourceInfo{UserName: keepLatest.Source.UserName, Host: keepLatest.Source.Host}
+	e.RunAndExpectSuccess(t, "policy", "set", userHost.String(), "--keep-daily=3")
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td, "--effective"))
+	require.Contains(t, lines, "retention.keepLatest 7 path ("+keepLatest.Source.String()+")")
+	require.Contains(t, lines, "retention.keepDaily 3 user ("+userHost.String()+")")
+	require.Contains(t, lines, "retention.keepHourly 48 global ((global))")
+}
@@ -1,8 +1,13 @@
 package cli_test
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/cli"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/testenv"
@@ -21,3 +26,54 @@ func TestRepoStatusJSON(t *testing.T) {
 	e.RunAndExpectSuccess(t, "repo", "status")
 	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs)
 }
+
+func TestRepoStatusStorageStats(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	require.Contains(t, mustGetLineContaining(t, e.RunAndExpectSuccess(t, "repo", "status"), "Last maintenance:"), "never")
+
+	e.RunAndExpectSuccess(t, "policy", "set", "--global", "--compression=zstd")
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1"), bytes.Repeat([]byte("kopia"), 100000), 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var rs cli.RepositoryStatus
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs)
+	require.Equal(t, "zstd", rs.Compression)
+	require.Nil(t, rs.Usage)
+
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "repo", "status", "--json", "--storage-stats"), &rs)
+	require.NotNil(t, rs.LastMaintenance)
+	require.NotNil(t, rs.Usage)
+
+	// the file is highly compressible, so logical size exceeds physical size.
+	require.GreaterOrEqual(t, rs.Usage.LogicalBytes, int64(500000))
+	require.Less(t, rs.Usage.PhysicalBytes, rs.Usage.LogicalBytes)
+	require.Positive(t, rs.Usage.BlobsByType["data"].Count)
+	require.Positive(t, rs.Usage.BlobsByType["metadata"].Count)
+	require.Positive(t, rs.Usage.BlobsByType["index"].Count)
+	require.Positive(t, rs.Usage.BlobsByType["repository"].Count)
+
+	var total int64
+	for _, bu := range rs.Usage.BlobsByType {
+		total += bu.Bytes
+	}
+
+	require.Equal(t, rs.Usage.PhysicalBytes, total)
+
+	lines := e.RunAndExpectSuccess(t, "repo", "status", "--storage-stats")
+	require.Contains(t, mustGetLineContaining(t, lines, "Compression:"), "zstd")
+	require.NotContains(t, mustGetLineContaining(t, lines, "Last maintenance:"), "never")
+	mustGetLineContaining(t, lines, "Physical bytes:")
+	mustGetLineContaining(t, lines, "Logical bytes:")
+}
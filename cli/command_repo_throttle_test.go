@@ -79,3 +79,48 @@ func TestRepoThrottle(t *testing.T) {
 		ConcurrentWrites:       400,
 	}, limits)
 }
+
+func TestRepoThrottleCommandLineOverride(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	defer env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	env.RunAndExpectSuccess(t, "repo", "throttle", "set", "--download-bytes-per-second=1000000000")
+
+	out := env.RunAndExpectSuccess(t, "repo", "throttle", "get", "--upload-limit=10MB", "--download-limit=1KB")
+	require.Contains(t, out, "Max Download Speed:            1 KB/s")
+	require.Contains(t, out, "Max Upload Speed:              10.5 MB/s")
+
+	// overrides only apply to a single invocation.
+	out = env.RunAndExpectSuccess(t, "repo", "throttle", "get")
+	require.Contains(t, out, "Max Download Speed:            1 GB/s")
+	require.Contains(t, out, "Max Upload Speed:              (unlimited)")
+
+	env.RunAndExpectFailure(t, "repo", "throttle", "get", "--upload-limit=fast")
+
+	// data still flows with a throttled upload.
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t), "--upload-limit=1MB")
+}
+
+func TestRepoThrottleCommandLineOverrideIsNotPersisted(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	defer env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// changing persistent limits while an override is in effect must not save the override.
+	env.RunAndExpectSuccess(t, "--upload-limit=1MB", "repo", "throttle", "set", "--concurrent-reads=5")
+
+	var limits throttling.Limits
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "repo", "throttle", "get", "--json"), &limits)
+	require.Equal(t, throttling.Limits{ConcurrentReads: 5}, limits)
+
+	out := env.RunAndExpectSuccess(t, "repo", "throttle", "get")
+	require.Contains(t, out, "Max Upload Speed:              (unlimited)")
+}
@@ -3,16 +3,24 @@
 type commandRepository struct {
 	connect          commandRepositoryConnect
 	create           commandRepositoryCreate
+	createToken      commandRepositoryCreateToken
+	deleteMigrated   commandRepositoryDeleteMigratedSource
 	disconnect       commandRepositoryDisconnect
+	listProfiles     commandRepositoryListProfiles
+	migrate          commandRepositoryMigrate
 	repair           commandRepositoryRepair
 	setClient        commandRepositorySetClient
 	setParameters    commandRepositorySetParameters
+	checkClock       commandRepositoryCheckClock
+	checkGenerations commandRepositoryCheckGenerations
 	changePassword   commandRepositoryChangePassword
+	passwordBatch    commandRepositoryChangePasswordBatch
 	status           commandRepositoryStatus
 	syncTo           commandRepositorySyncTo
 	throttle         commandRepositoryThrottle
 	validateProvider commandRepositoryValidateProvider
 	upgrade          commandRepositoryUpgrade
+	updateCheck      commandRepositoryUpdateCheck
 }
 
 func (c *commandRepository) setup(svc advancedAppServices, parent commandParent) {
@@ -20,7 +28,11 @@ func (c *commandRepository) setup(svc advancedAppServices, parent commandParent)
 
 	c.connect.setup(svc, cmd)
 	c.create.setup(svc, cmd)
+	c.createToken.setup(svc, cmd)
+	c.deleteMigrated.setup(svc, cmd)
 	c.disconnect.setup(svc, cmd)
+	c.listProfiles.setup(svc, cmd)
+	c.migrate.setup(svc, cmd)
 	c.repair.setup(svc, cmd)
 	c.setClient.setup(svc, cmd)
 	c.setParameters.setup(svc, cmd)
@@ -28,6 +40,10 @@ func (c *commandRepository) setup(svc advancedAppServices, parent commandParent)
 	c.syncTo.setup(svc, cmd)
 	c.throttle.setup(svc, cmd)
 	c.changePassword.setup(svc, cmd)
+	c.passwordBatch.setup(svc, cmd)
+	c.checkClock.setup(svc, cmd)
+	c.checkGenerations.setup(svc, cmd)
 	c.validateProvider.setup(svc, cmd)
 	c.upgrade.setup(svc, cmd)
+	c.updateCheck.setup(svc, cmd)
 }
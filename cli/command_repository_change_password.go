@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -10,6 +13,7 @@
 
 type commandRepositoryChangePassword struct {
 	newPassword string
+	yes         bool
 
 	svc advancedAppServices
 }
@@ -17,6 +21,7 @@ type commandRepositoryChangePassword struct {
 func (c *commandRepositoryChangePassword) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("change-password", "Change repository password")
 	cmd.Flag("new-password", "New password").Envar(svc.EnvName("KOPIA_NEW_PASSWORD")).StringVar(&c.newPassword)
+	cmd.Flag("yes", "Do not ask for confirmation before changing the password entered interactively").Short('y').BoolVar(&c.yes)
 
 	c.svc = svc
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
@@ -26,12 +31,16 @@ func (c *commandRepositoryChangePassword) run(ctx context.Context, rep repo.Dire
 	var newPass string
 
 	if c.newPassword == "" {
-		n, err := askForChangedRepositoryPassword(c.svc.stdout())
+		n, err := askForChangedRepositoryPassword(c.svc.stdout(), c.svc.getPasswordPromptAttempts())
 		if err != nil {
 			return err
 		}
 
 		newPass = n
+
+		if !c.yes && !c.confirm() {
+			return errors.New("password change not confirmed")
+		}
 	} else {
 		newPass = c.newPassword
 	}
@@ -42,9 +51,18 @@ func (c *commandRepositoryChangePassword) run(ctx context.Context, rep repo.Dire
 
 	log(ctx).Infof(`NOTE: Repository password has been changed.`)
 
-	if err := c.svc.passwordPersistenceStrategy().PersistPassword(ctx, c.svc.repositoryConfigFileName(), newPass); err != nil {
+	if err := c.svc.passwordPersistenceStrategy().PersistPassword(ctx, c.svc.repositoryConfigKey(), newPass); err != nil {
 		return errors.Wrap(err, "unable to persist password")
 	}
 
 	return nil
 }
+
+// confirm asks the user to confirm changing the password and returns true if confirmed.
+func (c *commandRepositoryChangePassword) confirm() bool {
+	fmt.Fprintf(c.svc.stdout(), "Change password of repository connected using %v? (y/N) ", c.svc.repositoryConfigKey()) //nolint:errcheck
+
+	answer, _ := bufio.NewReader(c.svc.stdin()).ReadString('\n')
+
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y")
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryChangePasswordBatch struct {
+	configFiles []string
+	oldPassword string
+	newPassword string
+	dryRun      bool
+	confirm     bool
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryChangePasswordBatch) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("change-password-batch", "Change password of multiple repositories sharing the same password")
+	cmd.Arg("config-file", "Configuration files of repositories to change password of").Required().StringsVar(&c.configFiles)
+	cmd.Flag("old-password", "Current password").Envar(svc.EnvName("KOPIA_OLD_PASSWORD")).StringVar(&c.oldPassword)
+	cmd.Flag("new-password", "New password").Envar(svc.EnvName("KOPIA_NEW_PASSWORD")).StringVar(&c.newPassword)
+	cmd.Flag("dry-run", "Only verify that the current password opens each repository").BoolVar(&c.dryRun)
+	cmd.Flag("confirm", "Confirm changing password of all provided repositories").BoolVar(&c.confirm)
+	cmd.Action(svc.noRepositoryAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryChangePasswordBatch) run(ctx context.Context) error {
+	if !c.dryRun && !c.confirm {
+		return errors.New("changing passwords requires --confirm, use --dry-run to only verify the current password")
+	}
+
+	oldPass, newPass, err := c.getPasswords()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, cfg := range c.configFiles {
+		if err := c.changePassword(ctx, cfg, oldPass, newPass); err != nil {
+			c.out.printStdout("%v: FAILED: %v\n", cfg, err)
+
+			failed++
+
+			continue
+		}
+
+		if c.dryRun {
+			c.out.printStdout("%v: OK (dry run)\n", cfg)
+		} else {
+			c.out.printStdout("%v: OK\n", cfg)
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("failed for %v out of %v repositories", failed, len(c.configFiles))
+	}
+
+	return nil
+}
+
+func (c *commandRepositoryChangePasswordBatch) getPasswords() (oldPass, newPass string, err error) {
+	oldPass = c.oldPassword
+	if oldPass == "" {
+		oldPass, err = askPass(c.svc.stdout(), "Enter current password: ", c.svc.getPasswordPromptAttempts())
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if c.dryRun {
+		return oldPass, "", nil
+	}
+
+	newPass = c.newPassword
+	if newPass == "" {
+		newPass, err = askForChangedRepositoryPassword(c.svc.stdout(), c.svc.getPasswordPromptAttempts())
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return oldPass, newPass, nil
+}
+
+// changePassword changes the password of a single repository, restoring the old password
+// if any step after the change fails.
+func (c *commandRepositoryChangePasswordBatch) changePassword(ctx context.Context, configFile, oldPass, newPass string) error {
+	rep, err := c.openDirect(ctx, configFile, oldPass)
+	if err != nil {
+		return err
+	}
+
+	defer rep.Close(ctx) //nolint:errcheck
+
+	fm := rep.FormatManager()
+
+	if !fm.SupportsPasswordChange() {
+		return errors.New("password changes are not supported for repositories created using Kopia v0.8 or older")
+	}
+
+	if c.dryRun {
+		return nil
+	}
+
+	changeErr := fm.ChangePassword(ctx, newPass)
+	if changeErr == nil {
+		changeErr = c.persistAndVerify(ctx, configFile, newPass)
+	}
+
+	if changeErr == nil {
+		return nil
+	}
+
+	if rerr := fm.ChangePassword(ctx, oldPass); rerr != nil {
+		return errors.Wrapf(changeErr, "unable to roll back password change (%v)", rerr)
+	}
+
+	if perr := c.svc.passwordPersistenceStrategy().PersistPassword(ctx, configFile, oldPass); perr != nil {
+		log(ctx).Warnf("unable to persist old password for %v: %v", configFile, perr)
+	}
+
+	return errors.Wrap(changeErr, "password change was rolled back")
+}
+
+// persistAndVerify persists the new password for the repository and ensures it can be opened with it.
+func (c *commandRepositoryChangePasswordBatch) persistAndVerify(ctx context.Context, configFile, newPass string) error {
+	if err := c.svc.passwordPersistenceStrategy().PersistPassword(ctx, configFile, newPass); err != nil {
+		return errors.Wrap(err, "unable to persist password")
+	}
+
+	rep, err := c.openDirect(ctx, configFile, newPass)
+	if err != nil {
+		return errors.Wrap(err, "unable to open repository using new password")
+	}
+
+	return errors.Wrap(rep.Close(ctx), "error closing repository")
+}
+
+func (c *commandRepositoryChangePasswordBatch) openDirect(ctx context.Context, configFile, password string) (repo.DirectRepository, error) {
+	r, err := repo.Open(ctx, configFile, password, c.svc.optionsFromFlags(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open repository")
+	}
+
+	dr, ok := r.(repo.DirectRepository)
+	if !ok {
+		r.Close(ctx) //nolint:errcheck
+
+		return nil, errors.New("password can only be changed for repositories with direct connection")
+	}
+
+	return dr, nil
+}
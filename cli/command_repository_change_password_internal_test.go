@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangePasswordConfirm(t *testing.T) {
+	for input, want := range map[string]bool{
+		"y\n":   true,
+		"YES\n": true,
+		"n\n":   false,
+		"\n":    false,
+		"":      false,
+	} {
+		var out bytes.Buffer
+
+		app := NewApp()
+		app.stdinReader = strings.NewReader(input)
+		app.stdoutWriter = &out
+
+		c := &commandRepositoryChangePassword{svc: app}
+
+		require.Equal(t, want, c.confirm(), "input %q", input)
+		require.Contains(t, out.String(), "(y/N)")
+	}
+}
@@ -1,8 +1,11 @@
 package cli_test
 
 import (
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/repo/format"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -42,3 +45,37 @@ func (s *formatSpecificTestSuite) TestRepositoryChangePassword(t *testing.T) {
 
 	env3.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", env1.RepoDir, "--disable-repository-format-cache")
 }
+
+func TestRepositoryChangePasswordBatch(t *testing.T) {
+	env1 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env2 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env1.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env1.RepoDir, "--disable-repository-format-cache")
+	env2.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env2.RepoDir, "--disable-repository-format-cache")
+
+	cfg1 := filepath.Join(env1.ConfigDir, ".kopia.config")
+	cfg2 := filepath.Join(env2.ConfigDir, ".kopia.config")
+	missingCfg := filepath.Join(env1.ConfigDir, "no-such.config")
+
+	oldPass := env1.Environment["KOPIA_PASSWORD"]
+
+	// confirmation is required
+	env1.RunAndExpectFailure(t, "repo", "change-password-batch", cfg1, cfg2, "--old-password", oldPass, "--new-password", "newPass")
+
+	out := env1.RunAndExpectSuccess(t, "repo", "change-password-batch", cfg1, cfg2, "--old-password", oldPass, "--dry-run")
+	require.Equal(t, []string{cfg1 + ": OK (dry run)", cfg2 + ": OK (dry run)"}, out)
+
+	// failure of one repository does not abort the batch.
+	out, _ = env1.RunAndExpectFailure(t, "repo", "change-password-batch", cfg1, missingCfg, cfg2, "--old-password", oldPass, "--new-password", "newPass", "--confirm")
+	require.Len(t, out, 3)
+	require.Equal(t, cfg1+": OK", out[0])
+	require.Contains(t, out[1], missingCfg+": FAILED")
+	require.Equal(t, cfg2+": OK", out[2])
+
+	// both repositories now require the new password.
+	env1.RunAndExpectFailure(t, "repo", "change-password-batch", cfg1, cfg2, "--old-password", oldPass, "--dry-run")
+	env1.RunAndExpectSuccess(t, "repo", "change-password-batch", cfg1, cfg2, "--old-password", "newPass", "--dry-run")
+
+	env2.Environment["KOPIA_PASSWORD"] = "newPass"
+	env2.RunAndExpectSuccess(t, "snapshot", "ls")
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+// clockCheckBlobIDPrefix is the prefix of probe blobs written to measure clock skew.
+const clockCheckBlobIDPrefix = "kopia.clockcheck."
+
+type commandRepositoryCheckClock struct {
+	maxSkew time.Duration
+
+	out textOutput
+}
+
+func (c *commandRepositoryCheckClock) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("check-clock", "Compare local clock against timestamps assigned by the storage backend.")
+	cmd.Flag("max-skew", "Maximum acceptable clock skew").Default(maintenance.MaxClockSkew.String()).DurationVar(&c.maxSkew)
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryCheckClock) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	skew, uncertainty, err := measureClockSkew(ctx, rep.BlobStorage(), rep.Time)
+	if err != nil {
+		return err
+	}
+
+	c.out.printStdout("Clock skew: %v (storage clock is %v local clock), measurement uncertainty: %v\n", skew.Abs(), aheadOrBehind(skew), uncertainty)
+
+	if skew.Abs() <= c.maxSkew {
+		c.out.printStdout("Clock skew is within the acceptable limit of %v.\n", c.maxSkew)
+		return nil
+	}
+
+	log(ctx).Warnf("Clock skew exceeds %v.", c.maxSkew)
+
+	if skew.Abs() > maintenance.MaxClockSkew {
+		log(ctx).Warnf("Maintenance will refuse to run until the clocks are synchronized.")
+	}
+
+	log(ctx).Warnf("Safety windows used by maintenance and age-based filtering of 'content verify' are computed relative to the local clock and may be incorrect.")
+
+	return nil
+}
+
+func aheadOrBehind(skew time.Duration) string {
+	if skew < 0 {
+		return "behind"
+	}
+
+	return "ahead of"
+}
+
+// measureClockSkew writes a probe blob and compares its timestamp assigned by the storage against the local
+// clock at the time of the write. Positive skew means the storage clock is ahead of the local clock.
+// The returned uncertainty is half of the time it took to write the probe blob.
+func measureClockSkew(ctx context.Context, st blob.Storage, now func() time.Time) (skew, uncertainty time.Duration, err error) {
+	var rnd [8]byte
+
+	if _, err := rand.Read(rnd[:]); err != nil {
+		return 0, 0, errors.Wrap(err, "error generating probe blob ID")
+	}
+
+	probeID := blob.ID(clockCheckBlobIDPrefix + hex.EncodeToString(rnd[:]))
+
+	t0 := now()
+
+	if err := st.PutBlob(ctx, probeID, gather.FromSlice([]byte("clock check")), blob.PutOptions{}); err != nil {
+		return 0, 0, errors.Wrap(err, "error writing probe blob")
+	}
+
+	t1 := now()
+
+	defer func() {
+		if derr := st.DeleteBlob(ctx, probeID); derr != nil {
+			log(ctx).Errorf("unable to delete probe blob %v: %v", probeID, derr)
+		}
+	}()
+
+	bm, err := st.GetMetadata(ctx, probeID)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "error reading probe blob metadata")
+	}
+
+	uncertainty = t1.Sub(t0) / 2 //nolint:mnd
+	localTime := t0.Add(uncertainty)
+
+	return bm.Timestamp.Sub(localTime), uncertainty, nil
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestMeasureClockSkew(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	localTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	localNow := func() time.Time { return localTime }
+
+	for _, skew := range []time.Duration{0, 10 * time.Minute, -3 * time.Hour} {
+		data := blobtesting.DataMap{}
+		st := blobtesting.NewMapStorage(data, nil, func() time.Time { return localTime.Add(skew) })
+
+		got, uncertainty, err := measureClockSkew(ctx, st, localNow)
+		require.NoError(t, err)
+		require.Equal(t, skew, got)
+		require.Equal(t, time.Duration(0), uncertainty)
+
+		// probe blob has been cleaned up.
+		require.Empty(t, data)
+	}
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// Categories of findings reported by 'repository check-generations'.
+const (
+	// generationFindingPackNewerThanIndex is reported for pack blobs referenced by the index which are newer
+	// than the newest index blob. Pack blobs are always written before the index blobs referencing them,
+	// so this indicates corruption.
+	generationFindingPackNewerThanIndex = "pack-newer-than-index"
+
+	// generationFindingUnindexedPack is reported for pack blobs not referenced by any index entry,
+	// which may contain data that was not indexed yet.
+	generationFindingUnindexedPack = "unindexed-pack"
+)
+
+type generationFinding struct {
+	Category     string    `json:"category"`
+	BlobID       blob.ID   `json:"blobID"`
+	Timestamp    time.Time `json:"timestamp"`
+	Length       int64     `json:"length"`
+	ContentCount int       `json:"contentCount,omitempty"`
+}
+
+type generationCheckResult struct {
+	IndexBlobCount  int                 `json:"indexBlobCount"`
+	NewestIndexBlob blob.ID             `json:"newestIndexBlob"`
+	NewestIndexTime time.Time           `json:"newestIndexTime"`
+	PackBlobCount   int                 `json:"packBlobCount"`
+	Findings        []generationFinding `json:"findings"`
+}
+
+type commandRepositoryCheckGenerations struct {
+	tolerance time.Duration
+
+	out textOutput
+	jo  jsonOutput
+}
+
+func (c *commandRepositoryCheckGenerations) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("check-generations", "Check that pack blobs are consistent with the generation of the newest index blob.")
+	cmd.Flag("tolerance", "Ignore pack blobs newer than the newest index blob by no more than the provided duration").Default("1m").DurationVar(&c.tolerance)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
+}
+
+func (c *commandRepositoryCheckGenerations) run(ctx context.Context, rep repo.DirectRepository) error {
+	result, err := c.checkGenerations(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(result))
+	} else {
+		c.printResult(result)
+	}
+
+	if n := countFindings(result.Findings, generationFindingPackNewerThanIndex); n > 0 {
+		return errors.Errorf("found %v pack blobs newer than the newest index blob", n)
+	}
+
+	return nil
+}
+
+func (c *commandRepositoryCheckGenerations) checkGenerations(ctx context.Context, rep repo.DirectRepository) (*generationCheckResult, error) {
+	indexBlobs, err := rep.IndexBlobs(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list index blobs")
+	}
+
+	result := &generationCheckResult{
+		IndexBlobCount: len(indexBlobs),
+		Findings:       []generationFinding{},
+	}
+
+	for _, ib := range indexBlobs {
+		if ib.Timestamp.After(result.NewestIndexTime) {
+			result.NewestIndexBlob = ib.BlobID
+			result.NewestIndexTime = ib.Timestamp
+		}
+	}
+
+	var (
+		mu sync.Mutex
+		// number of contents (including deleted) in each pack blob referenced by the index.
+		contentsPerPack = map[blob.ID]int{}
+	)
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		mu.Lock()
+		contentsPerPack[ci.PackBlobID]++
+		mu.Unlock()
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "unable to iterate contents")
+	}
+
+	for _, prefix := range content.PackBlobIDPrefixes {
+		if err := rep.BlobReader().ListBlobs(ctx, prefix, func(bm blob.Metadata) error {
+			result.PackBlobCount++
+
+			cnt, indexed := contentsPerPack[bm.BlobID]
+
+			switch {
+			case !indexed:
+				result.Findings = append(result.Findings, generationFinding{
+					Category:  generationFindingUnindexedPack,
+					BlobID:    bm.BlobID,
+					Timestamp: bm.Timestamp,
+					Length:    bm.Length,
+				})
+
+			case bm.Timestamp.After(result.NewestIndexTime.Add(c.tolerance)):
+				result.Findings = append(result.Findings, generationFinding{
+					Category:     generationFindingPackNewerThanIndex,
+					BlobID:       bm.BlobID,
+					Timestamp:    bm.Timestamp,
+					Length:       bm.Length,
+					ContentCount: cnt,
+				})
+			}
+
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "unable to list %v blobs", prefix)
+		}
+	}
+
+	sort.Slice(result.Findings, func(i, j int) bool {
+		if fi, fj := result.Findings[i], result.Findings[j]; fi.Category != fj.Category {
+			return fi.Category < fj.Category
+		}
+
+		return result.Findings[i].BlobID < result.Findings[j].BlobID
+	})
+
+	return result, nil
+}
+
+func (c *commandRepositoryCheckGenerations) printResult(result *generationCheckResult) {
+	if result.NewestIndexBlob == "" {
+		c.out.printStdout("Index blobs:      none\n")
+	} else {
+		c.out.printStdout("Index blobs:      %v, newest %v at %v\n", result.IndexBlobCount, result.NewestIndexBlob, formatTimestamp(result.NewestIndexTime))
+	}
+
+	c.out.printStdout("Pack blobs:       %v\n", result.PackBlobCount)
+
+	for _, cat := range []struct {
+		category    string
+		description string
+	}{
+		{generationFindingPackNewerThanIndex, "Pack blobs newer than the newest index blob (corruption)"},
+		{generationFindingUnindexedPack, "Pack blobs not referenced by the index (data not yet indexed)"},
+	} {
+		c.out.printStdout("\n%v: %v\n", cat.description, countFindings(result.Findings, cat.category))
+
+		for _, f := range result.Findings {
+			if f.Category != cat.category {
+				continue
+			}
+
+			if f.ContentCount > 0 {
+				c.out.printStdout("  %v %v %v (%v contents)\n", f.BlobID, formatTimestamp(f.Timestamp), units.BytesString(f.Length), f.ContentCount)
+			} else {
+				c.out.printStdout("  %v %v %v\n", f.BlobID, formatTimestamp(f.Timestamp), units.BytesString(f.Length))
+			}
+		}
+	}
+}
+
+func countFindings(findings []generationFinding, category string) int {
+	n := 0
+
+	for _, f := range findings {
+		if f.Category == category {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,70 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+type generationCheckResultJSON struct {
+	IndexBlobCount int `json:"indexBlobCount"`
+	PackBlobCount  int `json:"packBlobCount"`
+	Findings       []struct {
+		Category string `json:"category"`
+		BlobID   string `json:"blobID"`
+	} `json:"findings"`
+}
+
+func TestRepositoryCheckGenerations(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("hello world"), 0o600))
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	out := env.RunAndExpectSuccess(t, "repo", "check-generations")
+	require.Contains(t, mustGetLineContaining(t, out, "Pack blobs not referenced by the index"), ": 0")
+	require.Contains(t, mustGetLineContaining(t, out, "Pack blobs newer than the newest index blob"), ": 0")
+
+	// copy one of the pack blobs under a different name, so that it's not referenced by the index.
+	packBlobID := strings.Fields(env.RunAndExpectSuccess(t, "blob", "list", "--prefix=p")[0])[0]
+	unindexedBlobID := packBlobID[:len(packBlobID)-1] + "x"
+	// the beginning of blob ID is used to shard blobs into directories.
+	packFileSuffix := packBlobID[6:] + ".f"
+
+	require.NoError(t, filepath.WalkDir(env.RepoDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, packFileSuffix) {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		base := strings.TrimSuffix(path, ".f")
+
+		return os.WriteFile(base[:len(base)-1]+"x.f", data, 0o600)
+	}))
+
+	var result generationCheckResultJSON
+
+	require.NoError(t, json.Unmarshal([]byte(strings.Join(env.RunAndExpectSuccess(t, "repo", "check-generations", "--json"), "\n")), &result))
+	require.Positive(t, result.IndexBlobCount)
+	require.Len(t, result.Findings, 1)
+	require.Equal(t, "unindexed-pack", result.Findings[0].Category)
+	require.Equal(t, unindexedBlobID, result.Findings[0].BlobID)
+
+	// with negative tolerance all indexed pack blobs appear newer than the newest index blob.
+	env.RunAndExpectFailure(t, "repo", "check-generations", "--tolerance=-1000h")
+}
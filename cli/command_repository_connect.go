@@ -65,9 +65,9 @@ func (c *connectOptions) setup(svc appServices, cmd *kingpin.CmdClause) {
 	// we must use *Var() methods, otherwise one of the commands would always get default flag values.
 	cmd.Flag("cache-directory", "Cache directory").PlaceHolder("PATH").Envar(svc.EnvName("KOPIA_CACHE_DIRECTORY")).StringVar(&c.connectCacheDirectory)
 
-	c.maxListCacheDuration = 30 * time.Second //nolint:mnd
-	c.contentCacheSizeMB = 5000
-	c.metadataCacheSizeMB = 5000
+	c.maxListCacheDuration = defaultMaxListCacheDuration
+	c.contentCacheSizeMB = defaultCacheSizeMB
+	c.metadataCacheSizeMB = defaultCacheSizeMB
 	c.cacheSizeFlags.setup(cmd)
 
 	cmd.Flag("override-hostname", "Override hostname used by this repository connection").Hidden().StringVar(&c.connectHostname)
@@ -125,9 +125,25 @@ func (c *App) runConnectCommandWithStorage(ctx context.Context, co *connectOptio
 
 func (c *App) runConnectCommandWithStorageAndPassword(ctx context.Context, co *connectOptions, st blob.Storage, password string) error {
 	configFile := c.repositoryConfigFileName()
+
+	opt := co.toRepoConnectOptions()
+
+	// restrictions of the connection token can't be relaxed using flags,
+	// record them so that 'repository set-client' refuses to lift them later.
+	if c.tokenOptions.ReadOnly {
+		opt.ReadOnly = true
+		opt.ReadOnlyFromToken = true
+	}
+
+	opt.Profile = c.configProfile
+
+	if exp := c.tokenOptions.Expires; !exp.IsZero() {
+		opt.ExpiresAt = &exp
+	}
+
 	if err := passwordpersist.OnSuccess(
-		ctx, repo.Connect(ctx, configFile, st, password, co.toRepoConnectOptions()),
-		c.passwordPersistenceStrategy(), configFile, password); err != nil {
+		ctx, repo.Connect(ctx, configFile, st, password, opt),
+		c.passwordPersistenceStrategy(), c.repositoryConfigKey(), password); err != nil {
 		return errors.Wrap(err, "error connecting to repository")
 	}
 
@@ -8,6 +8,7 @@
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 )
@@ -57,7 +58,7 @@ func (c *storageFromConfigFlags) Connect(ctx context.Context, isCreate bool, for
 }
 
 func (c *storageFromConfigFlags) connectToStorageFromConfigFile(ctx context.Context) (blob.Storage, error) {
-	cfg, err := repo.LoadConfigFromFile(c.connectFromConfigFile)
+	cfg, err := repo.LoadConfigFromFile(c.connectFromConfigFile, "")
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to open config")
 	}
@@ -76,10 +77,21 @@ func (c *storageFromConfigFlags) connectToStorageFromConfigToken(ctx context.Con
 		return nil, errors.Wrap(err, "invalid token")
 	}
 
+	opt, err := repo.DecodeTokenOptions(token)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid token")
+	}
+
+	if !opt.Expires.IsZero() && clock.Now().After(opt.Expires) {
+		return nil, errors.Errorf("the provided token has expired on %v, ask for a new one", formatTimestamp(opt.Expires))
+	}
+
 	if pass != "" {
 		c.sps.setPasswordFromToken(pass)
 	}
 
+	c.sps.setTokenOptions(opt)
+
 	//nolint:wrapcheck
 	return blob.NewStorage(ctx, ci, false)
 }
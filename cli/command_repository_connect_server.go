@@ -45,6 +45,7 @@ func (c *commandRepositoryConnectServer) run(ctx context.Context) error {
 
 	configFile := c.svc.repositoryConfigFileName()
 	opt := c.co.toRepoConnectOptions()
+	opt.Profile = c.svc.configProfileName()
 
 	u := opt.Username
 	if u == "" {
@@ -65,7 +66,7 @@ func (c *commandRepositoryConnectServer) run(ctx context.Context) error {
 
 	if err := passwordpersist.OnSuccess(
 		ctx, repo.ConnectAPIServer(ctx, configFile, as, pass, opt),
-		c.svc.passwordPersistenceStrategy(), configFile, pass); err != nil {
+		c.svc.passwordPersistenceStrategy(), c.svc.repositoryConfigKey(), pass); err != nil {
 		return errors.Wrap(err, "error connecting to API server")
 	}
 
@@ -34,6 +34,8 @@ type commandRepositoryCreate struct {
 	createFormatVersion               int
 	retentionMode                     string
 	retentionPeriod                   time.Duration
+	indexCompression                  string
+	allowWeakPassword                 bool
 
 	co  connectOptions
 	svc advancedAppServices
@@ -52,6 +54,8 @@ func (c *commandRepositoryCreate) setup(svc advancedAppServices, parent commandP
 	cmd.Flag("format-version", "Force a particular repository format version (1, 2 or 3, 0==default)").IntVar(&c.createFormatVersion)
 	cmd.Flag("retention-mode", "Set the blob retention-mode for supported storage backends.").EnumVar(&c.retentionMode, blob.Governance.String(), blob.Compliance.String())
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
+	cmd.Flag("allow-weak-password", "Do not warn when the repository password is short or easy to guess.").BoolVar(&c.allowWeakPassword)
+	cmd.Flag("index-compression", "Compression algorithm used when writing index blobs (requires a recent client to open the repository).").PlaceHolder("ALGO").EnumVar(&c.indexCompression, supportedIndexCompressionAlgorithms()...)
 	//nolint:lll
 	cmd.Flag("format-block-key-derivation-algorithm", "Algorithm to derive the encryption key for the format block from the repository password").Default(format.DefaultKeyDerivationAlgorithm).EnumVar(&c.createBlockKeyDerivationAlgorithm, format.SupportedFormatBlobKeyDerivationAlgorithms()...)
 
@@ -81,7 +85,8 @@ func (c *commandRepositoryCreate) newRepositoryOptionsFromFlags() *repo.NewRepos
 	return &repo.NewRepositoryOptions{
 		BlockFormat: format.ContentFormat{
 			MutableParameters: format.MutableParameters{
-				Version: format.Version(c.createFormatVersion),
+				Version:          format.Version(c.createFormatVersion),
+				IndexCompression: indexCompressionFromFlag(c.indexCompression),
 			},
 			Hash:               c.createBlockHashFormat,
 			Encryption:         c.createBlockEncryptionFormat,
@@ -126,6 +131,10 @@ func (c *commandRepositoryCreate) runCreateCommandWithStorage(ctx context.Contex
 		return errors.Wrap(err, "getting password")
 	}
 
+	if reason := weakPasswordReason(pass); reason != "" && !c.allowWeakPassword {
+		log(ctx).Warnf("The repository password is weak: %v. Consider using a longer password with a mix of different characters (use --allow-weak-password to suppress this warning).", reason)
+	}
+
 	log(ctx).Info("Initializing repository with:")
 
 	if options.BlockFormat.Version != 0 {
@@ -1,10 +1,13 @@
 package cli_test
 
 import (
+	"encoding/json"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
@@ -61,3 +64,98 @@ func TestRepositoryCreateWithConfigFromStdin(t *testing.T) {
 
 	env.RunAndExpectSuccess(t, "repo", "create", "from-config", "--token-stdin")
 }
+
+func TestRepositoryCreateToken(t *testing.T) {
+	env := testenv.NewCLITest(t, nil, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	// password is not included by default
+	lines, stderr := env.RunAndExpectSuccessWithErrOut(t, "repo", "create-token")
+	require.Len(t, lines, 1)
+	require.Contains(t, strings.Join(stderr, "\n"), "the repository password will be required")
+
+	ci, pass, err := repo.DecodeToken(lines[0])
+	require.NoError(t, err)
+	require.Equal(t, "filesystem", ci.Type)
+	require.Empty(t, pass)
+
+	exp, err := repo.TokenExpiration(lines[0])
+	require.NoError(t, err)
+	require.True(t, exp.IsZero())
+
+	lines, stderr = env.RunAndExpectSuccessWithErrOut(t, "repo", "create-token", "--include-password", "--expires-in=1h")
+	require.Len(t, lines, 1)
+	require.Contains(t, strings.Join(stderr, "\n"), "trivially decoded to reveal the repository password")
+
+	_, pass, err = repo.DecodeToken(lines[0])
+	require.NoError(t, err)
+	require.Equal(t, env.Environment["KOPIA_PASSWORD"], pass)
+
+	exp, err = repo.TokenExpiration(lines[0])
+	require.NoError(t, err)
+	require.False(t, exp.IsZero())
+
+	env.RunAndExpectFailure(t, "repo", "create-token", "--expires-in=-1h")
+
+	// the token can be used to reconnect
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+	env.RunAndExpectSuccess(t, "repo", "connect", "from-config", "--token", lines[0])
+}
+
+func TestRepositoryCreateTokenRestrictions(t *testing.T) {
+	env := testenv.NewCLITest(t, nil, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	lines, stderr := env.RunAndExpectSuccessWithErrOut(t, "repo", "create-token", "--read-only", "--expires-in=1h")
+	require.Len(t, lines, 1)
+	require.Contains(t, strings.Join(stderr, "\n"), "Connections made using the token are read-only")
+
+	opt, err := repo.DecodeTokenOptions(lines[0])
+	require.NoError(t, err)
+	require.True(t, opt.ReadOnly)
+	require.False(t, opt.Expires.IsZero())
+
+	// connections made using the token are read-only, regardless of flags.
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+	env.RunAndExpectSuccess(t, "repo", "connect", "from-config", "--token", lines[0])
+	env.RunAndExpectSuccess(t, "snapshot", "list")
+	env.RunAndExpectFailure(t, "snapshot", "create", env.ConfigDir)
+
+	// read-only mode imposed by the token can't be lifted afterwards.
+	_, stderr = env.RunAndExpectFailure(t, "repo", "set-client", "--read-write")
+	require.Contains(t, strings.Join(stderr, "\n"), "imposed by the connection token")
+	env.RunAndExpectSuccess(t, "repo", "set-client", "--description", "changed")
+	env.RunAndExpectFailure(t, "snapshot", "create", env.ConfigDir)
+
+	// simulate the passage of time by moving the expiration of the connection into the past.
+	configFile := filepath.Join(env.ConfigDir, ".kopia.config")
+
+	var cfg map[string]any
+
+	b, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &cfg))
+	require.NotEmpty(t, cfg["expiresAt"])
+
+	cfg["expiresAt"] = time.Now().Add(-time.Minute).Format(time.RFC3339)
+
+	b, err = json.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(configFile, b, 0o600))
+
+	_, stderr = env.RunAndExpectFailure(t, "snapshot", "list")
+	require.Contains(t, strings.Join(stderr, "\n"), "connection expired")
+
+	// expired tokens can't be used to connect.
+	ci, _, err := repo.DecodeToken(lines[0])
+	require.NoError(t, err)
+
+	expired, err := repo.EncodeTokenWithOptions("", ci, repo.TokenOptions{Expires: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+	_, stderr = env.RunAndExpectFailure(t, "repo", "connect", "from-config", "--token", expired)
+	require.Contains(t, strings.Join(stderr, "\n"), "token has expired")
+}
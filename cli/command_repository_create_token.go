@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryCreateToken struct {
+	includePassword bool
+	expiresIn       time.Duration
+	readOnly        bool
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryCreateToken) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("create-token", "Create a token that can be used to connect to the current repository from another machine.")
+	cmd.Flag("include-password", "Embed the repository password in the token").BoolVar(&c.includePassword)
+	cmd.Flag("expires-in", "Prevent connecting and opening connections made using the token after the given duration").DurationVar(&c.expiresIn)
+	cmd.Flag("read-only", "Connections made using the token are read-only").BoolVar(&c.readOnly)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryCreateToken) run(ctx context.Context, rep repo.DirectRepository) error {
+	if c.expiresIn < 0 {
+		return errors.New("--expires-in must not be negative")
+	}
+
+	pass := ""
+
+	if c.includePassword {
+		var err error
+
+		pass, err = c.svc.getPasswordFromFlags(ctx, false, true)
+		if err != nil {
+			return errors.Wrap(err, "getting password")
+		}
+	}
+
+	opt := repo.TokenOptions{
+		ReadOnly: c.readOnly,
+	}
+
+	if c.expiresIn > 0 {
+		opt.Expires = clock.Now().Add(c.expiresIn)
+	}
+
+	tok, err := repo.EncodeTokenWithOptions(pass, rep.BlobReader().ConnectionInfo(), opt)
+	if err != nil {
+		return errors.Wrap(err, "error computing repository token")
+	}
+
+	c.out.printStdout("%v\n", tok)
+
+	if !opt.Expires.IsZero() {
+		c.out.printStderr("The token can't be used to connect after %v.\n", formatTimestamp(opt.Expires))
+	}
+
+	if opt.ReadOnly {
+		c.out.printStderr("Connections made using the token are read-only.\n")
+	}
+
+	if !opt.Expires.IsZero() || opt.ReadOnly {
+		c.out.printStderr("NOTE: Restrictions are enforced by Kopia, the token still contains storage credentials which are not limited in any way.\n")
+	}
+
+	if pass != "" {
+		c.out.printStderr("NOTICE: The token printed above can be trivially decoded to reveal the repository password. Do not store it in an unsecured place.\n")
+	} else {
+		c.out.printStderr("NOTICE: The token printed above grants access to the repository storage, the repository password will be required to connect.\n")
+	}
+
+	return nil
+}
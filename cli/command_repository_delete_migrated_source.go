@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+type commandRepositoryDeleteMigratedSource struct {
+	from     string
+	parallel int
+	confirm  bool
+}
+
+func (c *commandRepositoryDeleteMigratedSource) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("delete-migrated-source", "Delete repository data left in the source storage by 'repository migrate' after verifying that the connected storage has identical copies of all of it.")
+	cmd.Flag("from", "Path to a JSON file describing the source storage connection").Required().StringVar(&c.from)
+	cmd.Flag("parallel", "Verification parallelism.").Default("1").IntVar(&c.parallel)
+	cmd.Flag("i-am-sure-source-can-be-deleted", "Confirm deletion of all data from the source storage.").BoolVar(&c.confirm)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandRepositoryDeleteMigratedSource) run(ctx context.Context, rep repo.DirectRepository) error {
+	if !c.confirm {
+		return errors.New("deleting source data requires --i-am-sure-source-can-be-deleted")
+	}
+
+	ci, err := readConnectionInfoFromFile(c.from)
+	if err != nil {
+		return err
+	}
+
+	src, err := blob.NewStorage(ctx, ci, false)
+	if err != nil {
+		return errors.Wrap(err, "can't connect to source storage")
+	}
+
+	defer src.Close(ctx) //nolint:errcheck
+
+	// never delete the storage the repository is connected to.
+	if err := ensureDifferentStorage(ctx, src, rep.BlobReader(), rep.UniqueID()); err != nil {
+		return err
+	}
+
+	blobs, err := verifyBlobsCopied(ctx, src, rep.BlobReader(), c.parallel)
+	if err != nil {
+		return errors.Wrap(err, "connected storage does not have identical copies of all source data, source was not deleted")
+	}
+
+	log(ctx).Infof("Deleting data from %v...", src.DisplayName())
+
+	for _, bm := range blobs {
+		if err := src.DeleteBlob(ctx, bm.BlobID); err != nil && !errors.Is(err, blob.ErrBlobNotFound) {
+			return errors.Wrapf(err, "error deleting %v", bm.BlobID)
+		}
+	}
+
+	log(ctx).Infof("Deleted %v blobs from the source storage.", len(blobs))
+
+	return nil
+}
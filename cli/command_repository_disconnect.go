@@ -22,11 +22,11 @@ func (c *commandRepositoryDisconnect) setup(svc advancedAppServices, parent comm
 func (c *commandRepositoryDisconnect) run(ctx context.Context) error {
 	c.svc.removeUpdateState()
 
-	if err := repo.Disconnect(ctx, c.svc.repositoryConfigFileName()); err != nil {
+	if err := repo.Disconnect(ctx, c.svc.repositoryConfigFileName(), c.svc.configProfileName()); err != nil {
 		return errors.Wrap(err, "unable to disconnect from repository")
 	}
 
-	if err := c.svc.passwordPersistenceStrategy().DeletePassword(ctx, c.svc.repositoryConfigFileName()); err != nil {
+	if err := c.svc.passwordPersistenceStrategy().DeletePassword(ctx, c.svc.repositoryConfigKey()); err != nil {
 		return errors.Wrap(err, "unable to remove persisted password")
 	}
 
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+// name used for the profile stored in the config file specified by --config-file.
+const defaultProfileName = "(default)"
+
+type commandRepositoryListProfiles struct {
+	svc advancedAppServices
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandRepositoryListProfiles) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("list-profiles", "List connection profiles stored in the config file")
+	cmd.Action(svc.noRepositoryAction(c.run))
+
+	c.svc = svc
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+}
+
+// connectionProfile describes a single connection profile.
+type connectionProfile struct {
+	Name        string `json:"name"`
+	ConfigFile  string `json:"configFile"`
+	Description string `json:"description,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Active      bool   `json:"active"`
+}
+
+func (c *commandRepositoryListProfiles) run(ctx context.Context) error {
+	profiles, err := listConnectionProfiles(c.svc.repositoryConfigFileName(), c.svc.configProfileName())
+	if err != nil {
+		return err
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(profiles))
+		return nil
+	}
+
+	if len(profiles) == 0 {
+		log(ctx).Info("No connection profiles found.")
+		return nil
+	}
+
+	for _, p := range profiles {
+		marker := " "
+		if p.Active {
+			marker = "*"
+		}
+
+		c.out.printStdout("%v %-20v %-40v %v\n", marker, p.Name, p.Location, p.Description)
+	}
+
+	return nil
+}
+
+// listConnectionProfiles returns all profiles stored in the provided config file.
+func listConnectionProfiles(configFile, activeProfile string) ([]connectionProfile, error) {
+	names, err := repo.ListProfiles(configFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list profiles")
+	}
+
+	result := []connectionProfile{}
+
+	for _, profile := range names {
+		p := connectionProfile{
+			Name:       profile,
+			ConfigFile: configFile,
+			Active:     profile == activeProfile,
+		}
+
+		if profile == "" {
+			p.Name = defaultProfileName
+		}
+
+		lc, err := repo.LoadConfigFromFile(configFile, profile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to load profile %v", p.Name)
+		}
+
+		p.Description = lc.ClientOptions.Description
+
+		switch {
+		case lc.APIServer != nil:
+			p.Location = "server " + lc.APIServer.BaseURL
+		case lc.Storage != nil:
+			p.Location = lc.Storage.Type
+		}
+
+		result = append(result, p)
+	}
+
+	return result, nil
+}
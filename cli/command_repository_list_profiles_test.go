@@ -0,0 +1,87 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryProfiles(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	require.Empty(t, env.RunAndExpectSuccess(t, "repo", "list-profiles"))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--description", "first")
+	env.RunAndExpectSuccess(t, "--profile", "other", "repo", "create", "filesystem", "--path", testutil.TempDirectory(t), "--description", "second")
+
+	// both profiles are stored in the same config file.
+	files, err := os.ReadDir(env.ConfigDir)
+	require.NoError(t, err)
+
+	var configFiles []string
+
+	for _, f := range files {
+		if filepath.Ext(f.Name()) == ".config" {
+			configFiles = append(configFiles, f.Name())
+		}
+	}
+
+	require.Equal(t, []string{".kopia.config"}, configFiles)
+
+	var contents map[string]any
+
+	b, err := os.ReadFile(filepath.Join(env.ConfigDir, ".kopia.config"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &contents))
+	require.Contains(t, contents, "storage")
+	require.Contains(t, contents["profiles"], "other")
+
+	var profiles []struct {
+		Name        string `json:"name"`
+		ConfigFile  string `json:"configFile"`
+		Description string `json:"description"`
+		Location    string `json:"location"`
+		Active      bool   `json:"active"`
+	}
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "--profile", "other", "repo", "list-profiles", "--json"), &profiles)
+	require.Len(t, profiles, 2)
+	require.Equal(t, "(default)", profiles[0].Name)
+	require.Equal(t, "first", profiles[0].Description)
+	require.Equal(t, "filesystem", profiles[0].Location)
+	require.False(t, profiles[0].Active)
+	require.Equal(t, "other", profiles[1].Name)
+	require.Equal(t, "second", profiles[1].Description)
+	require.True(t, profiles[1].Active)
+
+	lines := env.RunAndExpectSuccess(t, "repo", "list-profiles")
+	require.Len(t, lines, 2)
+	require.Equal(t, "* (default)", lines[0][:11])
+
+	// each profile is connected to its own repository.
+	require.Contains(t, mustGetLineContaining(t, env.RunAndExpectSuccess(t, "repo", "status"), "Description:"), "first")
+
+	status := env.RunAndExpectSuccess(t, "--profile", "other", "repo", "status")
+	require.Contains(t, mustGetLineContaining(t, status, "Description:"), "second")
+	require.True(t, strings.HasSuffix(mustGetLineContaining(t, status, "Config file:"), ".kopia.config"))
+	require.Contains(t, mustGetLineContaining(t, status, "Profile:"), "other")
+
+	// disconnecting the default profile keeps the other one.
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+	env.RunAndExpectFailure(t, "repo", "status")
+	require.Len(t, env.RunAndExpectSuccess(t, "repo", "list-profiles"), 1)
+	env.RunAndExpectSuccess(t, "--profile", "other", "repo", "status")
+
+	env.RunAndExpectSuccess(t, "--profile", "other", "repo", "disconnect")
+	require.NoFileExists(t, filepath.Join(env.ConfigDir, ".kopia.config"))
+
+	env.RunAndExpectFailure(t, "--profile", "../evil", "repo", "status")
+	env.RunAndExpectFailure(t, "--profile", "missing", "repo", "status")
+}
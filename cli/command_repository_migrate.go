@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// migrateProbeBlobPrefix is the prefix of probe blobs used to detect that two storages are the same location.
+const migrateProbeBlobPrefix = "_migrate_probe_"
+
+type commandRepositoryMigrate struct {
+	migrateTo       string
+	migrateDryRun   bool
+	migrateParallel int
+
+	sync commandRepositorySyncTo
+}
+
+func (c *commandRepositoryMigrate) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("migrate", "Migrate repository data to a new storage location and reconnect to it. Source data is left intact, use 'repository delete-migrated-source' to remove it.")
+	cmd.Flag("to", "Path to a JSON file describing the destination storage connection").Required().StringVar(&c.migrateTo)
+	cmd.Flag("dry-run", "Only report what would be copied.").Short('n').BoolVar(&c.migrateDryRun)
+	cmd.Flag("parallel", "Copy and verification parallelism.").Default("1").IntVar(&c.migrateParallel)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.sync.out.setup(svc)
+}
+
+func (c *commandRepositoryMigrate) run(ctx context.Context, rep repo.DirectRepository) error {
+	ci, err := readConnectionInfoFromFile(c.migrateTo)
+	if err != nil {
+		return err
+	}
+
+	dst, err := blob.NewStorage(ctx, ci, true)
+	if err != nil {
+		return errors.Wrap(err, "can't connect to destination storage")
+	}
+
+	defer dst.Close(ctx) //nolint:errcheck
+
+	if err := ensureDifferentStorage(ctx, dst, rep.BlobReader(), rep.UniqueID()); err != nil {
+		return err
+	}
+
+	// copying is resumable, blobs that have already been copied are skipped.
+	c.sync.repositorySyncUpdate = true
+	c.sync.repositorySyncDryRun = c.migrateDryRun
+	c.sync.repositorySyncParallelism = c.migrateParallel
+
+	if err := c.sync.runSyncWithStorage(ctx, rep.BlobReader(), dst); err != nil {
+		return errors.Wrap(err, "error copying blobs")
+	}
+
+	if c.migrateDryRun {
+		log(ctx).Info("Dry run, not verifying or switching to the destination storage.")
+		return nil
+	}
+
+	if _, err := verifyBlobsCopied(ctx, rep.BlobReader(), dst, c.migrateParallel); err != nil {
+		return errors.Wrap(err, "verification of migrated data failed, repository connection was not changed")
+	}
+
+	if err := repo.SetStorageConnectionInfo(ctx, rep.ConfigFilename(), rep.ConfigProfile(), ci); err != nil {
+		return errors.Wrap(err, "error updating repository connection")
+	}
+
+	log(ctx).Infof("Repository is now connected to %v.", dst.DisplayName())
+	log(ctx).Info("Source data was left intact. To remove it, run 'kopia repository delete-migrated-source' with a file describing the source storage connection.")
+
+	return nil
+}
+
+// ensureDifferentStorage verifies that the provided storages are not the same location by writing
+// a probe blob named after the repository ID and a random suffix to the writable storage
+// and making sure it's not visible in the other one.
+func ensureDifferentStorage(ctx context.Context, st blob.Storage, other blob.Reader, uniqueID []byte) error {
+	suffix := make([]byte, 8)
+
+	if _, err := rand.Read(suffix); err != nil {
+		return errors.Wrap(err, "unable to generate probe blob ID")
+	}
+
+	probeID := blob.ID(fmt.Sprintf("%v%x-%x", migrateProbeBlobPrefix, uniqueID, suffix))
+
+	if err := st.PutBlob(ctx, probeID, gather.FromSlice([]byte(probeID)), blob.PutOptions{}); err != nil {
+		return errors.Wrap(err, "unable to write probe blob")
+	}
+
+	defer func() {
+		if err := st.DeleteBlob(ctx, probeID); err != nil {
+			log(ctx).Warnf("unable to delete probe blob %v: %v", probeID, err)
+		}
+	}()
+
+	_, err := other.GetMetadata(ctx, probeID)
+
+	switch {
+	case err == nil:
+		return errors.New("source and destination storage are the same location")
+	case errors.Is(err, blob.ErrBlobNotFound):
+		return nil
+	default:
+		return errors.Wrap(err, "unable to check for probe blob")
+	}
+}
+
+// verifyBlobsCopied ensures that all source blobs exist in the destination with the same contents
+// and returns the verified source blobs.
+func verifyBlobsCopied(ctx context.Context, src, dst blob.Reader, parallel int) ([]blob.Metadata, error) {
+	log(ctx).Info("Verifying migrated data...")
+
+	dstMetadata, err := blob.ReadBlobMap(ctx, dst)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list destination blobs")
+	}
+
+	srcBlobs, err := blob.ListAllBlobs(ctx, src, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list source blobs")
+	}
+
+	var (
+		totalBytes int64
+		problems   atomic.Int32
+	)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	ch := sliceToChannel(ctx, srcBlobs)
+
+	for range max(parallel, 1) {
+		eg.Go(func() error {
+			for srcmd := range ch {
+				dstmd, ok := dstMetadata[srcmd.BlobID]
+
+				switch {
+				case !ok:
+					log(ctx).Errorf("blob %v is missing in the destination", srcmd.BlobID)
+					problems.Add(1)
+
+				case dstmd.Length != srcmd.Length:
+					log(ctx).Errorf("blob %v has length %v in the destination, expected %v", srcmd.BlobID, dstmd.Length, srcmd.Length)
+					problems.Add(1)
+
+				default:
+					same, err := sameBlobContents(ctx, srcmd.BlobID, src, dst)
+					if err != nil {
+						return err
+					}
+
+					if !same {
+						log(ctx).Errorf("blob %v differs between source and destination", srcmd.BlobID)
+						problems.Add(1)
+					}
+				}
+			}
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, errors.Wrap(err, "error comparing blobs")
+	}
+
+	for _, bm := range srcBlobs {
+		totalBytes += bm.Length
+	}
+
+	log(ctx).Infof("Compared contents of %v blobs (%v), found %v problems.", len(srcBlobs), units.BytesString(totalBytes), problems.Load())
+
+	if n := problems.Load(); n > 0 {
+		return nil, errors.Errorf("found %v problems", n)
+	}
+
+	return srcBlobs, nil
+}
+
+// sameBlobContents returns true if the blob has the same contents in both storages.
+func sameBlobContents(ctx context.Context, blobID blob.ID, src, dst blob.Reader) (bool, error) {
+	srcHash, err := blobContentHash(ctx, blobID, src)
+	if err != nil {
+		return false, errors.Wrap(err, "error reading source blob")
+	}
+
+	dstHash, err := blobContentHash(ctx, blobID, dst)
+	if err != nil {
+		return false, errors.Wrap(err, "error reading destination blob")
+	}
+
+	return bytes.Equal(srcHash, dstHash), nil
+}
+
+func blobContentHash(ctx context.Context, blobID blob.ID, r blob.Reader) ([]byte, error) {
+	var data gather.WriteBuffer
+	defer data.Close()
+
+	if err := r.GetBlob(ctx, blobID, 0, -1, &data); err != nil {
+		return nil, errors.Wrapf(err, "error reading %v", blobID)
+	}
+
+	h := sha256.New()
+
+	if _, err := data.Bytes().WriteTo(h); err != nil {
+		return nil, errors.Wrap(err, "error hashing blob")
+	}
+
+	return h.Sum(nil), nil
+}
+
+func readConnectionInfoFromFile(fname string) (blob.ConnectionInfo, error) {
+	var ci blob.ConnectionInfo
+
+	b, err := os.ReadFile(fname) //nolint:gosec
+	if err != nil {
+		return ci, errors.Wrap(err, "unable to read storage connection file")
+	}
+
+	if err := json.Unmarshal(b, &ci); err != nil {
+		return ci, errors.Wrap(err, "invalid storage connection file")
+	}
+
+	return ci, nil
+}
@@ -56,6 +56,10 @@ func (c *commandRepositorySetClient) run(ctx context.Context, rep repo.Repositor
 	}
 
 	if c.repoClientOptionsReadWrite {
+		if opt.ReadOnlyFromToken {
+			return errors.Wrap(repo.ErrReadOnlyImposedByToken, "unable to set repository to read-write mode")
+		}
+
 		if !opt.ReadOnly {
 			log(ctx).Info("Repository is already in read-write mode.")
 		} else {
@@ -117,5 +121,5 @@ func (c *commandRepositorySetClient) run(ctx context.Context, rep repo.Repositor
 	}
 
 	//nolint:wrapcheck
-	return repo.SetClientOptions(ctx, c.svc.repositoryConfigFileName(), opt)
+	return repo.SetClientOptions(ctx, c.svc.repositoryConfigFileName(), c.svc.configProfileName(), opt)
 }
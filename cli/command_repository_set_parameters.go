@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -11,6 +12,7 @@
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/format"
 	"github.com/kopia/kopia/repo/maintenance"
 )
@@ -20,6 +22,7 @@ type commandRepositorySetParameters struct {
 	indexFormatVersion int
 	retentionMode      string
 	retentionPeriod    time.Duration
+	indexCompression   string
 
 	epochRefreshFrequency    time.Duration
 	epochMinDuration         time.Duration
@@ -45,6 +48,7 @@ func (c *commandRepositorySetParameters) setup(svc appServices, parent commandPa
 	cmd.Flag("index-version", "Set version of index format used for writing").IntVar(&c.indexFormatVersion)
 	cmd.Flag("retention-mode", "Set the blob retention-mode for supported storage backends.").EnumVar(&c.retentionMode, "none", blob.Governance.String(), blob.Compliance.String())
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
+	cmd.Flag("index-compression", "Set compression algorithm used when writing index blobs, existing indexes are compressed as they are compacted").PlaceHolder("ALGO").EnumVar(&c.indexCompression, supportedIndexCompressionAlgorithms()...)
 
 	cmd.Flag("upgrade", "Upgrade repository to the latest stable format").BoolVar(&c.upgradeRepositoryFormat)
 
@@ -111,6 +115,52 @@ func setRetentionModeParameter(ctx context.Context, v blob.RetentionMode, desc s
 	log(ctx).Infof(" - setting %v to %s.\n", desc, v)
 }
 
+func supportedIndexCompressionAlgorithms() []string {
+	var res []string
+
+	for name := range compression.ByName {
+		if !compression.IsDeprecated[name] {
+			res = append(res, string(name))
+		}
+	}
+
+	sort.Strings(res)
+
+	return append([]string{"none"}, res...)
+}
+
+func indexCompressionFromFlag(v string) compression.Name {
+	if v == "none" {
+		return ""
+	}
+
+	return compression.Name(v)
+}
+
+func setIndexCompressionParameter(ctx context.Context, v string, mp *format.MutableParameters, requiredFeatures []feature.Required, anyChange *bool) []feature.Required {
+	if v == "" || indexCompressionFromFlag(v) == mp.IndexCompression {
+		return requiredFeatures
+	}
+
+	mp.IndexCompression = indexCompressionFromFlag(v)
+	*anyChange = true
+
+	log(ctx).Infof(" - setting index compression to %v.\n", v)
+
+	if mp.IndexCompression == "" {
+		// indexes written so far may still be compressed, so the required feature stays in place.
+		return requiredFeatures
+	}
+
+	for _, rf := range requiredFeatures {
+		if rf.Feature == format.IndexCompressionFeature {
+			return requiredFeatures
+		}
+	}
+
+	return append(requiredFeatures, feature.Required{Feature: format.IndexCompressionFeature})
+}
+
 func updateRepositoryParameters(
 	ctx context.Context,
 	upgradeToEpochManager bool,
@@ -214,6 +264,7 @@ func (c *commandRepositorySetParameters) run(ctx context.Context, rep repo.Direc
 	setIntParameter(ctx, c.epochDeleteParallelism, "epoch delete parallelism", &mp.EpochParameters.DeleteParallelism, &anyChange)
 	setIntParameter(ctx, c.epochCheckpointFrequency, "epoch checkpoint frequency", &mp.EpochParameters.FullCheckpointFrequency, &anyChange)
 
+	requiredFeatures = setIndexCompressionParameter(ctx, c.indexCompression, &mp, requiredFeatures, &anyChange)
 	requiredFeatures = c.addRemoveUpdateRequiredFeatures(requiredFeatures, &anyChange)
 
 	if !anyChange {
@@ -253,6 +253,30 @@ func (s *formatSpecificTestSuite) TestRepositorySetParametersRequiredFeatures(t
 	env.RunAndExpectSuccess(t, "repository", "set-parameters", "--remove-required-feature", "no-such-feature")
 }
 
+func (s *formatSpecificTestSuite) TestRepositorySetParametersIndexCompression(t *testing.T) {
+	env := s.setupInMemoryRepo(t)
+
+	env.RunAndExpectFailure(t, "repository", "set-parameters", "--index-compression=no-such-compression")
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	env.RunAndExpectSuccess(t, "repository", "set-parameters", "--index-compression=zstd")
+	out := env.RunAndExpectSuccess(t, "repository", "status")
+	require.Contains(t, out, "Index Compression:   zstd")
+	require.Contains(t, out, "Required Features:   index-compression")
+
+	// new indexes are compressed, old ones remain readable.
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	env.RunAndExpectSuccess(t, "index", "optimize", "--all")
+	env.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
+	env.RunAndExpectSuccess(t, "snapshot", "list", "--all")
+	env.RunAndExpectSuccess(t, "content", "verify")
+
+	env.RunAndExpectSuccess(t, "repository", "set-parameters", "--index-compression=none")
+	out = env.RunAndExpectSuccess(t, "repository", "status")
+	require.NotContains(t, out, "Index Compression:")
+	require.Contains(t, out, "Required Features:   index-compression")
+}
+
 func (s *formatSpecificTestSuite) TestRepositorySetParametersRequiredFeatures_ServerMode(t *testing.T) {
 	env := s.setupInMemoryRepo(t)
 
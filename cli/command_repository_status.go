@@ -8,6 +8,7 @@
 	"path/filepath"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -22,6 +23,7 @@
 type commandRepositoryStatus struct {
 	statusReconnectToken                bool
 	statusReconnectTokenIncludePassword bool
+	storageStats                        bool
 
 	svc advancedAppServices
 	jo  jsonOutput
@@ -31,6 +33,7 @@ type commandRepositoryStatus struct {
 // RepositoryStatus is used to display the repository info in JSON format.
 type RepositoryStatus struct {
 	ConfigFile  string `json:"configFile"`
+	Profile     string `json:"profile,omitempty"`
 	UniqueIDHex string `json:"uniqueIDHex"`
 
 	ClientOptions repo.ClientOptions              `json:"clientOptions"`
@@ -39,12 +42,17 @@ type RepositoryStatus struct {
 	ContentFormat format.ContentFormat            `json:"contentFormat"`
 	ObjectFormat  format.ObjectFormat             `json:"objectFormat"`
 	BlobRetention format.BlobStorageConfiguration `json:"blobRetention"`
+
+	Compression     string           `json:"compression,omitempty"`
+	LastMaintenance *time.Time       `json:"lastMaintenance,omitempty"`
+	Usage           *RepositoryUsage `json:"usage,omitempty"`
 }
 
 func (c *commandRepositoryStatus) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("status", "Display the status of connected repository.")
 	cmd.Flag("reconnect-token", "Display reconnect command").Short('t').BoolVar(&c.statusReconnectToken)
 	cmd.Flag("reconnect-token-with-password", "Include password in reconnect token").Short('s').BoolVar(&c.statusReconnectTokenIncludePassword)
+	cmd.Flag("storage-stats", "Compute logical and physical storage usage (lists all blobs and contents)").BoolVar(&c.storageStats)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 
 	c.svc = svc
@@ -55,9 +63,17 @@ func (c *commandRepositoryStatus) setup(svc advancedAppServices, parent commandP
 func (c *commandRepositoryStatus) outputJSON(ctx context.Context, r repo.Repository) error {
 	s := RepositoryStatus{
 		ConfigFile:    c.svc.repositoryConfigFileName(),
+		Profile:       c.svc.configProfileName(),
 		ClientOptions: r.ClientOptions(),
 	}
 
+	comp, err := globalCompression(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	s.Compression = comp
+
 	dr, ok := r.(repo.DirectRepository)
 	if ok {
 		ci := dr.BlobReader().ConnectionInfo()
@@ -75,6 +91,22 @@ func (c *commandRepositoryStatus) outputJSON(ctx context.Context, r repo.Reposit
 		default:
 			return errors.Wrap(err, "unable to get storage volume capacity")
 		}
+
+		lm, err := lastMaintenanceTime(ctx, dr)
+		if err != nil {
+			return err
+		}
+
+		if !lm.IsZero() {
+			s.LastMaintenance = &lm
+		}
+
+		if c.storageStats {
+			s.Usage, err = computeRepositoryUsage(ctx, dr)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	c.out.printStdout("%s\n", c.jo.jsonBytes(s))
@@ -135,6 +167,10 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 	}
 
 	c.out.printStdout("Config file:         %v\n", c.svc.repositoryConfigFileName())
+
+	if p := c.svc.configProfileName(); p != "" {
+		c.out.printStdout("Profile:             %v\n", p)
+	}
 	c.out.printStdout("\n")
 	c.out.printStdout("Description:         %v\n", rep.ClientOptions().Description)
 	c.out.printStdout("Hostname:            %v\n", rep.ClientOptions().Hostname)
@@ -188,11 +224,22 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 	c.out.printStdout("Content compression: %v\n", mp.IndexVersion >= index.Version2)
 	c.out.printStdout("Password changes:    %v\n", contentFormat.SupportsPasswordChange())
 
+	comp, err := globalCompression(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	c.out.printStdout("Compression:         %v\n", comp)
+
 	c.outputRequiredFeatures(ctx, dr)
 
 	c.out.printStdout("Max pack length:     %v\n", units.BytesString(mp.MaxPackSize))
 	c.out.printStdout("Index Format:        v%v\n", mp.IndexVersion)
 
+	if mp.IndexCompression != "" {
+		c.out.printStdout("Index Compression:   %v\n", mp.IndexCompression)
+	}
+
 	emgr, epochMgrEnabled, emerr := dr.ContentReader().EpochManager(ctx)
 	if emerr != nil {
 		return errors.Wrap(emerr, "epoch manager")
@@ -216,6 +263,28 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 		c.out.printStdout("Epoch Manager:       disabled\n")
 	}
 
+	lm, err := lastMaintenanceTime(ctx, dr)
+	if err != nil {
+		return err
+	}
+
+	c.out.printStdout("\n")
+
+	if lm.IsZero() {
+		c.out.printStdout("Last maintenance:    never\n")
+	} else {
+		c.out.printStdout("Last maintenance:    %v\n", formatTimestamp(lm))
+	}
+
+	if c.storageStats {
+		u, err := computeRepositoryUsage(ctx, dr)
+		if err != nil {
+			return err
+		}
+
+		c.dumpUsage(u)
+	}
+
 	c.dumpRetentionStatus(ctx, dr)
 
 	if err := c.dumpUpgradeStatus(ctx, dr); err != nil {
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/epoch"
+	"github.com/kopia/kopia/internal/repodiag"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/indexblob"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+// blob types reported by 'repository status --storage-stats'.
+const (
+	blobTypeData       = "data"
+	blobTypeMetadata   = "metadata"
+	blobTypeIndex      = "index"
+	blobTypeSession    = "session"
+	blobTypeLog        = "log"
+	blobTypeRepository = "repository"
+	blobTypeOther      = "other"
+)
+
+// BlobTypeUsage describes the number and total size of blobs of a single type.
+type BlobTypeUsage struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// RepositoryUsage describes the logical and physical space used by the repository.
+type RepositoryUsage struct {
+	// LogicalBytes is the total original length of all contents before compression and encryption.
+	LogicalBytes int64 `json:"logicalBytes"`
+	PackedBytes  int64 `json:"packedBytes"`
+	ContentCount int64 `json:"contentCount"`
+
+	// PhysicalBytes is the total size of all blobs in the storage, as reported by ListBlobs.
+	PhysicalBytes int64                    `json:"physicalBytes"`
+	BlobCount     int                      `json:"blobCount"`
+	BlobsByType   map[string]BlobTypeUsage `json:"blobsByType"`
+}
+
+// blobTypeOf returns the type of the blob with the provided ID based on its prefix.
+func blobTypeOf(id blob.ID) string {
+	s := string(id)
+
+	switch {
+	case strings.HasPrefix(s, string(content.PackBlobIDPrefixRegular)):
+		return blobTypeData
+	case strings.HasPrefix(s, string(content.PackBlobIDPrefixSpecial)):
+		return blobTypeMetadata
+	case strings.HasPrefix(s, string(epoch.EpochManagerIndexUberPrefix)),
+		strings.HasPrefix(s, indexblob.V0IndexBlobPrefix),
+		strings.HasPrefix(s, indexblob.V0CompactionLogBlobPrefix),
+		strings.HasPrefix(s, indexblob.V0CleanupBlobPrefix):
+		return blobTypeIndex
+	case strings.HasPrefix(s, string(content.BlobIDPrefixSession)):
+		return blobTypeSession
+	case strings.HasPrefix(s, repodiag.LogBlobPrefix):
+		return blobTypeLog
+	case strings.HasPrefix(s, "kopia."):
+		return blobTypeRepository
+	default:
+		return blobTypeOther
+	}
+}
+
+// computeRepositoryUsage lists all blobs and contents in the repository to determine its space usage.
+func computeRepositoryUsage(ctx context.Context, dr repo.DirectRepository) (*RepositoryUsage, error) {
+	u := &RepositoryUsage{
+		BlobsByType: map[string]BlobTypeUsage{},
+	}
+
+	if err := dr.BlobReader().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		t := blobTypeOf(bm.BlobID)
+
+		bu := u.BlobsByType[t]
+		bu.Count++
+		bu.Bytes += bm.Length
+		u.BlobsByType[t] = bu
+
+		u.BlobCount++
+		u.PhysicalBytes += bm.Length
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error listing blobs")
+	}
+
+	if err := dr.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		u.ContentCount++
+		u.LogicalBytes += int64(ci.OriginalLength)
+		u.PackedBytes += int64(ci.PackedLength)
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	return u, nil
+}
+
+// lastMaintenanceTime returns the end time of the most recent maintenance task or zero time if maintenance never ran.
+func lastMaintenanceTime(ctx context.Context, dr repo.DirectRepository) (time.Time, error) {
+	sch, err := maintenance.GetSchedule(ctx, dr)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "unable to get maintenance schedule")
+	}
+
+	var last time.Time
+
+	for _, runs := range sch.Runs {
+		// runs are stored most recent first.
+		if len(runs) > 0 && runs[0].End.After(last) {
+			last = runs[0].End
+		}
+	}
+
+	return last, nil
+}
+
+// globalCompression returns the name of the compressor set in the global policy or "none".
+func globalCompression(ctx context.Context, rep repo.Repository) (string, error) {
+	pol, err := policy.GetDefinedPolicy(ctx, rep, policy.GlobalPolicySourceInfo)
+	if errors.Is(err, policy.ErrPolicyNotFound) {
+		return "none", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get global policy")
+	}
+
+	if pol.CompressionPolicy.CompressorName == "" {
+		return "none", nil
+	}
+
+	return string(pol.CompressionPolicy.CompressorName), nil
+}
+
+func (c *commandRepositoryStatus) dumpUsage(u *RepositoryUsage) {
+	c.out.printStdout("\n")
+	c.out.printStdout("Contents:            %v\n", u.ContentCount)
+	c.out.printStdout("Logical bytes:       %v\n", units.BytesString(u.LogicalBytes))
+	c.out.printStdout("Packed bytes:        %v\n", units.BytesString(u.PackedBytes))
+	c.out.printStdout("Physical bytes:      %v in %v blobs\n", units.BytesString(u.PhysicalBytes), u.BlobCount)
+
+	var types []string
+	for t := range u.BlobsByType {
+		types = append(types, t)
+	}
+
+	sort.Strings(types)
+
+	for _, t := range types {
+		bu := u.BlobsByType[t]
+		c.out.printStdout("  %-18v %8v %10v\n", t+":", bu.Count, units.BytesString(bu.Bytes))
+	}
+}
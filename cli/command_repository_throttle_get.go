@@ -6,6 +6,7 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob/throttling"
 )
 
 type commandRepositoryThrottleGet struct {
@@ -22,6 +23,11 @@ func (c *commandRepositoryThrottleGet) setup(svc appServices, parent commandPare
 func (c *commandRepositoryThrottleGet) run(ctx context.Context, rep repo.DirectRepository) error {
 	limits := rep.Throttler().Limits()
 
+	// include one-off overrides, such as --upload-limit and --download-limit.
+	if el, ok := rep.Throttler().(interface{ EffectiveLimits() throttling.Limits }); ok {
+		limits = el.EffectiveLimits()
+	}
+
 	if err := c.ctg.output(&limits); err != nil {
 		return errors.Wrap(err, "output")
 	}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryUpdateCheck struct {
+	status   bool
+	reset    bool
+	checkNow bool
+	disable  bool
+
+	svc advancedAppServices
+	out textOutput
+	jo  jsonOutput
+}
+
+// updateCheckStatus describes the state of periodic update checks for a repository connection.
+type updateCheckStatus struct {
+	StateFile             string `json:"stateFile"`
+	Enabled               bool   `json:"enabled"`
+	DisabledByEnvironment bool   `json:"disabledByEnvironment,omitempty"`
+	CurrentVersion        string `json:"currentVersion"`
+	*updateState
+}
+
+func (c *commandRepositoryUpdateCheck) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("update-check", "Show or change the state of periodic checks for Kopia updates.")
+	cmd.Flag("status", "Show the state of update checks (default)").BoolVar(&c.status)
+	cmd.Flag("reset", "Reset the update check state, enabling update checks if they were disabled").BoolVar(&c.reset)
+	cmd.Flag("check-now", "Check for updates now instead of waiting for the next scheduled check").BoolVar(&c.checkNow)
+	cmd.Flag("disable", "Disable update checks for this connection").BoolVar(&c.disable)
+	cmd.Action(svc.noRepositoryAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
+}
+
+func (c *commandRepositoryUpdateCheck) run(ctx context.Context) error {
+	if c.reset && c.disable {
+		return errors.New("--reset and --disable are mutually exclusive")
+	}
+
+	if !repo.ConfigExists(c.svc.repositoryConfigFileName(), c.svc.configProfileName()) {
+		return errors.New("not connected to a repository")
+	}
+
+	switch {
+	case c.disable:
+		c.svc.removeUpdateState()
+		log(ctx).Info("Update checks disabled.")
+
+	case c.reset:
+		c.svc.maybeInitializeUpdateCheck(ctx, &connectOptions{connectCheckForUpdates: true})
+	}
+
+	if c.checkNow {
+		us, err := c.svc.checkForUpdatesNow(ctx)
+		if err != nil {
+			return errors.Wrap(err, "unable to check for updates")
+		}
+
+		log(ctx).Infof("Latest available version: %v", ensureVPrefix(us.AvailableVersion))
+	}
+
+	if c.status || c.jo.jsonOutput || !(c.reset || c.disable || c.checkNow) {
+		return c.showStatus()
+	}
+
+	return nil
+}
+
+func (c *commandRepositoryUpdateCheck) showStatus() error {
+	st := updateCheckStatus{
+		StateFile:             c.svc.updateStateFilename(),
+		DisabledByEnvironment: c.svc.updateCheckDisabledByEnvironment(),
+		CurrentVersion:        ensureVPrefix(repo.BuildVersion),
+	}
+
+	us, err := c.svc.getUpdateState()
+
+	switch {
+	case err == nil:
+		st.updateState = us
+		st.Enabled = !st.DisabledByEnvironment
+
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(st))
+		return nil
+	}
+
+	c.out.printStdout("State file:        %v\n", st.StateFile)
+
+	switch {
+	case st.DisabledByEnvironment:
+		c.out.printStdout("Update checks:     disabled by %v environment variable\n", c.svc.EnvName(checkForUpdatesEnvar))
+	case st.Enabled:
+		c.out.printStdout("Update checks:     enabled\n")
+	default:
+		c.out.printStdout("Update checks:     disabled\n")
+	}
+
+	c.out.printStdout("Current version:   %v\n", st.CurrentVersion)
+
+	if us == nil {
+		return nil
+	}
+
+	c.out.printStdout("Last check:        %v\n", timestampOrNever(us.LastCheckTime))
+	c.out.printStdout("Next check:        %v\n", formatTimestamp(us.NextCheckTime))
+
+	if us.AvailableVersion != "" {
+		c.out.printStdout("Available version: %v\n", ensureVPrefix(us.AvailableVersion))
+	}
+
+	return nil
+}
+
+func timestampOrNever(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	return formatTimestamp(t)
+}
@@ -0,0 +1,50 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryUpdateCheck(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	getStatus := func() map[string]any {
+		t.Helper()
+
+		var st map[string]any
+
+		require.NoError(t, json.Unmarshal([]byte(strings.Join(env.RunAndExpectSuccess(t, "repo", "update-check", "--json"), "\n")), &st))
+
+		return st
+	}
+
+	st := getStatus()
+	require.Equal(t, true, st["enabled"])
+	require.NotEmpty(t, st["nextCheckTimestamp"])
+
+	env.RunAndExpectSuccess(t, "repo", "update-check", "--disable")
+
+	st = getStatus()
+	require.Equal(t, false, st["enabled"])
+	require.NotContains(t, st, "nextCheckTimestamp")
+	require.Contains(t, env.RunAndExpectSuccess(t, "repo", "update-check"), "Update checks:     disabled")
+
+	env.RunAndExpectSuccess(t, "repo", "update-check", "--reset")
+
+	st = getStatus()
+	require.Equal(t, true, st["enabled"])
+	require.Contains(t, env.RunAndExpectSuccess(t, "repo", "update-check", "--status"), "Last check:        never")
+
+	env.RunAndExpectFailure(t, "repo", "update-check", "--reset", "--disable")
+
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+	env.RunAndExpectFailure(t, "repo", "update-check")
+}
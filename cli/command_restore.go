@@ -9,10 +9,12 @@
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
+	atunits "github.com/alecthomas/units"
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/fs"
@@ -114,19 +116,30 @@ type commandRestore struct {
 	restoreOverwriteFiles         bool
 	restoreOverwriteSymlinks      bool
 	restoreWriteSparseFiles       bool
+	restoreVerifySparseFiles      bool
 	restoreConsistentAttributes   bool
 	restoreMode                   string
 	restoreParallel               int
+	restoreWriteBufferSize        atunits.Base2Bytes
+	restorePrefetchFiles          bool
 	restoreIgnorePermissionErrors bool
 	restoreWriteFilesAtomically   bool
 	restoreSkipTimes              bool
 	restoreSkipOwners             bool
 	restoreSkipPermissions        bool
+	restoreXattrs                 bool
+	restoreACLs                   bool
 	restoreIncremental            bool
+	restoreOverwrite              bool
+	restoreFailOnExisting         bool
+	restoreSkipExistingCheck      string
 	restoreIgnoreErrors           bool
 	restoreShallowAtDepth         int32
 	minSizeForPlaceholder         int32
 	snapshotTime                  string
+	tagFilter                     snapshotTagFilter
+	restoreFlatten                bool
+	restoreFlattenCollisions      string
 
 	restores []restoreSourceTarget
 
@@ -143,19 +156,30 @@ func (c *commandRestore) setup(svc appServices, parent commandParent) {
 	cmd.Flag("overwrite-files", "Specifies whether or not to overwrite already existing files").Default("true").BoolVar(&c.restoreOverwriteFiles)
 	cmd.Flag("overwrite-symlinks", "Specifies whether or not to overwrite already existing symlinks").Default("true").BoolVar(&c.restoreOverwriteSymlinks)
 	cmd.Flag("write-sparse-files", "When doing a restore, attempt to write files sparsely-allocating the minimum amount of disk space needed.").Default("false").BoolVar(&c.restoreWriteSparseFiles)
+	cmd.Flag("verify-sparse-files", "When writing sparse files, verify that holes were preserved on disk.").BoolVar(&c.restoreVerifySparseFiles)
 	cmd.Flag("consistent-attributes", "When multiple snapshots match, fail if they have inconsistent attributes").Envar(svc.EnvName("KOPIA_RESTORE_CONSISTENT_ATTRIBUTES")).BoolVar(&c.restoreConsistentAttributes)
 	cmd.Flag("mode", "Override restore mode").Default(restoreModeAuto).EnumVar(&c.restoreMode, restoreModeAuto, restoreModeLocal, restoreModeZip, restoreModeZipNoCompress, restoreModeTar, restoreModeTgz)
-	cmd.Flag("parallel", "Restore parallelism (1=disable)").Default("8").IntVar(&c.restoreParallel)
+	cmd.Flag("parallel", "Restore parallelism (0=twice the number of CPUs, 1=disable), limited by the open file limit").Default("0").IntVar(&c.restoreParallel)
+	cmd.Flag("write-buffer-size", "Fetch file contents ahead of writing using buffers of this size (0=disable)").Default("0").BytesVar(&c.restoreWriteBufferSize)
+	cmd.Flag("prefetch-files", "Prefetch contents of upcoming files while writing current ones").BoolVar(&c.restorePrefetchFiles)
 	cmd.Flag("skip-owners", "Skip owners during restore").BoolVar(&c.restoreSkipOwners)
 	cmd.Flag("skip-permissions", "Skip permissions during restore").BoolVar(&c.restoreSkipPermissions)
 	cmd.Flag("skip-times", "Skip times during restore").BoolVar(&c.restoreSkipTimes)
+	cmd.Flag("restore-xattrs", "Restore extended attributes captured in the snapshot").BoolVar(&c.restoreXattrs)
+	cmd.Flag("restore-acls", "Restore POSIX ACLs captured in the snapshot").BoolVar(&c.restoreACLs)
 	cmd.Flag("ignore-permission-errors", "Ignore permission errors").Default("true").BoolVar(&c.restoreIgnorePermissionErrors)
 	cmd.Flag("write-files-atomically", "Write files atomically to disk, ensuring they are either fully committed, or not written at all, preventing partially written files").Default("false").BoolVar(&c.restoreWriteFilesAtomically)
 	cmd.Flag("ignore-errors", "Ignore all errors").BoolVar(&c.restoreIgnoreErrors)
 	cmd.Flag("skip-existing", "Skip files and symlinks that exist in the output").BoolVar(&c.restoreIncremental)
+	cmd.Flag("skip-existing-check", "How --skip-existing decides that an existing file was already restored ('metadata' compares size and modification time, 'none' skips any existing file)").Default(restoreSkipExistingCheckMetadata).EnumVar(&c.restoreSkipExistingCheck, restoreSkipExistingCheckMetadata, restoreSkipExistingCheckNone)
+	cmd.Flag("overwrite", "Overwrite files and symlinks that exist in the output").BoolVar(&c.restoreOverwrite)
+	cmd.Flag("fail-on-existing", "Fail if a file or symlink already exists in the output").BoolVar(&c.restoreFailOnExisting)
 	cmd.Flag("shallow", "Shallow restore the directory hierarchy starting at this level (default is to deep restore the entire hierarchy.)").Int32Var(&c.restoreShallowAtDepth)
 	cmd.Flag("shallow-minsize", "When doing a shallow restore, write actual files instead of placeholders smaller than this size.").Int32Var(&c.minSizeForPlaceholder)
+	cmd.Flag("flatten", "Restore all files directly into the target directory instead of recreating the directory hierarchy").BoolVar(&c.restoreFlatten)
+	cmd.Flag("flatten-collisions", "When flattening, how to name files whose names collide ('suffix' appends a number, 'path' joins elements of the original path)").Default(restore.FlattenCollisionSuffix).EnumVar(&c.restoreFlattenCollisions, restore.FlattenCollisionSuffix, restore.FlattenCollisionPath)
 	cmd.Flag("snapshot-time", "When using a path as the source, use the latest snapshot available before this date. Default is latest").Default("latest").StringVar(&c.snapshotTime)
+	c.tagFilter.setup(cmd)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 }
 
@@ -168,6 +192,11 @@ func (c *commandRestore) setup(svc appServices, parent commandParent) {
 	restoreModeTgz           = "tgz"
 )
 
+const (
+	restoreSkipExistingCheckMetadata = "metadata"
+	restoreSkipExistingCheckNone     = "none"
+)
+
 // constructTargetPairs builds the sourceIdPathPairs array for this
 // command for the two forms of command: expansion of one or more
 // placeholders or restoring of a single source to a single destination.
@@ -267,6 +296,12 @@ func (c *commandRestore) restoreOutput(ctx context.Context, rep repo.Repository)
 			SkipPermissions:        c.restoreSkipPermissions,
 			SkipTimes:              c.restoreSkipTimes,
 			WriteSparseFiles:       c.restoreWriteSparseFiles,
+			VerifySparseFiles:      c.restoreVerifySparseFiles,
+			WriteBufferSize:        int(c.restoreWriteBufferSize),
+
+			SkipExistingIgnoreMetadata: c.restoreSkipExistingCheck == restoreSkipExistingCheckNone,
+			RestoreExtendedAttributes:  c.restoreXattrs,
+			RestoreACLs:                c.restoreACLs,
 		}
 
 		if err := o.Init(ctx); err != nil {
@@ -352,6 +387,14 @@ func printRestoreStats(ctx context.Context, st *restore.Stats) {
 		maybeSkipped, maybeErrors)
 }
 
+func printRestoreThroughput(ctx context.Context, st *restore.Stats, dur time.Duration) {
+	if dur <= 0 || st.RestoredTotalFileSize == 0 {
+		return
+	}
+
+	log(ctx).Infof("Restore took %v (%v/s).", dur.Round(time.Millisecond), units.BytesString(int64(float64(st.RestoredTotalFileSize)/dur.Seconds())))
+}
+
 func (c *commandRestore) setupPlaceholderExpansion(ctx context.Context, rep repo.Repository, rstp restoreSourceTarget, output restore.Output) (fs.Entry, error) {
 	rootEntry, err := snapshotfs.GetEntryFromPlaceholder(ctx, rep, localfs.PlaceholderFilePath(rstp.source))
 	if err != nil {
@@ -389,11 +432,34 @@ func (c *commandRestore) getRestoreProgress() RestoreProgress {
 }
 
 func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
+	if c.restoreParallel < 0 {
+		return errors.New("--parallel must not be negative")
+	}
+
+	c.restoreParallel = effectiveRestoreParallelism(ctx, c.restoreParallel, maxOpenFiles())
+
+	if err := c.applyExistingFilesMode(); err != nil {
+		return err
+	}
+
 	output, oerr := c.restoreOutput(ctx, rep)
 	if oerr != nil {
 		return errors.Wrap(oerr, "unable to initialize output")
 	}
 
+	fso, _ := output.(*restore.FilesystemOutput)
+
+	var flat *restore.FlattenOutput
+
+	if c.restoreFlatten {
+		fo, err := c.flattenOutput(output)
+		if err != nil {
+			return err
+		}
+
+		flat, output = fo, fo
+	}
+
 	for _, rstp := range c.restores {
 		var rootEntry fs.Entry
 
@@ -423,12 +489,15 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 			restoreProgress.SetCounters(stats)
 		}
 
+		timer := timetrack.StartTimer()
+
 		st, err := restore.Entry(ctx, rep, output, rootEntry, restore.Options{
 			Parallel:               c.restoreParallel,
 			Incremental:            c.restoreIncremental,
 			IgnoreErrors:           c.restoreIgnoreErrors,
 			RestoreDirEntryAtDepth: c.restoreShallowAtDepth,
 			MinSizeForPlaceholder:  c.minSizeForPlaceholder,
+			PrefetchFiles:          c.restorePrefetchFiles,
 			ProgressCallback:       progressCallback,
 		})
 		if err != nil {
@@ -438,11 +507,107 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 		progressCallback(ctx, st)
 		restoreProgress.Flush() // Force last progress values to be printed
 		printRestoreStats(ctx, &st)
+		printRestoreThroughput(ctx, &st, timer.Elapsed())
+	}
+
+	if fso != nil && fso.WriteSparseFiles {
+		log(ctx).Infof("Sparse files saved %v of disk space.", units.BytesString(fso.SparseBytesSaved()))
+	}
+
+	printFlattenRenames(ctx, flat)
+
+	return nil
+}
+
+func (c *commandRestore) flattenOutput(output restore.Output) (*restore.FlattenOutput, error) {
+	for _, rstp := range c.restores {
+		if rstp.isplaceholder {
+			return nil, errors.New("--flatten can't be used when expanding placeholders")
+		}
+	}
+
+	if c.restoreShallowAtDepth != unlimitedDepth {
+		return nil, errors.New("--flatten can't be used with --shallow")
+	}
+
+	//nolint:wrapcheck
+	return restore.NewFlattenOutput(output, c.restoreFlattenCollisions)
+}
+
+func printFlattenRenames(ctx context.Context, flat *restore.FlattenOutput) {
+	if flat == nil {
+		return
+	}
+
+	renamed := flat.Renamed()
+
+	for _, r := range renamed {
+		log(ctx).Infof("Restored %v as %v to avoid name collision.", r.RelativePath, r.Name)
+	}
+
+	if len(renamed) > 0 {
+		log(ctx).Infof("Renamed %v entries to avoid name collisions.", len(renamed))
+	}
+}
+
+// applyExistingFilesMode validates --overwrite, --skip-existing and --fail-on-existing, which are mutually
+// exclusive, and applies the selected mode to the individual overwrite options.
+func (c *commandRestore) applyExistingFilesMode() error {
+	modes := 0
+
+	for _, v := range []bool{c.restoreOverwrite, c.restoreIncremental, c.restoreFailOnExisting} {
+		if v {
+			modes++
+		}
+	}
+
+	if modes > 1 {
+		return errors.New("only one of --overwrite, --skip-existing and --fail-on-existing can be specified")
+	}
+
+	switch {
+	case c.restoreOverwrite:
+		c.restoreOverwriteFiles = true
+		c.restoreOverwriteSymlinks = true
+
+	case c.restoreFailOnExisting:
+		c.restoreOverwriteFiles = false
+		c.restoreOverwriteSymlinks = false
 	}
 
 	return nil
 }
 
+// restoreOpenFilesPerWorker is the number of file descriptors each restore worker may hold at once.
+const restoreOpenFilesPerWorker = 2
+
+// restoreReservedOpenFiles is the number of file descriptors reserved for the repository, caches and logs.
+const restoreReservedOpenFiles = 64
+
+// effectiveRestoreParallelism returns the restore parallelism to use, defaulting to twice the number of
+// CPUs and making sure that the workers do not exceed the provided limit of open files (0 = unknown).
+func effectiveRestoreParallelism(ctx context.Context, parallel int, openFileLimit uint64) int {
+	if parallel == 0 {
+		parallel = 2 * runtime.NumCPU() //nolint:mnd
+	}
+
+	if openFileLimit == 0 {
+		return parallel
+	}
+
+	maxParallel := 1
+	if openFileLimit > restoreReservedOpenFiles+restoreOpenFilesPerWorker {
+		maxParallel = int((openFileLimit - restoreReservedOpenFiles) / restoreOpenFilesPerWorker)
+	}
+
+	if parallel > maxParallel {
+		log(ctx).Infof("Reducing restore parallelism from %v to %v because of the open file limit (%v).", parallel, maxParallel, openFileLimit)
+		return maxParallel
+	}
+
+	return parallel
+}
+
 // tryToConvertPathToID checks if the source is a path and in this case returns the ID of the snapshot
 // containing the latest version available.
 func (c *commandRestore) tryToConvertPathToID(ctx context.Context, rep repo.Repository, source string) (string, error) {
@@ -476,7 +641,12 @@ func (c *commandRestore) tryToConvertPathToID(ctx context.Context, rep repo.Repo
 		return "", errors.New("the source must contain a path element")
 	}
 
-	manifestIDs, err := findSnapshotsForSource(ctx, rep, si, map[string]string{})
+	tags, err := c.tagFilter.labels()
+	if err != nil {
+		return "", err
+	}
+
+	manifestIDs, err := findSnapshotsForSource(ctx, rep, si, tags)
 	if err != nil {
 		return "", err
 	}
@@ -490,6 +660,11 @@ func (c *commandRestore) tryToConvertPathToID(ctx context.Context, rep repo.Repo
 		return "", errors.Wrap(err, "unable to load snapshots")
 	}
 
+	ms, err = c.tagFilter.filter(ms)
+	if err != nil {
+		return "", err
+	}
+
 	m, relPath, ohid := findLastManifestWithPath(ctx, rep, ms, si.Path, filter)
 	if m == nil {
 		return "", errors.Errorf("no snapshots contain data for %v", source)
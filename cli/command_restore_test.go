@@ -1,12 +1,14 @@
 package cli
 
 import (
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/testlogging"
 )
 
 func TestRestoreSnapshotMaxTime(t *testing.T) {
@@ -60,3 +62,43 @@ func TestRestoreSnapshotFilter(t *testing.T) {
 	require.False(t, f(nil, 0, 2))
 	require.True(t, f(nil, 1, 2))
 }
+
+func TestEffectiveRestoreParallelism(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	require.Equal(t, 2*runtime.NumCPU(), effectiveRestoreParallelism(ctx, 0, 0))
+	require.Equal(t, 1, effectiveRestoreParallelism(ctx, 1, 0))
+	require.Equal(t, 100, effectiveRestoreParallelism(ctx, 100, 0))
+	require.Equal(t, 100, effectiveRestoreParallelism(ctx, 100, 1024))
+
+	// (256 - 64 reserved) / 2 files per worker
+	require.Equal(t, 96, effectiveRestoreParallelism(ctx, 100, 256))
+
+	// never goes below 1, even with a very low limit.
+	require.Equal(t, 1, effectiveRestoreParallelism(ctx, 100, 10))
+}
+
+func TestRestoreExistingFilesMode(t *testing.T) {
+	c := &commandRestore{restoreOverwriteFiles: true, restoreOverwriteSymlinks: true}
+	require.NoError(t, c.applyExistingFilesMode())
+	require.True(t, c.restoreOverwriteFiles)
+	require.True(t, c.restoreOverwriteSymlinks)
+
+	c = &commandRestore{restoreFailOnExisting: true, restoreOverwriteFiles: true, restoreOverwriteSymlinks: true}
+	require.NoError(t, c.applyExistingFilesMode())
+	require.False(t, c.restoreOverwriteFiles)
+	require.False(t, c.restoreOverwriteSymlinks)
+
+	c = &commandRestore{restoreOverwrite: true}
+	require.NoError(t, c.applyExistingFilesMode())
+	require.True(t, c.restoreOverwriteFiles)
+	require.True(t, c.restoreOverwriteSymlinks)
+
+	c = &commandRestore{restoreIncremental: true, restoreOverwriteFiles: true}
+	require.NoError(t, c.applyExistingFilesMode())
+	require.True(t, c.restoreOverwriteFiles)
+
+	require.Error(t, (&commandRestore{restoreOverwrite: true, restoreIncremental: true}).applyExistingFilesMode())
+	require.Error(t, (&commandRestore{restoreOverwrite: true, restoreFailOnExisting: true}).applyExistingFilesMode())
+	require.Error(t, (&commandRestore{restoreIncremental: true, restoreFailOnExisting: true}).applyExistingFilesMode())
+}
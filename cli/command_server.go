@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"time"
+
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pkg/errors"
 
@@ -14,6 +16,7 @@ type commandServer struct {
 	flush    commandServerFlush
 	pause    commandServerPause
 	refresh  commandServerRefresh
+	reload   commandServerReload
 	resume   commandServerResume
 	start    commandServerStart
 	status   commandServerStatus
@@ -39,6 +42,12 @@ type serverClientFlags struct {
 	serverUsername        string
 	serverPassword        string
 	serverCertFingerprint string
+	serverHTTPVersion     string
+
+	serverConnectTimeout    time.Duration
+	serverReadTimeout       time.Duration
+	serverMaxRetries        int
+	serverRetryInitialDelay time.Duration
 }
 
 func (c *serverClientFlags) setup(svc appServices, cmd *kingpin.CmdClause) {
@@ -53,6 +62,11 @@ func (c *serverClientFlags) setup(svc appServices, cmd *kingpin.CmdClause) {
 	cmd.Flag("server-password", "Server control password").Hidden().StringVar(&c.serverPassword)
 
 	cmd.Flag("server-cert-fingerprint", "Server certificate fingerprint").PlaceHolder("SHA256-FINGERPRINT").Envar(svc.EnvName("KOPIA_SERVER_CERT_FINGERPRINT")).StringVar(&c.serverCertFingerprint)
+	cmd.Flag("server-http-version", "HTTP protocol version to use when connecting to the server").Envar(svc.EnvName("KOPIA_SERVER_HTTP_VERSION")).Default(apiclient.HTTPVersionAuto).EnumVar(&c.serverHTTPVersion, apiclient.HTTPVersionAuto, apiclient.HTTPVersion1, apiclient.HTTPVersion2)
+	cmd.Flag("server-connect-timeout", "Timeout for connecting to the server").Default("30s").DurationVar(&c.serverConnectTimeout)
+	cmd.Flag("server-read-timeout", "Timeout for the server to start responding to a request (0=unlimited)").Default("0s").DurationVar(&c.serverReadTimeout)
+	cmd.Flag("server-max-retries", "Number of times to retry requests that fail due to transient errors").Default("3").IntVar(&c.serverMaxRetries)
+	cmd.Flag("server-retry-delay", "Delay before the first retry, doubled after each attempt").Default("500ms").DurationVar(&c.serverRetryInitialDelay)
 }
 
 func (c *commandServer) setup(svc advancedAppServices, parent commandParent) {
@@ -64,6 +78,7 @@ func (c *commandServer) setup(svc advancedAppServices, parent commandParent) {
 
 	c.status.setup(svc, cmd)
 	c.refresh.setup(svc, cmd)
+	c.reload.setup(svc, cmd)
 	c.flush.setup(svc, cmd)
 	c.shutdown.setup(svc, cmd)
 
@@ -84,5 +99,10 @@ func (c *serverClientFlags) serverAPIClientOptions() (apiclient.Options, error)
 		Username:                            c.serverUsername,
 		Password:                            c.serverPassword,
 		TrustedServerCertificateFingerprint: c.serverCertFingerprint,
+		HTTPVersion:                         c.serverHTTPVersion,
+		ConnectTimeout:                      c.serverConnectTimeout,
+		ReadTimeout:                         c.serverReadTimeout,
+		MaxRetries:                          c.serverMaxRetries,
+		RetryInitialDelay:                   c.serverRetryInitialDelay,
 	}, nil
 }
@@ -79,6 +79,7 @@ func TestServerControl(t *testing.T) {
 	}, waitTimeout, pollFrequency)
 
 	env.RunAndExpectSuccess(t, "server", "flush", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
+	env.RunAndExpectSuccess(t, "server", "reload", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
 
 	// trigger server snapshot
 	env.RunAndExpectSuccess(t, "server", "snapshot", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "--all")
@@ -146,7 +147,7 @@ func TestServerControl(t *testing.T) {
 		ConcurrentWrites:       400,
 	}, limits)
 
-	env.RunAndExpectSuccess(t, "server", "shutdown", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
+	env.RunAndExpectSuccess(t, "server", "shutdown", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword, "--force")
 
 	select {
 	case <-serverStopped:
@@ -160,6 +161,7 @@ func TestServerControl(t *testing.T) {
 	env.RunAndExpectFailure(t, "server", "status", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
 	env.RunAndExpectFailure(t, "server", "flush", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
 	env.RunAndExpectFailure(t, "server", "refresh", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
+	env.RunAndExpectFailure(t, "server", "reload", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
 	env.RunAndExpectFailure(t, "server", "shutdown", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword)
 }
 
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/serverapi"
+)
+
+type commandServerReload struct {
+	sf serverClientFlags
+
+	out textOutput
+}
+
+func (c *commandServerReload) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("reload", "Reload repository, users and access rules in Kopia server (equivalent to SIGHUP)")
+	c.sf.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.serverAction(&c.sf, c.run))
+}
+
+func (c *commandServerReload) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
+	st, err := serverapi.Reload(ctx, cli)
+	if err != nil {
+		return errors.Wrap(err, "unable to reload server")
+	}
+
+	if !st.Connected {
+		c.out.printStderr("Server reloaded, not connected to a repository.\n")
+		return nil
+	}
+
+	c.out.printStderr("Server reloaded, connected to repository %v.\n", st.Storage)
+
+	return nil
+}
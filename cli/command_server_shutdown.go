@@ -3,6 +3,8 @@
 import (
 	"context"
 
+	"github.com/pkg/errors"
+
 	"github.com/kopia/kopia/internal/apiclient"
 	"github.com/kopia/kopia/internal/serverapi"
 )
@@ -10,17 +12,38 @@
 type commandServerShutdown struct {
 	sf serverClientFlags
 
+	force bool
+
 	out textOutput
 }
 
 func (c *commandServerShutdown) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("shutdown", "Gracefully shutdown the server")
+	cmd.Flag("force", "Cancel snapshots in progress instead of waiting for them to finish").BoolVar(&c.force)
 	c.sf.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.serverAction(&c.sf, c.run))
 }
 
 func (c *commandServerShutdown) run(ctx context.Context, cli *apiclient.KopiaAPIClient) error {
-	//nolint:wrapcheck
-	return cli.Post(ctx, "control/shutdown", &serverapi.Empty{}, &serverapi.Empty{})
+	var resp serverapi.ShutdownResponse
+
+	if err := cli.Post(ctx, "control/shutdown", &serverapi.ShutdownRequest{Force: c.force}, &resp); err != nil {
+		return errors.Wrap(err, "unable to shut down server")
+	}
+
+	switch {
+	case resp.InProgressSnapshots == 0:
+		c.out.printStderr("Server is shutting down.\n")
+	case resp.Canceled:
+		c.out.printStderr("Server is shutting down, canceled %v snapshot(s) in progress.\n", resp.InProgressSnapshots)
+	default:
+		c.out.printStderr("Server will shut down after %v snapshot(s) in progress finish, no new snapshots will be started.\n", resp.InProgressSnapshots)
+
+		if resp.WaitTimeout > 0 {
+			c.out.printStderr("Snapshots still in progress after %v will be canceled, use --force to cancel them now.\n", resp.WaitTimeout)
+		}
+	}
+
+	return nil
 }
@@ -70,11 +70,14 @@ type commandServerStart struct {
 	debugScheduler                      bool
 	minMaintenanceInterval              time.Duration
 
-	shutdownGracePeriod  time.Duration
-	kopiauiNotifications bool
+	shutdownGracePeriod   time.Duration
+	shutdownUploadTimeout time.Duration
+	kopiauiNotifications  bool
 
 	logServerRequests bool
 
+	rateLimit server.RateLimitOptions
+
 	disableCSRFTokenChecks bool // disable CSRF token checks - used for development/debugging only
 
 	sf  serverFlags
@@ -108,23 +111,35 @@ func (c *commandServerStart) setup(svc advancedAppServices, parent commandParent
 
 	cmd.Flag("shutdown-on-stdin", "Shut down the server when stdin handle has closed.").Hidden().BoolVar(&c.serverStartShutdownWhenStdinClosed)
 
-	cmd.Flag("tls-generate-cert", "Generate TLS certificate").Hidden().BoolVar(&c.serverStartTLSGenerateCert)
-	cmd.Flag("tls-cert-file", "TLS certificate PEM").StringVar(&c.serverStartTLSCertFile)
-	cmd.Flag("tls-key-file", "TLS key PEM file").StringVar(&c.serverStartTLSKeyFile)
-	cmd.Flag("tls-generate-rsa-key-size", "TLS RSA Key size (bits)").Hidden().Default("4096").IntVar(&c.serverStartTLSGenerateRSAKeySize)
+	cmd.Flag("tls-generate", "Generate a self-signed TLS certificate on first start and reuse it afterwards, stored next to the config file unless --tls-cert and --tls-key are provided").BoolVar(&c.serverStartTLSGenerateCert)
+	cmd.Flag("tls-cert", "TLS certificate PEM file, reloaded on SIGHUP").StringVar(&c.serverStartTLSCertFile)
+	cmd.Flag("tls-key", "TLS key PEM file, reloaded on SIGHUP").StringVar(&c.serverStartTLSKeyFile)
+	cmd.Flag("tls-generate-rsa-bits", "RSA key size (bits) of generated TLS certificates").Default("4096").IntVar(&c.serverStartTLSGenerateRSAKeySize)
 	cmd.Flag("tls-generate-cert-valid-days", "How long should the TLS certificate be valid").Default("3650").Hidden().IntVar(&c.serverStartTLSGenerateCertValidDays)
 	cmd.Flag("tls-generate-cert-name", "Host names/IP addresses to generate TLS certificate for").Default("127.0.0.1").Hidden().StringsVar(&c.serverStartTLSGenerateCertNames)
 	cmd.Flag("tls-print-server-cert", "Print server certificate").Hidden().BoolVar(&c.serverStartTLSPrintFullServerCert)
 
+	// aliases for backwards compat
+	cmd.Flag("tls-cert-file", "TLS certificate PEM file").Hidden().StringVar(&c.serverStartTLSCertFile)
+	cmd.Flag("tls-key-file", "TLS key PEM file").Hidden().StringVar(&c.serverStartTLSKeyFile)
+	cmd.Flag("tls-generate-rsa-key-size", "TLS RSA Key size (bits)").Hidden().IntVar(&c.serverStartTLSGenerateRSAKeySize)
+	cmd.Flag("tls-generate-cert", "Generate TLS certificate").Hidden().BoolVar(&c.serverStartTLSGenerateCert)
+
 	cmd.Flag("async-repo-connect", "Connect to repository asynchronously").Hidden().BoolVar(&c.asyncRepoConnect)
 	cmd.Flag("persistent-logs", "Persist logs in a file").Default("true").BoolVar(&c.persistentLogs)
 	cmd.Flag("ui-title-prefix", "UI title prefix").Hidden().Envar(svc.EnvName("KOPIA_UI_TITLE_PREFIX")).StringVar(&c.uiTitlePrefix)
 	cmd.Flag("ui-preferences-file", "Path to JSON file storing UI preferences").StringVar(&c.uiPreferencesFile)
 
 	cmd.Flag("log-server-requests", "Log server requests").Hidden().BoolVar(&c.logServerRequests)
+
+	cmd.Flag("rate-limit-per-ip", "Maximum number of requests per second from a single IP address (0=unlimited)").Default("0").Float64Var(&c.rateLimit.PerIP)
+	cmd.Flag("rate-limit-per-user", "Maximum number of requests per second from a single authenticated user (0=unlimited)").Default("0").Float64Var(&c.rateLimit.PerUser)
+	cmd.Flag("rate-limit-burst", "Number of requests allowed to momentarily exceed the rate limits").Default("20").IntVar(&c.rateLimit.Burst)
+
 	cmd.Flag("disable-csrf-token-checks", "Disable CSRF token").Hidden().BoolVar(&c.disableCSRFTokenChecks)
 
 	cmd.Flag("shutdown-grace-period", "Grace period for shutting down the server").Default("5s").DurationVar(&c.shutdownGracePeriod)
+	cmd.Flag("shutdown-upload-timeout", "Maximum time to wait for snapshots in progress when shutting down, after which they are canceled").Default("30m").DurationVar(&c.shutdownUploadTimeout)
 
 	cmd.Flag("kopiaui-notifications", "Enable notifications to be printed to stdout for KopiaUI").BoolVar(&c.kopiauiNotifications)
 
@@ -149,6 +164,7 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 
 	return &server.Options{
 		ConfigFile:           c.svc.repositoryConfigFileName(),
+		ConfigProfile:        c.svc.configProfileName(),
 		ConnectOptions:       c.co.toRepoConnectOptions(),
 		RefreshInterval:      c.serverStartRefreshInterval,
 		MaxConcurrency:       c.serverStartMaxConcurrency,
@@ -169,6 +185,8 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 
 		EnableErrorNotifications: c.svc.enableErrorNotifications(),
 		NotifyTemplateOptions:    c.svc.notificationTemplateOptions(),
+		RateLimit:                c.rateLimit,
+		ShutdownUploadTimeout:    c.shutdownUploadTimeout,
 	}, nil
 }
 
@@ -249,6 +267,9 @@ func (c *commandServerStart) run(ctx context.Context) (reterr error) {
 
 	m := mux.NewRouter()
 
+	// health checks don't require authentication and must be registered before the UI catch-all route.
+	srv.SetupHealthHandlers(m)
+
 	c.setupHandlers(srv, m)
 
 	// init prometheus after adding interceptors that require credentials, so that this
@@ -261,6 +282,8 @@ func (c *commandServerStart) run(ctx context.Context) (reterr error) {
 		handler = srv.GRPCRouterHandler(handler)
 	}
 
+	handler = server.RateLimitHandler(handler, c.rateLimit)
+
 	httpServer.Handler = handler
 
 	if c.serverStartShutdownWhenStdinClosed {
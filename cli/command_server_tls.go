@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bytes"
 	"context"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -15,6 +14,7 @@
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -70,27 +70,44 @@ func (c *commandServerStart) startServerWithOptionalTLS(ctx context.Context, htt
 	return c.startServerWithOptionalTLSAndListener(ctx, httpServer, l)
 }
 
+// maybeGenerateTLS handles --tls-generate by generating a certificate and key on first start
+// and reusing them on subsequent starts.
 func (c *commandServerStart) maybeGenerateTLS(ctx context.Context) error {
-	if !c.serverStartTLSGenerateCert || c.serverStartTLSCertFile == "" || c.serverStartTLSKeyFile == "" {
+	if !c.serverStartTLSGenerateCert {
 		return nil
 	}
 
-	if _, err := os.Stat(c.serverStartTLSCertFile); err == nil {
-		return errors.Errorf("TLS cert file already exists: %q", c.serverStartTLSCertFile)
+	if c.serverStartTLSCertFile == "" && c.serverStartTLSKeyFile == "" {
+		c.serverStartTLSCertFile = c.svc.repositoryConfigKey() + ".tls-cert.pem"
+		c.serverStartTLSKeyFile = c.svc.repositoryConfigKey() + ".tls-key.pem"
 	}
 
-	if _, err := os.Stat(c.serverStartTLSKeyFile); err == nil {
-		return errors.Errorf("TLS key file already exists: %q", c.serverStartTLSKeyFile)
+	if c.serverStartTLSCertFile == "" || c.serverStartTLSKeyFile == "" {
+		return errors.New("--tls-generate requires both --tls-cert and --tls-key or neither of them")
 	}
 
+	_, certErr := os.Stat(c.serverStartTLSCertFile)
+	_, keyErr := os.Stat(c.serverStartTLSKeyFile)
+
+	switch {
+	case certErr == nil && keyErr == nil:
+		log(ctx).Infof("using previously generated TLS certificate %v", c.serverStartTLSCertFile)
+		return nil
+
+	case os.IsNotExist(certErr) && os.IsNotExist(keyErr):
+		return c.generateTLSFiles(ctx)
+
+	default:
+		return errors.Errorf("unable to use TLS certificate %q and key %q, remove them to generate new ones", c.serverStartTLSCertFile, c.serverStartTLSKeyFile)
+	}
+}
+
+func (c *commandServerStart) generateTLSFiles(ctx context.Context) error {
 	cert, key, err := c.generateServerCertificate(ctx)
 	if err != nil {
 		return errors.Wrap(err, "unable to generate server cert")
 	}
 
-	fingerprint := sha256.Sum256(cert.Raw)
-	fmt.Fprintf(c.out.stderr(), "SERVER CERT SHA256: %v\n", hex.EncodeToString(fingerprint[:])) //nolint:errcheck
-
 	log(ctx).Infof("writing TLS certificate to %v", c.serverStartTLSCertFile)
 
 	if err := tlsutil.WriteCertificateToFile(c.serverStartTLSCertFile, cert); err != nil {
@@ -118,41 +135,33 @@ func (c *commandServerStart) startServerWithOptionalTLSAndListener(ctx context.C
 
 	switch {
 	case c.serverStartTLSCertFile != "" && c.serverStartTLSKeyFile != "":
-		// PEM files provided
-		fmt.Fprintf(c.out.stderr(), "SERVER ADDRESS: %shttps://%v\n", udsPfx, httpServer.Addr) //nolint:errcheck
-		c.showServerUIPrompt(ctx)
-
-		return checkErrServerClosed(ctx, httpServer.ServeTLS(listener, c.serverStartTLSCertFile, c.serverStartTLSKeyFile), "error starting TLS server")
+		// PEM files provided, serve them through GetCertificate so that they can be reloaded
+		// on SIGHUP without restarting the server or dropping existing connections.
+		r := &tlsCertificateReloader{certFile: c.serverStartTLSCertFile, keyFile: c.serverStartTLSKeyFile}
 
-	case c.serverStartTLSGenerateCert:
-		// PEM files not provided, generate in-memory TLS cert/key but don't persit.
-		cert, key, err := c.generateServerCertificate(ctx)
+		fingerprint, err := r.reload()
 		if err != nil {
-			return errors.Wrap(err, "unable to generate server cert")
+			return err
 		}
 
 		httpServer.TLSConfig = &tls.Config{
-			MinVersion: tls.VersionTLS13,
-			Certificates: []tls.Certificate{
-				{
-					Certificate: [][]byte{cert.Raw},
-					PrivateKey:  key,
-				},
-			},
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: r.getCertificate,
 		}
 
-		fingerprint := sha256.Sum256(cert.Raw)
-		fmt.Fprintf(c.out.stderr(), "SERVER CERT SHA256: %v\n", hex.EncodeToString(fingerprint[:])) //nolint:errcheck
+		onExternalConfigReloadRequest(func() {
+			if fp, err := r.reload(); err != nil {
+				log(ctx).Errorf("unable to reload TLS certificate, continuing to use the previous one: %v", err)
+			} else {
+				log(ctx).Infof("reloaded TLS certificate with SHA256 fingerprint %v", fp)
+			}
+		})
+
+		fmt.Fprintf(c.out.stderr(), "SERVER CERT SHA256: %v\n", fingerprint) //nolint:errcheck
 
 		if c.serverStartTLSPrintFullServerCert {
 			// dump PEM-encoded server cert, only used by KopiaUI to securely connect.
-			var b bytes.Buffer
-
-			if err := pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
-				return errors.Wrap(err, "Failed to write data")
-			}
-
-			fmt.Fprintf(c.out.stderr(), "SERVER CERTIFICATE: %v\n", base64.StdEncoding.EncodeToString(b.Bytes())) //nolint:errcheck
+			fmt.Fprintf(c.out.stderr(), "SERVER CERTIFICATE: %v\n", base64.StdEncoding.EncodeToString(r.certificatePEM())) //nolint:errcheck
 		}
 
 		fmt.Fprintf(c.out.stderr(), "SERVER ADDRESS: %shttps://%v\n", udsPfx, httpServer.Addr) //nolint:errcheck
@@ -172,6 +181,48 @@ func (c *commandServerStart) startServerWithOptionalTLSAndListener(ctx context.C
 	}
 }
 
+// tlsCertificateReloader provides the server TLS certificate loaded from PEM files, which can be reloaded at any time.
+type tlsCertificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu sync.RWMutex
+	// +checklocks:mu
+	cert *tls.Certificate
+}
+
+// reload loads the certificate and key files and returns the SHA256 fingerprint of the certificate.
+// On failure the previously loaded certificate remains in use.
+func (r *tlsCertificateReloader) reload() (string, error) {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to load TLS certificate")
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+
+	fingerprint := sha256.Sum256(cert.Certificate[0])
+
+	return hex.EncodeToString(fingerprint[:]), nil
+}
+
+// certificatePEM returns the PEM-encoded server certificate currently in use.
+func (r *tlsCertificateReloader) certificatePEM() []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: r.cert.Certificate[0]})
+}
+
+func (r *tlsCertificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
 func (c *commandServerStart) showServerUIPrompt(ctx context.Context) {
 	if c.serverStartUI {
 		log(ctx).Info("Open the address above in a web browser to use the UI.")
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/tlsutil"
+)
+
+func TestTLSCertificateReloader(t *testing.T) {
+	ctx := testlogging.Context(t)
+	dir := t.TempDir()
+
+	r := &tlsCertificateReloader{
+		certFile: filepath.Join(dir, "cert.pem"),
+		keyFile:  filepath.Join(dir, "key.pem"),
+	}
+
+	writeCert := func() string {
+		t.Helper()
+
+		cert, key, err := tlsutil.GenerateServerCertificate(ctx, 2048, time.Hour, []string{"127.0.0.1"})
+		require.NoError(t, err)
+		require.NoError(t, tlsutil.WriteCertificateToFile(r.certFile, cert))
+		require.NoError(t, tlsutil.WritePrivateKeyToFile(r.keyFile, key))
+
+		fp := sha256.Sum256(cert.Raw)
+
+		return hex.EncodeToString(fp[:])
+	}
+
+	currentFingerprint := func() string {
+		t.Helper()
+
+		c, err := r.getCertificate(nil)
+		require.NoError(t, err)
+
+		fp := sha256.Sum256(c.Certificate[0])
+
+		return hex.EncodeToString(fp[:])
+	}
+
+	_, err := r.reload()
+	require.Error(t, err)
+
+	fp1 := writeCert()
+
+	got, err := r.reload()
+	require.NoError(t, err)
+	require.Equal(t, fp1, got)
+	require.Equal(t, fp1, currentFingerprint())
+
+	fp2 := writeCert()
+	require.NotEqual(t, fp1, fp2)
+
+	// certificate is not replaced until reloaded.
+	require.Equal(t, fp1, currentFingerprint())
+
+	got, err = r.reload()
+	require.NoError(t, err)
+	require.Equal(t, fp2, got)
+	require.Equal(t, fp2, currentFingerprint())
+
+	certPEM, err := os.ReadFile(r.certFile)
+	require.NoError(t, err)
+	require.Equal(t, certPEM, r.certificatePEM())
+
+	// failed reload keeps the previous certificate.
+	require.NoError(t, os.WriteFile(r.keyFile, []byte("garbage"), 0o600))
+
+	_, err = r.reload()
+	require.Error(t, err)
+	require.Equal(t, fp2, currentFingerprint())
+}
@@ -0,0 +1,67 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestServerStartTLSGenerate(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	startServer := func(extraArgs ...string) testutil.ServerParameters {
+		t.Helper()
+
+		var sp testutil.ServerParameters
+
+		wait, kill := env.RunAndProcessStderr(t, sp.ProcessOutput, append([]string{
+			"server", "start",
+			"--address=localhost:0",
+			"--random-server-control-password",
+			"--shutdown-grace-period", "100ms",
+			"--tls-generate-rsa-bits=2048", // use shorter key size to speed up generation
+		}, extraArgs...)...)
+
+		kill()
+		wait()
+
+		require.NotEmpty(t, sp.SHA256Fingerprint)
+
+		return sp
+	}
+
+	sp1 := startServer("--tls-generate")
+
+	// certificate and key are stored next to the config file.
+	require.FileExists(t, filepath.Join(env.ConfigDir, ".kopia.config.tls-cert.pem"))
+	require.FileExists(t, filepath.Join(env.ConfigDir, ".kopia.config.tls-key.pem"))
+
+	// and reused on subsequent starts.
+	sp2 := startServer("--tls-generate")
+	require.Equal(t, sp1.SHA256Fingerprint, sp2.SHA256Fingerprint)
+
+	// hidden --tls-generate-cert is an alias of --tls-generate.
+	sp2 = startServer("--tls-generate-cert")
+	require.Equal(t, sp1.SHA256Fingerprint, sp2.SHA256Fingerprint)
+
+	// explicitly provided paths are used instead.
+	certFile := filepath.Join(env.ConfigDir, "custom.cert")
+	keyFile := filepath.Join(env.ConfigDir, "custom.key")
+
+	sp3 := startServer("--tls-generate", "--tls-cert", certFile, "--tls-key", keyFile)
+	require.NotEqual(t, sp1.SHA256Fingerprint, sp3.SHA256Fingerprint)
+	require.FileExists(t, certFile)
+
+	// refuse to overwrite a partial certificate/key pair.
+	require.NoError(t, os.Remove(keyFile))
+	env.RunAndExpectFailure(t, "server", "start", "--address=localhost:0", "--random-server-control-password",
+		"--tls-generate", "--tls-cert", certFile, "--tls-key", keyFile)
+
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 
 	"github.com/pkg/errors"
@@ -11,17 +12,23 @@
 )
 
 type commandShow struct {
-	path string
+	path            string
+	previewBytes    int
+	previewParallel int
 
 	out textOutput
+	jo  jsonOutput
 }
 
 func (c *commandShow) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("show", "Displays the contents of a repository object.").Alias("cat")
 	cmd.Arg("object-path", "Path").Required().StringVar(&c.path)
+	cmd.Flag("preview-bytes", "After the object, show up to N first bytes of each content referenced by it or by entries of the directory it represents").PlaceHolder("N").IntVar(&c.previewBytes)
+	cmd.Flag("preview-parallel", "Number of contents to fetch in parallel for previews").Default("8").IntVar(&c.previewParallel)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 
 	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
 }
 
 func (c *commandShow) run(ctx context.Context, rep repo.Repository) error {
@@ -37,5 +44,36 @@ func (c *commandShow) run(ctx context.Context, rep repo.Repository) error {
 
 	defer r.Close() //nolint:errcheck
 
-	return errors.Wrap(iocopy.JustCopy(c.out.stdout(), r), "unable to copy data")
+	if c.previewBytes <= 0 {
+		return errors.Wrap(iocopy.JustCopy(c.out.stdout(), r), "unable to copy data")
+	}
+
+	br := bufio.NewReader(r)
+
+	if !c.jo.jsonOutput {
+		// peek before copying, so that the beginning of the object is still available to detect directories.
+		isDir := isDirectoryManifest(br)
+
+		if err := iocopy.JustCopy(c.out.stdout(), br); err != nil {
+			return errors.Wrap(err, "unable to copy data")
+		}
+
+		previews, err := c.contentPreviews(ctx, rep, oid, isDir)
+		if err != nil {
+			return err
+		}
+
+		c.printContentPreviews(previews)
+
+		return nil
+	}
+
+	previews, err := c.contentPreviews(ctx, rep, oid, isDirectoryManifest(br))
+	if err != nil {
+		return err
+	}
+
+	c.out.printStdout("%s\n", c.jo.jsonBytes(showPreviewJSON{ObjectID: oid, Previews: previews}))
+
+	return nil
 }
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// maxPreviewBytes is the maximum number of bytes of each content shown by 'show --preview-bytes'.
+const maxPreviewBytes = 4096
+
+// directoryManifestPrefix is the beginning of serialized directory manifests.
+const directoryManifestPrefix = `{"stream":"` + snapshotfs.DirectoryStreamType + `"`
+
+// contentPreview holds the first bytes of a content referenced by the displayed object.
+type contentPreview struct {
+	// name of the directory entry referencing the content, empty for the displayed object itself.
+	Name      string     `json:"name,omitempty"`
+	ObjectID  object.ID  `json:"objectID"`
+	ContentID content.ID `json:"contentID"`
+	Length    int        `json:"length"`
+	Preview   []byte     `json:"preview"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type showPreviewJSON struct {
+	ObjectID object.ID        `json:"objectID"`
+	Previews []contentPreview `json:"previews"`
+}
+
+// isDirectoryManifest returns true if the object read by the provided reader is a directory manifest,
+// without consuming any data.
+func isDirectoryManifest(br *bufio.Reader) bool {
+	b, _ := br.Peek(len(directoryManifestPrefix))
+
+	return string(b) == directoryManifestPrefix
+}
+
+// contentPreviews returns previews of contents of the provided object or, if it is a directory,
+// of contents of objects referenced by its entries.
+func (c *commandShow) contentPreviews(ctx context.Context, rep repo.Repository, oid object.ID, isDir bool) ([]contentPreview, error) {
+	dr, ok := rep.(repo.DirectRepository)
+	if !ok {
+		return nil, errors.New("content previews require direct repository connection")
+	}
+
+	n := min(c.previewBytes, maxPreviewBytes)
+	if n < c.previewBytes {
+		log(ctx).Infof("Limiting previews to %v bytes.", n)
+	}
+
+	type namedObject struct {
+		name string
+		oid  object.ID
+	}
+
+	objects := []namedObject{{"", oid}}
+
+	if isDir {
+		dm, err := readDirManifest(ctx, rep, oid)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = nil
+
+		for _, de := range dm.Entries {
+			objects = append(objects, namedObject{de.Name, de.ObjectID})
+		}
+	}
+
+	var previews []contentPreview
+
+	for _, o := range objects {
+		cids, err := rep.VerifyObject(ctx, o.oid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error listing contents of %v", o.oid)
+		}
+
+		for _, cid := range cids {
+			previews = append(previews, contentPreview{Name: o.name, ObjectID: o.oid, ContentID: cid})
+		}
+	}
+
+	ch := make(chan *contentPreview, len(previews))
+	for i := range previews {
+		ch <- &previews[i]
+	}
+
+	close(ch)
+
+	var eg errgroup.Group
+
+	for range max(c.previewParallel, 1) {
+		eg.Go(func() error {
+			for p := range ch {
+				b, err := dr.ContentReader().GetContent(ctx, p.ContentID)
+				if err != nil {
+					p.Error = err.Error()
+					continue
+				}
+
+				p.Length = len(b)
+				// copy the preview, so that the rest of the content can be released.
+				p.Preview = bytes.Clone(b[:min(len(b), n)])
+			}
+
+			return nil
+		})
+	}
+
+	//nolint:wrapcheck
+	return previews, eg.Wait()
+}
+
+// readDirManifest reads the directory manifest stored in the provided object.
+func readDirManifest(ctx context.Context, rep repo.Repository, oid object.ID) (*snapshot.DirManifest, error) {
+	r, err := rep.OpenObject(ctx, oid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening directory %v", oid)
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	var dm snapshot.DirManifest
+
+	if err := json.NewDecoder(r).Decode(&dm); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse directory %v", oid)
+	}
+
+	return &dm, nil
+}
+
+func (c *commandShow) printContentPreviews(previews []contentPreview) {
+	c.out.printStdout("\n--- content previews (first %v bytes, hex) ---\n", min(c.previewBytes, maxPreviewBytes))
+
+	for _, p := range previews {
+		var sb strings.Builder
+
+		if p.Name != "" {
+			sb.WriteString(p.Name + " ")
+		}
+
+		sb.WriteString(p.ContentID.String())
+
+		if p.Error != "" {
+			c.out.printStdout("%v: error: %v\n", sb.String(), p.Error)
+			continue
+		}
+
+		c.out.printStdout("%v (%v bytes): %v\n", sb.String(), p.Length, hex.EncodeToString(p.Preview))
+	}
+}
@@ -0,0 +1,63 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestShowPreviewBytes(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte("hello world"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var manifests []cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "list", "--json"), &manifests)
+	require.Len(t, manifests, 1)
+
+	rootID := manifests[0].RootObjectID().String()
+
+	// without previews, the object is shown unchanged.
+	lines := env.RunAndExpectSuccess(t, "show", rootID)
+	require.NotContains(t, strings.Join(lines, "\n"), "content previews")
+
+	lines = env.RunAndExpectSuccess(t, "show", rootID, "--preview-bytes=5")
+	require.Contains(t, lines, "--- content previews (first 5 bytes, hex) ---")
+	require.Contains(t, mustGetLineContaining(t, lines, "file1.txt "), "(11 bytes): 68656c6c6f")
+
+	// previews of a file are shown after its contents.
+	lines = env.RunAndExpectSuccess(t, "show", rootID+"/file1.txt", "--preview-bytes=5")
+	require.Equal(t, "hello world", lines[0])
+	require.NotContains(t, mustGetLineContaining(t, lines, "(11 bytes): 68656c6c6f"), "file1.txt")
+
+	var out struct {
+		ObjectID string `json:"objectID"`
+		Previews []struct {
+			Name    string `json:"name"`
+			Length  int    `json:"length"`
+			Preview []byte `json:"preview"`
+		} `json:"previews"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(strings.Join(env.RunAndExpectSuccess(t, "show", rootID, "--preview-bytes=5", "--json"), "\n")), &out))
+	require.Equal(t, rootID, out.ObjectID)
+	require.Len(t, out.Previews, 1)
+	require.Equal(t, "file1.txt", out.Previews[0].Name)
+	require.Equal(t, 11, out.Previews[0].Length)
+	require.Equal(t, []byte("hello"), out.Previews[0].Preview)
+}
@@ -42,6 +42,7 @@ type commandSnapshotCreate struct {
 	snapshotCreateStdinFileName           string
 	snapshotCreateCheckpointUploadLimitMB int64
 	snapshotCreateTags                    []string
+	snapshotCreateCaptureXattrs           bool
 	flushPerSource                        bool
 	sourceOverride                        string
 	sendSnapshotReport                    bool
@@ -72,7 +73,8 @@ func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
 	cmd.Flag("end-time", "Override snapshot end timestamp.").StringVar(&c.snapshotCreateEndTime)
 	cmd.Flag("force-enable-actions", "Enable snapshot actions even if globally disabled on this client").Hidden().BoolVar(&c.snapshotCreateForceEnableActions)
 	cmd.Flag("force-disable-actions", "Disable snapshot actions even if globally enabled on this client").Hidden().BoolVar(&c.snapshotCreateForceDisableActions)
-	cmd.Flag("stdin-file", "File path to be used for stdin data snapshot.").StringVar(&c.snapshotCreateStdinFileName)
+	cmd.Flag("capture-xattrs", "Capture extended attributes and ACLs of files and directories.").Envar(svc.EnvName("KOPIA_SNAPSHOT_CAPTURE_XATTRS")).BoolVar(&c.snapshotCreateCaptureXattrs)
+	cmd.Flag("stdin-file", "File name under which data read from stdin is stored, requires a single source.").StringVar(&c.snapshotCreateStdinFileName)
 	cmd.Flag("tags", "Tags applied on the snapshot. Must be provided in the <key>:<value> format.").StringsVar(&c.snapshotCreateTags)
 	cmd.Flag("pin", "Create a pinned snapshot that will not expire automatically").StringsVar(&c.pins)
 	cmd.Flag("flush-per-source", "Flush writes at the end of each source").Hidden().BoolVar(&c.flushPerSource)
@@ -118,6 +120,10 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 		return errors.New("no snapshot sources")
 	}
 
+	if err := validateStdinFileName(c.snapshotCreateStdinFileName, sources); err != nil {
+		return err
+	}
+
 	if err := validateStartEndTime(c.snapshotCreateStartTime, c.snapshotCreateEndTime); err != nil {
 		return err
 	}
@@ -177,6 +183,23 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 	return errors.Errorf("encountered %v errors:\n%v", len(finalErrors), strings.Join(finalErrors, "\n"))
 }
 
+// validateStdinFileName ensures that stdin data, which can only be consumed once, is stored in exactly one snapshot.
+func validateStdinFileName(name string, sources []string) error {
+	if name == "" {
+		return nil
+	}
+
+	if len(sources) != 1 {
+		return errors.New("--stdin-file requires exactly one source")
+	}
+
+	if strings.ContainsAny(name, `/\`) {
+		return errors.Errorf("--stdin-file must be a file name, not a path: %q", name)
+	}
+
+	return nil
+}
+
 func getTags(tagStrings []string) (map[string]string, error) {
 	numberOfPartsInTagString := 2
 	// tagKeyPrefix is the prefix for user defined tag keys.
@@ -253,6 +276,7 @@ func (c *commandSnapshotCreate) setupUploader(rep repo.RepositoryWriter) *snapsh
 	u.ParallelUploads = c.snapshotCreateParallelUploads
 
 	u.FailFast = c.snapshotCreateFailFast
+	u.CaptureExtendedAttributes = c.snapshotCreateCaptureXattrs
 	u.Progress = c.svc.getProgress()
 
 	return u
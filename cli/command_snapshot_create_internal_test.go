@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStdinFileName(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		sources []string
+		wantErr string
+	}{
+		{name: "not provided", sources: []string{"a", "b"}},
+		{name: "single source", file: "stream-file", sources: []string{"a"}},
+		{name: "multiple sources", file: "stream-file", sources: []string{"a", "b"}, wantErr: "exactly one source"},
+		{name: "forward slash", file: "subdir/stream-file", sources: []string{"a"}, wantErr: "not a path"},
+		{name: "backslash", file: `subdir\stream-file`, sources: []string{"a"}, wantErr: "not a path"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateStdinFileName(tc.file, tc.sources)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -19,7 +19,6 @@
 type commandSnapshotEstimate struct {
 	snapshotEstimateSource      string
 	snapshotEstimateShowFiles   bool
-	snapshotEstimateQuiet       bool
 	snapshotEstimateUploadSpeed float64
 	maxExamplesPerBucket        int
 
@@ -30,7 +29,8 @@ func (c *commandSnapshotEstimate) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("estimate", "Estimate the snapshot size and upload time.")
 	cmd.Arg("source", "File or directory to analyze.").Required().ExistingFileOrDirVar(&c.snapshotEstimateSource)
 	cmd.Flag("show-files", "Show files").BoolVar(&c.snapshotEstimateShowFiles)
-	cmd.Flag("quiet", "Do not display scanning progress").Short('q').BoolVar(&c.snapshotEstimateQuiet)
+	// '--quiet' and '-q' that used to be defined here are now accepted by the global flag of the same name,
+	// which suppresses scanning progress the same way (kingpin does not allow redefining it here).
 	cmd.Flag("upload-speed", "Upload speed to use for estimation").Default("10").PlaceHolder("mbit/s").Float64Var(&c.snapshotEstimateUploadSpeed)
 	cmd.Flag("max-examples-per-bucket", "Max examples per bucket").Default("10").IntVar(&c.maxExamplesPerBucket)
 	cmd.Action(svc.repositoryReaderAction(c.run))
@@ -42,13 +42,10 @@ type estimateProgress struct {
 	included     snapshotfs.SampleBuckets
 	excluded     snapshotfs.SampleBuckets
 	excludedDirs []string
-	quiet        bool
 }
 
 func (ep *estimateProgress) Processing(ctx context.Context, dirname string) {
-	if !ep.quiet {
-		log(ctx).Infof("Analyzing %v...", dirname)
-	}
+	log(ctx).Infof("Analyzing %v...", dirname)
 }
 
 func (ep *estimateProgress) Error(ctx context.Context, filename string, err error, isIgnored bool) {
@@ -92,8 +89,6 @@ func (c *commandSnapshotEstimate) run(ctx context.Context, rep repo.Repository)
 
 	var ep estimateProgress
 
-	ep.quiet = c.snapshotEstimateQuiet
-
 	policyTree, err := policy.TreeForSource(ctx, rep, sourceInfo)
 	if err != nil {
 		return errors.Wrapf(err, "error creating policy tree for %v", sourceInfo)
@@ -8,6 +8,7 @@
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/internal/logfile"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -46,6 +47,31 @@ func TestSnapshotEstimate(t *testing.T) {
 	require.Contains(t, out, "Snapshot excludes 1 directories. Examples:")
 }
 
+func TestSnapshotEstimateQuiet(t *testing.T) {
+	runner := testenv.NewInProcRunner(t)
+	runner.CustomizeApp = logfile.Attach
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1.txt"), []byte{1, 2, 3}, 0o600))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "snapshot", "estimate", dir, "--no-auto-maintenance", "--log-dir", testutil.TempDirectory(t))
+	mustGetLineContaining(t, stderr, "Analyzing")
+
+	// '--quiet' and '-q' of the estimate command map to the global flag.
+	for _, flag := range []string{"--quiet", "-q"} {
+		out, stderr := env.RunAndExpectSuccessWithErrOut(t, "snapshot", "estimate", dir, flag, "--no-auto-maintenance", "--log-dir", testutil.TempDirectory(t))
+		require.Contains(t, out, "Snapshot includes 1 file(s), total size 3 B")
+
+		for _, l := range stderr {
+			require.NotContains(t, l, "Analyzing")
+		}
+	}
+}
+
 func TestSnapshotEstimate_NotADirectory(t *testing.T) {
 	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
 
@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -21,6 +23,13 @@
 	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
+const (
+	snapshotListSortTime   = "time"
+	snapshotListSortSize   = "size"
+	snapshotListSortSource = "source"
+	snapshotListSortFiles  = "files"
+)
+
 type commandSnapshotList struct {
 	snapshotListPath                 string
 	snapshotListIncludeIncomplete    bool
@@ -33,9 +42,12 @@ type commandSnapshotList struct {
 	snapshotListShowIdentical        bool
 	snapshotListShowAll              bool
 	maxResultsPerPath                int
-	snapshotListTags                 []string
+	tagFilter                        snapshotTagFilter
 	storageStats                     bool
 	reverseSort                      bool
+	sortBy                           string
+	limit                            int
+	showSourceSizes                  bool
 
 	jo  jsonOutput
 	out textOutput
@@ -46,17 +58,20 @@ func (c *commandSnapshotList) setup(svc appServices, parent commandParent) {
 	cmd.Arg("source", "File or directory to show history of.").StringVar(&c.snapshotListPath)
 	cmd.Flag("incomplete", "Include incomplete.").Short('i').BoolVar(&c.snapshotListIncludeIncomplete)
 	cmd.Flag("human-readable", "Show human-readable units").Default("true").BoolVar(&c.snapshotListShowHumanReadable)
-	cmd.Flag("delta", "Include deltas.").Short('d').BoolVar(&c.snapshotListShowDelta)
+	cmd.Flag("delta", "Include deltas from the previous snapshot, only when sorted by time in chronological order.").Short('d').BoolVar(&c.snapshotListShowDelta)
 	cmd.Flag("manifest-id", "Include manifest item ID.").Short('m').BoolVar(&c.snapshotListShowItemID)
 	cmd.Flag("retention", "Include retention reasons.").Default("true").BoolVar(&c.snapshotListShowRetentionReasons)
 	cmd.Flag("mtime", "Include file mod time").BoolVar(&c.snapshotListShowModTime)
 	cmd.Flag("owner", "Include owner").BoolVar(&c.shapshotListShowOwner)
 	cmd.Flag("show-identical", "Show identical snapshots").Short('l').BoolVar(&c.snapshotListShowIdentical)
 	cmd.Flag("storage-stats", "Compute and show storage statistics").BoolVar(&c.storageStats)
+	cmd.Flag("size", "Compute and show logical and unique size of each source instead of listing snapshots").BoolVar(&c.showSourceSizes)
 	cmd.Flag("reverse", "Reverse sort order").BoolVar(&c.reverseSort)
+	cmd.Flag("sort", "Sort snapshots of each source by start time, total size, source or number of files, ties are broken by start time, source and manifest ID").Default(snapshotListSortTime).EnumVar(&c.sortBy, snapshotListSortTime, snapshotListSortSize, snapshotListSortSource, snapshotListSortFiles)
+	cmd.Flag("limit", "Maximum number of snapshots to list in total, after sorting (0 = unlimited)").IntVar(&c.limit)
 	cmd.Flag("all", "Show all snapshots (not just current username/host)").Short('a').BoolVar(&c.snapshotListShowAll)
-	cmd.Flag("max-results", "Maximum number of entries per source.").Short('n').IntVar(&c.maxResultsPerPath)
-	cmd.Flag("tags", "Tag filters to apply on the list items. Must be provided in the <key>:<value> format.").StringsVar(&c.snapshotListTags)
+	cmd.Flag("max-results", "Maximum number of entries per source, the most recent ones when sorted by time, otherwise the first ones in sort order.").Short('n').IntVar(&c.maxResultsPerPath)
+	c.tagFilter.setup(cmd)
 	c.jo.setup(svc, cmd)
 	c.out.setup(svc)
 	cmd.Action(svc.repositoryReaderAction(c.run))
@@ -120,7 +135,7 @@ func findManifestIDs(ctx context.Context, rep repo.Repository, source string, ta
 }
 
 func (c *commandSnapshotList) run(ctx context.Context, rep repo.Repository) error {
-	tags, err := getTags(c.snapshotListTags)
+	tags, err := c.tagFilter.labels()
 	if err != nil {
 		return err
 	}
@@ -135,6 +150,19 @@ func (c *commandSnapshotList) run(ctx context.Context, rep repo.Repository) erro
 		return errors.Wrap(err, "unable to load snapshots")
 	}
 
+	manifests, err = c.tagFilter.filter(manifests)
+	if err != nil {
+		return err
+	}
+
+	if c.showSourceSizes {
+		return c.outputSourceSizes(ctx, rep, manifests)
+	}
+
+	if c.limit > 0 {
+		manifests = c.limitSnapshots(rep, manifests)
+	}
+
 	if c.jo.jsonOutput {
 		return c.outputJSON(ctx, rep, manifests)
 	}
@@ -142,6 +170,106 @@ func (c *commandSnapshotList) run(ctx context.Context, rep repo.Repository) erro
 	return c.outputManifestGroups(ctx, rep, manifests, fullPath)
 }
 
+// sortSnapshots returns a copy of the provided manifests sorted according to --sort and --reverse.
+// Ties are broken by start time, then by source and finally by manifest ID, so the order is deterministic.
+func (c *commandSnapshotList) sortSnapshots(manifests []*snapshot.Manifest) []*snapshot.Manifest {
+	result := append([]*snapshot.Manifest(nil), manifests...)
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if c.reverseSort {
+			return c.compareSnapshots(result[j], result[i]) < 0
+		}
+
+		return c.compareSnapshots(result[i], result[j]) < 0
+	})
+
+	return result
+}
+
+func (c *commandSnapshotList) compareSnapshots(a, b *snapshot.Manifest) int {
+	var v int
+
+	switch c.sortBy {
+	case snapshotListSortSize:
+		v = cmp.Compare(a.Stats.TotalFileSize, b.Stats.TotalFileSize)
+	case snapshotListSortFiles:
+		v = cmp.Compare(snapshotFileCount(a), snapshotFileCount(b))
+	case snapshotListSortSource:
+		v = strings.Compare(a.Source.String(), b.Source.String())
+	}
+
+	if v != 0 {
+		return v
+	}
+
+	if v = cmp.Compare(a.StartTime, b.StartTime); v != 0 {
+		return v
+	}
+
+	if v = strings.Compare(a.Source.String(), b.Source.String()); v != 0 {
+		return v
+	}
+
+	return strings.Compare(string(a.ID), string(b.ID))
+}
+
+// snapshotFileCount returns the total number of files in the snapshot, preferring the root directory summary
+// since manifest stats only count files that were not cached.
+func snapshotFileCount(m *snapshot.Manifest) int64 {
+	if m.RootEntry != nil && m.RootEntry.DirSummary != nil {
+		return m.RootEntry.DirSummary.TotalFileCount
+	}
+
+	return int64(atomic.LoadInt32(&m.Stats.TotalFileCount))
+}
+
+// limitSnapshots sorts the manifests and returns the first --limit of them which would be listed.
+func (c *commandSnapshotList) limitSnapshots(rep repo.Repository, manifests []*snapshot.Manifest) []*snapshot.Manifest {
+	var result []*snapshot.Manifest
+
+	for _, m := range c.sortSnapshots(manifests) {
+		if len(result) >= c.limit {
+			break
+		}
+
+		// skip snapshots which would not be listed in text output anyway.
+		if !c.jo.jsonOutput {
+			if m.IncompleteReason != "" && !c.snapshotListIncludeIncomplete {
+				continue
+			}
+
+			if !c.shouldOutputSnapshotSource(rep, m.Source) {
+				continue
+			}
+		}
+
+		result = append(result, m)
+	}
+
+	return result
+}
+
+// limitPerSource returns up to --max-results of the provided snapshots of a single source, which are sorted by sortSnapshots.
+// When sorting by time the most recent snapshots are kept in either direction, otherwise the first ones in the chosen order.
+func (c *commandSnapshotList) limitPerSource(sorted []*snapshot.Manifest) []*snapshot.Manifest {
+	n := c.maxResultsPerPath
+	if n <= 0 || len(sorted) <= n {
+		return sorted
+	}
+
+	if c.sortBy == snapshotListSortTime && !c.reverseSort {
+		return sorted[len(sorted)-n:]
+	}
+
+	return sorted[:n]
+}
+
+// showDelta returns true if deltas from the previously listed snapshot should be shown, which is only
+// meaningful when snapshots are listed in chronological order.
+func (c *commandSnapshotList) showDelta() bool {
+	return c.snapshotListShowDelta && c.sortBy == snapshotListSortTime && !c.reverseSort
+}
+
 // SnapshotManifest defines the JSON output for the CLI snapshot commands.
 type SnapshotManifest struct {
 	*snapshot.Manifest
@@ -155,11 +283,7 @@ func (c *commandSnapshotList) outputJSON(ctx context.Context, rep repo.Repositor
 	defer jl.end()
 
 	for _, snapshotGroup := range snapshot.GroupBySource(manifests) {
-		snapshotGroup = snapshot.SortByTime(snapshotGroup, c.reverseSort)
-
-		if c.maxResultsPerPath > 0 && len(snapshotGroup) > c.maxResultsPerPath {
-			snapshotGroup = snapshotGroup[len(snapshotGroup)-c.maxResultsPerPath:]
-		}
+		snapshotGroup = c.limitPerSource(c.sortSnapshots(snapshotGroup))
 
 		if c.snapshotListShowRetentionReasons {
 			src := snapshotGroup[0].Source
@@ -268,10 +392,7 @@ func (c *commandSnapshotList) iterateSnapshotsMaybeWithStorageStats(ctx context.
 func (c *commandSnapshotList) outputManifestFromSingleSource(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest, parts []string) error {
 	var lastTotalFileSize int64
 
-	manifests = snapshot.SortByTime(manifests, c.reverseSort)
-	if c.maxResultsPerPath > 0 && len(manifests) > c.maxResultsPerPath {
-		manifests = manifests[len(manifests)-c.maxResultsPerPath:]
-	}
+	manifests = c.limitPerSource(c.sortSnapshots(manifests))
 
 	var rows []*snapshotListRow
 
@@ -424,8 +545,8 @@ func (c *commandSnapshotList) entryBits(ctx context.Context, m *snapshot.Manifes
 		bits = append(bits, "manifest:"+string(m.ID))
 	}
 
-	if c.snapshotListShowDelta {
-		bits = append(bits, deltaBytes(ent.Size()-lastTotalFileSize))
+	if c.showDelta() {
+		bits = append(bits, deltaBytes(totalBytes-lastTotalFileSize))
 	}
 
 	if summary != nil {
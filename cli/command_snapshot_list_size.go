@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// snapshotsSize describes how much storage is used by a set of snapshots.
+type snapshotsSize struct {
+	SnapshotCount int `json:"snapshotCount"`
+	// total size of files in the latest snapshot
+	LogicalBytes int64 `json:"logicalBytes"`
+	// packed size of all contents referenced by the snapshots
+	StoredBytes int64 `json:"storedBytes"`
+	// packed size of contents not referenced by snapshots of any other listed source
+	UniqueBytes int64 `json:"uniqueBytes"`
+}
+
+// sourceSize describes how much storage is used by snapshots of a single source.
+type sourceSize struct {
+	Source snapshot.SourceInfo `json:"source"`
+	snapshotsSize
+}
+
+type snapshotSizesJSON struct {
+	Sources []sourceSize  `json:"sources"`
+	Total   snapshotsSize `json:"total"`
+}
+
+// sourceSizeCalculator computes sizes of snapshot sources by collecting the set of contents
+// referenced by each source and then attributing packed sizes of contents found in the index.
+type sourceSizeCalculator struct {
+	rep repo.DirectRepository
+
+	totalStoredBytes int64
+}
+
+func newSourceSizeCalculator(rep repo.DirectRepository) *sourceSizeCalculator {
+	return &sourceSizeCalculator{rep: rep}
+}
+
+// calculate returns sizes of all sources in the provided snapshot groups.
+func (s *sourceSizeCalculator) calculate(ctx context.Context, groups [][]*snapshot.Manifest) ([]sourceSize, error) {
+	var (
+		result         []sourceSize
+		sourceContents []*bigmap.Set
+	)
+
+	defer func() {
+		for _, sc := range sourceContents {
+			sc.Close(ctx)
+		}
+	}()
+
+	for _, g := range groups {
+		g = snapshot.SortByTime(g, false)
+
+		log(ctx).Infof("Computing size of %v...", g[0].Source)
+
+		contents, err := bigmap.NewSet(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create new set")
+		}
+
+		sourceContents = append(sourceContents, contents)
+
+		if err := s.findSourceContents(ctx, g, contents); err != nil {
+			return nil, err
+		}
+
+		result = append(result, sourceSize{
+			Source: g[0].Source,
+			snapshotsSize: snapshotsSize{
+				SnapshotCount: len(g),
+				LogicalBytes:  atomic.LoadInt64(&g[len(g)-1].Stats.TotalFileSize),
+			},
+		})
+	}
+
+	err := s.rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		var (
+			cidbuf [128]byte
+			owner  = -1
+			owners = 0
+		)
+
+		key := ci.ContentID.Append(cidbuf[:0])
+
+		for i, sc := range sourceContents {
+			if !sc.Contains(key) {
+				continue
+			}
+
+			result[i].StoredBytes += int64(ci.PackedLength)
+			owner = i
+			owners++
+		}
+
+		if owners > 0 {
+			s.totalStoredBytes += int64(ci.PackedLength)
+		}
+
+		if owners == 1 {
+			result[owner].UniqueBytes += int64(ci.PackedLength)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	return result, nil
+}
+
+// findSourceContents adds IDs of all contents referenced by the provided snapshots to the set.
+func (s *sourceSizeCalculator) findSourceContents(ctx context.Context, snapshots []*snapshot.Manifest, contents *bigmap.Set) error {
+	// objects shared between snapshots of the same source are only processed once by the walker.
+	w, err := snapshotfs.NewTreeWalker(ctx, snapshotfs.TreeWalkerOptions{
+		EntryCallback: func(ctx context.Context, _ fs.Entry, oid object.ID, _ string) error {
+			return s.addBackingContents(ctx, oid, contents)
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create tree walker")
+	}
+
+	defer w.Close(ctx)
+
+	for _, m := range snapshots {
+		root, err := snapshotfs.SnapshotRoot(s.rep, m)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get root of snapshot %v", m.ID)
+		}
+
+		if err := w.Process(ctx, root, ""); err != nil {
+			return errors.Wrapf(err, "error walking snapshot %v", m.ID)
+		}
+	}
+
+	return nil
+}
+
+// addBackingContents adds IDs of contents the object is composed of to the set, reading index objects as needed.
+func (s *sourceSizeCalculator) addBackingContents(ctx context.Context, oid object.ID, contents *bigmap.Set) error {
+	if cid, _, ok := oid.ContentID(); ok {
+		var cidbuf [128]byte
+
+		contents.Put(ctx, cid.Append(cidbuf[:0]))
+
+		return nil
+	}
+
+	indexObjectID, ok := oid.IndexObjectID()
+	if !ok {
+		return errors.Errorf("unrecognized object type: %v", oid)
+	}
+
+	if err := s.addBackingContents(ctx, indexObjectID, contents); err != nil {
+		return err
+	}
+
+	entries, err := object.LoadIndexObject(ctx, indexContentReader{s.rep.ContentReader(), s.rep}, indexObjectID)
+	if err != nil {
+		return errors.Wrapf(err, "error loading index object %v", indexObjectID)
+	}
+
+	for _, e := range entries {
+		if err := s.addBackingContents(ctx, e.Object, contents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexContentReader adds prefetching from the repository to content.Reader, as needed for reading index objects.
+type indexContentReader struct {
+	content.Reader
+
+	rep repo.Repository
+}
+
+func (r indexContentReader) PrefetchContents(ctx context.Context, contentIDs []content.ID, hint string) []content.ID {
+	return r.rep.PrefetchContents(ctx, contentIDs, hint)
+}
+
+func (c *commandSnapshotList) outputSourceSizes(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest) error {
+	var groups [][]*snapshot.Manifest
+
+	for _, g := range snapshot.GroupBySource(manifests) {
+		if !c.shouldOutputSnapshotSource(rep, g[0].Source) {
+			continue
+		}
+
+		var complete []*snapshot.Manifest
+
+		for _, m := range g {
+			if m.IncompleteReason == "" || c.snapshotListIncludeIncomplete {
+				complete = append(complete, m)
+			}
+		}
+
+		if len(complete) > 0 {
+			groups = append(groups, complete)
+		}
+	}
+
+	dr, ok := rep.(repo.DirectRepository)
+	if !ok {
+		return errors.New("computing source sizes requires a direct repository connection")
+	}
+
+	calc := newSourceSizeCalculator(dr)
+
+	sizes, err := calc.calculate(ctx, groups)
+	if err != nil {
+		return err
+	}
+
+	total := snapshotsSize{StoredBytes: calc.totalStoredBytes}
+
+	for _, ss := range sizes {
+		total.SnapshotCount += ss.SnapshotCount
+		total.LogicalBytes += ss.LogicalBytes
+		total.UniqueBytes += ss.UniqueBytes
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(snapshotSizesJSON{Sources: sizes, Total: total}))
+		return nil
+	}
+
+	for _, ss := range sizes {
+		c.out.printStdout("%v\n  snapshots:%v logical:%v stored:%v unique:%v\n",
+			ss.Source,
+			ss.SnapshotCount,
+			maybeHumanReadableBytes(c.snapshotListShowHumanReadable, ss.LogicalBytes),
+			maybeHumanReadableBytes(c.snapshotListShowHumanReadable, ss.StoredBytes),
+			maybeHumanReadableBytes(c.snapshotListShowHumanReadable, ss.UniqueBytes))
+	}
+
+	c.out.printStdout("\nTotal: snapshots:%v logical:%v stored:%v unique:%v\n",
+		total.SnapshotCount,
+		maybeHumanReadableBytes(c.snapshotListShowHumanReadable, total.LogicalBytes),
+		maybeHumanReadableBytes(c.snapshotListShowHumanReadable, total.StoredBytes),
+		maybeHumanReadableBytes(c.snapshotListShowHumanReadable, total.UniqueBytes))
+
+	return nil
+}
@@ -1,14 +1,19 @@
 package cli_test
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/cli"
 	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/tests/testenv"
 )
 
@@ -122,3 +127,169 @@ func TestSnapshotListWithSameFileInMultipleSnapshots(t *testing.T) {
 		filepath.Join(srcdir, "a", "b", "c", "d", "e.txt"),
 	}, sps)
 }
+
+func TestSnapshotListSize(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir1 := testutil.TempDirectory(t)
+	dir2 := testutil.TempDirectory(t)
+
+	shared := bytes.Repeat([]byte("shared"), 1000)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir1, "shared"), shared, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir2, "shared"), shared, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir1)
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir2)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir1, "unique"), bytes.Repeat([]byte("unique"), 500), 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir1)
+
+	var sizes struct {
+		Sources []struct {
+			Source        snapshot.SourceInfo `json:"source"`
+			SnapshotCount int                 `json:"snapshotCount"`
+			LogicalBytes  int64               `json:"logicalBytes"`
+			StoredBytes   int64               `json:"storedBytes"`
+			UniqueBytes   int64               `json:"uniqueBytes"`
+		} `json:"sources"`
+		Total struct {
+			SnapshotCount int   `json:"snapshotCount"`
+			LogicalBytes  int64 `json:"logicalBytes"`
+			StoredBytes   int64 `json:"storedBytes"`
+			UniqueBytes   int64 `json:"uniqueBytes"`
+		} `json:"total"`
+	}
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", "--size", "--json"), &sizes)
+	require.Len(t, sizes.Sources, 2)
+
+	byPath := map[string]int{}
+	for i, s := range sizes.Sources {
+		byPath[s.Source.Path] = i
+	}
+
+	s1 := sizes.Sources[byPath[dir1]]
+	s2 := sizes.Sources[byPath[dir2]]
+
+	require.Equal(t, 2, s1.SnapshotCount)
+	require.Equal(t, int64(9000), s1.LogicalBytes)
+	require.Equal(t, 1, s2.SnapshotCount)
+	require.Equal(t, int64(6000), s2.LogicalBytes)
+
+	// the only data unique to dir1 is the file that is not present in dir2.
+	require.Greater(t, s1.UniqueBytes, int64(0))
+	require.Less(t, s1.UniqueBytes, s1.StoredBytes)
+	require.Less(t, s2.UniqueBytes, s2.StoredBytes)
+
+	require.Equal(t, 3, sizes.Total.SnapshotCount)
+	require.Equal(t, s1.UniqueBytes+s2.UniqueBytes, sizes.Total.UniqueBytes)
+	require.Less(t, sizes.Total.StoredBytes, s1.StoredBytes+s2.StoredBytes)
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "list", "--size")
+	require.Contains(t, lines[len(lines)-1], "Total: snapshots:3 logical:15 KB")
+}
+
+func TestSnapshotListSizeIndirectObjects(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+
+	// a file large enough to be split into multiple contents referenced from an index object.
+	data := make([]byte, 20<<20)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "large"), data, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	var sizes struct {
+		Sources []struct {
+			Source        snapshot.SourceInfo `json:"source"`
+			SnapshotCount int                 `json:"snapshotCount"`
+			LogicalBytes  int64               `json:"logicalBytes"`
+			StoredBytes   int64               `json:"storedBytes"`
+			UniqueBytes   int64               `json:"uniqueBytes"`
+		} `json:"sources"`
+		Total json.RawMessage `json:"total"`
+	}
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", "--size", "--json"), &sizes)
+	require.Len(t, sizes.Sources, 1)
+	require.GreaterOrEqual(t, sizes.Sources[0].StoredBytes, int64(len(data)))
+	require.Equal(t, sizes.Sources[0].StoredBytes, sizes.Sources[0].UniqueBytes)
+}
+
+func TestSnapshotListSortAndLimit(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "some-file2"), []byte{1, 2, 3}, 0o755))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "some-file3"), []byte{1, 2, 3, 4}, 0o755))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	require.NoError(t, os.Remove(filepath.Join(srcdir, "some-file2")))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	listSizes := func(args ...string) []int64 {
+		t.Helper()
+
+		var snapshots []*cli.SnapshotManifest
+
+		testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, append([]string{"snapshot", "list", "--json"}, args...)...), &snapshots)
+
+		var result []int64
+
+		for _, s := range snapshots {
+			result = append(result, s.Stats.TotalFileSize)
+		}
+
+		return result
+	}
+
+	require.Equal(t, []int64{3, 7, 4}, listSizes())
+	require.Equal(t, []int64{3, 4, 7}, listSizes("--sort=size"))
+	require.Equal(t, []int64{7, 4, 3}, listSizes("--sort=size", "--reverse"))
+	require.Equal(t, []int64{3, 4, 7}, listSizes("--sort=files"))
+	require.Equal(t, []int64{4}, listSizes("--sort=time", "--reverse", "--limit=1"))
+	require.Equal(t, []int64{3, 4}, listSizes("--sort=size", "--limit=2"))
+
+	// --max-results keeps the most recent snapshots when sorting by time, otherwise the first ones in sort order.
+	require.Equal(t, []int64{7, 4}, listSizes("--max-results=2"))
+	require.Equal(t, []int64{4, 7}, listSizes("--sort=time", "--reverse", "--max-results=2"))
+	require.Equal(t, []int64{3, 4}, listSizes("--sort=size", "--max-results=2"))
+	require.Equal(t, []int64{7}, listSizes("--sort=size", "--reverse", "--max-results=1"))
+
+	// deltas are only shown in chronological order.
+	require.Contains(t, strings.Join(e.RunAndExpectSuccess(t, "snapshot", "list", "--delta"), "\n"), "(+4 B)")
+
+	for _, args := range [][]string{{"--sort=size"}, {"--sort=time", "--reverse"}} {
+		require.NotContains(t, strings.Join(e.RunAndExpectSuccess(t, append([]string{"snapshot", "list", "--delta"}, args...)...), "\n"), "(+")
+	}
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "list", "--sort=time", "--reverse", "--limit=2")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[1], " 4 B ")
+	require.Contains(t, lines[2], " 7 B ")
+
+	e.RunAndExpectFailure(t, "snapshot", "list", "--sort=name")
+}
@@ -21,6 +21,7 @@ type commandSnapshotVerify struct {
 	verifyCommandSnapshotIDs    []string
 	verifyCommandAllSources     bool
 	verifyCommandSources        []string
+	tagFilter                   snapshotTagFilter
 	verifyCommandParallel       int
 	verifyCommandFilesPercent   float64
 
@@ -38,6 +39,7 @@ func (c *commandSnapshotVerify) setup(svc appServices, parent commandParent) {
 	cmd.Flag("file-id", "File object IDs to verify").StringsVar(&c.verifyCommandFileObjectIDs)
 	cmd.Flag("all-sources", "Verify all snapshots (DEPRECATED)").Hidden().BoolVar(&c.verifyCommandAllSources)
 	cmd.Flag("sources", "Verify the provided sources").StringsVar(&c.verifyCommandSources)
+	c.tagFilter.setup(cmd)
 	cmd.Flag("parallel", "Parallelization").Default("8").IntVar(&c.verifyCommandParallel)
 	cmd.Flag("file-queue-length", "Queue length for file verification").Default("20000").IntVar(&c.fileQueueLength)
 	cmd.Flag("file-parallelism", "Parallelism for file verification").IntVar(&c.fileParallelism)
@@ -85,7 +87,10 @@ func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) er
 			return err
 		}
 
-		manifests = append(manifests, snapIDManifests...)
+		manifests, err = c.tagFilter.filter(append(manifests, snapIDManifests...))
+		if err != nil {
+			return err
+		}
 
 		for _, man := range manifests {
 			rootPath := fmt.Sprintf("%v@%v", man.Source, formatTimestamp(man.StartTime.ToTime()))
@@ -133,10 +138,15 @@ func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) er
 func (c *commandSnapshotVerify) loadSourceManifests(ctx context.Context, rep repo.Repository) ([]*snapshot.Manifest, error) {
 	var manifestIDs []manifest.ID
 
+	tags, err := c.tagFilter.labels()
+	if err != nil {
+		return nil, err
+	}
+
 	if c.noVerifyTargetArgsProvided() {
 		// User didn't specify any particular snapshot or snapshots to verify.
 		// Read out all manifests and verify everything.
-		man, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+		man, err := snapshot.ListSnapshotManifests(ctx, rep, nil, tags)
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to list snapshot manifests")
 		}
@@ -149,7 +159,7 @@ func (c *commandSnapshotVerify) loadSourceManifests(ctx context.Context, rep rep
 				return nil, errors.Wrapf(err, "error parsing %q", srcStr)
 			}
 
-			man, err := snapshot.ListSnapshotManifests(ctx, rep, &src, nil)
+			man, err := snapshot.ListSnapshotManifests(ctx, rep, &src, tags)
 			if err != nil {
 				return nil, errors.Wrapf(err, "unable to list snapshot manifests for %v", src)
 			}
@@ -0,0 +1,60 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/internal/xattr"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotCaptureExtendedAttributes(t *testing.T) {
+	t.Parallel()
+
+	srcdir := testutil.TempDirectory(t)
+	fname := filepath.Join(srcdir, "file")
+
+	require.NoError(t, os.WriteFile(fname, []byte("data"), 0o600))
+
+	if err := xattr.Set(fname, "user.kopia", []byte("value")); err != nil {
+		t.Skipf("extended attributes not supported: %v", err)
+	}
+
+	require.NoError(t, xattr.Set(srcdir, "user.kopia-root", []byte("root-value")))
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	// createSnapshot returns entries of the snapshot root and of the file in it.
+	createSnapshot := func(args ...string) (root, file *snapshot.DirEntry) {
+		t.Helper()
+
+		var man snapshot.Manifest
+
+		require.NoError(t, json.Unmarshal([]byte(strings.Join(e.RunAndExpectSuccess(t, append([]string{"snapshot", "create", srcdir, "--json"}, args...)...), "\n")), &man))
+
+		var dir snapshot.DirManifest
+
+		require.NoError(t, json.Unmarshal([]byte(strings.Join(e.RunAndExpectSuccess(t, "show", man.RootObjectID().String()), "\n")), &dir))
+		require.Len(t, dir.Entries, 1)
+
+		return man.RootEntry, dir.Entries[0]
+	}
+
+	root, file := createSnapshot()
+	require.Empty(t, root.ExtendedAttributes)
+	require.Empty(t, file.ExtendedAttributes)
+
+	root, file = createSnapshot("--capture-xattrs")
+	require.Equal(t, map[string][]byte{"user.kopia-root": []byte("root-value")}, root.ExtendedAttributes)
+	require.Equal(t, map[string][]byte{"user.kopia": []byte("value")}, file.ExtendedAttributes)
+}
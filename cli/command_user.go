@@ -1,5 +1,16 @@
 package cli
 
+import (
+	"context"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/repo"
+)
+
 type commandServerUser struct {
 	add    commandServerUserAddSet
 	set    commandServerUserAddSet
@@ -19,3 +30,58 @@ func (c *commandServerUser) setup(svc appServices, parent commandParent) {
 	c.info.setup(svc, cmd)
 	c.list.setup(svc, cmd)
 }
+
+// serverUserNotifyFlags allows user management commands, which modify user profiles directly in the repository,
+// to make a running server reload them immediately.
+type serverUserNotifyFlags struct {
+	notifyServer bool
+	sf           serverClientFlags
+}
+
+func (c *serverUserNotifyFlags) setup(svc appServices, cmd *kingpin.CmdClause) {
+	cmd.Flag("notify-server", "Make a running server reload users immediately after the change").BoolVar(&c.notifyServer)
+	c.sf.setup(svc, cmd)
+}
+
+// flushAndNotify flushes changes to user profiles and, if requested, asks the running server to reload them.
+// Once the changes are flushed, failure to notify the server is only reported as a warning.
+func (c *serverUserNotifyFlags) flushAndNotify(ctx context.Context, rep repo.RepositoryWriter) error {
+	if !c.notifyServer {
+		log(ctx).Info("Updated user credentials will take effect in 5-10 minutes or when the server is restarted.\n" +
+			"To refresh credentials in a running server use 'kopia server refresh' command or pass --notify-server.")
+
+		return nil
+	}
+
+	if err := rep.Flush(ctx); err != nil {
+		return errors.Wrap(err, "error flushing repository")
+	}
+
+	if err := c.notify(ctx); err != nil {
+		log(ctx).Warnf("Unable to notify the server, updated user credentials will take effect in 5-10 minutes or when the server is restarted: %v", err)
+
+		return nil
+	}
+
+	log(ctx).Info("Server reloaded updated user credentials.")
+
+	return nil
+}
+
+func (c *serverUserNotifyFlags) notify(ctx context.Context) error {
+	opts, err := c.sf.serverAPIClientOptions()
+	if err != nil {
+		return errors.Wrap(err, "unable to create API client options")
+	}
+
+	cli, err := apiclient.NewKopiaAPIClient(opts)
+	if err != nil {
+		return errors.Wrap(err, "unable to create API client")
+	}
+
+	if err := cli.Post(ctx, "control/refresh", &serverapi.Empty{}, &serverapi.Empty{}); err != nil {
+		return errors.Wrap(err, "unable to refresh server")
+	}
+
+	return nil
+}
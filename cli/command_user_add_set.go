@@ -17,7 +17,10 @@ type commandServerUserAddSet struct {
 	userSetPassword     string
 	userSetPasswordHash string
 
+	notify serverUserNotifyFlags
+
 	isNew bool // true == 'add', false == 'update'
+	svc   appServices
 	out   textOutput
 }
 
@@ -29,15 +32,17 @@ func (c *commandServerUserAddSet) setup(svc appServices, parent commandParent, i
 	if isNew {
 		cmd = parent.Command("add", "Add new repository user").Alias("create")
 	} else {
-		cmd = parent.Command("set", "Set password for a repository user.").Alias("update")
+		cmd = parent.Command("set", "Set password for a repository user.").Alias("update").Alias("set-password")
 	}
 
 	cmd.Flag("ask-password", "Ask for user password").BoolVar(&c.userAskPassword)
 	cmd.Flag("user-password", "Password").StringVar(&c.userSetPassword)
 	cmd.Flag("user-password-hash", "Password hash").StringVar(&c.userSetPasswordHash)
 	cmd.Arg("username", "Username").Required().StringVar(&c.userSetName)
+	c.notify.setup(svc, cmd)
 	cmd.Action(svc.repositoryWriterAction(c.runServerUserAddSet))
 
+	c.svc = svc
 	c.out.setup(svc)
 }
 
@@ -80,7 +85,7 @@ func (c *commandServerUserAddSet) runServerUserAddSet(ctx context.Context, rep r
 	}
 
 	if up.PasswordHash == nil || c.userAskPassword {
-		pwd, err := askConfirmPass(c.out.stdout(), "Enter new password for user "+username+": ")
+		pwd, err := askConfirmPass(c.out.stdout(), "Enter new password for user "+username+": ", c.svc.getPasswordPromptAttempts())
 		if err != nil {
 			return err
 		}
@@ -100,21 +105,16 @@ func (c *commandServerUserAddSet) runServerUserAddSet(ctx context.Context, rep r
 		return errors.Wrap(err, "error setting user profile")
 	}
 
-	log(ctx).Infof(`
-Updated user credentials will take effect in 5-10 minutes or when the server is restarted.
-To refresh credentials in a running server use 'kopia server refresh' command.
-`)
-
-	return nil
+	return c.notify.flushAndNotify(ctx, rep)
 }
 
-func askConfirmPass(out io.Writer, initialPrompt string) (string, error) {
-	pwd, err := askPass(out, initialPrompt)
+func askConfirmPass(out io.Writer, initialPrompt string, attempts int) (string, error) {
+	pwd, err := askPass(out, initialPrompt, attempts)
 	if err != nil {
 		return "", errors.Wrap(err, "error asking for password")
 	}
 
-	pwd2, err := askPass(out, "Re-enter password for verification: ")
+	pwd2, err := askPass(out, "Re-enter password for verification: ", attempts)
 	if err != nil {
 		return "", errors.Wrap(err, "error asking for password")
 	}
@@ -11,11 +11,14 @@
 
 type commandServerUserDelete struct {
 	name string
+
+	notify serverUserNotifyFlags
 }
 
 func (c *commandServerUserDelete) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("delete", "Delete user").Alias("remove").Alias("rm")
 	cmd.Arg("username", "The username to delete.").Required().StringVar(&c.name)
+	c.notify.setup(svc, cmd)
 	cmd.Action(svc.repositoryWriterAction(c.run))
 }
 
@@ -27,5 +30,5 @@ func (c *commandServerUserDelete) run(ctx context.Context, rep repo.RepositoryWr
 
 	log(ctx).Infof("User %q deleted.", c.name)
 
-	return nil
+	return c.notify.flushAndNotify(ctx, rep)
 }
@@ -12,6 +12,7 @@
 type commandServerUserHashPassword struct {
 	password string
 
+	svc appServices
 	out textOutput
 }
 
@@ -22,6 +23,7 @@ func (c *commandServerUserHashPassword) setup(svc appServices, parent commandPar
 
 	cmd.Action(svc.repositoryWriterAction(c.runServerUserHashPassword))
 
+	c.svc = svc
 	c.out.setup(svc)
 }
 
@@ -33,7 +35,7 @@ func (c *commandServerUserHashPassword) setup(svc appServices, parent commandPar
 func (c *commandServerUserHashPassword) runServerUserHashPassword(ctx context.Context, _ repo.RepositoryWriter) error {
 	if c.password == "" {
 		// when password hash is empty, ask for password
-		pwd, err := askConfirmPass(c.out.stdout(), "Enter password to hash: ")
+		pwd, err := askConfirmPass(c.out.stdout(), "Enter password to hash: ", c.svc.getPasswordPromptAttempts())
 		if err != nil {
 			return errors.Wrap(err, "error getting password")
 		}
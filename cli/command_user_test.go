@@ -0,0 +1,68 @@
+package cli_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestServerUserNotifyServer(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	var sp testutil.ServerParameters
+
+	wait, kill := env.RunAndProcessStderr(t, sp.ProcessOutput,
+		"server", "start", "--insecure", "--random-server-control-password", "--address=127.0.0.1:0")
+
+	defer wait()
+	defer kill()
+
+	ctx := testlogging.Context(t)
+
+	// returns the HTTP status code of an authenticated request made as the provided user.
+	statusAs := func(username, password string) int {
+		t.Helper()
+
+		cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+			BaseURL:  sp.BaseURL,
+			Username: username,
+			Password: password,
+		})
+		require.NoError(t, err)
+
+		var hs apiclient.HTTPStatusError
+
+		err = cli.Get(ctx, "objects/k0123456789abcdef0123456789abcdef", nil, &struct{}{})
+		require.ErrorAs(t, err, &hs)
+
+		return hs.HTTPStatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, statusAs("foo@bar", "baz"))
+
+	notifyArgs := []string{"--notify-server", "--address", sp.BaseURL, "--server-control-password", sp.ServerControlPassword}
+
+	env.RunAndExpectSuccess(t, append([]string{"server", "users", "add", "foo@bar", "--user-password", "baz"}, notifyArgs...)...)
+	require.NotEqual(t, http.StatusUnauthorized, statusAs("foo@bar", "baz"))
+
+	env.RunAndExpectSuccess(t, append([]string{"server", "users", "set-password", "foo@bar", "--user-password", "qux"}, notifyArgs...)...)
+	require.Equal(t, http.StatusUnauthorized, statusAs("foo@bar", "baz"))
+	require.NotEqual(t, http.StatusUnauthorized, statusAs("foo@bar", "qux"))
+
+	env.RunAndExpectSuccess(t, append([]string{"server", "users", "delete", "foo@bar"}, notifyArgs...)...)
+	require.Equal(t, http.StatusUnauthorized, statusAs("foo@bar", "qux"))
+
+	// without a running server the notification fails after the change was written, which is only a warning.
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "server", "users", "add", "other@bar", "--user-password", "baz", "--notify-server", "--address", "http://127.0.0.1:1")
+	require.Contains(t, strings.Join(stderr, "\n"), "Unable to notify the server")
+	require.Contains(t, env.RunAndExpectSuccess(t, "server", "users", "list"), "other@bar")
+}
@@ -5,10 +5,8 @@
 	"fmt"
 	"io"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"runtime"
-	"syscall"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pkg/errors"
@@ -16,6 +14,7 @@
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/localfs"
 	"github.com/kopia/kopia/internal/ospath"
+	"github.com/kopia/kopia/internal/retry"
 	"github.com/kopia/kopia/repo"
 )
 
@@ -30,26 +29,17 @@ func (c *App) onRepositoryFatalError(f func(err error)) {
 	c.onFatalErrorCallbacks = append(c.onFatalErrorCallbacks, f)
 }
 
+// onTerminate registers the function to be invoked instead of canceling the command context
+// when the first interrupt signal is received, which allows the command to shut down gracefully.
 func (c *App) onTerminate(f func()) {
-	s := make(chan os.Signal, 1)
-	signal.Notify(s, os.Interrupt, syscall.SIGTERM)
-
-	go func() {
-		// invoke the function when either real or simulated Ctrl-C signal is delivered
-		select {
-		case v := <-c.simulatedCtrlC:
-			if !v {
-				return
-			}
+	c.terminateMutex.Lock()
+	defer c.terminateMutex.Unlock()
 
-		case <-s:
-		}
-		f()
-	}()
+	c.terminateCallbacks = append(c.terminateCallbacks, f)
 }
 
 func (c *App) openRepository(ctx context.Context, required bool) (repo.Repository, error) {
-	if _, err := os.Stat(c.repositoryConfigFileName()); os.IsNotExist(err) {
+	if !repo.ConfigExists(c.repositoryConfigFileName(), c.configProfile) {
 		if !required {
 			return nil, nil
 		}
@@ -64,7 +54,7 @@ func (c *App) openRepository(ctx context.Context, required bool) (repo.Repositor
 		return nil, errors.Wrap(err, "get password")
 	}
 
-	r, err := repo.Open(ctx, c.repositoryConfigFileName(), pass, c.optionsFromFlags(ctx))
+	r, err := c.openWithRetry(ctx, pass)
 	if os.IsNotExist(err) {
 		return nil, errors.New("not connected to a repository, use 'kopia connect'")
 	}
@@ -72,12 +62,37 @@ func (c *App) openRepository(ctx context.Context, required bool) (repo.Repositor
 	return r, errors.Wrap(err, "unable to open repository")
 }
 
+// openWithRetry opens the repository, retrying storage and network errors known to be transient up to --retry-open times.
+func (c *App) openWithRetry(ctx context.Context, pass string) (repo.Repository, error) {
+	if c.retryOpen <= 0 {
+		//nolint:wrapcheck
+		return repo.Open(ctx, c.repositoryConfigFileName(), pass, c.optionsFromFlags(ctx))
+	}
+
+	attempt := 0
+
+	//nolint:wrapcheck
+	return retry.WithExponentialBackoffMaxRetries(ctx, c.retryOpen+1, "opening repository", func() (repo.Repository, error) {
+		if attempt > 0 {
+			log(ctx).Infof("Retrying opening repository (attempt %v of %v)...", attempt, c.retryOpen)
+		}
+
+		attempt++
+
+		return repo.Open(ctx, c.repositoryConfigFileName(), pass, c.optionsFromFlags(ctx))
+	}, isTransientStorageError)
+}
+
 func (c *App) optionsFromFlags(ctx context.Context) *repo.Options {
 	return &repo.Options{
 		TraceStorage:        c.traceStorage,
 		DisableInternalLog:  c.disableInternalLog,
 		UpgradeOwnerID:      c.upgradeOwnerID,
 		DoNotWaitForUpgrade: c.doNotWaitForUpgrade,
+		Profile:             c.configProfile,
+
+		MaxUploadBytesPerSecond:   float64(c.uploadLimit),
+		MaxDownloadBytesPerSecond: float64(c.downloadLimit),
 
 		// when a fatal error is encountered in the repository, run all registered callbacks
 		// and exit the program.
@@ -95,6 +110,12 @@ func (c *App) optionsFromFlags(ctx context.Context) *repo.Options {
 	}
 }
 
+// repositoryConfigKey returns the string identifying the active connection profile, which is used
+// to name files associated with the connection and to store its password.
+func (c *App) repositoryConfigKey() string {
+	return repo.ProfileKey(c.repositoryConfigFileName(), c.configProfile)
+}
+
 func (c *App) repositoryConfigFileName() string {
 	if filepath.Base(c.configPath) != c.configPath {
 		return c.configPath
@@ -140,3 +161,7 @@ func getLocalFSEntry(ctx context.Context, path0 string) (fs.Entry, error) {
 func isWindows() bool {
 	return runtime.GOOS == "windows"
 }
+
+func (c *App) configProfileName() string {
+	return c.configProfile
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattn/go-isatty"
+
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/internal/units"
+)
+
+// cliVerifyProgress displays a single-line progress of content verification.
+// All methods are safe to call on nil progress, in which case they do nothing.
+type cliVerifyProgress struct {
+	downloadedBytes atomic.Int64
+	verifiedCount   atomic.Int32
+	errorCount      atomic.Int32
+	totalCount      atomic.Int32
+
+	progressUpdateInterval time.Duration
+	showDownloaded         bool
+
+	outputThrottle timetrack.Throttle
+	outputMutex    sync.Mutex
+	out            textOutput          // +checklocksignore: outputMutex just happens to be held always.
+	eta            timetrack.Estimator // +checklocksignore: outputMutex just happens to be held always.
+
+	// +checklocks:outputMutex
+	lastLineLength int
+}
+
+// newVerifyProgress returns progress of content verification or nil if progress should not be displayed,
+// because it's disabled, JSON output was requested or stdout is not an interactive terminal.
+func newVerifyProgress(pf progressFlags, jsonOutput, showDownloaded bool) *cliVerifyProgress {
+	if !pf.enableProgress || jsonOutput {
+		return nil
+	}
+
+	if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		return nil
+	}
+
+	return &cliVerifyProgress{
+		progressUpdateInterval: pf.progressUpdateInterval,
+		showDownloaded:         showDownloaded,
+		out:                    pf.out,
+		eta:                    timetrack.Start(),
+	}
+}
+
+func (p *cliVerifyProgress) setCounters(verified, errors, total int32) {
+	if p == nil {
+		return
+	}
+
+	p.verifiedCount.Store(verified)
+	p.errorCount.Store(errors)
+	p.totalCount.Store(total)
+
+	p.maybeOutput()
+}
+
+func (p *cliVerifyProgress) downloaded(numBytes int64) {
+	if p == nil {
+		return
+	}
+
+	p.downloadedBytes.Add(numBytes)
+}
+
+func (p *cliVerifyProgress) finish() {
+	if p == nil {
+		return
+	}
+
+	p.outputThrottle.Reset()
+	p.output("\n")
+}
+
+func (p *cliVerifyProgress) maybeOutput() {
+	if p.outputThrottle.ShouldOutput(p.progressUpdateInterval) {
+		p.output("")
+	}
+}
+
+func (p *cliVerifyProgress) output(suffix string) {
+	p.outputMutex.Lock()
+	defer p.outputMutex.Unlock()
+
+	line := p.line()
+
+	var extraSpaces string
+
+	if len(line) < p.lastLineLength {
+		// add extra spaces to wipe over previous line if it was longer than current
+		extraSpaces = strings.Repeat(" ", p.lastLineLength-len(line))
+	}
+
+	p.lastLineLength = len(line)
+	p.out.printStderr("\r%v%v%v", line, extraSpaces, suffix)
+}
+
+func (p *cliVerifyProgress) line() string {
+	verified := p.verifiedCount.Load()
+	total := p.totalCount.Load()
+
+	var maybeDownloaded, maybeRemaining string
+
+	if p.showDownloaded {
+		maybeDownloaded = fmt.Sprintf(", downloaded %v", units.BytesString(p.downloadedBytes.Load()))
+	}
+
+	if est, ok := p.eta.Estimate(float64(verified), float64(total)); ok {
+		maybeRemaining = fmt.Sprintf(" (%.1f%%) remaining %v, ETA %v", est.PercentComplete, est.Remaining, formatTimestamp(est.EstimatedEndTime))
+	}
+
+	if total == 0 {
+		return fmt.Sprintf("Verified %v contents, %v errors%v, estimating...", verified, p.errorCount.Load(), maybeDownloaded)
+	}
+
+	return fmt.Sprintf("Verified %v of %v contents%v, %v errors%v.", verified, total, maybeRemaining, p.errorCount.Load(), maybeDownloaded)
+}
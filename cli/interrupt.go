@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// errInterrupted is the cause of cancellation of the command context after an interrupt signal.
+var errInterrupted = errors.New("interrupted")
+
+// handleInterrupts handles interrupt signals (Ctrl-C and SIGTERM) until the returned stop function is called.
+//
+// The first signal invokes functions registered with onTerminate() or, if the command did not register
+// any, cancels the returned context, so that in-progress work is abandoned and the repository is closed.
+// The second signal exits immediately.
+func (c *App) handleInterrupts(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	s := make(chan os.Signal, 1)
+	signal.Notify(s, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		for interrupts := 0; ; interrupts++ {
+			// wait for either real or simulated Ctrl-C signal
+			select {
+			case <-done:
+				return
+
+			case v, ok := <-c.simulatedCtrlC:
+				if !ok || !v {
+					return
+				}
+
+			case <-s:
+			}
+
+			if interrupts == 0 {
+				fmt.Fprintln(c.Stderr(), "Interrupted, finishing in-progress work and closing the repository. Press Ctrl-C again to exit immediately.") //nolint:errcheck
+				c.terminate(cancel)
+
+				continue
+			}
+
+			fmt.Fprintln(c.Stderr(), "Interrupted again, exiting immediately.") //nolint:errcheck
+			c.exitWithError(errInterrupted)
+
+			// only reached in tests, where exitWithError() does not exit the process.
+			cancel(errInterrupted)
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(s)
+		close(done)
+		<-finished
+
+		cancel(nil)
+
+		c.terminateMutex.Lock()
+		defer c.terminateMutex.Unlock()
+
+		c.terminateCallbacks = nil
+	}
+}
+
+// terminate invokes functions registered with onTerminate() or cancels the command context if there are none.
+func (c *App) terminate(cancel context.CancelCauseFunc) {
+	c.terminateMutex.Lock()
+	callbacks := append([]func(){}, c.terminateCallbacks...)
+	c.terminateMutex.Unlock()
+
+	if len(callbacks) == 0 {
+		cancel(errInterrupted)
+		return
+	}
+
+	for _, f := range callbacks {
+		f()
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleInterruptsCancelsContext(t *testing.T) {
+	var stderr bytes.Buffer
+
+	app := NewApp()
+	app.stderrWriter = &stderr
+	app.simulatedCtrlC = make(chan bool, 1)
+
+	exited := make(chan error, 1)
+
+	app.exitWithError = func(err error) { exited <- err }
+
+	ctx, stop := app.handleInterrupts(context.Background())
+
+	app.simulatedCtrlC <- true
+	<-ctx.Done()
+
+	require.ErrorIs(t, context.Cause(ctx), errInterrupted)
+	require.Contains(t, stderr.String(), "Press Ctrl-C again to exit immediately")
+	require.Empty(t, exited)
+
+	// second interrupt exits immediately.
+	app.simulatedCtrlC <- true
+
+	require.ErrorIs(t, <-exited, errInterrupted)
+
+	stop()
+
+	require.Contains(t, stderr.String(), "exiting immediately")
+}
+
+func TestHandleInterruptsInvokesTerminateCallbacks(t *testing.T) {
+	app := NewApp()
+	app.stderrWriter = &bytes.Buffer{}
+	app.simulatedCtrlC = make(chan bool, 1)
+
+	ctx, stop := app.handleInterrupts(context.Background())
+
+	terminated := make(chan struct{})
+
+	app.onTerminate(func() { close(terminated) })
+
+	app.simulatedCtrlC <- true
+	<-terminated
+
+	// command that handles termination itself keeps its context.
+	require.NoError(t, ctx.Err())
+
+	stop()
+
+	require.True(t, errors.Is(ctx.Err(), context.Canceled))
+
+	app.terminateMutex.Lock()
+	defer app.terminateMutex.Unlock()
+
+	require.Empty(t, app.terminateCallbacks)
+}
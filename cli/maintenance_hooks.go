@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
+)
+
+const (
+	maintenanceHookPhasePre  = "pre"
+	maintenanceHookPhasePost = "post"
+
+	// maximum time to wait for the output of a hook after it has been killed due to timeout.
+	maintenanceHookWaitDelay = 3 * time.Second
+)
+
+// maintenanceHooks returns hooks that run the commands provided via --pre-maintenance-hook and
+// --post-maintenance-hook around automatic maintenance.
+func (c *App) maintenanceHooks() snapshotmaintenance.Hooks {
+	var hooks snapshotmaintenance.Hooks
+
+	if c.preMaintenanceHook != "" {
+		hooks.Before = func(ctx context.Context, mode maintenance.Mode) error {
+			return c.runMaintenanceHook(ctx, c.preMaintenanceHook, maintenanceHookEnv(maintenanceHookPhasePre, mode, nil))
+		}
+	}
+
+	if c.postMaintenanceHook != "" {
+		hooks.After = func(ctx context.Context, mode maintenance.Mode, err error) {
+			if herr := c.runMaintenanceHook(ctx, c.postMaintenanceHook, maintenanceHookEnv(maintenanceHookPhasePost, mode, err)); herr != nil {
+				log(ctx).Errorf("post-maintenance hook failed: %v", herr)
+			}
+		}
+	}
+
+	return hooks
+}
+
+// maintenanceHookEnv returns environment variables describing maintenance status passed to the hook.
+func maintenanceHookEnv(phase string, mode maintenance.Mode, maintenanceErr error) []string {
+	env := []string{
+		"KOPIA_MAINTENANCE_PHASE=" + phase,
+		"KOPIA_MAINTENANCE_MODE=" + string(mode),
+	}
+
+	if phase != maintenanceHookPhasePost {
+		return env
+	}
+
+	if maintenanceErr != nil {
+		return append(env,
+			"KOPIA_MAINTENANCE_STATUS=failed",
+			"KOPIA_MAINTENANCE_ERROR="+maintenanceErr.Error())
+	}
+
+	return append(env, "KOPIA_MAINTENANCE_STATUS=success")
+}
+
+func (c *App) runMaintenanceHook(ctx context.Context, command string, env []string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.maintenanceHookTimeout)
+	defer cancel()
+
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = c.stderrWriter
+	cmd.Stderr = c.stderrWriter
+	cmd.WaitDelay = maintenanceHookWaitDelay
+
+	log(ctx).Debugf("running maintenance hook: %v", command)
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return errors.Errorf("maintenance hook timed out after %v", c.maintenanceHookTimeout)
+		}
+
+		return errors.Wrap(err, "error running maintenance hook")
+	}
+
+	return nil
+}
+
+// shellCommand returns a command that runs the provided command line using the shell of the operating system.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, os.Getenv("COMSPEC"), "/c", command) //nolint:gosec
+	}
+
+	return exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec
+}
@@ -0,0 +1,144 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestMaintenanceHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test relies on POSIX shell")
+	}
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+	outDir := testutil.TempDirectory(t)
+	preOut := filepath.Join(outDir, "pre.txt")
+	postOut := filepath.Join(outDir, "post.txt")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcDir,
+		"--pre-maintenance-hook=echo $KOPIA_MAINTENANCE_PHASE $KOPIA_MAINTENANCE_MODE > "+preOut,
+		"--post-maintenance-hook=echo $KOPIA_MAINTENANCE_PHASE $KOPIA_MAINTENANCE_MODE $KOPIA_MAINTENANCE_STATUS > "+postOut)
+
+	b, err := os.ReadFile(preOut)
+	require.NoError(t, err)
+	require.Equal(t, "pre full\n", string(b))
+
+	b, err = os.ReadFile(postOut)
+	require.NoError(t, err)
+	require.Equal(t, "post full success\n", string(b))
+
+	// maintenance is no longer due, hooks are not invoked.
+	require.NoError(t, os.Remove(preOut))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcDir,
+		"--pre-maintenance-hook=echo pre > "+preOut)
+	require.NoFileExists(t, preOut)
+}
+
+func TestMaintenanceSkippedAfterCommandWithoutWrites(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test relies on POSIX shell")
+	}
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+	preOut := filepath.Join(testutil.TempDirectory(t), "pre.txt")
+
+	// maintenance is due, but the command did not write anything to the repository.
+	e.RunAndExpectSuccess(t, "snapshot", "expire", "--all",
+		"--pre-maintenance-hook=echo pre > "+preOut)
+	require.NoFileExists(t, preOut)
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcDir,
+		"--pre-maintenance-hook=echo pre > "+preOut)
+	require.FileExists(t, preOut)
+}
+
+func TestMaintenanceSkipReasons(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "expire", "--all", "--verbose-auto-maintenance")
+	mustGetLineContaining(t, stderr, "Skipping automatic maintenance because the command did not write to the repository")
+
+	_, stderr = e.RunAndExpectSuccessWithErrOut(t, "snapshot", "create", srcDir, "--verbose-auto-maintenance", "--no-auto-maintenance")
+	mustGetLineContaining(t, stderr, "Skipping automatic maintenance because automatic maintenance is disabled")
+
+	e.RunAndExpectSuccess(t, "maintenance", "set", "--owner=someone@elsewhere")
+
+	_, stderr = e.RunAndExpectSuccessWithErrOut(t, "snapshot", "create", srcDir, "--verbose-auto-maintenance")
+	mustGetLineContaining(t, stderr, "Skipping automatic maintenance because maintenance is owned by someone@elsewhere")
+}
+
+func TestMaintenanceHooksPreHookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test relies on POSIX shell")
+	}
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+	postOut := filepath.Join(testutil.TempDirectory(t), "post.txt")
+
+	// failing pre-hook prevents maintenance from running, but does not fail the command.
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "create", srcDir,
+		"--pre-maintenance-hook=exit 1",
+		"--post-maintenance-hook=echo post > "+postOut)
+	mustGetLineContaining(t, stderr, "pre-maintenance hook failed")
+	require.NoFileExists(t, postOut)
+
+	// hook exceeding the timeout is treated as failure.
+	e2 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e2.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e2.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e2.RepoDir)
+
+	_, stderr = e2.RunAndExpectSuccessWithErrOut(t, "snapshot", "create", srcDir,
+		"--pre-maintenance-hook=sleep 10",
+		"--maintenance-hook-timeout=100ms")
+	mustGetLineContaining(t, stderr, "maintenance hook timed out after 100ms")
+}
+
+func TestMaxAutoMaintenanceDuration(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	// the deadline passes before the first task, so maintenance stops without running any tasks.
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "create", testutil.TempDirectory(t), "--max-auto-maintenance-duration=1ns")
+	mustGetLineContaining(t, stderr, "Automatic maintenance was stopped after 1ns")
+
+	var info map[string]any
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "info", "--json"), &info)
+	require.Empty(t, info["schedule"].(map[string]any)["runs"]) //nolint:forcetypeassert
+}
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"os"
@@ -43,6 +44,10 @@
 type observabilityFlags struct {
 	enablePProf         bool
 	metricsListenAddr   string
+	metricsAllowRemote  bool
+	metricsUsername     string
+	metricsPassword     string
+	metricsToken        string
 	metricsPushAddr     string
 	metricsJob          string
 	metricsPushInterval time.Duration
@@ -56,6 +61,8 @@ type observabilityFlags struct {
 	enableJaeger bool
 	otlpTrace    bool
 
+	metricsListener net.Listener
+
 	stopPusher chan struct{}
 	pusherWG   sync.WaitGroup
 
@@ -64,6 +71,10 @@ type observabilityFlags struct {
 
 func (c *observabilityFlags) setup(svc appServices, app *kingpin.Application) {
 	app.Flag("metrics-listen-addr", "Expose Prometheus metrics on a given host:port").Hidden().StringVar(&c.metricsListenAddr)
+	app.Flag("metrics-allow-remote", "Allow exposing Prometheus metrics on non-loopback addresses").Envar(svc.EnvName("KOPIA_METRICS_ALLOW_REMOTE")).Hidden().BoolVar(&c.metricsAllowRemote)
+	app.Flag("metrics-username", "Username required to access Prometheus metrics (basic authentication)").Envar(svc.EnvName("KOPIA_METRICS_USERNAME")).Hidden().StringVar(&c.metricsUsername)
+	app.Flag("metrics-password", "Password required to access Prometheus metrics (basic authentication)").Envar(svc.EnvName("KOPIA_METRICS_PASSWORD")).Hidden().StringVar(&c.metricsPassword)
+	app.Flag("metrics-token", "Bearer token required to access Prometheus metrics").Envar(svc.EnvName("KOPIA_METRICS_TOKEN")).Hidden().StringVar(&c.metricsToken)
 	app.Flag("enable-pprof", "Expose pprof handlers").Hidden().BoolVar(&c.enablePProf)
 
 	// push gateway parameters
@@ -111,7 +122,9 @@ func (c *observabilityFlags) initialize(ctx *kingpin.ParseContext) error {
 }
 
 func (c *observabilityFlags) startMetrics(ctx context.Context) error {
-	c.maybeStartListener(ctx)
+	if err := c.maybeStartListener(ctx); err != nil {
+		return err
+	}
 
 	if err := c.maybeStartMetricsPusher(ctx); err != nil {
 		return err
@@ -130,9 +143,19 @@ func (c *observabilityFlags) startMetrics(ctx context.Context) error {
 }
 
 // Starts observability listener when a listener address is specified.
-func (c *observabilityFlags) maybeStartListener(ctx context.Context) {
+func (c *observabilityFlags) maybeStartListener(ctx context.Context) error {
 	if c.metricsListenAddr == "" {
-		return
+		return nil
+	}
+
+	addr, err := metricsListenAddress(c.metricsListenAddr, c.metricsAllowRemote)
+	if err != nil {
+		return err
+	}
+
+	handler, err := c.metricsAuthHandler()
+	if err != nil {
+		return err
 	}
 
 	m := mux.NewRouter()
@@ -147,9 +170,19 @@ func (c *observabilityFlags) maybeStartListener(ctx context.Context) {
 		m.HandleFunc("/debug/pprof/{cmd}", pprof.Index) // special handling for Gorilla mux, see https://stackoverflow.com/questions/30560859/cant-use-go-tool-pprof-with-an-existing-server/71032595#71032595
 	}
 
-	log(ctx).Infof("starting prometheus metrics on %v", c.metricsListenAddr)
+	// bind synchronously, so that failure to expose metrics is reported to the user.
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "unable to start prometheus metrics listener on %v", addr)
+	}
+
+	c.metricsListener = l
+
+	log(ctx).Infof("starting prometheus metrics on %v", l.Addr())
+
+	go http.Serve(l, handler(m)) //nolint:errcheck,gosec
 
-	go http.ListenAndServe(c.metricsListenAddr, m) //nolint:errcheck,gosec
+	return nil
 }
 
 func (c *observabilityFlags) maybeStartMetricsPusher(ctx context.Context) error {
@@ -229,6 +262,12 @@ func (c *observabilityFlags) maybeStartTraceExporter(ctx context.Context) error
 }
 
 func (c *observabilityFlags) stopMetrics(ctx context.Context) {
+	if c.metricsListener != nil {
+		c.metricsListener.Close() //nolint:errcheck
+
+		c.metricsListener = nil
+	}
+
 	if c.stopPusher != nil {
 		close(c.stopPusher)
 
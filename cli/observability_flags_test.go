@@ -2,6 +2,7 @@
 
 import (
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -103,3 +104,19 @@ func TestMetricsSaveToOutputDirFlags(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, entries, 1, "a metrics output file should have been created")
 }
+
+func TestMetricsListenerBindFailure(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer l.Close()
+
+	// port is already in use.
+	env.RunAndExpectFailure(t, "repo", "status", "--metrics-listen-addr", l.Addr().String())
+
+	env.RunAndExpectSuccess(t, "repo", "status", "--metrics-listen-addr", "127.0.0.1:0")
+}
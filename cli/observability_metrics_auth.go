@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// metricsListenAddress returns the address to expose metrics on. Addresses without a host are bound
+// to the loopback interface and non-loopback addresses are only allowed with --metrics-allow-remote.
+func metricsListenAddress(addr string, allowRemote bool) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid metrics listen address %q", addr)
+	}
+
+	if allowRemote {
+		return addr, nil
+	}
+
+	if host == "" {
+		return net.JoinHostPort("127.0.0.1", port), nil
+	}
+
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", errors.Errorf("refusing to expose metrics on non-loopback address %q, use --metrics-allow-remote to allow it", addr)
+	}
+
+	return addr, nil
+}
+
+// metricsAuthHandler returns a function that wraps metrics handler with authentication required
+// by --metrics-username/--metrics-password or --metrics-token, if provided.
+func (c *observabilityFlags) metricsAuthHandler() (func(h http.Handler) http.Handler, error) {
+	if (c.metricsUsername == "") != (c.metricsPassword == "") {
+		return nil, errors.New("--metrics-username and --metrics-password must be provided together")
+	}
+
+	if c.metricsUsername == "" && c.metricsToken == "" {
+		return func(h http.Handler) http.Handler { return h }, nil
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.isAuthorizedMetricsRequest(r) {
+				if c.metricsUsername != "" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="Kopia Metrics"`)
+				}
+
+				http.Error(w, "access denied", http.StatusUnauthorized)
+
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// isAuthorizedMetricsRequest returns true if the request provides either valid basic authentication
+// credentials or a valid bearer token.
+func (c *observabilityFlags) isAuthorizedMetricsRequest(r *http.Request) bool {
+	if c.metricsUsername != "" {
+		if u, p, ok := r.BasicAuth(); ok &&
+			subtle.ConstantTimeCompare([]byte(u), []byte(c.metricsUsername))*
+				subtle.ConstantTimeCompare([]byte(p), []byte(c.metricsPassword)) == 1 {
+			return true
+		}
+	}
+
+	if c.metricsToken != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok &&
+			subtle.ConstantTimeCompare([]byte(token), []byte(c.metricsToken)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
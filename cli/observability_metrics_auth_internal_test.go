@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsListenAddress(t *testing.T) {
+	cases := []struct {
+		addr        string
+		allowRemote bool
+		want        string
+		wantErr     bool
+	}{
+		{addr: ":9090", want: "127.0.0.1:9090"},
+		{addr: "localhost:9090", want: "localhost:9090"},
+		{addr: "127.0.0.1:9090", want: "127.0.0.1:9090"},
+		{addr: "[::1]:9090", want: "[::1]:9090"},
+		{addr: "0.0.0.0:9090", wantErr: true},
+		{addr: "example.com:9090", wantErr: true},
+		{addr: "0.0.0.0:9090", allowRemote: true, want: "0.0.0.0:9090"},
+		{addr: ":9090", allowRemote: true, want: ":9090"},
+		{addr: "no-port", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := metricsListenAddress(tc.addr, tc.allowRemote)
+		if tc.wantErr {
+			require.Error(t, err, tc.addr)
+			continue
+		}
+
+		require.NoError(t, err, tc.addr)
+		require.Equal(t, tc.want, got, tc.addr)
+	}
+}
+
+func TestMetricsAuthHandler(t *testing.T) {
+	c := &observabilityFlags{
+		metricsUsername: "user",
+		metricsPassword: "pass",
+		metricsToken:    "secret-token",
+	}
+
+	wrap, err := c.metricsAuthHandler()
+	require.NoError(t, err)
+
+	h := wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		setup func(r *http.Request)
+		want  int
+	}{
+		{func(*http.Request) {}, http.StatusUnauthorized},
+		{func(r *http.Request) { r.SetBasicAuth("user", "pass") }, http.StatusOK},
+		{func(r *http.Request) { r.SetBasicAuth("user", "wrong") }, http.StatusUnauthorized},
+		{func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret-token") }, http.StatusOK},
+		{func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody)
+		tc.setup(r)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		require.Equal(t, tc.want, rec.Code)
+	}
+
+	_, err = (&observabilityFlags{metricsUsername: "user"}).metricsAuthHandler()
+	require.Error(t, err)
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import "syscall"
+
+// maxOpenFiles returns the current limit of open files for the process or 0 if unknown.
+func maxOpenFiles() uint64 {
+	var rl syscall.Rlimit
+
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0
+	}
+
+	return uint64(rl.Cur) //nolint:unconvert
+}
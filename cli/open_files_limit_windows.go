@@ -0,0 +1,6 @@
+package cli
+
+// maxOpenFiles returns 0 since Windows does not have a per-process limit of open files.
+func maxOpenFiles() uint64 {
+	return 0
+}
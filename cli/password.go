@@ -1,26 +1,42 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"runtime"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 	"golang.org/x/term"
 
 	"github.com/kopia/kopia/internal/passwordpersist"
+	"github.com/kopia/kopia/repo"
 )
 
-func askForNewRepositoryPassword(out io.Writer) (string, error) {
+const (
+	// maximum time to wait for the output of the password command after it has been killed due to timeout.
+	passwordCommandWaitDelay = 3 * time.Second
+
+	// new repository passwords shorter than this or with less estimated entropy are considered weak.
+	minPasswordLength      = 12
+	minPasswordEntropyBits = 50
+)
+
+func askForNewRepositoryPassword(out io.Writer, attempts int) (string, error) {
 	for {
-		p1, err := askPass(out, "Enter password to create new repository: ")
+		p1, err := askPass(out, "Enter password to create new repository: ", attempts)
 		if err != nil {
 			return "", errors.Wrap(err, "password entry")
 		}
 
-		p2, err := askPass(out, "Re-enter password for verification: ")
+		p2, err := askPass(out, "Re-enter password for verification: ", attempts)
 		if err != nil {
 			return "", errors.Wrap(err, "password verification")
 		}
@@ -33,14 +49,14 @@ func askForNewRepositoryPassword(out io.Writer) (string, error) {
 	}
 }
 
-func askForChangedRepositoryPassword(out io.Writer) (string, error) {
+func askForChangedRepositoryPassword(out io.Writer, attempts int) (string, error) {
 	for {
-		p1, err := askPass(out, "Enter new password: ")
+		p1, err := askPass(out, "Enter new password: ", attempts)
 		if err != nil {
 			return "", errors.Wrap(err, "password entry")
 		}
 
-		p2, err := askPass(out, "Re-enter password for verification: ")
+		p2, err := askPass(out, "Re-enter password for verification: ", attempts)
 		if err != nil {
 			return "", errors.Wrap(err, "password verification")
 		}
@@ -53,8 +69,8 @@ func askForChangedRepositoryPassword(out io.Writer) (string, error) {
 	}
 }
 
-func askForExistingRepositoryPassword(out io.Writer) (string, error) {
-	p1, err := askPass(out, "Enter password to open repository: ")
+func askForExistingRepositoryPassword(out io.Writer, attempts int) (string, error) {
+	p1, err := askPass(out, "Enter password to open repository: ", attempts)
 	if err != nil {
 		return "", err
 	}
@@ -68,17 +84,28 @@ func (c *App) setPasswordFromToken(pwd string) {
 	c.password = pwd
 }
 
+// setTokenOptions records restrictions of the connection token, which are applied when connecting.
+func (c *App) setTokenOptions(opt repo.TokenOptions) {
+	c.tokenOptions = opt
+}
+
 func (c *App) getPasswordFromFlags(ctx context.Context, isCreate, allowPersistent bool) (string, error) {
 	switch {
 	case c.password != "":
 		// password provided via --password flag or KOPIA_PASSWORD environment variable
 		return strings.TrimSpace(c.password), nil
+	case c.passwordFile != "":
+		// password provided via --password-file flag or KOPIA_PASSWORD_FILE environment variable
+		return readPasswordFile(ctx, c.passwordFile)
+	case c.passwordCommand != "":
+		// password provided by running --password-command or KOPIA_PASSWORD_COMMAND environment variable
+		return runPasswordCommand(ctx, c.passwordCommand, c.passwordCommandTimeout)
 	case isCreate:
 		// this is a new repository, ask for password
-		return askForNewRepositoryPassword(c.stdoutWriter)
+		return askForNewRepositoryPassword(c.stdoutWriter, c.passwordPromptAttempts)
 	case allowPersistent:
 		// try fetching the password from persistent storage specific to the configuration file.
-		pass, err := c.passwordPersistenceStrategy().GetPassword(ctx, c.repositoryConfigFileName())
+		pass, err := c.passwordPersistenceStrategy().GetPassword(ctx, c.repositoryConfigKey())
 		if err == nil {
 			return pass, nil
 		}
@@ -89,15 +116,86 @@ func (c *App) getPasswordFromFlags(ctx context.Context, isCreate, allowPersisten
 	}
 
 	// fall back to asking for existing password
-	return askForExistingRepositoryPassword(c.stdoutWriter)
+	return askForExistingRepositoryPassword(c.stdoutWriter, c.passwordPromptAttempts)
 }
 
-// askPass presents a given prompt and asks the user for password.
-func askPass(out io.Writer, prompt string) (string, error) {
-	for range 5 {
+// readPasswordFile returns the first line of the provided file, warning if the file is readable by other users.
+func readPasswordFile(ctx context.Context, fname string) (string, error) {
+	st, err := os.Stat(fname)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read password file")
+	}
+
+	if runtime.GOOS != "windows" && st.Mode().Perm()&0o004 != 0 {
+		log(ctx).Warnf("Password file %v is readable by all users, consider restricting its permissions.", fname)
+	}
+
+	b, err := os.ReadFile(fname) //nolint:gosec
+	if err != nil {
+		return "", errors.Wrap(err, "unable to read password file")
+	}
+
+	pass, _, _ := strings.Cut(string(b), "\n")
+	pass = strings.TrimSuffix(pass, "\r")
+
+	if pass == "" {
+		return "", errors.Errorf("password file %v is empty", fname)
+	}
+
+	return pass, nil
+}
+
+// runPasswordCommand runs the provided shell command and returns its output with surrounding whitespace removed.
+func runPasswordCommand(ctx context.Context, command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := shellCommand(ctx, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = passwordCommandWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", errors.Errorf("password command timed out after %v", timeout)
+		}
+
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.Wrapf(err, "password command failed: %v", msg)
+		}
+
+		return "", errors.Wrap(err, "password command failed")
+	}
+
+	pass := strings.TrimSpace(stdout.String())
+	if pass == "" {
+		return "", errors.New("password command returned empty password")
+	}
+
+	return pass, nil
+}
+
+func (c *App) getPasswordPromptAttempts() int {
+	return c.passwordPromptAttempts
+}
+
+// askPass presents a given prompt and asks the user for password, repeating the prompt up to the provided
+// number of times if no password is entered.
+func askPass(out io.Writer, prompt string, attempts int) (string, error) {
+	return askPassUsing(out, prompt, attempts, func() ([]byte, error) {
+		//nolint:wrapcheck
+		return term.ReadPassword(int(os.Stdin.Fd()))
+	})
+}
+
+func askPassUsing(out io.Writer, prompt string, attempts int, readPassword func() ([]byte, error)) (string, error) {
+	for range max(attempts, 1) {
 		fmt.Fprint(out, prompt) //nolint:errcheck
 
-		passBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+		passBytes, err := readPassword()
 		if err != nil {
 			return "", errors.Wrap(err, "password prompt error")
 		}
@@ -111,5 +209,77 @@ func askPass(out io.Writer, prompt string) (string, error) {
 		return string(passBytes), nil
 	}
 
-	return "", errors.New("can't get password")
+	return "", errors.Errorf("can't get password for prompt %q after %v attempts", strings.TrimSpace(prompt), max(attempts, 1))
+}
+
+// estimatePasswordEntropy returns a rough estimate of the number of bits of entropy of the password,
+// based on the classes of characters used and on how often characters repeat.
+func estimatePasswordEntropy(pass string) float64 {
+	var (
+		lower, upper, digit, symbol, other bool
+		counts                             = map[rune]int{}
+		total                              int
+	)
+
+	for _, ch := range pass {
+		switch {
+		case ch >= 'a' && ch <= 'z':
+			lower = true
+		case ch >= 'A' && ch <= 'Z':
+			upper = true
+		case ch >= '0' && ch <= '9':
+			digit = true
+		case ch < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+
+		counts[ch]++
+		total++
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	pool := 0
+
+	for _, c := range []struct {
+		used bool
+		size int
+	}{
+		{lower, 26},  //nolint:mnd
+		{upper, 26},  //nolint:mnd
+		{digit, 10},  //nolint:mnd
+		{symbol, 33}, //nolint:mnd
+		{other, 100}, //nolint:mnd
+	} {
+		if c.used {
+			pool += c.size
+		}
+	}
+
+	// entropy of the distribution of characters, which penalizes passwords such as 'aaaaaaaaaaaa'.
+	var shannon float64
+
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		shannon -= p * math.Log2(p)
+	}
+
+	return float64(total) * min(math.Log2(float64(pool)), shannon)
+}
+
+// weakPasswordReason returns the reason why the provided password is considered weak or an empty string if it's not.
+func weakPasswordReason(pass string) string {
+	if n := utf8.RuneCountInString(pass); n < minPasswordLength {
+		return fmt.Sprintf("it has %v characters, at least %v are recommended", n, minPasswordLength)
+	}
+
+	if bits := estimatePasswordEntropy(pass); bits < minPasswordEntropyBits {
+		return fmt.Sprintf("it is too predictable, estimated entropy is %.0f bits", bits)
+	}
+
+	return ""
 }
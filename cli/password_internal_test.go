@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeakPasswordReason(t *testing.T) {
+	cases := []struct {
+		pass     string
+		wantWeak bool
+	}{
+		{"", true},
+		{"short", true},
+		{"aaaaaaaaaaaaaaaaaaaa", true},
+		{"abababababababababab", true},
+		{"password1234", true},
+		{"qWQPJ2hiiLgWRRCr", false},
+		{"correct horse battery staple", false},
+		{"Tr0ub4dor&3-extended!", false},
+	}
+
+	for _, tc := range cases {
+		reason := weakPasswordReason(tc.pass)
+		require.Equal(t, tc.wantWeak, reason != "", "password %q, reason %q, entropy %v", tc.pass, reason, estimatePasswordEntropy(tc.pass))
+	}
+
+	require.Zero(t, estimatePasswordEntropy(""))
+	require.Greater(t, estimatePasswordEntropy("qWQPJ2hiiLgWRRCr"), estimatePasswordEntropy("qwqpjhiilgwrrcr"))
+}
+
+func TestAskPassAttempts(t *testing.T) {
+	var (
+		out   bytes.Buffer
+		calls int
+	)
+
+	_, err := askPassUsing(&out, "Enter password: ", 3, func() ([]byte, error) {
+		calls++
+		return nil, nil
+	})
+
+	require.ErrorContains(t, err, `can't get password for prompt "Enter password:" after 3 attempts`)
+	require.Equal(t, 3, calls)
+	require.Equal(t, 3, strings.Count(out.String(), "Enter password: "))
+
+	calls = 0
+
+	pass, err := askPassUsing(&out, "Enter password: ", 3, func() ([]byte, error) {
+		calls++
+		if calls < 2 {
+			return nil, nil
+		}
+
+		return []byte("secret"), nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "secret", pass)
+	require.Equal(t, 2, calls)
+}
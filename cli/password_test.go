@@ -0,0 +1,94 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestPasswordFile(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	delete(env.Environment, "KOPIA_PASSWORD")
+
+	dir := testutil.TempDirectory(t)
+	passwordFile := filepath.Join(dir, "password")
+
+	// only the first line is used.
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret-pass\r\nignored\n"), 0o600))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--password-file", passwordFile, "--no-persist-credentials")
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// connect using environment variable.
+	env.Environment["KOPIA_PASSWORD_FILE"] = passwordFile
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials")
+	require.NotContains(t, stderr, "readable by all users")
+
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// world-readable password file produces a warning.
+	require.NoError(t, os.Chmod(passwordFile, 0o644))
+
+	_, stderr = env.RunAndExpectSuccessWithErrOut(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials")
+	mustGetLineContaining(t, stderr, "readable by all users")
+
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// wrong password is rejected.
+	require.NoError(t, os.WriteFile(passwordFile, []byte("wrong-pass\n"), 0o600))
+	env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials")
+
+	require.NoError(t, os.WriteFile(passwordFile, nil, 0o600))
+	env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials")
+}
+
+func TestPasswordCommand(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("test relies on POSIX shell")
+	}
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	delete(env.Environment, "KOPIA_PASSWORD")
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--password-command", "echo '  secret-pass  '", "--no-persist-credentials")
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	env.Environment["KOPIA_PASSWORD_COMMAND"] = "printf secret-pass"
+	env.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials")
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// stderr of the failed command is reported.
+	_, stderr := env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials", "--password-command", "echo vault is sealed >&2; exit 3")
+	mustGetLineContaining(t, stderr, "password command failed: vault is sealed")
+
+	_, stderr = env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials", "--password-command", "sleep 10", "--password-command-timeout", "100ms")
+	mustGetLineContaining(t, stderr, "password command timed out after 100ms")
+
+	_, stderr = env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--no-persist-credentials", "--password-command", "true")
+	mustGetLineContaining(t, stderr, "password command returned empty password")
+}
+
+func TestWeakPasswordWarning(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--password", "weak", "--create-only")
+	mustGetLineContaining(t, stderr, "The repository password is weak: it has 4 characters")
+
+	env2 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	_, stderr = env2.RunAndExpectSuccessWithErrOut(t, "repo", "create", "filesystem", "--path", env2.RepoDir, "--password", "weak", "--create-only", "--allow-weak-password")
+	require.NotContains(t, strings.Join(stderr, "\n"), "password is weak")
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+// snapshotTagFilter selects snapshots based on their tags, it is shared by all snapshot commands
+// supporting tag filters so that they behave identically.
+type snapshotTagFilter struct {
+	tags       []string
+	legacyTags []string
+	anyTag     bool
+}
+
+func (f *snapshotTagFilter) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("tag", "Only select snapshots with the provided tag. Must be provided in the <key>:<value> format.").StringsVar(&f.tags)
+	cmd.Flag("tags", "Only select snapshots with the provided tag. Must be provided in the <key>:<value> format.").Hidden().StringsVar(&f.legacyTags)
+	cmd.Flag("any-tag", "Select snapshots with any of the provided tags instead of all of them").BoolVar(&f.anyTag)
+}
+
+func (f *snapshotTagFilter) tagStrings() []string {
+	return append(append([]string(nil), f.tags...), f.legacyTags...)
+}
+
+// tagPairs returns the manifest tag keys and values to match.
+func (f *snapshotTagFilter) tagPairs() ([][2]string, error) {
+	var result [][2]string
+
+	for _, kv := range f.tagStrings() {
+		tags, err := getTags([]string{kv})
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range tags {
+			result = append(result, [2]string{k, v})
+		}
+	}
+
+	return result, nil
+}
+
+// labels returns manifest labels which can be used to narrow down the listing of snapshot manifests
+// when all tags must match, or nil when any tag may match.
+func (f *snapshotTagFilter) labels() (map[string]string, error) {
+	if f.anyTag {
+		_, err := f.tagPairs()
+
+		return nil, err
+	}
+
+	return getTags(f.tagStrings())
+}
+
+// filter returns the manifests matching all (or with --any-tag, any) of the provided tags.
+func (f *snapshotTagFilter) filter(manifests []*snapshot.Manifest) ([]*snapshot.Manifest, error) {
+	if _, err := f.labels(); err != nil {
+		return nil, err
+	}
+
+	pairs, err := f.tagPairs()
+	if err != nil || len(pairs) == 0 {
+		return manifests, err
+	}
+
+	var result []*snapshot.Manifest
+
+	for _, m := range manifests {
+		if f.matches(m, pairs) {
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *snapshotTagFilter) matches(m *snapshot.Manifest, pairs [][2]string) bool {
+	for _, p := range pairs {
+		v, ok := m.Tags[p[0]]
+		if ok && v == p[1] {
+			if f.anyTag {
+				return true
+			}
+
+			continue
+		}
+
+		if !f.anyTag {
+			return false
+		}
+	}
+
+	return !f.anyTag
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestSnapshotTagFilter(t *testing.T) {
+	prod := &snapshot.Manifest{ID: "prod", Tags: map[string]string{"tag:env": "prod", "tag:job": "db"}}
+	staging := &snapshot.Manifest{ID: "staging", Tags: map[string]string{"tag:env": "staging", "tag:job": "db"}}
+	untagged := &snapshot.Manifest{ID: "untagged"}
+	all := []*snapshot.Manifest{prod, staging, untagged}
+
+	cases := []struct {
+		tags       []string
+		legacyTags []string
+		anyTag     bool
+		want       []*snapshot.Manifest
+		wantErr    bool
+	}{
+		{want: all},
+		{tags: []string{"env:prod"}, want: []*snapshot.Manifest{prod}},
+		{tags: []string{"job:db"}, want: []*snapshot.Manifest{prod, staging}},
+		{tags: []string{"job:db"}, legacyTags: []string{"env:staging"}, want: []*snapshot.Manifest{staging}},
+		{tags: []string{"job:db", "env:other"}},
+		{tags: []string{"env:prod", "env:staging"}, wantErr: true},
+		{tags: []string{"env:prod", "env:staging"}, anyTag: true, want: []*snapshot.Manifest{prod, staging}},
+		{tags: []string{"env:other", "env:staging"}, anyTag: true, want: []*snapshot.Manifest{staging}},
+		{tags: []string{"badtag"}, wantErr: true},
+		{tags: []string{"badtag"}, anyTag: true, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		f := snapshotTagFilter{tags: tc.tags, legacyTags: tc.legacyTags, anyTag: tc.anyTag}
+
+		got, err := f.filter(all)
+		if tc.wantErr {
+			require.Error(t, err, "tags: %v", tc.tags)
+			continue
+		}
+
+		require.NoError(t, err, "tags: %v", tc.tags)
+		require.Equal(t, tc.want, got, "tags: %v any: %v", tc.tags, tc.anyTag)
+
+		labels, err := f.labels()
+		require.NoError(t, err)
+
+		if tc.anyTag {
+			require.Nil(t, labels)
+		} else {
+			require.Len(t, labels, len(tc.tags)+len(tc.legacyTags))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotTagFiltering(t *testing.T) {
+	t.Parallel()
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file"), []byte("prod"), 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--tags", "env:prod", "--tags", "job:db")
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file"), []byte("staging"), 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--tags", "env:staging", "--tags", "job:db")
+
+	listIDs := func(args ...string) []string {
+		t.Helper()
+
+		var snapshots []*cli.SnapshotManifest
+
+		testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, append([]string{"snapshot", "list", "--json"}, args...)...), &snapshots)
+
+		var result []string
+
+		for _, s := range snapshots {
+			result = append(result, s.Tags["tag:env"])
+		}
+
+		return result
+	}
+
+	require.Equal(t, []string{"prod", "staging"}, listIDs())
+	require.Equal(t, []string{"prod"}, listIDs("--tag", "env:prod"))
+	require.Equal(t, []string{"staging"}, listIDs("--tag", "job:db", "--tag", "env:staging"))
+	require.Equal(t, []string{"staging"}, listIDs("--tags", "env:staging"))
+	require.Empty(t, listIDs("--tag", "env:prod", "--tag", "job:web"))
+	require.Equal(t, []string{"prod", "staging"}, listIDs("--tag", "env:prod", "--tag", "env:staging", "--any-tag"))
+
+	e.RunAndExpectFailure(t, "snapshot", "list", "--tag", "env:prod", "--tag", "env:staging")
+	e.RunAndExpectFailure(t, "snapshot", "list", "--tag", "badtag")
+
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "verify", "--tag", "env:prod")
+	mustGetLineContaining(t, stderr, "Finished processing")
+
+	// restore by path only considers snapshots with matching tags.
+	_, stderr = e.RunAndExpectFailure(t, "restore", filepath.Join(srcdir, "file"), filepath.Join(testutil.TempDirectory(t), "file"), "--tag", "env:other")
+	mustGetLineContaining(t, stderr, "no snapshots contain data for")
+}
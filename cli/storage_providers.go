@@ -6,6 +6,7 @@
 
 	"github.com/alecthomas/kingpin/v2"
 
+	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/blob/throttling"
 )
@@ -15,8 +16,10 @@
 type StorageProviderServices interface {
 	EnvName(s string) string
 	setPasswordFromToken(pwd string)
+	setTokenOptions(opt repo.TokenOptions)
 	storageProviders() []StorageProvider
 	stdin() io.Reader
+	getPasswordPromptAttempts() int
 }
 
 // StorageFlags is implemented by cli storage providers which need to support a
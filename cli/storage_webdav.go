@@ -13,9 +13,13 @@
 type storageWebDAVFlags struct {
 	options     webdav.Options
 	connectFlat bool
+
+	svc StorageProviderServices
 }
 
 func (c *storageWebDAVFlags) Setup(svc StorageProviderServices, cmd *kingpin.CmdClause) {
+	c.svc = svc
+
 	cmd.Flag("url", "URL of WebDAV server").Required().StringVar(&c.options.URL)
 	cmd.Flag("flat", "Use flat directory structure").BoolVar(&c.connectFlat)
 	cmd.Flag("webdav-username", "WebDAV username").Envar(svc.EnvName("KOPIA_WEBDAV_USERNAME")).StringVar(&c.options.Username)
@@ -30,7 +34,7 @@ func (c *storageWebDAVFlags) Connect(ctx context.Context, isCreate bool, formatV
 	wo := c.options
 
 	if wo.Username != "" && wo.Password == "" {
-		pass, err := askPass(os.Stdout, "Enter WebDAV password: ")
+		pass, err := askPass(os.Stdout, "Enter WebDAV password: ", c.svc.getPasswordPromptAttempts())
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+)
+
+// isTransientStorageError returns true for storage and network errors that are known to go away
+// when retried, such as timeouts, dropped connections and throttling. All other errors, including
+// ones that are not recognized, are considered permanent.
+func isTransientStorageError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ETIMEDOUT) {
+		return true
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+
+	return isTransientHTTPStatus(storageErrorHTTPStatus(err))
+}
+
+// storageErrorHTTPStatus returns the HTTP status code reported by the cloud storage provider or 0.
+func storageErrorHTTPStatus(err error) int {
+	var me minio.ErrorResponse
+	if errors.As(err, &me) {
+		return me.StatusCode
+	}
+
+	var ge *googleapi.Error
+	if errors.As(err, &ge) {
+		return ge.Code
+	}
+
+	var ae *azcore.ResponseError
+	if errors.As(err, &ae) {
+		return ae.StatusCode
+	}
+
+	return 0
+}
+
+func isTransientHTTPStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+
+	default:
+		return false
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+func TestIsTransientStorageError(t *testing.T) {
+	require.True(t, isTransientStorageError(errors.Wrap(context.DeadlineExceeded, "some op")))
+	require.True(t, isTransientStorageError(errors.Wrap(syscall.ECONNRESET, "read")))
+	require.True(t, isTransientStorageError(&net.DNSError{IsTimeout: true}))
+	require.True(t, isTransientStorageError(errors.Wrap(minio.ErrorResponse{StatusCode: http.StatusServiceUnavailable, Code: "SlowDown"}, "get")))
+	require.True(t, isTransientStorageError(&googleapi.Error{Code: http.StatusTooManyRequests}))
+
+	require.False(t, isTransientStorageError(nil))
+	require.False(t, isTransientStorageError(context.Canceled))
+	require.False(t, isTransientStorageError(blob.ErrBlobNotFound))
+	require.False(t, isTransientStorageError(&net.DNSError{}))
+	require.False(t, isTransientStorageError(minio.ErrorResponse{StatusCode: http.StatusForbidden}))
+	require.False(t, isTransientStorageError(errors.New("invalid checksum")))
+
+	// errors opening the repository that are not caused by storage or network failures are not retried.
+	require.False(t, isTransientStorageError(errors.Wrap(os.ErrNotExist, "some error")))
+	require.False(t, isTransientStorageError(errors.Wrap(blob.ErrBlobNotFound, "unable to read format blob")))
+	require.False(t, isTransientStorageError(blob.ErrInvalidCredentials))
+	require.False(t, isTransientStorageError(errors.Wrap(repo.ErrInvalidPassword, "unable to open")))
+	require.False(t, isTransientStorageError(repo.ErrRepositoryNotInitialized))
+	require.False(t, isTransientStorageError(repo.ErrRepositoryUnavailableDueToUpgradeInProgress))
+	require.False(t, isTransientStorageError(errors.New("connection reset by peer")))
+	require.True(t, isTransientStorageError(errors.Wrap(errors.Wrap(syscall.ECONNRESET, "read"), "unable to read format blob")))
+}
@@ -42,6 +42,7 @@
 // updateState is persisted in a JSON file and used to determine when to check for updates
 // and whether to notify user about updates.
 type updateState struct {
+	LastCheckTime    time.Time `json:"lastCheckTimestamp"`
 	NextCheckTime    time.Time `json:"nextCheckTimestamp"`
 	NextNotifyTime   time.Time `json:"nextNotifyTimestamp"`
 	AvailableVersion string    `json:"availableVersion"`
@@ -49,7 +50,7 @@ type updateState struct {
 
 // updateStateFilename returns the name of the update state.
 func (c *App) updateStateFilename() string {
-	return c.repositoryConfigFileName() + ".update-info.json"
+	return c.repositoryConfigKey() + ".update-info.json"
 }
 
 // writeUpdateState writes update state file.
@@ -157,14 +158,23 @@ func verifyGitHubReleaseIsComplete(ctx context.Context, releaseName string) erro
 	return nil
 }
 
-func (c *App) maybeCheckForUpdates(ctx context.Context) (string, error) {
+// updateCheckDisabledByEnvironment returns true if update checks were disabled using an environment variable.
+func (c *App) updateCheckDisabledByEnvironment() bool {
 	if v := os.Getenv(c.EnvName(checkForUpdatesEnvar)); v != "" {
 		// see if environment variable is set to false.
 		if b, err := strconv.ParseBool(v); err == nil && !b {
-			return "", errors.New("update check disabled")
+			return true
 		}
 	}
 
+	return false
+}
+
+func (c *App) maybeCheckForUpdates(ctx context.Context) (string, error) {
+	if c.updateCheckDisabledByEnvironment() {
+		return "", errors.New("update check disabled")
+	}
+
 	us, err := c.getUpdateState()
 	if err != nil {
 		return "", err
@@ -203,6 +213,7 @@ func (c *App) maybeCheckGithub(ctx context.Context, us *updateState) error {
 
 	// before we check for update, write update state file again, so if this fails
 	// we won't bother GitHub for a while
+	us.LastCheckTime = clock.Now()
 	us.NextCheckTime = clock.Now().Add(c.updateCheckInterval)
 	if err := c.writeUpdateState(us); err != nil {
 		return errors.Wrap(err, "unable to write update state")
@@ -231,6 +242,26 @@ func (c *App) maybeCheckGithub(ctx context.Context, us *updateState) error {
 	return nil
 }
 
+// checkForUpdatesNow checks GitHub for the latest release immediately, regardless of the time of next scheduled check.
+func (c *App) checkForUpdatesNow(ctx context.Context) (*updateState, error) {
+	if repo.BuildGitHubRepo == "" {
+		return nil, errors.New("update checks are not supported for builds not published on GitHub")
+	}
+
+	us, err := c.getUpdateState()
+	if err != nil {
+		return nil, err
+	}
+
+	us.NextCheckTime = time.Time{}
+
+	if err := c.maybeCheckGithub(ctx, us); err != nil {
+		return nil, errors.Wrap(err, "error checking GitHub")
+	}
+
+	return us, nil
+}
+
 // maybePrintUpdateNotification prints notification about available version.
 func (c *App) maybePrintUpdateNotification(ctx context.Context) {
 	if repo.BuildGitHubRepo == "" {
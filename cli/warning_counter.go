@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/logging"
+)
+
+// warningCountingLoggerFactory returns a logger factory that counts warnings and errors logged
+// during the invocation, so that --fail-on-warnings can report them at the end.
+func (c *App) warningCountingLoggerFactory(f logging.LoggerFactory) logging.LoggerFactory {
+	return func(module string) logging.Logger {
+		if module == content.FormatLogModule {
+			// content format log is a diagnostic log and does not carry user-facing warnings.
+			return f(module)
+		}
+
+		return f(module).Desugar().WithOptions(zap.Hooks(func(e zapcore.Entry) error {
+			if e.Level >= zapcore.WarnLevel {
+				c.warningCount.Add(1)
+			}
+
+			return nil
+		})).Sugar()
+	}
+}
+
+// checkWarnings returns an error if any warnings were logged and --fail-on-warnings is in effect.
+func (c *App) checkWarnings() error {
+	if !c.failOnWarnings {
+		return nil
+	}
+
+	if n := c.warningCount.Load(); n > 0 {
+		return errors.Errorf("%v warning(s) were reported, failing because of --fail-on-warnings", n)
+	}
+
+	return nil
+}
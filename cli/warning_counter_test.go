@@ -0,0 +1,27 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/kopia/kopia/internal/logfile"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestFailOnWarnings(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	runner.CustomizeApp = logfile.Attach
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	tmpLogDir := testutil.TempDirectory(t)
+
+	env.RunAndExpectSuccess(t, "content", "verify", "--fail-on-warnings", "--log-dir", tmpLogDir)
+
+	// the same command succeeds when it logs a warning, unless --fail-on-warnings is specified.
+	env.RunAndExpectSuccess(t, "content", "verify", "--simulate-missing=no-such-blob", "--log-dir", tmpLogDir)
+	env.RunAndExpectFailure(t, "content", "verify", "--simulate-missing=no-such-blob", "--fail-on-warnings", "--log-dir", tmpLogDir)
+}
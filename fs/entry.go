@@ -117,6 +117,12 @@ type DirectoryWithSummary interface {
 	Summary(ctx context.Context) (*DirectorySummary, error)
 }
 
+// HasExtendedAttributes is implemented by entries which can provide their extended attributes,
+// including POSIX ACLs on platforms which store them as extended attributes.
+type HasExtendedAttributes interface {
+	ExtendedAttributes() (map[string][]byte, error)
+}
+
 // ErrorEntry represents entry in a Directory that had encountered an error or is unknown/unsupported (ErrUnknown).
 type ErrorEntry interface {
 	Entry
@@ -9,6 +9,7 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/xattr"
 )
 
 const numEntriesToRead = 100 // number of directory entries to read in one shot
@@ -64,6 +65,11 @@ func (e *filesystemEntry) LocalFilesystemPath() string {
 	return e.fullPath()
 }
 
+func (e *filesystemEntry) ExtendedAttributes() (map[string][]byte, error) {
+	//nolint:wrapcheck
+	return xattr.List(e.fullPath())
+}
+
 type filesystemDirectory struct {
 	filesystemEntry
 }
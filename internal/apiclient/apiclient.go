@@ -4,6 +4,7 @@
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,7 +13,10 @@
 	"net/http/cookiejar"
 	net_url "net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -203,8 +207,31 @@ type Options struct {
 	TrustedServerCertificateFingerprint string
 
 	LogRequests bool
+
+	// HTTPVersion forces the HTTP protocol version used to talk to the server, one of
+	// HTTPVersionAuto (default), HTTPVersion1 or HTTPVersion2.
+	HTTPVersion string
+
+	// ConnectTimeout limits the time it takes to connect to the server, including TLS handshake (0 == default).
+	ConnectTimeout time.Duration
+
+	// ReadTimeout limits the time to wait for the server to start responding to a request (0 == unlimited).
+	ReadTimeout time.Duration
+
+	// MaxRetries is the number of times requests failing due to transient errors are retried.
+	MaxRetries int
+
+	// RetryInitialDelay is the delay before the first retry, which is doubled after each subsequent attempt.
+	RetryInitialDelay time.Duration
 }
 
+// Supported values of Options.HTTPVersion.
+const (
+	HTTPVersionAuto = "auto"
+	HTTPVersion1    = "1.1"
+	HTTPVersion2    = "2"
+)
+
 // NewKopiaAPIClient creates a client for connecting to Kopia HTTP API.
 func NewKopiaAPIClient(options Options) (*KopiaAPIClient, error) {
 	var transport http.RoundTripper
@@ -216,6 +243,11 @@ func NewKopiaAPIClient(options Options) (*KopiaAPIClient, error) {
 		transport = http.DefaultTransport
 	}
 
+	transport, err := withTimeouts(transport, options.ConnectTimeout, options.ReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+
 	uri := options.BaseURL
 
 	if strings.HasPrefix(options.BaseURL, "unix+https://") || strings.HasPrefix(options.BaseURL, "unix+http://") {
@@ -225,11 +257,16 @@ func NewKopiaAPIClient(options Options) (*KopiaAPIClient, error) {
 		transport = tp.Clone()
 		tp, _ = transport.(*http.Transport)
 		tp.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
-			dial, err := net.Dial("unix", u.Path)
+			dial, err := net.DialTimeout("unix", u.Path, options.ConnectTimeout)
 			return dial, errors.Wrap(err, "Failed to conect to socket: "+options.BaseURL)
 		}
 	}
 
+	transport, err = withHTTPVersion(transport, options.BaseURL, options.HTTPVersion)
+	if err != nil {
+		return nil, err
+	}
+
 	// wrap with a round-tripper that provides basic authentication
 	if options.Username != "" || options.Password != "" {
 		transport = basicAuthTransport{transport, options.Username, options.Password}
@@ -239,6 +276,10 @@ func NewKopiaAPIClient(options Options) (*KopiaAPIClient, error) {
 		transport = loggingTransport{transport}
 	}
 
+	if options.MaxRetries > 0 {
+		transport = retryTransport{transport, options.MaxRetries, max(options.RetryInitialDelay, minRetryDelay)}
+	}
+
 	cj, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create cookie jar")
@@ -254,6 +295,78 @@ func NewKopiaAPIClient(options Options) (*KopiaAPIClient, error) {
 	}, nil
 }
 
+// withHTTPVersion returns a transport configured to use the provided HTTP protocol version.
+func withHTTPVersion(transport http.RoundTripper, baseURL, version string) (http.RoundTripper, error) {
+	switch version {
+	case "", HTTPVersionAuto:
+		return transport, nil
+
+	case HTTPVersion1, HTTPVersion2:
+		// handled below
+
+	default:
+		return nil, errors.Errorf("unsupported HTTP version %q, must be one of %v, %v or %v", version, HTTPVersionAuto, HTTPVersion1, HTTPVersion2)
+	}
+
+	tp, ok := transport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unable to configure HTTP version for custom transport")
+	}
+
+	tp = tp.Clone()
+
+	if tp.TLSClientConfig == nil {
+		tp.TLSClientConfig = &tls.Config{} //nolint:gosec
+	}
+
+	if version == HTTPVersion1 {
+		// non-nil empty TLSNextProto disables HTTP/2.
+		tp.ForceAttemptHTTP2 = false
+		tp.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		tp.TLSClientConfig.NextProtos = []string{"http/1.1"}
+
+		return tp, nil
+	}
+
+	// HTTP/2 is only negotiated over TLS.
+	if !strings.HasPrefix(baseURL, "https://") && !strings.HasPrefix(baseURL, "unix+https://") {
+		return nil, errors.New("HTTP/2 requires an https:// server address")
+	}
+
+	tp.ForceAttemptHTTP2 = true
+	tp.TLSClientConfig.NextProtos = []string{"h2"}
+
+	return tp, nil
+}
+
+// withTimeouts returns a transport configured with the provided connection and response timeouts.
+func withTimeouts(transport http.RoundTripper, connectTimeout, readTimeout time.Duration) (http.RoundTripper, error) {
+	if connectTimeout <= 0 && readTimeout <= 0 {
+		return transport, nil
+	}
+
+	tp, ok := transport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unable to configure timeouts for custom transport")
+	}
+
+	tp = tp.Clone()
+
+	if connectTimeout > 0 {
+		tp.DialContext = (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: defaultKeepAlive,
+		}).DialContext
+		tp.TLSHandshakeTimeout = connectTimeout
+	}
+
+	if readTimeout > 0 {
+		tp.ResponseHeaderTimeout = readTimeout
+	}
+
+	return tp, nil
+}
+
 type basicAuthTransport struct {
 	base     http.RoundTripper
 	username string
@@ -285,3 +398,118 @@ func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	return resp, nil
 }
+
+const (
+	defaultKeepAlive = 30 * time.Second
+	minRetryDelay    = 10 * time.Millisecond
+	maxRetryDelay    = 10 * time.Second
+)
+
+// retryTransport retries requests that failed because of transient network errors or server unavailability,
+// such as during a server restart. Requests which may have reached the server are only retried when
+// their method is idempotent.
+type retryTransport struct {
+	base         http.RoundTripper
+	maxRetries   int
+	initialDelay time.Duration
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	delay := t.initialDelay
+
+	for attempt := 0; ; attempt++ {
+		r := req
+
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to rewind request body")
+			}
+
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(r)
+		if attempt >= t.maxRetries || !isRetriable(req, resp, err) {
+			//nolint:wrapcheck
+			return resp, err
+		}
+
+		sleepTime := delay
+
+		if resp != nil {
+			sleepTime = max(sleepTime, retryAfter(resp))
+			log(ctx).Debugf("%v %v returned %v, retrying in %v", req.Method, req.URL, resp.Status, sleepTime)
+
+			io.Copy(io.Discard, resp.Body) //nolint:errcheck
+			resp.Body.Close()              //nolint:errcheck
+		} else {
+			log(ctx).Debugf("%v %v failed with %v, retrying in %v", req.Method, req.URL, err, sleepTime)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "request canceled while waiting to retry")
+
+		case <-time.After(sleepTime):
+		}
+
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
+
+// isRetriable determines whether the request should be retried given the response or error from the previous attempt.
+func isRetriable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// request body can't be replayed.
+		return false
+	}
+
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+
+		var oe *net.OpError
+		if errors.As(err, &oe) && oe.Op == "dial" {
+			// the request never reached the server, so it's safe to retry regardless of method.
+			return true
+		}
+
+		return isIdempotent(req.Method) &&
+			(errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		// the request was rejected without being processed.
+		return true
+
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+
+	default:
+		return false
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter returns the delay requested by the server via Retry-After header, capped at maxRetryDelay.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+
+	return min(time.Duration(secs)*time.Second, maxRetryDelay)
+}
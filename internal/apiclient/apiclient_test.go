@@ -0,0 +1,136 @@
+package apiclient_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestHTTPVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto)) //nolint:errcheck
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	h := sha256.Sum256(srv.Certificate().Raw)
+	fingerprint := hex.EncodeToString(h[:])
+
+	cases := map[string]string{
+		"":                        "HTTP/2.0",
+		apiclient.HTTPVersionAuto: "HTTP/2.0",
+		apiclient.HTTPVersion1:    "HTTP/1.1",
+		apiclient.HTTPVersion2:    "HTTP/2.0",
+	}
+
+	for version, wantProto := range cases {
+		t.Run(version, func(t *testing.T) {
+			cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+				BaseURL:                             srv.URL,
+				TrustedServerCertificateFingerprint: fingerprint,
+				HTTPVersion:                         version,
+			})
+			require.NoError(t, err)
+
+			var resp []byte
+
+			require.NoError(t, cli.Get(testlogging.Context(t), "/proto", nil, &resp))
+			require.Equal(t, wantProto, string(resp))
+		})
+	}
+}
+
+func TestHTTPVersionInvalid(t *testing.T) {
+	_, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:     "https://localhost:1234",
+		HTTPVersion: "3",
+	})
+	require.ErrorContains(t, err, "unsupported HTTP version")
+
+	_, err = apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:     "http://localhost:1234",
+		HTTPVersion: apiclient.HTTPVersion2,
+	})
+	require.ErrorContains(t, err, "requires an https://")
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fail first 2 calls, then succeed.
+		if calls.Add(1) <= 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(r.Method)) //nolint:errcheck
+	}))
+
+	defer srv.Close()
+
+	newClient := func(maxRetries int) *apiclient.KopiaAPIClient {
+		calls.Store(0)
+
+		cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+			BaseURL:           srv.URL,
+			MaxRetries:        maxRetries,
+			RetryInitialDelay: time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		return cli
+	}
+
+	ctx := testlogging.Context(t)
+
+	var resp []byte
+
+	require.NoError(t, newClient(3).Get(ctx, "/x", nil, &resp))
+	require.Equal(t, "GET", string(resp))
+	require.EqualValues(t, 3, calls.Load())
+
+	require.NoError(t, newClient(2).Put(ctx, "/x", map[string]string{"a": "b"}, &resp))
+	require.Equal(t, "PUT", string(resp))
+	require.EqualValues(t, 3, calls.Load())
+
+	var herr apiclient.HTTPStatusError
+
+	require.ErrorAs(t, newClient(1).Get(ctx, "/x", nil, &resp), &herr)
+	require.Equal(t, http.StatusServiceUnavailable, herr.HTTPStatusCode)
+	require.EqualValues(t, 2, calls.Load())
+
+	// non-idempotent requests which reached the server are not retried.
+	require.ErrorAs(t, newClient(3).Post(ctx, "/x", nil, &resp), &herr)
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestReadTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+
+	defer srv.Close()
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:     srv.URL,
+		ReadTimeout: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	require.ErrorContains(t, cli.Get(testlogging.Context(t), "/x", nil, nil), "timeout awaiting response headers")
+}
@@ -2,6 +2,19 @@
 
 import "github.com/kopia/kopia/internal/metrics"
 
+// names of metrics reported by each cache, labeled with the cache ID.
+const (
+	metricNameHit       = "cache_hit"
+	metricNameHitBytes  = "cache_hit_bytes"
+	metricNameMiss      = "cache_miss"
+	metricNameMalformed = "cache_malformed"
+	metricNameMissBytes = "cache_miss_bytes"
+	metricNameMissError = "cache_miss_errors"
+	metricNameStoreErr  = "cache_store_errors"
+
+	metricLabelCache = "cache"
+)
+
 type metricsStruct struct {
 	metricHitCount                *metrics.Counter
 	metricHitBytes                *metrics.Counter
@@ -14,36 +27,36 @@ type metricsStruct struct {
 
 func initMetricsStruct(mr *metrics.Registry, cacheID string) metricsStruct {
 	labels := map[string]string{
-		"cache": cacheID,
+		metricLabelCache: cacheID,
 	}
 
 	return metricsStruct{
 		metricHitCount: mr.CounterInt64(
-			"cache_hit",
+			metricNameHit,
 			"Number of time content was retrieved from the cache", labels),
 
 		metricHitBytes: mr.CounterInt64(
-			"cache_hit_bytes",
+			metricNameHitBytes,
 			"Number of bytes retrieved from the cache", labels),
 
 		metricMissCount: mr.CounterInt64(
-			"cache_miss",
+			metricNameMiss,
 			"Number of time content was not found in the cache and fetched from the storage", labels),
 
 		metricMalformedCacheDataCount: mr.CounterInt64(
-			"cache_malformed",
+			metricNameMalformed,
 			"Number of times malformed content was read from the cache", labels),
 
 		metricMissBytes: mr.CounterInt64(
-			"cache_miss_bytes",
+			metricNameMissBytes,
 			"Number of bytes retrieved from the underlying storage", labels),
 
 		metricMissErrors: mr.CounterInt64(
-			"cache_miss_errors",
+			metricNameMissError,
 			"Number of time content could not be found in the underlying storage", labels),
 
 		metricStoreErrors: mr.CounterInt64(
-			"cache_store_errors",
+			metricNameStoreErr,
 			"Number of time content could not be saved in the cache", labels),
 	}
 }
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"strings"
+
+	"github.com/kopia/kopia/internal/metrics"
+)
+
+// Stats summarizes the activity of a single cache since the metrics were last reset.
+type Stats struct {
+	HitCount       int64 `json:"hitCount"`
+	HitBytes       int64 `json:"hitBytes"`
+	MissCount      int64 `json:"missCount"`
+	MissBytes      int64 `json:"missBytes"`
+	MissErrors     int64 `json:"missErrors"`
+	MalformedCount int64 `json:"malformedCount"`
+	StoreErrors    int64 `json:"storeErrors"`
+}
+
+// HitRate returns the fraction of lookups that were served from the cache or zero if there were none.
+func (s Stats) HitRate() float64 {
+	total := s.HitCount + s.MissCount
+	if total == 0 {
+		return 0
+	}
+
+	return float64(s.HitCount) / float64(total)
+}
+
+// StatsFromMetrics extracts statistics of all caches from the provided metrics snapshot, keyed by cache ID.
+func StatsFromMetrics(snap metrics.Snapshot) map[string]Stats {
+	result := map[string]Stats{}
+
+	for fullName, v := range snap.Counters {
+		name, cacheID, ok := parseCacheCounterName(fullName)
+		if !ok {
+			continue
+		}
+
+		st := result[cacheID]
+
+		switch name {
+		case metricNameHit:
+			st.HitCount += v
+		case metricNameHitBytes:
+			st.HitBytes += v
+		case metricNameMiss:
+			st.MissCount += v
+		case metricNameMissBytes:
+			st.MissBytes += v
+		case metricNameMissError:
+			st.MissErrors += v
+		case metricNameMalformed:
+			st.MalformedCount += v
+		case metricNameStoreErr:
+			st.StoreErrors += v
+		default:
+			continue
+		}
+
+		result[cacheID] = st
+	}
+
+	return result
+}
+
+// parseCacheCounterName splits the full name of a counter, such as 'cache_hit[cache:contents]',
+// into the metric name and cache ID.
+func parseCacheCounterName(fullName string) (name, cacheID string, ok bool) {
+	name, labels, ok := strings.Cut(fullName, "[")
+	if !ok {
+		return "", "", false
+	}
+
+	cacheID, ok = strings.CutPrefix(strings.TrimSuffix(labels, "]"), metricLabelCache+":")
+	if !ok || strings.Contains(cacheID, ";") {
+		return "", "", false
+	}
+
+	return name, cacheID, true
+}
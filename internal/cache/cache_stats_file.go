@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+)
+
+// StatsFileName is the name of the file in the cache directory which accumulates statistics
+// of caches of all processes that used the directory.
+const StatsFileName = "cache-stats.json"
+
+// Add returns the sum of the statistics.
+func (s Stats) Add(o Stats) Stats {
+	s.HitCount += o.HitCount
+	s.HitBytes += o.HitBytes
+	s.MissCount += o.MissCount
+	s.MissBytes += o.MissBytes
+	s.MissErrors += o.MissErrors
+	s.MalformedCount += o.MalformedCount
+	s.StoreErrors += o.StoreErrors
+
+	return s
+}
+
+// ReadStatsFile returns the statistics accumulated in the provided cache directory, keyed by cache ID.
+func ReadStatsFile(cacheDir string) (map[string]Stats, error) {
+	result := map[string]Stats{}
+
+	b, err := os.ReadFile(filepath.Join(cacheDir, StatsFileName)) //nolint:gosec
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read cache statistics")
+	}
+
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, errors.Wrap(err, "invalid cache statistics")
+	}
+
+	return result, nil
+}
+
+// AddToStatsFile adds the provided statistics to the ones accumulated in the provided cache directory.
+func AddToStatsFile(cacheDir string, stats map[string]Stats) error {
+	for cacheID, st := range stats {
+		if st == (Stats{}) {
+			delete(stats, cacheID)
+		}
+	}
+
+	if len(stats) == 0 {
+		return nil
+	}
+
+	fname := filepath.Join(cacheDir, StatsFileName)
+
+	l := flock.New(fname + ".lock")
+	if err := l.Lock(); err != nil {
+		return errors.Wrap(err, "error acquiring cache statistics lock")
+	}
+
+	defer l.Unlock() //nolint:errcheck
+
+	total, err := ReadStatsFile(cacheDir)
+	if err != nil {
+		// start over rather than failing forever on a damaged file.
+		total = map[string]Stats{}
+	}
+
+	for cacheID, st := range stats {
+		total[cacheID] = total[cacheID].Add(st)
+	}
+
+	b, err := json.Marshal(total)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize cache statistics")
+	}
+
+	return errors.Wrap(atomicfile.Write(fname, bytes.NewReader(b)), "unable to write cache statistics")
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/metrics"
+)
+
+func TestStatsFromMetrics(t *testing.T) {
+	mr := metrics.NewRegistry()
+
+	m1 := initMetricsStruct(mr, "contents")
+	m2 := initMetricsStruct(mr, "metadata")
+
+	m1.reportHitBytes(100)
+	m1.reportHitBytes(50)
+	m1.reportMissBytes(30)
+	m1.reportMissError()
+	m2.reportMissBytes(10)
+	m2.reportStoreError()
+
+	mr.CounterInt64("other_counter", "unrelated", map[string]string{"cache": "contents"}).Add(5)
+	mr.CounterInt64("cache_stats_test_unlabeled", "unlabeled", nil).Add(7)
+
+	stats := StatsFromMetrics(mr.Snapshot(false))
+
+	require.Equal(t, map[string]Stats{
+		"contents": {HitCount: 2, HitBytes: 150, MissCount: 1, MissBytes: 30, MissErrors: 1},
+		"metadata": {MissCount: 1, MissBytes: 10, StoreErrors: 1},
+	}, stats)
+
+	require.InDelta(t, 2.0/3.0, stats["contents"].HitRate(), 1e-9)
+	require.Zero(t, stats["metadata"].HitRate())
+	require.Zero(t, Stats{}.HitRate())
+}
+
+func TestStatsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := ReadStatsFile(dir)
+	require.NoError(t, err)
+	require.Empty(t, st)
+
+	require.NoError(t, AddToStatsFile(dir, map[string]Stats{
+		"contents": {HitCount: 2, HitBytes: 200, MissCount: 1},
+		"metadata": {},
+	}))
+	require.NoError(t, AddToStatsFile(dir, map[string]Stats{
+		"contents": {HitCount: 1, HitBytes: 50},
+		"indexes":  {MissCount: 3, MissErrors: 1},
+	}))
+
+	st, err = ReadStatsFile(dir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]Stats{
+		"contents": {HitCount: 3, HitBytes: 250, MissCount: 1},
+		"indexes":  {MissCount: 3, MissErrors: 1},
+	}, st)
+}
@@ -21,6 +21,23 @@
 
 var log = logging.Module("diff")
 
+// Kinds of changes reported in Change.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Change describes a single difference between two filesystems.
+type Change struct {
+	Kind    string `json:"change"`
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	OldType string `json:"oldType,omitempty"`
+	OldSize *int64 `json:"oldSize,omitempty"`
+	NewSize *int64 `json:"newSize,omitempty"`
+}
+
 // Comparer outputs diff information between two filesystems.
 type Comparer struct {
 	out    io.Writer
@@ -28,6 +45,10 @@ type Comparer struct {
 
 	DiffCommand   string
 	DiffArguments []string
+
+	// OnChange, when set, receives structured information about each difference
+	// instead of the human-readable output being written.
+	OnChange func(Change)
 }
 
 // Compare compares two filesystem entries and emits their diff information.
@@ -79,13 +100,23 @@ func (c *Comparer) compareEntry(ctx context.Context, e1, e2 fs.Entry, path strin
 	if h1, ok := e1.(object.HasObjectID); ok {
 		if h2, ok := e2.(object.HasObjectID); ok {
 			if h1.ObjectID() == h2.ObjectID() {
+				if !attributesEqual(e1, e2) {
+					c.report(ChangeModified, path, e1, e2)
+					c.output("changed attributes of %v\n", path)
+
+					return nil
+				}
+
 				log(ctx).Debugf("unchanged %v", path)
+
 				return nil
 			}
 		}
 	}
 
 	if e1 == nil {
+		c.report(ChangeAdded, path, nil, e2)
+
 		if dir2, isDir2 := e2.(fs.Directory); isDir2 {
 			c.output("added directory %v\n", path)
 			return c.compareDirectories(ctx, nil, dir2, path)
@@ -103,6 +134,8 @@ func (c *Comparer) compareEntry(ctx context.Context, e1, e2 fs.Entry, path strin
 	}
 
 	if e2 == nil {
+		c.report(ChangeRemoved, path, e1, nil)
+
 		if dir1, isDir1 := e1.(fs.Directory); isDir1 {
 			c.output("removed directory %v\n", path)
 			return c.compareDirectories(ctx, dir1, nil, path)
@@ -119,11 +152,30 @@ func (c *Comparer) compareEntry(ctx context.Context, e1, e2 fs.Entry, path strin
 		return nil
 	}
 
-	compareEntry(e1, e2, path, c.out)
+	metadataOut := c.out
+	if c.OnChange != nil {
+		metadataOut = io.Discard
+	}
+
+	equal := compareEntry(e1, e2, path, metadataOut)
 
 	dir1, isDir1 := e1.(fs.Directory)
 	dir2, isDir2 := e2.(fs.Directory)
 
+	_, isFile1 := e1.(fs.File)
+	_, isFile2 := e2.(fs.File)
+
+	switch {
+	case isDir1 && isDir2:
+		// size and modification time of a directory change with its contents, which are reported separately.
+		if !attributesEqual(e1, e2) {
+			c.report(ChangeModified, path, e1, e2)
+		}
+
+	case !equal || isDir1 != isDir2 || (isFile1 && isFile2):
+		c.report(ChangeModified, path, e1, e2)
+	}
+
 	if isDir1 {
 		if !isDir2 {
 			// right is a non-directory, left is a directory
@@ -206,6 +258,11 @@ func compareEntry(e1, e2 fs.Entry, fullpath string, out io.Writer) bool {
 	return equal
 }
 
+// attributesEqual returns true if the entries have the same mode and owner.
+func attributesEqual(e1, e2 fs.Entry) bool {
+	return e1.Mode() == e2.Mode() && e1.Owner() == e2.Owner()
+}
+
 func (c *Comparer) compareDirectoryEntries(ctx context.Context, entries1, entries2 []fs.Entry, dirPath string) error {
 	e1byname := map[string]fs.Entry{}
 	for _, e1 := range entries1 {
@@ -298,9 +355,65 @@ func downloadFile(ctx context.Context, f fs.File, fname string) error {
 }
 
 func (c *Comparer) output(msg string, args ...interface{}) {
+	if c.OnChange != nil {
+		return
+	}
+
 	fmt.Fprintf(c.out, msg, args...) //nolint:errcheck
 }
 
+func (c *Comparer) report(kind, path string, oldEntry, newEntry fs.Entry) {
+	if c.OnChange == nil {
+		return
+	}
+
+	ch := Change{Kind: kind, Path: path}
+
+	if oldEntry != nil {
+		ch.Type = entryType(oldEntry)
+		ch.OldSize = entrySize(oldEntry)
+	}
+
+	if newEntry != nil {
+		if ch.Type != "" && ch.Type != entryType(newEntry) {
+			ch.OldType = ch.Type
+		}
+
+		ch.Type = entryType(newEntry)
+		ch.NewSize = entrySize(newEntry)
+	}
+
+	c.OnChange(ch)
+}
+
+func entryType(e fs.Entry) string {
+	switch e.(type) {
+	case fs.Directory:
+		return "directory"
+	case fs.Symlink:
+		return "symlink"
+	case fs.File:
+		return "file"
+	default:
+		if e.IsDir() {
+			return "directory"
+		}
+
+		return "file"
+	}
+}
+
+// entrySize returns the size of a non-directory entry, directory sizes are not meaningful.
+func entrySize(e fs.Entry) *int64 {
+	if e.IsDir() {
+		return nil
+	}
+
+	s := e.Size()
+
+	return &s
+}
+
 // NewComparer creates a comparer for a given repository that will output the results to a given writer.
 func NewComparer(out io.Writer) (*Comparer, error) {
 	tmp, err := os.MkdirTemp("", "kopia")
@@ -230,3 +230,51 @@ func TestCompareDifferentDirectories_FileTimeDiff(t *testing.T) {
 func createTestDirectory(name string, modtime time.Time, files ...fs.Entry) *testDirectory {
 	return &testDirectory{name: name, files: files, modtime: modtime}
 }
+
+func TestCompareOnChange(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := context.Background()
+
+	modtime := time.Date(2023, time.April, 12, 10, 30, 0, 0, time.UTC)
+	dir1 := createTestDirectory(
+		"testDir1",
+		modtime,
+		&testFile{name: "file1.txt", content: "abcdefghij", modtime: modtime},
+		&testFile{name: "file2.txt", content: "klmnop", modtime: modtime},
+		createTestDirectory("sub", modtime),
+	)
+	dir2 := createTestDirectory(
+		"testDir2",
+		modtime,
+		&testFile{name: "file1.txt", content: "abcdefghij", modtime: modtime},
+		&testFile{name: "file2.txt", content: "klmnopqrs", modtime: modtime.Add(time.Hour)},
+		&testFile{name: "file3.txt", content: "xyz", modtime: modtime},
+	)
+
+	c, err := diff.NewComparer(&buf)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	var changes []diff.Change
+
+	c.OnChange = func(ch diff.Change) {
+		changes = append(changes, ch)
+	}
+
+	require.NoError(t, c.Compare(ctx, dir1, dir2))
+
+	int64Ptr := func(v int64) *int64 { return &v }
+
+	require.Equal(t, []diff.Change{
+		{Kind: diff.ChangeModified, Path: "./file2.txt", Type: "file", OldSize: int64Ptr(6), NewSize: int64Ptr(9)},
+		{Kind: diff.ChangeAdded, Path: "./file3.txt", Type: "file", NewSize: int64Ptr(3)},
+		{Kind: diff.ChangeRemoved, Path: "./sub", Type: "directory"},
+	}, changes)
+
+	// human-readable output is suppressed.
+	require.Empty(t, buf.String())
+}
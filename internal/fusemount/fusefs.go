@@ -72,7 +72,12 @@ type fuseFileNode struct {
 	fuseNode
 }
 
-func (f *fuseFileNode) Open(ctx context.Context, _ uint32) (gofusefs.FileHandle, uint32, syscall.Errno) {
+func (f *fuseFileNode) Open(ctx context.Context, flags uint32) (gofusefs.FileHandle, uint32, syscall.Errno) {
+	// snapshots are immutable, reject attempts to open files for writing.
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_TRUNC|syscall.O_APPEND) != 0 {
+		return nil, 0, syscall.EROFS
+	}
+
 	reader, err := f.entry.(fs.File).Open(ctx)
 	if err != nil {
 		log(ctx).Errorf("error opening %v: %v", f.entry.Name(), err)
@@ -7,6 +7,7 @@
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -44,9 +45,12 @@ type loggingFlags struct {
 	logFileMaxSegmentSize       int
 	logLevel                    string
 	fileLogLevel                string
+	logLevelModules             []string
 	fileLogLocalTimezone        bool
 	jsonLogFile                 bool
 	jsonLogConsole              bool
+	logFormat                   string
+	fileLogFormat               string
 	forceColor                  bool
 	disableColor                bool
 	consoleLogTimestamps        bool
@@ -69,6 +73,9 @@ func (c *loggingFlags) setup(cliApp *cli.App, app *kingpin.Application) {
 	app.Flag("content-log-dir-max-age", "Maximum age of content log files to retain").Envar(cliApp.EnvName("KOPIA_CONTENT_LOG_DIR_MAX_AGE")).Default("720h").Hidden().DurationVar(&c.contentLogDirMaxAge)
 	app.Flag("content-log-dir-max-total-size-mb", "Maximum total size of log files to retain").Envar(cliApp.EnvName("KOPIA_CONTENT_LOG_DIR_MAX_SIZE_MB")).Hidden().Default("1000").Float64Var(&c.contentLogDirMaxTotalSizeMB)
 	app.Flag("log-level", "Console log level").Default("info").EnumVar(&c.logLevel, logLevels...)
+	app.Flag("log-level-module", "Override console log level for a module and its submodules (NAME=LEVEL, can be repeated)").PlaceHolder("NAME=LEVEL").StringsVar(&c.logLevelModules)
+	app.Flag("log-format", "Console log format").Envar(cliApp.EnvName("KOPIA_LOG_FORMAT")).Default(logFormatText).EnumVar(&c.logFormat, logFormats...)
+	app.Flag("file-log-format", "File log format").Envar(cliApp.EnvName("KOPIA_FILE_LOG_FORMAT")).Default(logFormatText).EnumVar(&c.fileLogFormat, logFormats...)
 	app.Flag("json-log-console", "JSON log file").Hidden().BoolVar(&c.jsonLogConsole)
 	app.Flag("json-log-file", "JSON log file").Hidden().BoolVar(&c.jsonLogFile)
 	app.Flag("file-log-level", "File log level").Default("debug").EnumVar(&c.fileLogLevel, logLevels...)
@@ -94,8 +101,32 @@ func Attach(cliApp *cli.App, app *kingpin.Application) {
 	logFileNameSuffix = ".log"
 )
 
+// supported values of --log-format and --file-log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+//nolint:gochecknoglobals
+var logFormats = []string{logFormatText, logFormatJSON}
+
+// jsonConsole returns true if console logs should be emitted as JSON lines.
+func (c *loggingFlags) jsonConsole() bool {
+	return c.jsonLogConsole || c.logFormat == logFormatJSON
+}
+
+// jsonFile returns true if file logs should be emitted as JSON lines.
+func (c *loggingFlags) jsonFile() bool {
+	return c.jsonLogFile || c.fileLogFormat == logFormatJSON
+}
+
 // initialize is invoked as part of command execution to create log file just before it's needed.
 func (c *loggingFlags) initialize(ctx *kingpin.ParseContext) error {
+	moduleLevels, err := parseModuleLogLevels(c.logLevelModules, logging.Modules())
+	if err != nil {
+		return err
+	}
+
 	if c.logDir == "" {
 		return nil
 	}
@@ -112,18 +143,43 @@ func (c *loggingFlags) initialize(ctx *kingpin.ParseContext) error {
 		suffix = strings.ReplaceAll(c.FullCommand(), " ", "-")
 	}
 
+	fileCore := c.setupLogFileCore(now, suffix)
+
 	rootLogger := zap.New(zapcore.NewTee(
-		c.setupConsoleCore(),
-		c.setupLogFileCore(now, suffix),
+		c.setupConsoleCore(c.consoleLogLevel()),
+		fileCore,
 	), zap.WithClock(zaplogutil.Clock()))
 
-	contentLogger := zap.New(c.setupContentLogFileBackend(now, suffix), zap.WithClock(zaplogutil.Clock())).Sugar()
+	// loggers for modules with overridden console log level share the log file core with the root logger.
+	moduleLoggers := map[string]*zap.Logger{}
+
+	for name, lvl := range moduleLevels {
+		moduleLoggers[name] = zap.New(zapcore.NewTee(
+			c.setupConsoleCore(lvl),
+			fileCore,
+		), zap.WithClock(zaplogutil.Clock()))
+	}
+
+	contentCore := c.setupContentLogFileBackend(now, suffix)
+	contentLogger := zap.New(contentCore, zap.WithClock(zaplogutil.Clock())).Sugar()
+
+	// content logs only go to the content log file, unless their console log level is overridden.
+	if name, ok := longestModulePrefix(moduleLevels, content.FormatLogModule); ok {
+		contentLogger = zap.New(zapcore.NewTee(
+			c.setupConsoleCore(moduleLevels[name]),
+			contentCore,
+		), zap.WithClock(zaplogutil.Clock())).Sugar()
+	}
 
 	c.cliApp.SetLoggerFactory(func(module string) logging.Logger {
 		if module == content.FormatLogModule {
 			return contentLogger
 		}
 
+		if name, ok := longestModulePrefix(moduleLevels, module); ok {
+			return moduleLoggers[name].Named(module).Sugar()
+		}
+
 		return rootLogger.Named(module).Sugar()
 	})
 
@@ -138,7 +194,7 @@ func (c *loggingFlags) initialize(ctx *kingpin.ParseContext) error {
 	return nil
 }
 
-func (c *loggingFlags) setupConsoleCore() zapcore.Core {
+func (c *loggingFlags) setupConsoleCore(lvl zapcore.LevelEnabler) zapcore.Core {
 	ec := zapcore.EncoderConfig{
 		LevelKey:         "l",
 		MessageKey:       "m",
@@ -151,10 +207,11 @@ func (c *loggingFlags) setupConsoleCore() zapcore.Core {
 
 	timeFormat := zaplogutil.PreciseLayout
 
-	if c.consoleLogTimestamps {
+	// JSON lines are meant for log processing and always include timestamps.
+	if c.consoleLogTimestamps || c.jsonConsole() {
 		ec.TimeKey = "t"
 
-		if c.jsonLogConsole {
+		if c.jsonConsole() {
 			ec.EncodeTime = zapcore.RFC3339NanoTimeEncoder
 		} else {
 			// always log local timestamps to the console, not UTC
@@ -170,7 +227,7 @@ func (c *loggingFlags) setupConsoleCore() zapcore.Core {
 		LocalTime:  true,
 	}
 
-	if c.jsonLogConsole {
+	if c.jsonConsole() {
 		ec.EncodeLevel = zapcore.CapitalLevelEncoder
 
 		ec.NameKey = "n"
@@ -182,12 +239,24 @@ func (c *loggingFlags) setupConsoleCore() zapcore.Core {
 	}
 
 	return zapcore.NewCore(
-		c.jsonOrConsoleEncoder(stec, ec, c.jsonLogConsole),
+		c.jsonOrConsoleEncoder(stec, ec, c.jsonConsole()),
 		zapcore.AddSync(c.cliApp.Stderr()),
-		logLevelFromFlag(c.logLevel),
+		lvl,
 	)
 }
 
+// consoleLogLevel returns the console log level, raised to warnings when --quiet is specified.
+func (c *loggingFlags) consoleLogLevel() zapcore.LevelEnabler {
+	lvl := logLevelFromFlag(c.logLevel)
+
+	// --quiet never lowers the level requested with --log-level
+	if c.cliApp.Quiet() && lvl.Enabled(zap.InfoLevel) {
+		return zap.WarnLevel
+	}
+
+	return lvl
+}
+
 func (c *loggingFlags) setupLogFileBasedLogger(now time.Time, subdir, suffix, logFileOverride string, maxFiles int, maxSizeMB float64, maxAge time.Duration) zapcore.WriteSyncer {
 	var logFileName, symlinkName string
 
@@ -271,7 +340,7 @@ func (c *loggingFlags) setupLogFileCore(now time.Time, suffix string) zapcore.Co
 				EncodeDuration:   zapcore.StringDurationEncoder,
 				ConsoleSeparator: " ",
 			},
-			c.jsonLogFile),
+			c.jsonFile()),
 		c.setupLogFileBasedLogger(now, "cli-logs", suffix, c.logFile, c.logDirMaxFiles, c.logDirMaxTotalSizeMB, c.logDirMaxAge),
 		logLevelFromFlag(c.fileLogLevel),
 	)
@@ -379,6 +448,55 @@ func logLevelFromFlag(levelString string) zapcore.LevelEnabler {
 	}
 }
 
+// parseModuleLogLevels parses NAME=LEVEL overrides specified using --log-level-module.
+// Each name must be one of the known modules or a parent of at least one of them.
+func parseModuleLogLevels(specs, knownModules []string) (map[string]zapcore.LevelEnabler, error) {
+	result := map[string]zapcore.LevelEnabler{}
+
+	for _, spec := range specs {
+		name, level, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid module log level %q, expected NAME=LEVEL", spec)
+		}
+
+		name = strings.Trim(strings.TrimSpace(name), "/")
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, errors.Errorf("invalid module name in %q", spec)
+		}
+
+		if !slices.ContainsFunc(knownModules, func(m string) bool { return m == name || strings.HasPrefix(m, name+"/") }) {
+			return nil, errors.Errorf("unknown log module %q, must be one of: %v", name, strings.Join(knownModules, ", "))
+		}
+
+		level = strings.TrimSpace(level)
+		if !slices.Contains(logLevels, level) {
+			return nil, errors.Errorf("invalid log level %q for module %v, must be one of: %v", level, name, strings.Join(logLevels, ", "))
+		}
+
+		result[name] = logLevelFromFlag(level)
+	}
+
+	return result, nil
+}
+
+// longestModulePrefix returns the longest name in the provided map that is equal to the module name
+// or is its parent, such that "kopia/content" matches module "kopia/content/cache" but not "kopia/contents".
+func longestModulePrefix(names map[string]zapcore.LevelEnabler, module string) (string, bool) {
+	best, found := "", false
+
+	for name := range names {
+		if module != name && !strings.HasPrefix(module, name+"/") {
+			continue
+		}
+
+		if !found || len(name) > len(best) {
+			best, found = name, true
+		}
+	}
+
+	return best, found
+}
+
 type onDemandFile struct {
 	// +checklocks:mu
 	segmentCounter int // number of segments written
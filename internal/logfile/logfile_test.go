@@ -2,6 +2,7 @@
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -96,6 +97,12 @@ func TestLoggingFlags(t *testing.T) {
 		"--no-auto-maintenance", "--log-dir", tmpLogDir)
 	require.NoError(t, err)
 	require.Empty(t, stderr)
+
+	// run command with --quiet so neither progress nor informational logs are produced on the console
+	_, stderr, err = env.Run(t, false, "snap", "create", dir1,
+		"--quiet", "--no-auto-maintenance", "--log-dir", tmpLogDir)
+	require.NoError(t, err)
+	require.Empty(t, stderr)
 }
 
 func TestLogFileRotation(t *testing.T) {
@@ -236,3 +243,111 @@ func getTotalDirSize(t *testing.T, dir string) int {
 
 	return totalSize
 }
+
+func TestLogLevelModule(t *testing.T) {
+	runner := testenv.NewInProcRunner(t)
+	runner.CustomizeApp = logfile.Attach
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir1 := testutil.TempDirectory(t)
+	tmpLogDir := testutil.TempDirectory(t)
+
+	// debug logs of the uploader are emitted while everything else is limited to warnings.
+	_, stderr, err := env.Run(t, false, "snap", "create", dir1,
+		"--no-progress", "--log-level=warning", "--log-level-module=uploader=debug", "--disable-color",
+		"--no-auto-maintenance", "--log-dir", tmpLogDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, stderr)
+
+	for _, l := range stderr {
+		require.True(t, strings.HasPrefix(l, "DEBUG "), l)
+	}
+
+	// overrides of unrelated modules don't affect the console.
+	_, stderr, err = env.Run(t, false, "snap", "create", dir1,
+		"--no-progress", "--log-level=warning", "--log-level-module=sftp=debug",
+		"--no-auto-maintenance", "--log-dir", tmpLogDir)
+	require.NoError(t, err)
+	require.Empty(t, stderr)
+
+	env.RunAndExpectFailure(t, "snap", "list", "--log-level-module=uploader", "--log-dir", tmpLogDir)
+	env.RunAndExpectFailure(t, "snap", "list", "--log-level-module=uploader=verbose", "--log-dir", tmpLogDir)
+	env.RunAndExpectFailure(t, "snap", "list", "--log-level-module==debug", "--log-dir", tmpLogDir)
+
+	// content logs, which otherwise only go to the content log file, can be sent to the console.
+	_, stderr, err = env.Run(t, false, "snap", "list", "--log-level=warning", "--log-dir", tmpLogDir)
+	require.NoError(t, err)
+	require.NotContains(t, strings.Join(stderr, "\n"), "active indexes")
+
+	_, stderr, err = env.Run(t, false, "snap", "list",
+		"--log-level=warning", "--log-level-module=kopia/format=debug", "--log-dir", tmpLogDir)
+	require.NoError(t, err)
+	require.Contains(t, strings.Join(stderr, "\n"), "active indexes")
+
+	// module names must be known modules or their parents.
+	env.RunAndExpectSuccess(t, "snap", "list", "--log-level-module=repo=debug", "--log-dir", tmpLogDir)
+	env.RunAndExpectFailure(t, "snap", "list", "--log-level-module=upload=debug", "--log-dir", tmpLogDir)
+	env.RunAndExpectFailure(t, "snap", "list", "--log-level-module=repo/file=debug", "--log-dir", tmpLogDir)
+}
+
+func TestLogFormat(t *testing.T) {
+	runner := testenv.NewInProcRunner(t)
+	runner.CustomizeApp = logfile.Attach
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir1 := testutil.TempDirectory(t)
+	tmpLogDir := testutil.TempDirectory(t)
+
+	// JSON console logs, text file logs.
+	_, stderr, err := env.Run(t, false, "snap", "create", dir1,
+		"--no-progress", "--log-level=debug", "--log-format=json",
+		"--no-auto-maintenance", "--log-dir", tmpLogDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, stderr)
+
+	for _, l := range stderr {
+		verifyJSONLogLine(t, l)
+	}
+
+	verifyFileLogFormat(t, filepath.Join(tmpLogDir, "cli-logs", "latest.log"), cliLogFormat)
+
+	// text console logs, JSON file logs.
+	_, stderr, err = env.Run(t, false, "snap", "create", dir1,
+		"--no-progress", "--log-level=debug", "--disable-color", "--file-log-format=json",
+		"--no-auto-maintenance", "--log-dir", tmpLogDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, stderr)
+
+	for _, l := range stderr {
+		require.False(t, json.Valid([]byte(l)), l)
+	}
+
+	f, err := os.Open(filepath.Join(tmpLogDir, "cli-logs", "latest.log"))
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+
+	for s.Scan() {
+		verifyJSONLogLine(t, s.Text())
+	}
+
+	env.RunAndExpectFailure(t, "snap", "list", "--log-format=xml", "--log-dir", tmpLogDir)
+}
+
+func verifyJSONLogLine(t *testing.T, l string) {
+	t.Helper()
+
+	var entry map[string]any
+
+	require.NoError(t, json.Unmarshal([]byte(l), &entry), l)
+
+	for _, k := range []string{"t", "l", "n", "m"} {
+		require.Contains(t, entry, k, l)
+	}
+}
@@ -3,6 +3,7 @@
 
 import (
 	"context"
+	"time"
 
 	"github.com/kopia/kopia/repo/logging"
 )
@@ -25,4 +26,16 @@ type Options struct {
 	FuseAllowNonEmptyMount bool
 	// Use WebDAV even on platforms that support FUSE.
 	PreferWebDAV bool
+	// How long the kernel caches directory entries, including entries that were not found.
+	// Nil means DefaultCacheTimeout. Supported only on FUSE.
+	EntryCacheTimeout *time.Duration
+	// How long the kernel caches file attributes. Nil means DefaultCacheTimeout. Supported only on FUSE.
+	AttrCacheTimeout *time.Duration
+	// Mount the filesystem read-only, so that the kernel rejects all modifications with EROFS.
+	// Supported only on FUSE.
+	ReadOnly bool
 }
+
+// DefaultCacheTimeout is the default duration for which the kernel caches entries and attributes of
+// the mounted read-only filesystem.
+const DefaultCacheTimeout = 30 * time.Second
@@ -16,11 +16,6 @@
 	"github.com/kopia/kopia/internal/fusemount"
 )
 
-// we're serving read-only filesystem, cache some attributes for 30 seconds.
-//
-//nolint:gochecknoglobals
-var cacheTimeout = 30 * time.Second
-
 func (mo *Options) toFuseMountOptions() *gofusefs.Options {
 	o := &gofusefs.Options{
 		MountOptions: fuse.MountOptions{
@@ -29,12 +24,17 @@ func (mo *Options) toFuseMountOptions() *gofusefs.Options {
 			FsName:     "kopia",
 			Debug:      os.Getenv("KOPIA_DEBUG_FUSE") != "",
 		},
-		EntryTimeout:    &cacheTimeout,
-		AttrTimeout:     &cacheTimeout,
-		NegativeTimeout: &cacheTimeout,
+		EntryTimeout:    cacheTimeoutOrDefault(mo.EntryCacheTimeout),
+		AttrTimeout:     cacheTimeoutOrDefault(mo.AttrCacheTimeout),
+		NegativeTimeout: cacheTimeoutOrDefault(mo.EntryCacheTimeout),
 	}
 
 	o.Options = append(o.Options, "noatime")
+
+	if mo.ReadOnly {
+		o.Options = append(o.Options, "ro")
+	}
+
 	if mo.FuseAllowNonEmptyMount {
 		o.Options = append(o.Options, "nonempty")
 	}
@@ -42,6 +42,15 @@ func (mo *Options) toFuseMountOptions() *gofusefs.Options {
 	return o
 }
 
+func cacheTimeoutOrDefault(d *time.Duration) *time.Duration {
+	if d == nil {
+		v := DefaultCacheTimeout
+		return &v
+	}
+
+	return d
+}
+
 // Directory mounts the given directory using FUSE.
 func Directory(ctx context.Context, entry fs.Directory, mountPoint string, mountOptions Options) (Controller, error) {
 	isTempDir := false
@@ -0,0 +1,29 @@
+//go:build !windows && !freebsd && !openbsd
+// +build !windows,!freebsd,!openbsd
+
+package mount
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuseMountOptions(t *testing.T) {
+	o := (&Options{}).toFuseMountOptions()
+	require.Equal(t, DefaultCacheTimeout, *o.EntryTimeout)
+	require.Equal(t, DefaultCacheTimeout, *o.AttrTimeout)
+	require.Equal(t, DefaultCacheTimeout, *o.NegativeTimeout)
+	require.NotContains(t, o.Options, "ro")
+
+	entryTTL := 5 * time.Minute
+	attrTTL := time.Duration(0)
+
+	o = (&Options{EntryCacheTimeout: &entryTTL, AttrCacheTimeout: &attrTTL, ReadOnly: true}).toFuseMountOptions()
+	require.Equal(t, entryTTL, *o.EntryTimeout)
+	require.Equal(t, entryTTL, *o.NegativeTimeout)
+	require.Equal(t, attrTTL, *o.AttrTimeout)
+	require.Contains(t, o.Options, "ro")
+	require.Contains(t, o.Options, "noatime")
+}
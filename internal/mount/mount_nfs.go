@@ -0,0 +1,130 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	nfs "github.com/willscott/go-nfs"
+	nfshelpers "github.com/willscott/go-nfs/helpers"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/nfsmount"
+)
+
+// maxNFSHandles is the number of NFS file handles kept by the server, clients holding handles
+// which were evicted get ESTALE errors.
+const maxNFSHandles = 100000
+
+// DirectoryNFS exposes the provided filesystem directory as a read-only NFSv3 export on localhost
+// using the provided port (0 picks a random one) and returns a controller.
+// The export must be attached by the user by running the command returned by NFSMountCommand().
+//
+// The export does not authenticate clients, so while it is being served, any user of this machine can
+// attach it and read all files of the provided directory regardless of their ownership and permissions.
+func DirectoryNFS(ctx context.Context, entry fs.Directory, mountPoint string, port int) (*NFSController, error) {
+	isTempDir := false
+
+	if mountPoint == "*" {
+		var err error
+
+		mountPoint, err = os.MkdirTemp("", "kopia-mount")
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating temp directory")
+		}
+
+		isTempDir = true
+	}
+
+	log(ctx).Debug("creating nfs server...")
+
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return nil, errors.Wrap(err, "listen error")
+	}
+
+	handler := nfshelpers.NewCachingHandler(nfshelpers.NewNullAuthHandler(nfsmount.NFSFS(ctx, entry)), maxNFSHandles)
+
+	c := &NFSController{
+		mountPoint: mountPoint,
+		port:       l.Addr().(*net.TCPAddr).Port, //nolint:forcetypeassert
+		listener:   l,
+		done:       make(chan struct{}),
+		isTempDir:  isTempDir,
+	}
+
+	go func() {
+		defer close(c.done)
+
+		log(ctx).Debugf("nfs server finished with %v", nfs.Serve(l, handler))
+	}()
+
+	return c, nil
+}
+
+// NFSController controls the NFS server started by DirectoryNFS.
+type NFSController struct {
+	mountPoint string
+	port       int
+	listener   net.Listener
+	done       chan struct{}
+	isTempDir  bool
+
+	closeOnce sync.Once
+}
+
+// Unmount stops the NFS server, the export must be detached by the user first.
+func (c *NFSController) Unmount(ctx context.Context) error {
+	var err error
+
+	c.closeOnce.Do(func() {
+		err = c.listener.Close()
+
+		if c.isTempDir {
+			if rerr := os.Remove(c.mountPoint); rerr != nil {
+				log(ctx).Errorf("unable to remove temporary mount point: %v", rerr)
+			}
+		}
+	})
+
+	return errors.Wrap(err, "error shutting down nfs server")
+}
+
+// MountPath returns the mount point the export should be attached to.
+func (c *NFSController) MountPath() string {
+	return c.mountPoint
+}
+
+// Done returns a channel that is closed when the NFS server stops.
+func (c *NFSController) Done() <-chan struct{} {
+	return c.done
+}
+
+// Port returns the TCP port the NFS server is listening on.
+func (c *NFSController) Port() int {
+	return c.port
+}
+
+// NFSMountCommand returns the command that attaches the export to the mount point on the current operating system.
+func (c *NFSController) NFSMountCommand() string {
+	return nfsMountCommand(runtime.GOOS, c.port, c.mountPoint)
+}
+
+// NFSUnmountCommand returns the command that detaches the export from the mount point.
+func (c *NFSController) NFSUnmountCommand() string {
+	return fmt.Sprintf("umount %q", c.mountPoint)
+}
+
+func nfsMountCommand(goos string, port int, mountPoint string) string {
+	switch goos {
+	case "darwin":
+		return fmt.Sprintf("mount -t nfs -o port=%v,mountport=%v,vers=3,tcp,locallocks,rdonly 127.0.0.1:/ %q", port, port, mountPoint)
+	default:
+		return fmt.Sprintf("mount -t nfs -o port=%v,mountport=%v,nfsvers=3,tcp,nolock,ro 127.0.0.1:/ %q", port, port, mountPoint)
+	}
+}
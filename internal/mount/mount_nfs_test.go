@@ -0,0 +1,76 @@
+package mount
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	nfsc "github.com/willscott/go-nfs-client/nfs"
+	"github.com/willscott/go-nfs-client/nfs/rpc"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestDirectoryNFS(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	root := mockfs.NewDirectory()
+	root.AddFile("file1", []byte("hello world"), 0o644)
+	root.AddDir("subdir", 0o755).AddFile("file2", []byte("nested"), 0o600)
+
+	ctrl, err := DirectoryNFS(ctx, root, t.TempDir(), 0)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, ctrl.Unmount(ctx))
+		<-ctrl.Done()
+	}()
+
+	c, err := rpc.DialTCP("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(ctrl.Port())), false)
+	require.NoError(t, err)
+
+	defer c.Close()
+
+	mounter := nfsc.Mount{Client: c}
+
+	target, err := mounter.Mount("/", rpc.AuthNull)
+	require.NoError(t, err)
+
+	entries, err := target.ReadDirPlus("/")
+	require.NoError(t, err)
+
+	var names []string
+
+	for _, e := range entries {
+		if e.FileName != "." && e.FileName != ".." {
+			names = append(names, e.FileName)
+		}
+	}
+
+	require.ElementsMatch(t, []string{"file1", "subdir"}, names)
+
+	f, err := target.Open("/subdir/file2")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "nested", string(data))
+
+	// the client commits on close, which is rejected by the read-only export.
+	f.Close()
+
+	_, err = target.Create("/newfile", 0o644)
+	require.Error(t, err)
+}
+
+func TestNFSMountCommand(t *testing.T) {
+	require.Equal(t,
+		`mount -t nfs -o port=12345,mountport=12345,nfsvers=3,tcp,nolock,ro 127.0.0.1:/ "/mnt/kopia"`,
+		nfsMountCommand("linux", 12345, "/mnt/kopia"))
+	require.Equal(t,
+		`mount -t nfs -o port=12345,mountport=12345,vers=3,tcp,locallocks,rdonly 127.0.0.1:/ "/Volumes/kopia"`,
+		nfsMountCommand("darwin", 12345, "/Volumes/kopia"))
+}
@@ -0,0 +1,252 @@
+// Package nfsmount adapts the webdav filesystem for serving snapshots to a read-only billy filesystem served over NFS.
+package nfsmount
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/chroot"
+	"github.com/pkg/errors"
+	nfsfile "github.com/willscott/go-nfs/file"
+	"golang.org/x/net/webdav"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/webdavmount"
+)
+
+var (
+	_ billy.Filesystem = (*nfsFS)(nil)
+	_ billy.Capable    = (*nfsFS)(nil)
+	_ billy.File       = (*nfsFile)(nil)
+	_ os.FileInfo      = nfsFileInfo{}
+)
+
+type nfsFileInfo struct {
+	os.FileInfo
+
+	path string
+}
+
+// Sys returns the ownership and a stable file ID derived from the path, which is used by the NFS server.
+func (fi nfsFileInfo) Sys() interface{} {
+	h := fnv.New64()
+	h.Write([]byte(fi.path)) //nolint:errcheck
+
+	result := nfsfile.FileInfo{
+		Nlink:  1,
+		Fileid: h.Sum64(),
+	}
+
+	if o, ok := fi.FileInfo.(interface{ Owner() fs.OwnerInfo }); ok {
+		result.UID = o.Owner().UserID
+		result.GID = o.Owner().GroupID
+	}
+
+	return result
+}
+
+type nfsFile struct {
+	name string
+
+	mu sync.Mutex
+
+	// +checklocks:mu
+	f webdav.File
+}
+
+func (f *nfsFile) Name() string {
+	return f.name
+}
+
+func (f *nfsFile) Read(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	//nolint:wrapcheck
+	return f.f.Read(b)
+}
+
+func (f *nfsFile) ReadAt(b []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.f.Seek(off, io.SeekStart); err != nil {
+		return 0, errors.Wrap(err, "seek error")
+	}
+
+	n, err := io.ReadFull(f.f, b)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		// io.ReaderAt reports short reads at the end of the file as io.EOF.
+		err = io.EOF
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+func (f *nfsFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	//nolint:wrapcheck
+	return f.f.Seek(offset, whence)
+}
+
+func (f *nfsFile) Write(_ []byte) (int, error) {
+	return 0, billy.ErrReadOnly
+}
+
+func (f *nfsFile) Truncate(int64) error {
+	return billy.ErrReadOnly
+}
+
+func (f *nfsFile) Lock() error {
+	return nil
+}
+
+func (f *nfsFile) Unlock() error {
+	return nil
+}
+
+func (f *nfsFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	//nolint:wrapcheck
+	return f.f.Close()
+}
+
+type nfsFS struct {
+	// nfsFS implements billy.Filesystem but needs context
+	ctx context.Context //nolint:containedctx
+
+	w webdav.FileSystem
+}
+
+func (n *nfsFS) Capabilities() billy.Capability {
+	return billy.ReadCapability | billy.SeekCapability
+}
+
+func (n *nfsFS) Create(filename string) (billy.File, error) {
+	return nil, errors.Wrapf(billy.ErrReadOnly, "can't create %q", filename)
+}
+
+func (n *nfsFS) Open(filename string) (billy.File, error) {
+	return n.OpenFile(filename, os.O_RDONLY, 0)
+}
+
+func (n *nfsFS) OpenFile(filename string, flag int, _ os.FileMode) (billy.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, errors.Wrapf(billy.ErrReadOnly, "can't open %q for writing", filename)
+	}
+
+	f, err := n.w.OpenFile(n.ctx, cleanPath(filename), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, notExistError("open", filename, err)
+	}
+
+	if fi, err := f.Stat(); err != nil || fi.IsDir() {
+		f.Close() //nolint:errcheck
+
+		return nil, errors.Errorf("can't open %q: not a file", filename)
+	}
+
+	return &nfsFile{name: filename, f: f}, nil
+}
+
+func (n *nfsFS) Stat(filename string) (os.FileInfo, error) {
+	return n.Lstat(filename)
+}
+
+func (n *nfsFS) Lstat(filename string) (os.FileInfo, error) {
+	fi, err := n.w.Stat(n.ctx, cleanPath(filename))
+	if err != nil {
+		return nil, notExistError("lstat", filename, err)
+	}
+
+	return nfsFileInfo{fi, cleanPath(filename)}, nil
+}
+
+// ReadDir returns the directory entries, symbolic links are skipped as in the WebDAV mount.
+func (n *nfsFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	d, err := n.w.OpenFile(n.ctx, cleanPath(dirname), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, notExistError("readdir", dirname, err)
+	}
+
+	defer d.Close() //nolint:errcheck
+
+	entries, err := d.Readdir(0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading directory %q", dirname)
+	}
+
+	result := make([]os.FileInfo, 0, len(entries))
+
+	for _, fi := range entries {
+		result = append(result, nfsFileInfo{fi, path.Join(cleanPath(dirname), fi.Name())})
+	}
+
+	return result, nil
+}
+
+func (n *nfsFS) Readlink(link string) (string, error) {
+	return "", errors.Wrapf(billy.ErrNotSupported, "can't read link %q", link)
+}
+
+func (n *nfsFS) Rename(oldpath, newpath string) error {
+	return errors.Wrapf(billy.ErrReadOnly, "can't rename %q to %q", oldpath, newpath)
+}
+
+func (n *nfsFS) Remove(filename string) error {
+	return errors.Wrapf(billy.ErrReadOnly, "can't remove %q", filename)
+}
+
+func (n *nfsFS) TempFile(dir, _ string) (billy.File, error) {
+	return nil, errors.Wrapf(billy.ErrReadOnly, "can't create temporary file in %q", dir)
+}
+
+func (n *nfsFS) MkdirAll(filename string, _ os.FileMode) error {
+	return errors.Wrapf(billy.ErrReadOnly, "can't create %q", filename)
+}
+
+func (n *nfsFS) Symlink(_, link string) error {
+	return errors.Wrapf(billy.ErrReadOnly, "can't create %q", link)
+}
+
+func (n *nfsFS) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (n *nfsFS) Chroot(p string) (billy.Filesystem, error) {
+	return chroot.New(n, p), nil
+}
+
+func (n *nfsFS) Root() string {
+	return "/"
+}
+
+// notExistError converts errors of entries that are not found into errors recognized by os.IsNotExist,
+// which the NFS server reports as NFS3ERR_NOENT.
+func notExistError(op, p string, err error) error {
+	if errors.Is(err, fs.ErrEntryNotFound) {
+		return &os.PathError{Op: op, Path: p, Err: os.ErrNotExist}
+	}
+
+	return err
+}
+
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// NFSFS returns a read-only billy.Filesystem implementation for a given directory, suitable for serving over NFS.
+// It is backed by the WebDAV filesystem, so symbolic links are not exposed.
+func NFSFS(ctx context.Context, entry fs.Directory) billy.Filesystem {
+	return &nfsFS{ctx, webdavmount.WebDAVFS(entry)}
+}
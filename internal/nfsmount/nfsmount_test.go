@@ -0,0 +1,81 @@
+package nfsmount_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/nfsmount"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestNFSFS(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	root := mockfs.NewDirectory()
+	root.AddFile("file1", []byte("hello world"), 0o644)
+	root.AddDir("subdir", 0o755).AddFile("file2", []byte("nested"), 0o600)
+	root.AddSymlink("link", "file1", 0o777)
+
+	nfs := nfsmount.NFSFS(ctx, root)
+
+	require.False(t, billy.CapabilityCheck(nfs, billy.WriteCapability))
+
+	// symbolic links are not exposed, like in the WebDAV mount.
+	entries, err := nfs.ReadDir("/")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	fi, err := nfs.Lstat("subdir/file2")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), fi.Size())
+
+	fi2, err := nfs.Stat("/subdir/../subdir/file2")
+	require.NoError(t, err)
+	require.Equal(t, fi.Sys(), fi2.Sys())
+
+	_, err = nfs.Lstat("subdir/missing")
+	require.True(t, os.IsNotExist(err), err)
+
+	_, err = nfs.Lstat("file1/child")
+	require.True(t, os.IsNotExist(err), err)
+
+	_, err = nfs.Readlink("link")
+	require.ErrorIs(t, err, billy.ErrNotSupported)
+
+	_, err = nfs.Open("subdir")
+	require.Error(t, err)
+
+	f, err := nfs.Open("file1")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	buf := make([]byte, 5)
+
+	n, err := f.ReadAt(buf, 6)
+	require.NoError(t, err)
+	require.Equal(t, "world", string(buf[:n]))
+
+	n, err = f.ReadAt(buf, 8)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, "rld", string(buf[:n]))
+
+	_, err = f.Write([]byte("x"))
+	require.ErrorIs(t, err, billy.ErrReadOnly)
+
+	_, err = nfs.OpenFile("file1", os.O_RDWR, 0)
+	require.ErrorIs(t, err, billy.ErrReadOnly)
+
+	require.ErrorIs(t, nfs.Remove("file1"), billy.ErrReadOnly)
+
+	sub, err := nfs.Chroot("subdir")
+	require.NoError(t, err)
+
+	_, err = sub.Stat("file2")
+	require.NoError(t, err)
+}
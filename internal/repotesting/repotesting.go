@@ -139,7 +139,7 @@ func (e *Environment) Close(ctx context.Context, tb testing.TB) {
 	require.NoError(tb, err, "unable to close")
 
 	if e.connected {
-		err := repo.Disconnect(ctx, e.ConfigFile())
+		err := repo.Disconnect(ctx, e.ConfigFile(), "")
 		require.NoError(tb, err, "error disconnecting")
 	}
 
@@ -38,6 +38,12 @@ func WithExponentialBackoffMaxRetries[T any](ctx context.Context, count int, des
 	return internalRetry(ctx, desc, attempt, isRetriableError, retryInitialSleepAmount, retryMaxSleepAmount, count, retryExponent)
 }
 
+// WithExponentialBackoffInitialDelay is the same as WithExponentialBackoffMaxRetries,
+// additionally it allows customizing the delay before the first retry.
+func WithExponentialBackoffInitialDelay[T any](ctx context.Context, initial time.Duration, count int, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc) (T, error) {
+	return internalRetry(ctx, desc, attempt, isRetriableError, initial, max(initial, retryMaxSleepAmount), count, retryExponent)
+}
+
 // Periodically runs the provided attempt until it succeeds, waiting given fixed amount between attempts.
 func Periodically[T any](ctx context.Context, interval time.Duration, count int, desc string, attempt func() (T, error), isRetriableError IsRetriableFunc) (T, error) {
 	return internalRetry(ctx, desc, attempt, isRetriableError, interval, interval, count, 1)
@@ -14,8 +14,13 @@ func handleCLIInfo(ctx context.Context, rc requestContext) (interface{}, *apiErr
 		executable = "kopia"
 	}
 
+	cmd := maybeQuote(executable) + " --config-file=" + maybeQuote(rc.srv.getOptions().ConfigFile)
+	if p := rc.srv.getOptions().ConfigProfile; p != "" {
+		cmd += " --profile=" + p
+	}
+
 	return &serverapi.CLIInfo{
-		Executable: maybeQuote(executable) + " --config-file=" + maybeQuote(rc.srv.getOptions().ConfigFile) + "",
+		Executable: cmd,
 	}, nil
 }
 
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SetupHealthHandlers registers unauthenticated liveness (/healthz) and readiness (/readyz) endpoints
+// suitable for load balancers and orchestrators. The responses never include repository details.
+func (s *Server) SetupHealthHandlers(m *mux.Router) {
+	m.HandleFunc("/healthz", s.handleHealthz).Methods(http.MethodGet, http.MethodHead)
+	m.HandleFunc("/readyz", s.handleReadyz).Methods(http.MethodGet, http.MethodHead)
+}
+
+// handleHealthz reports that the server process is alive and serving requests.
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeHealthResponse(w, http.StatusOK, "ok")
+}
+
+// handleReadyz reports whether the server has a repository open for writing.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if reason := s.notReadyReason(); reason != "" {
+		if s.options.LogRequests {
+			log(r.Context()).Debugf("server not ready: %v", reason)
+		}
+
+		writeHealthResponse(w, http.StatusServiceUnavailable, "not ready")
+
+		return
+	}
+
+	writeHealthResponse(w, http.StatusOK, "ok")
+}
+
+// notReadyReason returns the reason why the server can't accept requests or empty string if it's ready.
+func (s *Server) notReadyReason() string {
+	if s.getInitRepositoryTaskID() != "" {
+		return "repository is being opened"
+	}
+
+	s.serverMutex.RLock()
+	defer s.serverMutex.RUnlock()
+
+	if s.rep == nil {
+		return "repository not connected"
+	}
+
+	if s.rep.ClientOptions().ReadOnly {
+		return "repository is read-only"
+	}
+
+	return ""
+}
+
+func writeHealthResponse(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	_, _ = w.Write([]byte(msg + "\n"))
+}
@@ -0,0 +1,66 @@
+package server_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/auth"
+	"github.com/kopia/kopia/internal/passwordpersist"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/server"
+	"github.com/kopia/kopia/internal/servertesting"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	_, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	asi := servertesting.StartServer(t, env, false)
+
+	// no credentials are needed.
+	require.Equal(t, "ok\n", mustGetHealth(t, asi.BaseURL+"/healthz", http.StatusOK))
+	require.Equal(t, "ok\n", mustGetHealth(t, asi.BaseURL+"/readyz", http.StatusOK))
+}
+
+func TestReadyzNotConnected(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	s, err := server.New(ctx, &server.Options{
+		Authorizer:      auth.LegacyAuthorizer(),
+		PasswordPersist: passwordpersist.File(),
+	})
+	require.NoError(t, err)
+
+	m := mux.NewRouter()
+	s.SetupHealthHandlers(m)
+
+	hs := httptest.NewServer(m)
+	t.Cleanup(hs.Close)
+
+	require.Equal(t, "ok\n", mustGetHealth(t, hs.URL+"/healthz", http.StatusOK))
+	require.Equal(t, "not ready\n", mustGetHealth(t, hs.URL+"/readyz", http.StatusServiceUnavailable))
+}
+
+func mustGetHealth(t *testing.T, url string, wantStatus int) string {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	require.Equal(t, wantStatus, resp.StatusCode)
+
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(b)
+}
@@ -231,7 +231,7 @@ func handleRepoSetDescription(ctx context.Context, rc requestContext) (interface
 	cliOpt := rc.rep.ClientOptions()
 	cliOpt.Description = req.Description
 
-	if err := repo.SetClientOptions(ctx, rc.srv.getOptions().ConfigFile, cliOpt); err != nil {
+	if err := repo.SetClientOptions(ctx, rc.srv.getOptions().ConfigFile, rc.srv.getOptions().ConfigProfile, cliOpt); err != nil {
 		return nil, internalServerError(err)
 	}
 
@@ -329,6 +329,7 @@ func handleRepoSetThrottle(ctx context.Context, rc requestContext) (interface{},
 func (s *Server) getConnectOptions(cliOpts repo.ClientOptions) *repo.ConnectOptions {
 	o := *s.options.ConnectOptions
 	o.ClientOptions = o.ClientOptions.Override(cliOpts)
+	o.Profile = s.options.ConfigProfile
 
 	return &o
 }
@@ -336,12 +337,12 @@ func (s *Server) getConnectOptions(cliOpts repo.ClientOptions) *repo.ConnectOpti
 func connectAPIServerAndOpen(ctx context.Context, si *repo.APIServerInfo, password string, connectOpts *repo.ConnectOptions, opts *Options) (repo.Repository, error) {
 	if err := passwordpersist.OnSuccess(
 		ctx, repo.ConnectAPIServer(ctx, opts.ConfigFile, si, password, connectOpts),
-		opts.PasswordPersist, opts.ConfigFile, password); err != nil {
+		opts.PasswordPersist, repo.ProfileKey(opts.ConfigFile, opts.ConfigProfile), password); err != nil {
 		return nil, errors.Wrap(err, "error connecting to API server")
 	}
 
 	//nolint:wrapcheck
-	return repo.Open(ctx, opts.ConfigFile, password, nil)
+	return repo.Open(ctx, opts.ConfigFile, password, &repo.Options{Profile: opts.ConfigProfile})
 }
 
 func connectAndOpen(ctx context.Context, conn blob.ConnectionInfo, password string, connectOpts *repo.ConnectOptions, opts *Options) (repo.Repository, error) {
@@ -353,12 +354,12 @@ func connectAndOpen(ctx context.Context, conn blob.ConnectionInfo, password stri
 
 	if err = passwordpersist.OnSuccess(
 		ctx, repo.Connect(ctx, opts.ConfigFile, st, password, connectOpts),
-		opts.PasswordPersist, opts.ConfigFile, password); err != nil {
+		opts.PasswordPersist, repo.ProfileKey(opts.ConfigFile, opts.ConfigProfile), password); err != nil {
 		return nil, errors.Wrap(err, "error connecting")
 	}
 
 	//nolint:wrapcheck
-	return repo.Open(ctx, opts.ConfigFile, password, nil)
+	return repo.Open(ctx, opts.ConfigFile, password, &repo.Options{Profile: opts.ConfigProfile})
 }
 
 func handleRepoDisconnect(ctx context.Context, rc requestContext) (interface{}, *apiError) {
@@ -374,12 +375,12 @@ func (s *Server) disconnect(ctx context.Context) error {
 		return err
 	}
 
-	if err := repo.Disconnect(ctx, s.options.ConfigFile); err != nil {
+	if err := repo.Disconnect(ctx, s.options.ConfigFile, s.options.ConfigProfile); err != nil {
 		//nolint:wrapcheck
 		return err
 	}
 
-	if err := s.options.PasswordPersist.DeletePassword(ctx, s.options.ConfigFile); err != nil {
+	if err := s.options.PasswordPersist.DeletePassword(ctx, repo.ProfileKey(s.options.ConfigFile, s.options.ConfigProfile)); err != nil {
 		//nolint:wrapcheck
 		return err
 	}
@@ -90,6 +90,12 @@ func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 		return err
 	}
 
+	if d := s.reserveUserRequest(usernameAtHostname); d > 0 {
+		throttledRequestsTotal.WithLabelValues(rateLimitKindUser).Inc()
+
+		return status.Errorf(codes.ResourceExhausted, "too many requests for %v, retry after %v", usernameAtHostname, d)
+	}
+
 	authz := s.authorizer.Authorize(ctx, dr, usernameAtHostname)
 	if authz == nil {
 		authz = auth.NoAccess()
@@ -124,6 +130,15 @@ func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 			default:
 			}
 
+			// every request in the session consumes the budget of the user.
+			if resp := s.throttledSessionResponse(ctx, usernameAtHostname); resp != nil {
+				if err := s.send(srv, req.GetRequestId(), resp); err != nil {
+					return err
+				}
+
+				continue
+			}
+
 			// enforce limit on concurrent handling
 			if err := s.grpcServerState.sem.Acquire(ctx, 1); err != nil {
 				return errors.Wrap(err, "unable to acquire semaphore")
@@ -149,6 +164,21 @@ func (s *Server) Session(srv grpcapi.KopiaRepository_SessionServer) error {
 
 var tracer = otel.Tracer("kopia/grpc")
 
+// throttledSessionResponse consumes a token from the budget of the provided user and returns the error response
+// to send instead of handling the request when the budget is exhausted, or nil if the request is allowed.
+func (s *Server) throttledSessionResponse(ctx context.Context, usernameAtHostname string) *grpcapi.SessionResponse {
+	d := s.reserveUserRequest(usernameAtHostname)
+	if d <= 0 {
+		return nil
+	}
+
+	throttledRequestsTotal.WithLabelValues(rateLimitKindUser).Inc()
+
+	log(ctx).Debugf("throttled session request from %v", usernameAtHostname)
+
+	return errorResponse(errors.Errorf("too many requests for %v, retry after %v", usernameAtHostname, d))
+}
+
 func (s *Server) handleSessionRequest(ctx context.Context, dw repo.DirectRepositoryWriter, authz auth.AuthorizationInfo, usernameAtHostname string, req *grpcapi.SessionRequest, respond func(*grpcapi.SessionResponse)) {
 	if req.GetTraceContext() != nil {
 		var tc propagation.TraceContext
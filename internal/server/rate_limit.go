@@ -0,0 +1,163 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+
+	"github.com/kopia/kopia/internal/clock"
+)
+
+const (
+	rateLimitKindIP   = "ip"
+	rateLimitKindUser = "user"
+
+	// limiters that were not used for this long are discarded.
+	rateLimiterIdleTimeout = 10 * time.Minute
+)
+
+//nolint:gochecknoglobals
+var throttledRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "kopia_server_throttled_requests_total",
+	Help: "Number of server requests rejected because of rate limiting",
+}, []string{"limit"})
+
+// RateLimitOptions configures per-client rate limiting of server requests.
+type RateLimitOptions struct {
+	PerIP   float64 // requests per second allowed from a single IP address, 0 == unlimited
+	PerUser float64 // requests per second allowed for a single user, 0 == unlimited
+	Burst   int     // maximum number of requests allowed to exceed the rate momentarily
+}
+
+type rateLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// keyedRateLimiter maintains a separate token bucket for each key.
+type keyedRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu sync.Mutex
+	// +checklocks:mu
+	limiters map[string]*rateLimiterEntry
+	// +checklocks:mu
+	nextCleanup time.Time
+}
+
+func newKeyedRateLimiter(perSecond float64, burst int) *keyedRateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &keyedRateLimiter{
+		limit:    rate.Limit(perSecond),
+		burst:    max(burst, 1),
+		limiters: map[string]*rateLimiterEntry{},
+	}
+}
+
+// reserve consumes a token for the provided key and returns how long the caller must wait
+// before retrying, or zero if the request is allowed.
+func (l *keyedRateLimiter) reserve(key string) time.Duration {
+	now := clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.After(l.nextCleanup) {
+		for k, e := range l.limiters {
+			if now.Sub(e.lastUsed) > rateLimiterIdleTimeout {
+				delete(l.limiters, k)
+			}
+		}
+
+		l.nextCleanup = now.Add(rateLimiterIdleTimeout)
+	}
+
+	e := l.limiters[key]
+	if e == nil {
+		e = &rateLimiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = e
+	}
+
+	e.lastUsed = now
+
+	r := e.limiter.ReserveN(now, 1)
+	if d := r.DelayFrom(now); d > 0 {
+		// the request is rejected, don't consume the token.
+		r.CancelAt(now)
+		return d
+	}
+
+	return 0
+}
+
+// RateLimitHandler returns HTTP handler which rejects requests with 429 Too Many Requests
+// when a client IP address exceeds the configured rate. Health check endpoints are exempt.
+//
+// Per-user limits are applied by the server only after the user has been authenticated,
+// so that clients can't exhaust the budget of another user with invalid credentials.
+func RateLimitHandler(handler http.Handler, opts RateLimitOptions) http.Handler {
+	perIP := newKeyedRateLimiter(opts.PerIP, opts.Burst)
+	if perIP == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isRateLimitExempt(r) {
+			if d := perIP.reserve(requestClientIP(r)); d > 0 {
+				rejectThrottledRequest(w, r, rateLimitKindIP, d)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// isRateLimitExempt returns true for requests that must never be throttled, such as health checks.
+func isRateLimitExempt(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz":
+		return true
+	default:
+		return false
+	}
+}
+
+// reserveUserRequest consumes a token from the budget of the provided authenticated user and returns how long
+// the caller must wait before retrying, or zero if the request is allowed.
+func (s *Server) reserveUserRequest(username string) time.Duration {
+	if s.userRateLimiter == nil {
+		return 0
+	}
+
+	return s.userRateLimiter.reserve(username)
+}
+
+func rejectThrottledRequest(w http.ResponseWriter, r *http.Request, kind string, retryAfter time.Duration) {
+	throttledRequestsTotal.WithLabelValues(kind).Inc()
+
+	log(r.Context()).Debugf("throttled request %v from %v (%v limit)", r.URL, r.RemoteAddr, kind)
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, "Too many requests.\n", http.StatusTooManyRequests)
+}
+
+// requestClientIP returns the IP address of the client, or the whole remote address if it can't be parsed.
+func requestClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
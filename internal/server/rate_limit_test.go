@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/auth"
+	"github.com/kopia/kopia/internal/passwordpersist"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestRateLimitHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	h := RateLimitHandler(ok, RateLimitOptions{Burst: 1})
+
+	do := func(remoteAddr, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		req.RemoteAddr = remoteAddr
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	// no limits configured.
+	for range 10 {
+		require.Equal(t, http.StatusOK, do("10.0.0.1:1000", "/api/v1/repo/status").Code)
+	}
+
+	h = RateLimitHandler(ok, RateLimitOptions{PerIP: 0.1, PerUser: 0.1, Burst: 1})
+
+	require.Equal(t, http.StatusOK, do("10.0.0.1:1000", "/api/v1/repo/status").Code)
+
+	rec := do("10.0.0.1:1001", "/api/v1/repo/status")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "10", rec.Header().Get("Retry-After"))
+
+	// different IP address has its own budget.
+	require.Equal(t, http.StatusOK, do("10.0.0.2:1000", "/api/v1/repo/status").Code)
+
+	// health checks are never throttled.
+	require.Equal(t, http.StatusOK, do("10.0.0.1:1002", "/healthz").Code)
+	require.Equal(t, http.StatusOK, do("10.0.0.1:1003", "/readyz").Code)
+}
+
+func TestPerUserRateLimitAppliesAfterAuthentication(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	s, err := New(ctx, &Options{
+		Authenticator:   auth.AuthenticateSingleUser("alice", "pass"),
+		Authorizer:      auth.LegacyAuthorizer(),
+		PasswordPersist: passwordpersist.File(),
+		RateLimit:       RateLimitOptions{PerUser: 0.1, Burst: 1},
+	})
+	require.NoError(t, err)
+
+	h := s.requireAuth(csrfTokenNotRequired, func(_ context.Context, rc requestContext) {
+		rc.w.WriteHeader(http.StatusOK)
+	})
+
+	do := func(user, pass string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/repo/status", http.NoBody)
+		req.SetBasicAuth(user, pass)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	// failed logins don't consume the budget of the user whose name they claim.
+	for range 5 {
+		require.Equal(t, http.StatusUnauthorized, do("alice", "wrong"))
+	}
+
+	require.Equal(t, http.StatusOK, do("alice", "pass"))
+	require.Equal(t, http.StatusTooManyRequests, do("alice", "pass"))
+
+	// invalid users don't get a limiter at all.
+	s.userRateLimiter.mu.Lock()
+	require.Len(t, s.userRateLimiter.limiters, 1)
+	s.userRateLimiter.mu.Unlock()
+}
+
+func TestPerUserRateLimitAppliesToSessionRequests(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	s, err := New(ctx, &Options{
+		Authorizer:      auth.LegacyAuthorizer(),
+		PasswordPersist: passwordpersist.File(),
+		RateLimit:       RateLimitOptions{PerUser: 0.1, Burst: 2},
+	})
+	require.NoError(t, err)
+
+	// the session start consumes the first token, each request in the session consumes another one.
+	require.Zero(t, s.reserveUserRequest("alice@host"))
+	require.Nil(t, s.throttledSessionResponse(ctx, "alice@host"))
+
+	resp := s.throttledSessionResponse(ctx, "alice@host")
+	require.NotNil(t, resp)
+	require.Contains(t, resp.GetError().GetMessage(), "too many requests for alice@host")
+
+	// other users have their own budget.
+	require.Nil(t, s.throttledSessionResponse(ctx, "bob@host"))
+}
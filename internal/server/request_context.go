@@ -31,6 +31,7 @@ type serverInterface interface {
 	listMounts() map[object.ID]mount.Controller
 	disconnect(ctx context.Context) error
 	requestShutdown(ctx context.Context)
+	shutdownUploadTimeout() time.Duration
 	getOrCreateSourceManager(ctx context.Context, src snapshot.SourceInfo) *sourceManager
 	getInitRepositoryTaskID() string
 	getConnectOptions(cliOpts repo.ClientOptions) *repo.ConnectOptions
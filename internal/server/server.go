@@ -10,6 +10,7 @@
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -50,6 +51,8 @@
 	kopiaAuthCookieTTL      = 1 * time.Minute
 	kopiaAuthCookieAudience = "kopia"
 	kopiaAuthCookieIssuer   = "kopia-server"
+
+	defaultShutdownUploadTimeout = 30 * time.Minute
 )
 
 type csrfTokenOption int
@@ -86,6 +89,10 @@ type Server struct {
 	currentParallelSnapshots int
 	// +checklocks:parallelSnapshotsMutex
 	maxParallelSnapshots int
+	// +checklocks:parallelSnapshotsMutex
+	uploadsInProgress map[snapshot.SourceInfo]bool
+	// +checklocks:parallelSnapshotsMutex
+	shuttingDown bool // when set, new snapshots are not started
 
 	// +checklocks:parallelSnapshotsMutex
 	pendingMultiSnapshotStatus notifydata.MultiSnapshotStatus
@@ -113,6 +120,9 @@ type Server struct {
 	// +checklocks:nextRefreshTimeLock
 	nextRefreshTime time.Time
 
+	// per-user rate limiter applied after authentication, nil == unlimited
+	userRateLimiter *keyedRateLimiter
+
 	grpcServerState
 }
 
@@ -181,6 +191,7 @@ func (s *Server) SetupControlAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/control/flush", s.handleServerControlAPI(handleFlush)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/refresh", s.handleServerControlAPI(handleRefresh)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/shutdown", s.handleServerControlAPIPossiblyNotConnected(handleShutdown)).Methods(http.MethodPost)
+	m.HandleFunc("/api/v1/control/reload", s.handleServerControlAPIPossiblyNotConnected(handleReload)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/trigger-snapshot", s.handleServerControlAPI(handleUpload)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/cancel-snapshot", s.handleServerControlAPI(handleCancel)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/control/pause-source", s.handleServerControlAPI(handlePause)).Methods(http.MethodPost)
@@ -211,7 +222,7 @@ func (s *Server) isAuthenticated(rc requestContext) bool {
 		if rc.srv.isAuthCookieValid(username, c.Value) {
 			// found a short-term JWT cookie that matches given username, trust it.
 			// this avoids potentially expensive password hashing inside the authenticator.
-			return true
+			return s.checkUserRateLimit(rc, username)
 		}
 	}
 
@@ -244,6 +255,16 @@ func (s *Server) isAuthenticated(rc requestContext) bool {
 		}
 	}
 
+	return s.checkUserRateLimit(rc, username)
+}
+
+// checkUserRateLimit rejects the request when the authenticated user exceeded the per-user rate limit.
+func (s *Server) checkUserRateLimit(rc requestContext, username string) bool {
+	if d := s.reserveUserRequest(username); d > 0 {
+		rejectThrottledRequest(rc.w, rc.req, rateLimitKindUser, d)
+		return false
+	}
+
 	return true
 }
 
@@ -497,17 +518,62 @@ func handleFlush(ctx context.Context, rc requestContext) (interface{}, *apiError
 	return &serverapi.Empty{}, nil
 }
 
+func handleReload(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	log(ctx).Info("reloading due to API request")
+
+	rc.srv.Refresh()
+
+	return handleRepoStatus(ctx, rc)
+}
+
 func handleShutdown(ctx context.Context, rc requestContext) (interface{}, *apiError) {
-	log(ctx).Info("shutting down due to API request")
+	var req serverapi.ShutdownRequest
+
+	// older clients send an empty request
+	if len(rc.body) > 0 {
+		if err := json.Unmarshal(rc.body, &req); err != nil {
+			return nil, requestError(serverapi.ErrorMalformedRequest, "malformed request body")
+		}
+	}
+
+	resp := &serverapi.ShutdownResponse{}
+
+	for _, sm := range rc.srv.snapshotAllSourceManagers() {
+		if sm.Status().Status != "UPLOADING" {
+			continue
+		}
+
+		resp.InProgressSnapshots++
+
+		if req.Force {
+			sm.cancel(ctx)
+
+			resp.Canceled = true
+		}
+	}
+
+	log(ctx).Infow("shutting down due to API request", "force", req.Force, "inProgressSnapshots", resp.InProgressSnapshots)
 
 	rc.srv.requestShutdown(ctx)
 
-	return &serverapi.Empty{}, nil
+	if !req.Force {
+		resp.WaitTimeout = rc.srv.shutdownUploadTimeout()
+	}
+
+	return resp, nil
 }
 
 func (s *Server) requestShutdown(ctx context.Context) {
+	// stop starting new snapshots and wake up the ones waiting for an upload slot.
+	s.parallelSnapshotsMutex.Lock()
+	s.shuttingDown = true
+	s.parallelSnapshotsChanged.Broadcast()
+	s.parallelSnapshotsMutex.Unlock()
+
 	if f := s.OnShutdown; f != nil {
 		go func() {
+			s.waitForUploadsToFinish(ctx, s.shutdownUploadTimeout())
+
 			if err := f(ctx); err != nil {
 				log(ctx).Errorf("shutdown failed: %v", err)
 			}
@@ -515,6 +581,84 @@ func (s *Server) requestShutdown(ctx context.Context) {
 	}
 }
 
+func (s *Server) shutdownUploadTimeout() time.Duration {
+	if t := s.options.ShutdownUploadTimeout; t > 0 {
+		return t
+	}
+
+	return defaultShutdownUploadTimeout
+}
+
+// waitForUploadsToFinish blocks until there are no snapshots in progress or the timeout
+// elapses, in which case the remaining snapshots are canceled.
+func (s *Server) waitForUploadsToFinish(ctx context.Context, timeout time.Duration) {
+	var expired bool // protected by parallelSnapshotsMutex
+
+	// wake up the waiting loop when the timeout elapses.
+	t := time.AfterFunc(timeout, func() {
+		s.parallelSnapshotsMutex.Lock()
+		defer s.parallelSnapshotsMutex.Unlock()
+
+		expired = true
+
+		s.parallelSnapshotsChanged.Broadcast()
+	})
+	defer t.Stop()
+
+	pending := s.pendingUploads(ctx, clock.Now().Add(timeout), &expired)
+
+	if len(pending) == 0 {
+		return
+	}
+
+	log(ctx).Warnf("shutdown timed out after %v, canceling %v snapshot(s) in progress", timeout, len(pending))
+
+	sms := s.snapshotAllSourceManagers()
+
+	for _, src := range pending {
+		if sm := sms[src]; sm != nil {
+			sm.cancel(ctx)
+		}
+	}
+}
+
+// pendingUploads waits until there are no snapshots in progress or until the wait has expired and returns
+// the sources whose snapshots are still in progress.
+func (s *Server) pendingUploads(ctx context.Context, deadline time.Time, expired *bool) []snapshot.SourceInfo {
+	s.parallelSnapshotsMutex.Lock()
+	defer s.parallelSnapshotsMutex.Unlock()
+
+	lastCount := 0
+
+	for len(s.uploadsInProgress) > 0 && !*expired {
+		if n := len(s.uploadsInProgress); n != lastCount {
+			log(ctx).Infof("waiting until %v for %v snapshot(s) in progress to finish before shutting down: %v",
+				deadline.Format(time.RFC3339), n, s.uploadsInProgressLocked())
+
+			lastCount = n
+		}
+
+		s.parallelSnapshotsChanged.Wait()
+	}
+
+	return s.uploadsInProgressLocked()
+}
+
+// +checklocks:s.parallelSnapshotsMutex
+func (s *Server) uploadsInProgressLocked() []snapshot.SourceInfo {
+	var result []snapshot.SourceInfo
+
+	for src := range s.uploadsInProgress {
+		result = append(result, src)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+
+	return result
+}
+
 func (s *Server) setMaxParallelSnapshotsLocked(maxParallel int) {
 	s.parallelSnapshotsMutex.Lock()
 	defer s.parallelSnapshotsMutex.Unlock()
@@ -527,7 +671,7 @@ func (s *Server) beginUpload(ctx context.Context, src snapshot.SourceInfo) bool
 	s.parallelSnapshotsMutex.Lock()
 	defer s.parallelSnapshotsMutex.Unlock()
 
-	for s.currentParallelSnapshots >= s.maxParallelSnapshots && ctx.Err() == nil {
+	for s.currentParallelSnapshots >= s.maxParallelSnapshots && ctx.Err() == nil && !s.shuttingDown {
 		log(ctx).Debugf("waiting on for parallel snapshot upload slot to be available %v", src)
 		s.parallelSnapshotsChanged.Wait()
 	}
@@ -537,8 +681,14 @@ func (s *Server) beginUpload(ctx context.Context, src snapshot.SourceInfo) bool
 		return false
 	}
 
+	if s.shuttingDown {
+		log(ctx).Infof("not snapshotting %v because the server is shutting down", src)
+		return false
+	}
+
 	// at this point s.currentParallelSnapshots < s.maxParallelSnapshots and we are locked
 	s.currentParallelSnapshots++
+	s.uploadsInProgress[src] = true
 
 	return true
 }
@@ -550,6 +700,7 @@ func (s *Server) endUpload(ctx context.Context, src snapshot.SourceInfo, mwe *no
 	log(ctx).Debugf("finished uploading %v", src)
 
 	s.currentParallelSnapshots--
+	delete(s.uploadsInProgress, src)
 
 	s.pendingMultiSnapshotStatus.Snapshots = append(s.pendingMultiSnapshotStatus.Snapshots, mwe)
 
@@ -560,8 +711,8 @@ func (s *Server) endUpload(ctx context.Context, src snapshot.SourceInfo, mwe *no
 		s.pendingMultiSnapshotStatus.Snapshots = nil
 	}
 
-	// notify one of the waiters
-	s.parallelSnapshotsChanged.Signal()
+	// notify all waiters, including any pending shutdown
+	s.parallelSnapshotsChanged.Broadcast()
 }
 
 func (s *Server) sendSnapshotReport(st notifydata.MultiSnapshotStatus) {
@@ -835,6 +986,7 @@ func (s *Server) ServeStaticFiles(m *mux.Router, fs http.FileSystem) {
 // Options encompasses all API server options.
 type Options struct {
 	ConfigFile               string
+	ConfigProfile            string
 	ConnectOptions           *repo.ConnectOptions
 	RefreshInterval          time.Duration
 	MaxConcurrency           int
@@ -853,6 +1005,8 @@ type Options struct {
 	MinMaintenanceInterval   time.Duration
 	EnableErrorNotifications bool
 	NotifyTemplateOptions    notifytemplate.Options
+	RateLimit                RateLimitOptions // only PerUser and Burst are used, see RateLimitHandler() for per-IP limits
+	ShutdownUploadTimeout    time.Duration    // maximum time to wait for snapshots in progress when shutting down, after which they are canceled
 }
 
 // InitRepositoryFunc is a function that attempts to connect to/open repository.
@@ -1124,6 +1278,7 @@ func New(ctx context.Context, options *Options) (*Server, error) {
 		options:              *options,
 		sourceManagers:       map[snapshot.SourceInfo]*sourceManager{},
 		maxParallelSnapshots: 1,
+		uploadsInProgress:    map[snapshot.SourceInfo]bool{},
 		grpcServerState:      makeGRPCServerState(options.MaxConcurrency),
 		authenticator:        options.Authenticator,
 		authorizer:           options.Authorizer,
@@ -1132,6 +1287,7 @@ func New(ctx context.Context, options *Options) (*Server, error) {
 		authCookieSigningKey: []byte(options.AuthCookieSigningKey),
 		nextRefreshTime:      clock.Now().Add(options.RefreshInterval),
 		schedulerRefresh:     make(chan string, 1),
+		userRateLimiter:      newKeyedRateLimiter(options.RateLimit.PerUser, options.RateLimit.Burst),
 	}
 
 	s.parallelSnapshotsChanged = sync.NewCond(&s.parallelSnapshotsMutex)
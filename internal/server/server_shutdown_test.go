@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/auth"
+	"github.com/kopia/kopia/internal/passwordpersist"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/notification/notifydata"
+	"github.com/kopia/kopia/snapshot"
+)
+
+func newShutdownTestServer(t *testing.T, uploadTimeout time.Duration) (*Server, chan struct{}) {
+	t.Helper()
+
+	srv, err := New(testlogging.Context(t), &Options{
+		Authorizer:            auth.DefaultAuthorizer(),
+		PasswordPersist:       passwordpersist.None(),
+		ShutdownUploadTimeout: uploadTimeout,
+	})
+	require.NoError(t, err)
+
+	shutDown := make(chan struct{})
+
+	srv.OnShutdown = func(ctx context.Context) error {
+		close(shutDown)
+		return nil
+	}
+
+	return srv, shutDown
+}
+
+func TestShutdownWaitsForUploadsInProgress(t *testing.T) {
+	ctx := testlogging.Context(t)
+	srv, shutDown := newShutdownTestServer(t, time.Hour)
+
+	src1 := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/path1"}
+	src2 := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/path2"}
+
+	require.True(t, srv.beginUpload(ctx, src1))
+
+	srv.requestShutdown(ctx)
+
+	// new snapshots are not started once shutdown has been requested.
+	require.False(t, srv.beginUpload(ctx, src2))
+
+	select {
+	case <-shutDown:
+		t.Fatal("server shut down while a snapshot was in progress")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	srv.endUpload(ctx, src1, &notifydata.ManifestWithError{})
+
+	select {
+	case <-shutDown:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not shut down after snapshot finished")
+	}
+}
+
+func TestShutdownWaitForUploadsTimesOut(t *testing.T) {
+	ctx := testlogging.Context(t)
+	srv, shutDown := newShutdownTestServer(t, 100*time.Millisecond)
+
+	require.True(t, srv.beginUpload(ctx, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/path1"}))
+
+	srv.requestShutdown(ctx)
+
+	select {
+	case <-shutDown:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not shut down after the upload timeout")
+	}
+}
@@ -99,6 +99,16 @@ func Shutdown(ctx context.Context, c *apiclient.KopiaAPIClient) error {
 	return c.Post(ctx, "control/shutdown", &Empty{}, &Empty{})
 }
 
+// Reload invokes the 'control/reload' API.
+func Reload(ctx context.Context, c *apiclient.KopiaAPIClient) (*StatusResponse, error) {
+	resp := &StatusResponse{}
+	if err := c.Post(ctx, "control/reload", &Empty{}, resp); err != nil {
+		return nil, errors.Wrap(err, "Reload")
+	}
+
+	return resp, nil
+}
+
 // RepoStatus invokes the 'repo/status' API.
 func RepoStatus(ctx context.Context, c *apiclient.KopiaAPIClient) (*StatusResponse, error) {
 	resp := &StatusResponse{}
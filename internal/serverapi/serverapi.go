@@ -76,6 +76,21 @@ type PoliciesResponse struct {
 // Empty represents empty request/response.
 type Empty struct{}
 
+// ShutdownRequest contains request to shut down the server.
+type ShutdownRequest struct {
+	// Force cancels snapshots in progress instead of waiting for them to complete.
+	Force bool `json:"force,omitempty"`
+}
+
+// ShutdownResponse acknowledges the shutdown request.
+type ShutdownResponse struct {
+	InProgressSnapshots int  `json:"inProgressSnapshots"`
+	Canceled            bool `json:"canceled"`
+
+	// WaitTimeout is the maximum time the server waits for snapshots in progress before canceling them.
+	WaitTimeout time.Duration `json:"waitTimeout,omitempty"`
+}
+
 // APIErrorCode indicates machine-readable error code returned in API responses.
 type APIErrorCode string
 
@@ -68,6 +68,7 @@ func StartServerContext(ctx context.Context, t *testing.T, env *repotesting.Envi
 	asi := &repo.APIServerInfo{}
 
 	m := mux.NewRouter()
+	s.SetupHealthHandlers(m)
 	s.SetupHTMLUIAPIHandlers(m)
 	s.SetupControlAPIHandlers(m)
 	s.ServeStaticFiles(m, server.AssetFile())
@@ -103,7 +104,7 @@ func ConnectAndOpenAPIServer(t *testing.T, ctx context.Context, asi *repo.APISer
 	}
 
 	t.Cleanup(func() {
-		repo.Disconnect(ctx, configFile)
+		repo.Disconnect(ctx, configFile, "")
 	})
 
 	//
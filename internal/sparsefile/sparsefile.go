@@ -12,6 +12,14 @@
 // Copy copies a file sparsely (omitting holes) from src to dst, while recycling
 // shared buffers.
 func Copy(dst io.WriteSeeker, src io.Reader, bufSize uint64) (int64, error) {
+	written, _, err := CopyWithHoles(dst, src, bufSize)
+
+	return written, err
+}
+
+// CopyWithHoles is like Copy but additionally returns the number of bytes that were
+// skipped, creating holes in dst.
+func CopyWithHoles(dst io.WriteSeeker, src io.Reader, bufSize uint64) (written, holeBytes int64, err error) {
 	buf := iocopy.GetBuffer()
 	defer iocopy.ReleaseBuffer(buf)
 
@@ -20,14 +28,15 @@ func Copy(dst io.WriteSeeker, src io.Reader, bufSize uint64) (int64, error) {
 
 // Copy copies bits from src to dst, seeking past blocks of zero bits in src. These
 // blocks are omitted, creating a file with holes in dst.
-func copyBuffer(dst io.WriteSeeker, src io.Reader, buf []byte) (written int64, err error) {
+func copyBuffer(dst io.WriteSeeker, src io.Reader, buf []byte) (written, holeBytes int64, err error) {
 	for {
 		nr, er := src.Read(buf)
 		if nr > 0 { //nolint:nestif
 			// If non-zero data is read, write it. Otherwise, skip forwards.
-			if isAllZero(buf) {
+			if isAllZero(buf[0:nr]) {
 				dst.Seek(int64(nr), io.SeekCurrent) //nolint:errcheck
 				written += int64(nr)
+				holeBytes += int64(nr)
 
 				continue
 			}
@@ -63,7 +72,7 @@ func copyBuffer(dst io.WriteSeeker, src io.Reader, buf []byte) (written int64, e
 		}
 	}
 
-	return written, err
+	return written, holeBytes, err
 }
 
 func isAllZero(buf []byte) bool {
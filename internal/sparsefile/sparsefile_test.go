@@ -110,3 +110,34 @@ type chunk struct {
 		}
 	}
 }
+
+func TestCopyWithHoles(t *testing.T) {
+	t.Parallel()
+
+	const blk = 4096
+
+	var data []byte
+
+	data = append(data, make([]byte, blk)...)
+	data = append(data, bytes.Repeat([]byte{1}, blk)...)
+	data = append(data, make([]byte, blk)...)
+	data = append(data, bytes.Repeat([]byte{2}, 10)...)
+
+	dst := filepath.Join(t.TempDir(), "dst")
+
+	df, err := os.Create(dst)
+	require.NoError(t, err)
+
+	defer df.Close()
+
+	require.NoError(t, df.Truncate(int64(len(data))))
+
+	written, holeBytes, err := CopyWithHoles(df, bytes.NewReader(data), blk)
+	require.NoError(t, err)
+	require.EqualValues(t, len(data), written)
+	require.EqualValues(t, 2*blk, holeBytes)
+
+	d, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, data, d)
+}
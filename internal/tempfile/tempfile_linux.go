@@ -12,6 +12,8 @@
 func Create(dir string) (*os.File, error) {
 	// on reasonably modern Linux (3.11 and above) O_TMPFILE is supported,
 	// which creates invisible, unlinked file in a given directory.
+	dir = tempDirOr(dir)
+
 	fd, err := unix.Open(dir, unix.O_RDWR|unix.O_TMPFILE|unix.O_CLOEXEC, permissions)
 	if err == nil {
 		return os.NewFile(uintptr(fd), ""), nil
@@ -223,7 +223,7 @@ func (w *webdavFS) findEntry(ctx context.Context, path string) (fs.Entry, error)
 	for i, p := range parts {
 		d, ok := e.(fs.Directory)
 		if !ok {
-			return nil, errors.Errorf("%q not found in %q (not a directory)", p, strings.Join(parts[0:i], "/"))
+			return nil, errors.Wrapf(fs.ErrEntryNotFound, "%q not found in %q (not a directory)", p, strings.Join(parts[0:i], "/"))
 		}
 
 		var err error
@@ -234,7 +234,7 @@ func (w *webdavFS) findEntry(ctx context.Context, path string) (fs.Entry, error)
 		}
 
 		if e == nil {
-			return nil, errors.Errorf("%q not found in %q (not found)", p, strings.Join(parts[0:i], "/"))
+			return nil, errors.Wrapf(fs.ErrEntryNotFound, "%q not found in %q (not found)", p, strings.Join(parts[0:i], "/"))
 		}
 	}
 
@@ -0,0 +1,21 @@
+// Package xattr provides a cross-platform abstraction for reading and writing
+// extended attributes of local files, including POSIX ACLs which are stored
+// as extended attributes on Linux.
+package xattr
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotSupported is returned when extended attributes are not supported on the current platform.
+var ErrNotSupported = errors.New("extended attributes are not supported on this platform")
+
+// aclPrefix is the prefix of extended attributes storing POSIX ACLs on Linux.
+const aclPrefix = "system.posix_acl_"
+
+// IsACL returns true if the provided extended attribute holds an access control list.
+func IsACL(name string) bool {
+	return strings.HasPrefix(name, aclPrefix)
+}
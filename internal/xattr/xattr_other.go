@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package xattr
+
+// List returns no extended attributes since they are not supported on this platform.
+//
+//nolint:revive
+func List(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+// Set returns ErrNotSupported since extended attributes are not supported on this platform.
+//
+//nolint:revive
+func Set(path, name string, value []byte) error {
+	return ErrNotSupported
+}
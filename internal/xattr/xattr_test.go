@@ -0,0 +1,40 @@
+package xattr_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/xattr"
+)
+
+func TestListAndSet(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(fname, []byte("data"), 0o600))
+
+	attrs, err := xattr.List(fname)
+	require.NoError(t, err)
+	require.Empty(t, attrs)
+
+	err = xattr.Set(fname, "user.kopia", []byte("some value"))
+	if errors.Is(err, xattr.ErrNotSupported) {
+		t.Skipf("extended attributes not supported on %v: %v", runtime.GOOS, err)
+	}
+
+	require.NoError(t, err)
+
+	attrs, err = xattr.List(fname)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"user.kopia": []byte("some value")}, attrs)
+}
+
+func TestIsACL(t *testing.T) {
+	require.True(t, xattr.IsACL("system.posix_acl_access"))
+	require.True(t, xattr.IsACL("system.posix_acl_default"))
+	require.False(t, xattr.IsACL("user.kopia"))
+	require.False(t, xattr.IsACL("security.selinux"))
+}
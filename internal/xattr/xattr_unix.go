@@ -0,0 +1,100 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package xattr
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// List returns extended attributes of the provided path without following symbolic links.
+// It returns no attributes if the underlying filesystem does not support them.
+func List(path string) (map[string][]byte, error) {
+	names, err := readBuffer(func(buf []byte) (int, error) {
+		return unix.Llistxattr(path, buf)
+	})
+	if err != nil {
+		if isNotSupported(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "unable to list extended attributes of %v", path)
+	}
+
+	var result map[string][]byte
+
+	for _, name := range bytes.Split(names, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+
+		v, err := readBuffer(func(buf []byte) (int, error) {
+			return unix.Lgetxattr(path, string(name), buf)
+		})
+		if err != nil {
+			if errors.Is(err, unix.ENODATA) {
+				// attribute was removed in the meantime.
+				continue
+			}
+
+			return nil, errors.Wrapf(err, "unable to get extended attribute %q of %v", name, path)
+		}
+
+		if result == nil {
+			result = map[string][]byte{}
+		}
+
+		result[string(name)] = v
+	}
+
+	return result, nil
+}
+
+// Set sets the extended attribute of the provided path without following symbolic links.
+func Set(path, name string, value []byte) error {
+	if err := unix.Lsetxattr(path, name, value, 0); err != nil {
+		if isNotSupported(err) {
+			return errors.Wrapf(ErrNotSupported, "unable to set extended attribute %q of %v", name, path)
+		}
+
+		return errors.Wrapf(err, "unable to set extended attribute %q of %v", name, path)
+	}
+
+	return nil
+}
+
+func isNotSupported(err error) bool {
+	return errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP)
+}
+
+// readBuffer invokes the provided function with a buffer large enough to hold the result.
+func readBuffer(f func(buf []byte) (int, error)) ([]byte, error) {
+	for {
+		// query the size first.
+		n, err := f(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if n == 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, n)
+
+		n, err = f(buf)
+		if errors.Is(err, unix.ERANGE) {
+			// value grew between the calls, try again.
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+	}
+}
@@ -25,13 +25,13 @@ func ConnectAPIServer(ctx context.Context, configFile string, si *APIServerInfo,
 		ClientOptions: opt.ClientOptions.ApplyDefaults(ctx, "API Server: "+si.BaseURL),
 	}
 
-	if err := setupCachingOptionsWithDefaults(ctx, configFile, &lc, &opt.CachingOptions, []byte(si.BaseURL)); err != nil {
+	if err := setupCachingOptionsWithDefaults(ctx, ProfileKey(configFile, opt.Profile), &lc, &opt.CachingOptions, []byte(si.BaseURL)); err != nil {
 		return errors.Wrap(err, "unable to set up caching")
 	}
 
-	if err := lc.writeToFile(configFile); err != nil {
+	if err := lc.writeToFile(configFile, opt.Profile); err != nil {
 		return errors.Wrap(err, "unable to write config file")
 	}
 
-	return verifyConnect(ctx, configFile, password)
+	return verifyConnect(ctx, configFile, opt.Profile, password)
 }
@@ -33,6 +33,8 @@
 	timeMapKey = "Kopiamtime" // this must be capital letter followed by lowercase, to comply with AZ tags naming convention.
 )
 
+var log = logging.Module("azure-immutability")
+
 type azStorage struct {
 	Options
 	blob.DefaultProviderImplementation
@@ -329,8 +331,6 @@ func (az *azStorage) retryDeleteBlob(ctx context.Context, b blob.ID) error {
 		return errors.Wrap(err, "failed to soft delete blob")
 	}
 
-	log := logging.Module("azure-immutability")
-
 	if resp.VersionID == nil || *resp.VersionID == "" {
 		// shouldn't happen
 		log(ctx).Info("VersionID not returned, exiting without deleting the delete marker version")
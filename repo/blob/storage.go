@@ -11,6 +11,7 @@
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/kopia/kopia/internal/timetrack"
 	"github.com/kopia/kopia/repo/logging"
 )
 
@@ -395,6 +396,7 @@ func PutBlobAndGetMetadata(ctx context.Context, st Storage, blobID ID, data Byte
 // ReadBlobMap reads the map of all the blobs indexed by ID.
 func ReadBlobMap(ctx context.Context, br Reader) (map[ID]Metadata, error) {
 	blobMap := map[ID]Metadata{}
+	timer := timetrack.StartTimer()
 
 	log(ctx).Info("Listing blobs...")
 
@@ -405,10 +407,13 @@ func ReadBlobMap(ctx context.Context, br Reader) (map[ID]Metadata, error) {
 		}
 		return nil
 	}); err != nil {
-		return nil, errors.Wrap(err, "unable to list blobs")
+		// never return partial results, since callers would treat blobs that were not listed as missing.
+		return nil, errors.Wrapf(err, "unable to list blobs, listing was interrupted after %v blobs", len(blobMap))
 	}
 
-	log(ctx).Infof("Listed %v blobs.", len(blobMap))
+	dur := timer.Elapsed()
+
+	log(ctx).Infof("Listed %v blobs in %v (%.0f blobs/s).", len(blobMap), dur.Round(time.Millisecond), float64(len(blobMap))/max(dur.Seconds(), 1e-3))
 
 	return blobMap, nil
 }
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -199,3 +200,50 @@ func TestPutBlobAndGetMetadata(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, fixedTime, bm.Timestamp)
 }
+
+// paginatedFailingStorage simulates a paginated backend that fails after returning the specified number of pages.
+type paginatedFailingStorage struct {
+	blob.Storage
+
+	pageSize  int
+	failAfter int
+}
+
+func (s paginatedFailingStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	all, err := blob.ListAllBlobs(ctx, s.Storage, prefix)
+	if err != nil {
+		return err
+	}
+
+	for page := 0; page*s.pageSize < len(all); page++ {
+		if page == s.failAfter {
+			return errors.New("simulated pagination failure")
+		}
+
+		for _, bm := range all[page*s.pageSize : min((page+1)*s.pageSize, len(all))] {
+			if err := callback(bm); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestReadBlobMapPartialListing(t *testing.T) {
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+	ctx := context.Background()
+
+	for i := range 10 {
+		require.NoError(t, st.PutBlob(ctx, blob.ID(fmt.Sprintf("blob-%v", i)), gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+	}
+
+	bm, err := blob.ReadBlobMap(ctx, paginatedFailingStorage{st, 3, 5})
+	require.NoError(t, err)
+	require.Len(t, bm, 10)
+
+	bm, err = blob.ReadBlobMap(ctx, paginatedFailingStorage{st, 3, 2})
+	require.ErrorContains(t, err, "listing was interrupted after 6 blobs")
+	require.Nil(t, bm)
+}
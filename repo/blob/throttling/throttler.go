@@ -153,6 +153,10 @@ type Limits struct {
 
 // NewThrottler returns a Throttler with provided limits.
 func NewThrottler(limits Limits, window time.Duration, initialFillRatio float64) (SettableThrottler, error) {
+	return newTokenBucketBasedThrottler(limits, window, initialFillRatio)
+}
+
+func newTokenBucketBasedThrottler(limits Limits, window time.Duration, initialFillRatio float64) (*tokenBucketBasedThrottler, error) {
 	t := &tokenBucketBasedThrottler{
 		readOps:          newTokenBucket("read-ops", initialFillRatio*limits.ReadsPerSecond*window.Seconds(), 0, window),
 		writeOps:         newTokenBucket("write-ops", initialFillRatio*limits.WritesPerSecond*window.Seconds(), 0, window),
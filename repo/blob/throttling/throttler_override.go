@@ -0,0 +1,102 @@
+package throttling
+
+import (
+	"sync"
+	"time"
+)
+
+// overridingThrottler applies per-process overrides on top of base limits.
+// Limits() and update handlers only ever observe the base limits, so that the overrides are never persisted.
+type overridingThrottler struct {
+	*tokenBucketBasedThrottler
+
+	overrides Limits
+
+	mu sync.Mutex
+	// +checklocks:mu
+	base Limits
+	// +checklocks:mu
+	onUpdate []UpdatedHandler
+}
+
+// Limits returns the base limits, without overrides.
+func (t *overridingThrottler) Limits() Limits {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.base
+}
+
+// EffectiveLimits returns the limits currently in effect, including overrides.
+func (t *overridingThrottler) EffectiveLimits() Limits {
+	return t.tokenBucketBasedThrottler.Limits()
+}
+
+// SetLimits sets the base limits, the overrides remain in effect.
+func (t *overridingThrottler) SetLimits(limits Limits) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.tokenBucketBasedThrottler.SetLimits(applyOverrides(limits, t.overrides)); err != nil {
+		return err
+	}
+
+	t.base = limits
+
+	for _, h := range t.onUpdate {
+		if err := h(limits); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *overridingThrottler) OnUpdate(handler UpdatedHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onUpdate = append(t.onUpdate, handler)
+}
+
+// applyOverrides returns the base limits with all non-zero overrides applied.
+func applyOverrides(base, overrides Limits) Limits {
+	l := base
+
+	overrideFloat := func(v *float64, o float64) {
+		if o > 0 {
+			*v = o
+		}
+	}
+
+	overrideInt := func(v *int, o int) {
+		if o > 0 {
+			*v = o
+		}
+	}
+
+	overrideFloat(&l.ReadsPerSecond, overrides.ReadsPerSecond)
+	overrideFloat(&l.WritesPerSecond, overrides.WritesPerSecond)
+	overrideFloat(&l.ListsPerSecond, overrides.ListsPerSecond)
+	overrideFloat(&l.UploadBytesPerSecond, overrides.UploadBytesPerSecond)
+	overrideFloat(&l.DownloadBytesPerSecond, overrides.DownloadBytesPerSecond)
+	overrideInt(&l.ConcurrentReads, overrides.ConcurrentReads)
+	overrideInt(&l.ConcurrentWrites, overrides.ConcurrentWrites)
+
+	return l
+}
+
+// NewThrottlerWithOverrides returns a Throttler with provided base limits and non-zero overrides applied on top of them.
+// The overrides are not reported by Limits() nor passed to update handlers.
+func NewThrottlerWithOverrides(base, overrides Limits, window time.Duration, initialFillRatio float64) (SettableThrottler, error) {
+	inner, err := newTokenBucketBasedThrottler(applyOverrides(base, overrides), window, initialFillRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	return &overridingThrottler{
+		tokenBucketBasedThrottler: inner,
+		overrides:                 overrides,
+		base:                      base,
+	}, nil
+}
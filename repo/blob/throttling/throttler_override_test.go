@@ -0,0 +1,39 @@
+package throttling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottlerWithOverrides(t *testing.T) {
+	base := Limits{ReadsPerSecond: 10, UploadBytesPerSecond: 1000}
+	overrides := Limits{UploadBytesPerSecond: 50, DownloadBytesPerSecond: 70}
+
+	th, err := NewThrottlerWithOverrides(base, overrides, time.Second, 0)
+	require.NoError(t, err)
+	require.Equal(t, base, th.Limits())
+
+	//nolint:forcetypeassert
+	effective := th.(*overridingThrottler).EffectiveLimits()
+	require.Equal(t, Limits{ReadsPerSecond: 10, UploadBytesPerSecond: 50, DownloadBytesPerSecond: 70}, effective)
+
+	var saved []Limits
+
+	th.OnUpdate(func(l Limits) error {
+		saved = append(saved, l)
+		return nil
+	})
+
+	newBase := Limits{ReadsPerSecond: 20, ConcurrentReads: 5}
+	require.NoError(t, th.SetLimits(newBase))
+
+	// only base limits are reported to update handlers.
+	require.Equal(t, []Limits{newBase}, saved)
+	require.Equal(t, newBase, th.Limits())
+
+	//nolint:forcetypeassert
+	effective = th.(*overridingThrottler).EffectiveLimits()
+	require.Equal(t, Limits{ReadsPerSecond: 20, ConcurrentReads: 5, UploadBytesPerSecond: 50, DownloadBytesPerSecond: 70}, effective)
+}
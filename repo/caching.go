@@ -13,8 +13,8 @@
 )
 
 // GetCachingOptions reads caching configuration for a given repository.
-func GetCachingOptions(ctx context.Context, configFile string) (*content.CachingOptions, error) {
-	lc, err := LoadConfigFromFile(configFile)
+func GetCachingOptions(ctx context.Context, configFile, profile string) (*content.CachingOptions, error) {
+	lc, err := LoadConfigFromFile(configFile, profile)
 	if err != nil {
 		return nil, err
 	}
@@ -23,17 +23,17 @@ func GetCachingOptions(ctx context.Context, configFile string) (*content.Caching
 }
 
 // SetCachingOptions changes caching configuration for a given repository.
-func SetCachingOptions(ctx context.Context, configFile string, opt *content.CachingOptions) error {
-	lc, err := LoadConfigFromFile(configFile)
+func SetCachingOptions(ctx context.Context, configFile, profile string, opt *content.CachingOptions) error {
+	lc, err := LoadConfigFromFile(configFile, profile)
 	if err != nil {
 		return err
 	}
 
-	if err = setupCachingOptionsWithDefaults(ctx, configFile, lc, opt, nil); err != nil {
+	if err = setupCachingOptionsWithDefaults(ctx, ProfileKey(configFile, profile), lc, opt, nil); err != nil {
 		return errors.Wrap(err, "unable to set up caching")
 	}
 
-	return lc.writeToFile(configFile)
+	return lc.writeToFile(configFile, profile)
 }
 
 func setupCachingOptionsWithDefaults(ctx context.Context, configPath string, lc *LocalConfig, opt *content.CachingOptions, uniqueID []byte) error {
@@ -17,6 +17,9 @@
 type ConnectOptions struct {
 	ClientOptions
 
+	// Profile is the name of the profile of the config file to store the connection in, empty for the default profile.
+	Profile string `json:"-"`
+
 	content.CachingOptions
 }
 
@@ -53,24 +56,24 @@ func Connect(ctx context.Context, configFile string, st blob.Storage, password s
 	lc.Storage = &ci
 	lc.ClientOptions = opt.ClientOptions.ApplyDefaults(ctx, "Repository in "+st.DisplayName())
 
-	if err = setupCachingOptionsWithDefaults(ctx, configFile, &lc, &opt.CachingOptions, f.UniqueID); err != nil {
+	if err = setupCachingOptionsWithDefaults(ctx, ProfileKey(configFile, opt.Profile), &lc, &opt.CachingOptions, f.UniqueID); err != nil {
 		return errors.Wrap(err, "unable to set up caching")
 	}
 
-	if err := lc.writeToFile(configFile); err != nil {
+	if err := lc.writeToFile(configFile, opt.Profile); err != nil {
 		return errors.Wrap(err, "unable to write config file")
 	}
 
-	return verifyConnect(ctx, configFile, password)
+	return verifyConnect(ctx, configFile, opt.Profile, password)
 }
 
-func verifyConnect(ctx context.Context, configFile, password string) error {
+func verifyConnect(ctx context.Context, configFile, profile, password string) error {
 	// now verify that the repository can be opened with the provided config file.
-	r, err := Open(ctx, configFile, password, nil)
+	r, err := Open(ctx, configFile, password, &Options{Profile: profile})
 	if err != nil {
 		// we failed to open the repository after writing the config file,
 		// remove the config file we just wrote and any caches.
-		if derr := Disconnect(ctx, configFile); derr != nil {
+		if derr := Disconnect(ctx, configFile, profile); derr != nil {
 			log(ctx).Errorf("unable to disconnect after unsuccessful opening: %v", derr)
 		}
 
@@ -80,9 +83,10 @@ func verifyConnect(ctx context.Context, configFile, password string) error {
 	return errors.Wrap(r.Close(ctx), "error closing repository")
 }
 
-// Disconnect removes the specified configuration file and any local cache directories.
-func Disconnect(ctx context.Context, configFile string) error {
-	cfg, err := LoadConfigFromFile(configFile)
+// Disconnect removes the provided profile from the configuration file, removes any local cache directories
+// and removes the file once no profiles remain.
+func Disconnect(ctx context.Context, configFile, profile string) error {
+	cfg, err := LoadConfigFromFile(configFile, profile)
 	if err != nil {
 		return err
 	}
@@ -97,23 +101,50 @@ func Disconnect(ctx context.Context, configFile string) error {
 		}
 	}
 
-	maintenanceLock := configFile + ".mlock"
+	maintenanceLock := ProfileKey(configFile, profile) + ".mlock"
 	if err := os.RemoveAll(maintenanceLock); err != nil {
 		log(ctx).Error("unable to remove maintenance lock file", maintenanceLock)
 	}
 
-	//nolint:wrapcheck
-	return os.Remove(configFile)
+	return removeProfileConfig(configFile, profile)
 }
 
-// SetClientOptions updates client options stored in the provided configuration file.
-func SetClientOptions(ctx context.Context, configFile string, cliOpt ClientOptions) error {
-	lc, err := LoadConfigFromFile(configFile)
+// ErrReadOnlyImposedByToken is returned when attempting to make a connection read-write
+// after the connection token restricted it to read-only.
+var ErrReadOnlyImposedByToken = errors.New("read-only mode was imposed by the connection token and can't be lifted")
+
+// SetClientOptions updates client options stored in the provided profile of the configuration file.
+func SetClientOptions(ctx context.Context, configFile, profile string, cliOpt ClientOptions) error {
+	lc, err := LoadConfigFromFile(configFile, profile)
 	if err != nil {
 		return err
 	}
 
+	if lc.ClientOptions.ReadOnlyFromToken {
+		if !cliOpt.ReadOnly {
+			return ErrReadOnlyImposedByToken
+		}
+
+		cliOpt.ReadOnlyFromToken = true
+	}
+
 	lc.ClientOptions = cliOpt
 
-	return lc.writeToFile(configFile)
+	return lc.writeToFile(configFile, profile)
+}
+
+// SetStorageConnectionInfo updates blob storage connection stored in the provided profile of the configuration file.
+func SetStorageConnectionInfo(ctx context.Context, configFile, profile string, ci blob.ConnectionInfo) error {
+	lc, err := LoadConfigFromFile(configFile, profile)
+	if err != nil {
+		return err
+	}
+
+	if lc.Storage == nil {
+		return errors.New("configuration file does not describe a direct repository connection")
+	}
+
+	lc.Storage = &ci
+
+	return lc.writeToFile(configFile, profile)
 }
@@ -127,11 +127,30 @@ func (c *committedContentIndex) addIndexBlob(ctx context.Context, indexBlobID bl
 }
 
 func (c *committedContentIndex) listContents(r IDRange, cb func(i Info) error) error {
+	return c.listContentsWithCheck(r, nil, cb)
+}
+
+// listContentsWithCheck lists contents like listContents, but first invokes check, if provided, with the number of
+// index blobs and index entries the listed contents come from. If check returns an error, no contents are listed.
+func (c *committedContentIndex) listContentsWithCheck(r IDRange, check IndexCheckFunc, cb func(i Info) error) error {
 	c.mu.RLock()
 	m := append(index.Merged(nil), c.merged...)
 	deletionWatermark := c.deletionWatermark
+	indexBlobCount := len(c.inUse)
 	c.mu.RUnlock()
 
+	if check != nil {
+		entryCount := 0
+
+		for _, ndx := range m {
+			entryCount += ndx.ApproximateCount()
+		}
+
+		if err := check(indexBlobCount, entryCount); err != nil {
+			return err
+		}
+	}
+
 	//nolint:wrapcheck
 	return m.Iterate(r, func(i index.Info) error {
 		if shouldIgnore(i, deletionWatermark) {
@@ -624,7 +624,7 @@ func NewSharedManager(ctx context.Context, st blob.Storage, prov format.Provider
 		paddingUnit:             defaultPaddingUnit,
 		checkInvariantsOnUnlock: os.Getenv("KOPIA_VERIFY_INVARIANTS") != "",
 		repoLogManager:          repoLogManager,
-		contextLogger:           logging.Module(FormatLogModule)(ctx),
+		contextLogger:           formatLog(ctx),
 
 		metricsStruct: initMetricsStruct(mr),
 	}
@@ -42,6 +42,9 @@
 
 var tracer = otel.Tracer("kopia/content")
 
+// formatLog is created at package initialization, so that FormatLogModule is known to the logging configuration.
+var formatLog = logging.Module(FormatLogModule)
+
 // PackBlobIDPrefixes contains all possible prefixes for pack blobs.
 //
 //nolint:gochecknoglobals
@@ -460,6 +463,13 @@ func (bm *WriteManager) flushPackIndexesLocked(ctx context.Context, mp format.Mu
 
 		defer closeShards()
 
+		dataShards, closeCompressedShards, err := index.CompressShards(dataShards, mp.IndexCompression)
+		if err != nil {
+			return errors.Wrap(err, "unable to compress pack index")
+		}
+
+		defer closeCompressedShards()
+
 		// we must hold a lock between writing an index and adding index blob to committed contents index
 		// otherwise it is possible for concurrent compaction or refresh to forget about the blob we have just
 		// written
@@ -19,8 +19,15 @@ type IterateOptions struct {
 	Range          IDRange
 	IncludeDeleted bool
 	Parallel       int
+
+	// checkIndex is invoked before committed contents are listed.
+	checkIndex IndexCheckFunc
 }
 
+// IndexCheckFunc is invoked with the number of committed index blobs and the approximate number of entries in them
+// before contents of those index blobs are used. Returning an error aborts the operation.
+type IndexCheckFunc func(indexBlobCount, entryCount int) error
+
 // IterateCallback is the function type used as a callback during content iteration.
 type (
 	IterateCallback   func(Info) error
@@ -136,7 +143,7 @@ func (bm *WriteManager) IterateContents(ctx context.Context, opts IterateOptions
 		return callback(i)
 	}
 
-	if len(uncommitted) == 0 && opts.IncludeDeleted && opts.Range == index.AllIDs && opts.Parallel <= 1 {
+	if len(uncommitted) == 0 && opts.IncludeDeleted && opts.Range == index.AllIDs && opts.Parallel <= 1 && opts.checkIndex == nil {
 		// fast path, invoke callback directly
 		invokeCallback = callback
 	}
@@ -149,7 +156,7 @@ func (bm *WriteManager) IterateContents(ctx context.Context, opts IterateOptions
 		return err
 	}
 
-	if err := bm.committedContents.listContents(opts.Range, invokeCallback); err != nil {
+	if err := bm.committedContents.listContentsWithCheck(opts.Range, opts.checkIndex, invokeCallback); err != nil {
 		return err
 	}
 
@@ -161,6 +168,9 @@ type IteratePackOptions struct {
 	IncludePacksWithOnlyDeletedContent bool
 	IncludeContentInfos                bool
 	Prefixes                           []blob.ID
+
+	// checkIndex is invoked before committed contents are listed.
+	checkIndex IndexCheckFunc
 }
 
 func (o *IteratePackOptions) matchesBlob(id blob.ID) bool {
@@ -196,6 +206,7 @@ func (bm *WriteManager) IteratePacks(ctx context.Context, options IteratePackOpt
 		ctx,
 		IterateOptions{
 			IncludeDeleted: options.IncludePacksWithOnlyDeletedContent,
+			checkIndex:     options.checkIndex,
 		},
 		func(ci Info) error {
 			if !options.matchesBlob(ci.PackBlobID) {
@@ -229,6 +240,13 @@ func(ci Info) error {
 
 // IterateUnreferencedBlobs returns the list of unreferenced storage blobs.
 func (bm *WriteManager) IterateUnreferencedBlobs(ctx context.Context, blobPrefixes []blob.ID, parallellism int, callback func(blob.Metadata) error) error {
+	return bm.IterateUnreferencedBlobsWithIndexCheck(ctx, blobPrefixes, parallellism, nil, callback)
+}
+
+// IterateUnreferencedBlobsWithIndexCheck returns the list of unreferenced storage blobs like IterateUnreferencedBlobs,
+// but first invokes checkIndex with the committed index blobs that determine which blobs are referenced.
+// If checkIndex returns an error, no blobs are reported as unreferenced.
+func (bm *WriteManager) IterateUnreferencedBlobsWithIndexCheck(ctx context.Context, blobPrefixes []blob.ID, parallellism int, checkIndex IndexCheckFunc, callback func(blob.Metadata) error) error {
 	usedPacks, err := bigmap.NewSet(ctx)
 	if err != nil {
 		return errors.Wrap(err, "new set")
@@ -243,6 +261,7 @@ func (bm *WriteManager) IterateUnreferencedBlobs(ctx context.Context, blobPrefix
 		IteratePackOptions{
 			Prefixes:                           blobPrefixes,
 			IncludePacksWithOnlyDeletedContent: true,
+			checkIndex:                         checkIndex,
 		},
 		func(pi PackInfo) error {
 			if pi.ContentCount > 0 {
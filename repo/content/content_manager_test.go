@@ -1700,6 +1700,43 @@ func (s *contentManagerSuite) TestFindUnreferencedBlobs(t *testing.T) {
 	verifyUnreferencedBlobsCount(ctx, t, bm, 2)
 }
 
+func (s *contentManagerSuite) TestFindUnreferencedBlobsWithIndexCheck(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+	bm := s.newTestContentManager(t, st)
+
+	contentID := writeContentAndVerify(ctx, t, bm, seededRandomData(10, 100))
+	require.NoError(t, bm.Flush(ctx))
+	require.NoError(t, bm.RewriteContent(ctx, contentID))
+	require.NoError(t, bm.Flush(ctx))
+
+	var indexBlobCount, entryCount, unrefCount int
+
+	require.NoError(t, bm.IterateUnreferencedBlobsWithIndexCheck(ctx, nil, 1, func(ib, ec int) error {
+		indexBlobCount, entryCount = ib, ec
+		return nil
+	}, func(_ blob.Metadata) error {
+		unrefCount++
+		return nil
+	}))
+
+	require.Equal(t, 2, indexBlobCount)
+	require.Equal(t, 2, entryCount)
+	require.Equal(t, 1, unrefCount)
+
+	// failed check aborts before any blobs are reported.
+	errCheck := errors.New("check failed")
+
+	err := bm.IterateUnreferencedBlobsWithIndexCheck(ctx, nil, 1, func(int, int) error {
+		return errCheck
+	}, func(_ blob.Metadata) error {
+		t.Fatal("unexpected unreferenced blob")
+		return nil
+	})
+	require.ErrorIs(t, err, errCheck)
+}
+
 func (s *contentManagerSuite) TestFindUnreferencedBlobs2(t *testing.T) {
 	ctx := testlogging.Context(t)
 	data := blobtesting.DataMap{}
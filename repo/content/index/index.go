@@ -25,7 +25,17 @@ type Index interface {
 }
 
 // Open reads an Index from a given reader. The caller must call Close() when the index is no longer used.
+// Indexes compressed with CompressShards are transparently decompressed.
 func Open(data []byte, closer func() error, v1PerContentOverhead func() int) (Index, error) {
+	if IsCompressed(data) {
+		d, err := decompressIndex(data)
+		if err != nil {
+			return nil, err
+		}
+
+		data = d
+	}
+
 	h, err := v1ReadHeader(data)
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid header")
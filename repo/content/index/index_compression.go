@@ -0,0 +1,72 @@
+package index
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/compression"
+)
+
+// compressedIndexMarker is the first byte of a compressed index blob. It never collides with
+// uncompressed indexes, which always start with their format version (1 or 2).
+const compressedIndexMarker = 0xC1
+
+// IsCompressed returns true if the provided index data has been compressed using CompressShards.
+func IsCompressed(data []byte) bool {
+	return len(data) > 0 && data[0] == compressedIndexMarker
+}
+
+// CompressShards compresses the provided index shards using the provided compressor.
+// When the compressor name is empty, the shards are returned unchanged.
+// Returns shard bytes and function to clean up after the shards have been written.
+func CompressShards(shards []gather.Bytes, name compression.Name) ([]gather.Bytes, func(), error) {
+	if name == "" {
+		return shards, func() {}, nil
+	}
+
+	comp := compression.ByName[name]
+	if comp == nil {
+		return nil, nil, errors.Errorf("unsupported index compression %q", name)
+	}
+
+	var (
+		bufs   []*gather.WriteBuffer
+		result []gather.Bytes
+	)
+
+	closeShards := func() {
+		for _, b := range bufs {
+			b.Close()
+		}
+	}
+
+	for _, s := range shards {
+		buf := gather.NewWriteBuffer()
+		bufs = append(bufs, buf)
+
+		buf.Append([]byte{compressedIndexMarker})
+
+		if err := comp.Compress(buf, s.Reader()); err != nil {
+			closeShards()
+
+			return nil, nil, errors.Wrap(err, "error compressing index shard")
+		}
+
+		result = append(result, buf.Bytes())
+	}
+
+	return result, closeShards, nil
+}
+
+// decompressIndex returns the uncompressed contents of an index produced by CompressShards.
+func decompressIndex(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	if err := compression.DecompressByHeader(&out, bytes.NewReader(data[1:])); err != nil {
+		return nil, errors.Wrap(err, "error decompressing index")
+	}
+
+	return out.Bytes(), nil
+}
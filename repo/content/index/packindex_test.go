@@ -603,3 +603,52 @@ func mustParseID(t *testing.T, s string) ID {
 
 	return id
 }
+
+func TestCompressedIndex(t *testing.T) {
+	b := Builder{}
+
+	addIntsAsDeterministicContent(t, rand.Perm(1000), b.Add)
+
+	shards, closeShards, err := b.BuildShards(Version2, true, 10000)
+	require.NoError(t, err)
+
+	defer closeShards()
+
+	require.Len(t, shards, 1)
+
+	compressed, closeCompressed, err := CompressShards(shards, "zstd")
+	require.NoError(t, err)
+
+	defer closeCompressed()
+
+	require.Len(t, compressed, 1)
+	require.True(t, IsCompressed(compressed[0].ToByteSlice()))
+	require.False(t, IsCompressed(shards[0].ToByteSlice()))
+	require.Less(t, compressed[0].Length(), shards[0].Length())
+
+	for _, data := range [][]byte{shards[0].ToByteSlice(), compressed[0].ToByteSlice()} {
+		ndx, err := Open(data, nil, func() int { return fakeEncryptionOverhead })
+		require.NoError(t, err)
+
+		cnt := 0
+
+		require.NoError(t, ndx.Iterate(AllIDs, func(Info) error {
+			cnt++
+			return nil
+		}))
+
+		require.Equal(t, 1000, cnt)
+		require.NoError(t, ndx.Close())
+	}
+
+	// no compression returns shards unchanged
+	same, closeSame, err := CompressShards(shards, "")
+	require.NoError(t, err)
+
+	defer closeSame()
+
+	require.Equal(t, shards, same)
+
+	_, _, err = CompressShards(shards, "no-such-compression")
+	require.Error(t, err)
+}
@@ -514,6 +514,13 @@ func (m *ManagerV0) compactIndexBlobs(ctx context.Context, indexBlobs []Metadata
 
 	defer cleanupShards()
 
+	dataShards, cleanupCompressedShards, err := index.CompressShards(dataShards, mp.IndexCompression)
+	if err != nil {
+		return errors.Wrap(err, "unable to compress index shards")
+	}
+
+	defer cleanupCompressedShards()
+
 	compactedIndexBlobs, err := m.WriteIndexBlobs(ctx, dataShards, "")
 	if err != nil {
 		return errors.Wrap(err, "unable to write compacted indexes")
@@ -88,6 +88,13 @@ func (m *ManagerV1) CompactEpoch(ctx context.Context, blobIDs []blob.ID, outputP
 
 	defer cleanupShards()
 
+	dataShards, cleanupCompressedShards, err := index.CompressShards(dataShards, mp.IndexCompression)
+	if err != nil {
+		return errors.Wrap(err, "unable to compress index shards")
+	}
+
+	defer cleanupCompressedShards()
+
 	var rnd [8]byte
 
 	if _, err := rand.Read(rnd[:]); err != nil {
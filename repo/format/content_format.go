@@ -6,10 +6,15 @@
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/epoch"
+	"github.com/kopia/kopia/internal/feature"
 	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content/index"
 )
 
+// IndexCompressionFeature is the feature required to open repositories that store compressed index blobs.
+const IndexCompressionFeature feature.Feature = "index-compression"
+
 // ContentFormat describes the rules for formatting contents in repository.
 type ContentFormat struct {
 	Hash               string `json:"hash,omitempty"`                        // identifier of the hash algorithm used
@@ -67,6 +72,8 @@ type MutableParameters struct {
 	MaxPackSize     int              `json:"maxPackSize,omitempty"`     // maximum size of a pack object
 	IndexVersion    int              `json:"indexVersion,omitempty"`    // force particular index format version (1,2,..)
 	EpochParameters epoch.Parameters `json:"epochParameters,omitempty"` // epoch manager parameters
+
+	IndexCompression compression.Name `json:"indexCompression,omitempty"` // compression used when writing index blobs, empty means none
 }
 
 // Validate validates the parameters.
@@ -87,6 +94,10 @@ func (v *MutableParameters) Validate() error {
 		return errors.Wrap(err, "invalid epoch parameters")
 	}
 
+	if v.IndexCompression != "" && compression.ByName[v.IndexCompression] == nil {
+		return errors.Errorf("unsupported index compression %q", v.IndexCompression)
+	}
+
 	return nil
 }
 
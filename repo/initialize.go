@@ -9,6 +9,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/feature"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/ecc"
@@ -108,6 +109,8 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 				MaxPackSize:     applyDefaultInt(opt.BlockFormat.MaxPackSize, 20<<20), //nolint:mnd
 				IndexVersion:    applyDefaultInt(opt.BlockFormat.IndexVersion, content.DefaultIndexVersion),
 				EpochParameters: opt.BlockFormat.EpochParameters,
+
+				IndexCompression: opt.BlockFormat.IndexCompression,
 			},
 			EnablePasswordChange: opt.BlockFormat.EnablePasswordChange,
 		},
@@ -129,6 +132,11 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 		return nil, errors.Wrap(err, "error resolving format version")
 	}
 
+	if f.IndexCompression != "" {
+		// older clients can't read compressed indexes, make sure they refuse to open the repository.
+		f.RequiredFeatures = append(f.RequiredFeatures, feature.Required{Feature: format.IndexCompressionFeature})
+	}
+
 	return f, nil
 }
 
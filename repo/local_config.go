@@ -31,6 +31,9 @@ type ClientOptions struct {
 	ReadOnly               bool `json:"readonly,omitempty"`
 	PermissiveCacheLoading bool `json:"permissiveCacheLoading,omitempty"`
 
+	// ReadOnlyFromToken indicates that read-only mode was imposed by the connection token and can't be lifted.
+	ReadOnlyFromToken bool `json:"readOnlyFromToken,omitempty"`
+
 	// Description is human-readable description of the repository to use in the UI.
 	Description string `json:"description,omitempty"`
 
@@ -38,6 +41,9 @@ type ClientOptions struct {
 
 	FormatBlobCacheDuration time.Duration `json:"formatBlobCacheDuration,omitempty"`
 
+	// ExpiresAt is the time after which the connection can no longer be opened, usually inherited from the connection token.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
 	Throttling *throttling.Limits `json:"throttlingLimits,omitempty"`
 }
 
@@ -80,6 +86,15 @@ func (o ClientOptions) Override(other ClientOptions) ClientOptions {
 		o.ReadOnly = other.ReadOnly
 	}
 
+	if other.ReadOnlyFromToken {
+		o.ReadOnly = true
+		o.ReadOnlyFromToken = true
+	}
+
+	if other.ExpiresAt != nil {
+		o.ExpiresAt = other.ExpiresAt
+	}
+
 	return o
 }
 
@@ -101,50 +116,51 @@ type LocalConfig struct {
 	ClientOptions
 }
 
-// writeToFile writes the config to a given file.
-func (lc *LocalConfig) writeToFile(filename string) error {
+// writeToFile writes the config to the provided profile of the config file, leaving other profiles unchanged.
+func (lc *LocalConfig) writeToFile(fileName, profile string) error {
 	lc2 := *lc
 
 	if lc.Caching != nil {
 		lc2.Caching = lc.Caching.CloneOrDefault()
 
 		// try computing relative pathname from config dir to the cache dir.
-		d, err := filepath.Rel(filepath.Dir(filename), lc.Caching.CacheDirectory)
+		d, err := filepath.Rel(filepath.Dir(fileName), lc.Caching.CacheDirectory)
 		if err == nil {
 			lc2.Caching.CacheDirectory = d
 		}
 	}
 
-	b, err := json.MarshalIndent(lc2, "", "  ")
+	return updateConfigFile(fileName, false, func(c *configFileContents) {
+		c.setProfileConfig(profile, &lc2)
+	})
+}
+
+func writeConfigFileContents(fileName string, c *configFileContents) error {
+	b, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return errors.Wrap(err, "error creating config file contents")
 	}
 
-	if err = os.MkdirAll(filepath.Dir(filename), configDirMode); err != nil {
-		return errors.Wrap(err, "unable to create config directory")
-	}
-
-	return errors.Wrap(atomicfile.Write(filename, bytes.NewReader(b)), "error writing file")
+	return errors.Wrap(atomicfile.Write(fileName, bytes.NewReader(b)), "error writing file")
 }
 
-// LoadConfigFromFile reads the local configuration from the specified file.
-func LoadConfigFromFile(fileName string) (*LocalConfig, error) {
-	f, err := os.Open(fileName) //nolint:gosec
+// LoadConfigFromFile reads the local configuration of the provided profile from the specified file.
+// Empty profile refers to the default profile.
+func LoadConfigFromFile(configFile, profile string) (*LocalConfig, error) {
+	c, err := readConfigFileContents(configFile)
 	if err != nil {
-		return nil, errors.Wrap(err, "error loading config file")
+		return nil, err
 	}
-	defer f.Close() //nolint:errcheck
-
-	var lc LocalConfig
 
-	if err := json.NewDecoder(f).Decode(&lc); err != nil {
-		return nil, errors.Wrap(err, "error decoding config json")
+	lc := c.profileConfig(profile)
+	if lc == nil {
+		return nil, errors.Wrap(&os.PathError{Op: "open", Path: ProfileKey(configFile, profile), Err: os.ErrNotExist}, "error loading config file")
 	}
 
 	// cache directory is stored as relative to config file name, resolve it to absolute.
 	if lc.Caching != nil {
 		if lc.Caching.CacheDirectory != "" && !ospath.IsAbs(lc.Caching.CacheDirectory) {
-			lc.Caching.CacheDirectory = filepath.Join(filepath.Dir(fileName), lc.Caching.CacheDirectory)
+			lc.Caching.CacheDirectory = filepath.Join(filepath.Dir(configFile), lc.Caching.CacheDirectory)
 		}
 
 		// override cache directory from the environment variable.
@@ -157,5 +173,5 @@ func LoadConfigFromFile(fileName string) (*LocalConfig, error) {
 		return nil, errors.New("must have set KOPIA_UPGRADE_LOCK_ENABLED when connecting to repository with permissive cache loading")
 	}
 
-	return &lc, nil
+	return lc, nil
 }
@@ -0,0 +1,187 @@
+package repo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/gofrs/flock"
+	"github.com/pkg/errors"
+)
+
+// profileKeySeparator separates the name of the config file from the name of the profile in profile keys.
+const profileKeySeparator = "#"
+
+var validProfileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_-]*$`)
+
+// configFileContents describes the contents of the config file. The default profile is stored at
+// the top level, which keeps config files without named profiles unchanged.
+type configFileContents struct {
+	LocalConfig
+
+	Profiles map[string]*LocalConfig `json:"profiles,omitempty"`
+}
+
+func (c *configFileContents) hasDefaultProfile() bool {
+	return c.APIServer != nil || c.Storage != nil
+}
+
+// ValidateProfileName returns an error if the provided name can't be used as a profile name.
+func ValidateProfileName(profile string) error {
+	if profile != "" && !validProfileNameRegexp.MatchString(profile) {
+		return errors.Errorf("invalid profile name %q, must only contain letters, digits, '_' and '-'", profile)
+	}
+
+	return nil
+}
+
+// ProfileKey returns the string that identifies the provided profile of the config file. It is used
+// as a prefix of names of files associated with the connection and to identify the connection in the
+// OS keyring. The key of the default profile is the name of the config file itself.
+func ProfileKey(configFile, profile string) string {
+	if profile == "" {
+		return configFile
+	}
+
+	return configFile + profileKeySeparator + profile
+}
+
+func readConfigFileContents(fileName string) (*configFileContents, error) {
+	f, err := os.Open(fileName) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading config file")
+	}
+	defer f.Close() //nolint:errcheck
+
+	var c configFileContents
+
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return nil, errors.Wrap(err, "error decoding config json")
+	}
+
+	return &c, nil
+}
+
+// profileConfig returns the config of the provided profile or nil if it does not exist.
+func (c *configFileContents) profileConfig(profile string) *LocalConfig {
+	if profile != "" {
+		return c.Profiles[profile]
+	}
+
+	if !c.hasDefaultProfile() && len(c.Profiles) > 0 {
+		return nil
+	}
+
+	return &c.LocalConfig
+}
+
+// setProfileConfig sets or, when lc is nil, removes the config of the provided profile.
+func (c *configFileContents) setProfileConfig(profile string, lc *LocalConfig) {
+	switch {
+	case profile == "" && lc == nil:
+		c.LocalConfig = LocalConfig{}
+
+	case profile == "":
+		c.LocalConfig = *lc
+
+	case lc == nil:
+		delete(c.Profiles, profile)
+
+	default:
+		if c.Profiles == nil {
+			c.Profiles = map[string]*LocalConfig{}
+		}
+
+		c.Profiles[profile] = lc
+	}
+}
+
+// updateConfigFile applies the provided update to the contents of the config file while holding a lock,
+// which prevents concurrent updates of different profiles stored in the same file from overwriting each other.
+// When removeIfEmpty is set, the file is removed once no profiles remain.
+func updateConfigFile(fileName string, removeIfEmpty bool, update func(c *configFileContents)) error {
+	if err := os.MkdirAll(filepath.Dir(fileName), configDirMode); err != nil {
+		return errors.Wrap(err, "unable to create config directory")
+	}
+
+	lockFile := fileName + ".lock"
+
+	l := flock.New(lockFile)
+	if err := l.Lock(); err != nil {
+		return errors.Wrap(err, "error acquiring config file lock")
+	}
+
+	defer l.Unlock() //nolint:errcheck
+
+	c, err := readConfigFileContents(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		c = &configFileContents{}
+	} else if err != nil {
+		return err
+	}
+
+	update(c)
+
+	if removeIfEmpty && !c.hasDefaultProfile() && len(c.Profiles) == 0 {
+		if err := os.Remove(fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.Wrap(err, "unable to remove config file")
+		}
+
+		// the lock file is not needed once the config file is gone.
+		return errors.Wrap(os.Remove(lockFile), "unable to remove config file lock")
+	}
+
+	return writeConfigFileContents(fileName, c)
+}
+
+// removeProfileConfig removes the provided profile from the config file and removes the file once no profiles remain.
+func removeProfileConfig(configFile, profile string) error {
+	if _, err := os.Stat(configFile); err != nil {
+		return errors.Wrap(err, "error loading config file")
+	}
+
+	return updateConfigFile(configFile, true, func(c *configFileContents) {
+		c.setProfileConfig(profile, nil)
+	})
+}
+
+// ListProfiles returns the names of profiles stored in the provided config file, sorted by name.
+// The default profile, if present, is listed first as an empty string.
+func ListProfiles(configFile string) ([]string, error) {
+	c, err := readConfigFileContents(configFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for p := range c.Profiles {
+		result = append(result, p)
+	}
+
+	sort.Strings(result)
+
+	if c.hasDefaultProfile() {
+		result = append([]string{""}, result...)
+	}
+
+	return result, nil
+}
+
+// ConfigExists returns true if the config file exists and contains the provided profile.
+func ConfigExists(configFile, profile string) bool {
+	c, err := readConfigFileContents(configFile)
+	if err != nil {
+		// errors reading the default profile are reported when opening the repository.
+		_, serr := os.Stat(configFile)
+		return profile == "" && serr == nil
+	}
+
+	return c.profileConfig(profile) != nil
+}
@@ -2,15 +2,18 @@
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/kopia/kopia/internal/ospath"
 	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/content"
 )
 
@@ -24,12 +27,12 @@ func TestLocalConfig_withCaching(t *testing.T) {
 	}
 
 	cfgFile := filepath.Join(td, "repository.config")
-	require.NoError(t, originalLC.writeToFile(cfgFile))
+	require.NoError(t, originalLC.writeToFile(cfgFile, ""))
 
 	rawLC := LocalConfig{}
 	mustParseJSONFile(t, cfgFile, &rawLC)
 
-	loadedLC, err := LoadConfigFromFile(cfgFile)
+	loadedLC, err := LoadConfigFromFile(cfgFile, "")
 	require.NoError(t, err)
 
 	if ospath.IsAbs(rawLC.Caching.CacheDirectory) {
@@ -47,12 +50,12 @@ func TestLocalConfig_noCaching(t *testing.T) {
 	originalLC := &LocalConfig{}
 
 	cfgFile := filepath.Join(td, "repository.config")
-	require.NoError(t, originalLC.writeToFile(cfgFile))
+	require.NoError(t, originalLC.writeToFile(cfgFile, ""))
 
 	rawLC := LocalConfig{}
 	mustParseJSONFile(t, cfgFile, &rawLC)
 
-	loadedLC, err := LoadConfigFromFile(cfgFile)
+	loadedLC, err := LoadConfigFromFile(cfgFile, "")
 	require.NoError(t, err)
 
 	if got, want := loadedLC.Caching, originalLC.Caching; got != want {
@@ -61,7 +64,7 @@ func TestLocalConfig_noCaching(t *testing.T) {
 }
 
 func TestLocalConfig_notFound(t *testing.T) {
-	if _, err := LoadConfigFromFile("nosuchfile.json"); !errors.Is(err, os.ErrNotExist) {
+	if _, err := LoadConfigFromFile("nosuchfile.json", ""); !errors.Is(err, os.ErrNotExist) {
 		t.Fatalf("unexpected error %v: wanted ErrNotExist", err)
 	}
 }
@@ -76,3 +79,97 @@ func mustParseJSONFile(t *testing.T, fname string, o interface{}) {
 
 	require.NoError(t, json.NewDecoder(f).Decode(o))
 }
+
+func TestLocalConfig_profiles(t *testing.T) {
+	td := testutil.TempDirectory(t)
+
+	cfgFile := filepath.Join(td, "repository.config")
+
+	require.False(t, ConfigExists(cfgFile, ""))
+	require.False(t, ConfigExists(cfgFile, "work"))
+
+	require.NoError(t, (&LocalConfig{
+		Storage:       &blob.ConnectionInfo{Type: "filesystem"},
+		ClientOptions: ClientOptions{Description: "work"},
+	}).writeToFile(cfgFile, "work"))
+
+	// the default profile does not exist until it's written.
+	require.False(t, ConfigExists(cfgFile, ""))
+	require.True(t, ConfigExists(cfgFile, "work"))
+
+	_, err := LoadConfigFromFile(cfgFile, "")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, (&LocalConfig{
+		Storage:       &blob.ConnectionInfo{Type: "filesystem"},
+		ClientOptions: ClientOptions{Description: "default"},
+	}).writeToFile(cfgFile, ""))
+
+	profiles, err := ListProfiles(cfgFile)
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "work"}, profiles)
+
+	lc, err := LoadConfigFromFile(cfgFile, "")
+	require.NoError(t, err)
+	require.Equal(t, "default", lc.Description)
+
+	lc, err = LoadConfigFromFile(cfgFile, "work")
+	require.NoError(t, err)
+	require.Equal(t, "work", lc.Description)
+
+	// the default profile is stored at the top level, readable without profile support.
+	rawLC := LocalConfig{}
+	mustParseJSONFile(t, cfgFile, &rawLC)
+	require.Equal(t, "default", rawLC.Description)
+
+	require.NoError(t, removeProfileConfig(cfgFile, ""))
+	require.False(t, ConfigExists(cfgFile, ""))
+	require.True(t, ConfigExists(cfgFile, "work"))
+
+	require.NoError(t, removeProfileConfig(cfgFile, "work"))
+	require.NoFileExists(t, cfgFile)
+}
+
+func TestLocalConfig_fileNameWithHash(t *testing.T) {
+	td := testutil.TempDirectory(t)
+
+	// file names that look like profile keys refer to the file itself.
+	cfgFile := filepath.Join(td, "repo#work")
+
+	require.NoError(t, (&LocalConfig{
+		Storage:       &blob.ConnectionInfo{Type: "filesystem"},
+		ClientOptions: ClientOptions{Description: "default"},
+	}).writeToFile(cfgFile, ""))
+
+	require.FileExists(t, cfgFile)
+	require.NoFileExists(t, filepath.Join(td, "repo"))
+
+	lc, err := LoadConfigFromFile(cfgFile, "")
+	require.NoError(t, err)
+	require.Equal(t, "default", lc.Description)
+}
+
+func TestLocalConfig_concurrentProfileUpdates(t *testing.T) {
+	td := testutil.TempDirectory(t)
+
+	cfgFile := filepath.Join(td, "repository.config")
+
+	const numProfiles = 10
+
+	var eg errgroup.Group
+
+	for i := range numProfiles {
+		eg.Go(func() error {
+			return (&LocalConfig{
+				Storage:       &blob.ConnectionInfo{Type: "filesystem"},
+				ClientOptions: ClientOptions{Description: fmt.Sprintf("profile %v", i)},
+			}).writeToFile(cfgFile, fmt.Sprintf("p%v", i))
+		})
+	}
+
+	require.NoError(t, eg.Wait())
+
+	profiles, err := ListProfiles(cfgFile)
+	require.NoError(t, err)
+	require.Len(t, profiles, numProfiles)
+}
@@ -4,6 +4,8 @@
 import (
 	"context"
 	"io"
+	"sort"
+	"sync"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -17,8 +19,19 @@
 // LoggerFactory retrieves a named logger for a given module.
 type LoggerFactory func(module string) Logger
 
+//nolint:gochecknoglobals
+var (
+	modulesMu sync.Mutex
+	// +checklocks:modulesMu
+	modules = map[string]struct{}{}
+)
+
 // Module returns an function that returns a logger for a given module when provided with a context.
 func Module(module string) func(ctx context.Context) Logger {
+	modulesMu.Lock()
+	modules[module] = struct{}{}
+	modulesMu.Unlock()
+
 	return func(ctx context.Context) Logger {
 		if l := ctx.Value(loggerCacheKey); l != nil {
 			return l.(*loggerCache).getLogger(module) //nolint:forcetypeassert
@@ -28,6 +41,22 @@ func Module(module string) func(ctx context.Context) Logger {
 	}
 }
 
+// Modules returns the sorted names of all modules passed to Module, which for package-level loggers
+// includes all modules of the linked packages.
+func Modules() []string {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	result := make([]string, 0, len(modules))
+	for m := range modules {
+		result = append(result, m)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
 // ToWriter returns LoggerFactory that uses given writer for log output (unadorned).
 func ToWriter(w io.Writer) LoggerFactory {
 	return zap.New(zapcore.NewCore(
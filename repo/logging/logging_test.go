@@ -109,3 +109,13 @@ func BenchmarkLogger(b *testing.B) {
 		mod1(ctx)
 	}
 }
+
+func TestModules(t *testing.T) {
+	require.NotContains(t, logging.Modules(), "test-modules")
+
+	logging.Module("test-modules")
+
+	m := logging.Modules()
+	require.Contains(t, m, "test-modules")
+	require.IsIncreasing(t, m)
+}
@@ -20,12 +20,23 @@ type DeleteUnreferencedBlobsOptions struct {
 	Prefix       blob.ID
 	DryRun       bool
 	NotAfterTime time.Time
+
+	// checkIndex is invoked with the committed index used to find referenced blobs, before any blobs are deleted.
+	checkIndex content.IndexCheckFunc
 }
 
 // DeleteUnreferencedBlobs deletes o was created after maintenance startederenced by index entries.
+func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWriter, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) (int, error) {
+	cnt, _, err := deleteUnreferencedBlobs(ctx, rep, opt, safety)
+
+	return cnt, err
+}
+
+// deleteUnreferencedBlobs deletes unreferenced blobs and returns the number and total size of blobs
+// deleted (or to be deleted in dry-run mode).
 //
 //nolint:gocyclo,funlen
-func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWriter, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) (int, error) {
+func deleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWriter, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) (int, int64, error) {
 	if opt.Parallel == 0 {
 		opt.Parallel = 16
 	}
@@ -70,7 +81,7 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 
 	activeSessions, err := rep.ContentManager().ListActiveSessions(ctx)
 	if err != nil {
-		return 0, errors.Wrap(err, "unable to load active sessions")
+		return 0, 0, errors.Wrap(err, "unable to load active sessions")
 	}
 
 	cutoffTime := opt.NotAfterTime
@@ -87,7 +98,7 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 
 	// iterate all pack blobs + session blobs and keep ones that are too young or
 	// belong to alive sessions.
-	if err := rep.ContentManager().IterateUnreferencedBlobs(ctx, prefixes, opt.Parallel, func(bm blob.Metadata) error {
+	if err := rep.ContentManager().IterateUnreferencedBlobsWithIndexCheck(ctx, prefixes, opt.Parallel, opt.checkIndex, func(bm blob.Metadata) error {
 		if bm.Timestamp.After(cutoffTime) {
 			log(ctx).Debugf("  preserving %v because it was created after maintenance started", bm.BlobID)
 			return nil
@@ -114,7 +125,7 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 
 		return nil
 	}); err != nil {
-		return 0, errors.Wrap(err, "error looking for unreferenced blobs")
+		return 0, 0, errors.Wrap(err, "error looking for unreferenced blobs")
 	}
 
 	close(unused)
@@ -124,16 +135,16 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 
 	// wait for all delete workers to finish.
 	if err := eg.Wait(); err != nil {
-		return 0, errors.Wrap(err, "worker error")
+		return 0, 0, errors.Wrap(err, "worker error")
 	}
 
 	if opt.DryRun {
-		return int(unreferencedCount), nil
+		return int(unreferencedCount), unreferencedSize, nil
 	}
 
 	del, cnt := deleted.Approximate()
 
 	log(ctx).Infof("Deleted total %v unreferenced blobs (%v)", del, units.BytesString(cnt))
 
-	return int(del), nil
+	return int(del), cnt, nil
 }
@@ -0,0 +1,97 @@
+package maintenance
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// DefaultMaxBlobListingDropPercent is the default maximum percentage by which the number of entries in the listed
+// index blobs may drop since the last blob GC before blob GC refuses to run.
+const DefaultMaxBlobListingDropPercent = 50
+
+// ErrBlobListingTruncated is returned when the number of index entries dropped suspiciously since the last blob GC,
+// which may indicate that the storage returned incomplete listing of index blobs.
+var ErrBlobListingTruncated = errors.New("blob listing appears to be incomplete")
+
+// maxBlobListingDropPercent returns the effective maximum drop in the number of index entries.
+func (p *Params) maxBlobListingDropPercent() int {
+	if p.MaxBlobListingDropPercent == 0 {
+		return DefaultMaxBlobListingDropPercent
+	}
+
+	return p.MaxBlobListingDropPercent
+}
+
+// indexListingCheck returns a function that verifies the index blobs which determine the set of referenced pack blobs.
+// Incomplete listing of index blobs makes live pack blobs appear unreferenced, so blob GC refuses to run if the number
+// of index entries has dropped since the last blob GC by more than the allowed percentage.
+// The number of entries is used instead of the number of index blobs, which drops whenever indexes are compacted.
+// The observed number of entries is stored in *observed.
+func indexListingCheck(p *Params, s *Schedule, observed *int) content.IndexCheckFunc {
+	maxDrop := p.maxBlobListingDropPercent()
+
+	return func(indexBlobCount, entryCount int) error {
+		*observed = entryCount
+
+		last := s.LastIndexEntryCount
+		if last == 0 || entryCount >= last {
+			return nil
+		}
+
+		if drop := 100 * (last - entryCount) / last; drop > maxDrop {
+			return errors.Wrapf(ErrBlobListingTruncated,
+				"number of index entries dropped from %v to %v (in %v index blobs) since the last blob GC (%v%%, maximum allowed %v%%), refusing to delete unreferenced blobs. "+
+					"If this is expected, raise the threshold using 'kopia maintenance set --max-blob-listing-drop-percent'",
+				last, entryCount, indexBlobCount, drop, maxDrop)
+		}
+
+		return nil
+	}
+}
+
+// DeleteUnreferencedBlobsWithListingCheck deletes unreferenced blobs outside of the maintenance run, performing
+// the same blob listing check as maintenance and recording the number of remaining blobs in the maintenance schedule.
+// It returns the number and total size of blobs deleted (or to be deleted in dry-run mode).
+func DeleteUnreferencedBlobsWithListingCheck(ctx context.Context, rep repo.DirectRepositoryWriter, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) (int, int64, error) {
+	p, err := GetParams(ctx, rep)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "unable to get maintenance params")
+	}
+
+	s, err := GetSchedule(ctx, rep)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "unable to get maintenance schedule")
+	}
+
+	cnt, size, err := deleteUnreferencedBlobsWithListingCheck(ctx, rep, p, s, opt, safety)
+	if err != nil || opt.DryRun {
+		return cnt, size, err
+	}
+
+	if err := SetSchedule(ctx, rep, s); err != nil {
+		return cnt, size, errors.Wrap(err, "unable to update maintenance schedule")
+	}
+
+	return cnt, size, nil
+}
+
+// deleteUnreferencedBlobsWithListingCheck runs blob GC after verifying that the listing of index blobs used to determine
+// referenced blobs does not appear to be truncated, and records the number of index entries in the schedule.
+func deleteUnreferencedBlobsWithListingCheck(ctx context.Context, rep repo.DirectRepositoryWriter, p *Params, s *Schedule, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) (int, int64, error) {
+	var entryCount int
+
+	opt.checkIndex = indexListingCheck(p, s, &entryCount)
+
+	cnt, size, err := deleteUnreferencedBlobs(ctx, rep, opt, safety)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	s.LastIndexEntryCount = entryCount
+
+	return cnt, size, nil
+}
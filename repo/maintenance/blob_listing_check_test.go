@@ -0,0 +1,139 @@
+package maintenance_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+func TestBlobGCRefusesTruncatedListing(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, format.FormatVersion3)
+	rep := env.RepositoryWriter
+
+	p := maintenance.DefaultParams()
+	p.Owner = rep.ClientOptions().UsernameAtHost()
+	require.NoError(t, maintenance.SetParams(ctx, rep, &p))
+	require.NoError(t, rep.Flush(ctx))
+
+	for _, id := range []blob.ID{"pdeadbeef1", "pdeadbeef2", "pdeadbeef3", "qdeadbeef1"} {
+		mustPutDummyBlob(t, rep.BlobStorage(), id)
+	}
+
+	runFullBlobGC := func() (int, error) {
+		var cnt int
+
+		err := maintenance.RunExclusive(ctx, rep, maintenance.ModeFull, true, func(ctx context.Context, runParams maintenance.RunParameters) error {
+			var err error
+
+			cnt, _, err = maintenance.RunFullBlobGC(ctx, runParams, maintenance.SafetyNone)
+
+			return err
+		})
+
+		return cnt, err
+	}
+
+	cnt, err := runFullBlobGC()
+	require.NoError(t, err)
+	require.Equal(t, 4, cnt)
+
+	// the number of index entries used to find referenced blobs is recorded.
+	s, err := maintenance.GetSchedule(ctx, rep)
+	require.NoError(t, err)
+	require.Equal(t, countIndexEntries(ctx, t, rep), s.LastIndexEntryCount)
+
+	// simulate storage returning only a fraction of previously existing index blobs.
+	s.LastIndexEntryCount = 1000
+	require.NoError(t, maintenance.SetSchedule(ctx, rep, s))
+
+	mustPutDummyBlob(t, rep.BlobStorage(), "pdeadbeef4")
+
+	_, err = runFullBlobGC()
+	require.ErrorIs(t, err, maintenance.ErrBlobListingTruncated)
+	verifyBlobExists(t, rep.BlobStorage(), "pdeadbeef4")
+
+	// raising the threshold allows GC to proceed.
+	p.MaxBlobListingDropPercent = 100
+	require.NoError(t, maintenance.SetParams(ctx, rep, &p))
+
+	cnt, err = runFullBlobGC()
+	require.NoError(t, err)
+	require.Equal(t, 1, cnt)
+	verifyBlobNotFound(t, rep.BlobStorage(), "pdeadbeef4")
+
+	// with the count updated, default threshold succeeds again.
+	p.MaxBlobListingDropPercent = 0
+	require.NoError(t, maintenance.SetParams(ctx, rep, &p))
+
+	_, err = runFullBlobGC()
+	require.NoError(t, err)
+}
+
+func TestDeleteUnreferencedBlobsWithListingCheck(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, format.FormatVersion3)
+	rep := env.RepositoryWriter
+
+	_, err := rep.ContentManager().WriteContent(ctx, gather.FromSlice([]byte("some data")), "", compression.HeaderZstdFastest)
+	require.NoError(t, err)
+	require.NoError(t, rep.Flush(ctx))
+
+	for _, id := range []blob.ID{"pdeadbeef1", "pdeadbeef2", "qdeadbeef1"} {
+		mustPutDummyBlob(t, rep.BlobStorage(), id)
+	}
+
+	// dry run does not delete anything nor update the schedule.
+	cnt, _, err := maintenance.DeleteUnreferencedBlobsWithListingCheck(ctx, rep, maintenance.DeleteUnreferencedBlobsOptions{DryRun: true}, maintenance.SafetyNone)
+	require.NoError(t, err)
+	require.Equal(t, 3, cnt)
+	verifyBlobExists(t, rep.BlobStorage(), "pdeadbeef1")
+
+	s, err := maintenance.GetSchedule(ctx, rep)
+	require.NoError(t, err)
+	require.Zero(t, s.LastIndexEntryCount)
+
+	cnt, _, err = maintenance.DeleteUnreferencedBlobsWithListingCheck(ctx, rep, maintenance.DeleteUnreferencedBlobsOptions{}, maintenance.SafetyNone)
+	require.NoError(t, err)
+	require.Equal(t, 3, cnt)
+	verifyBlobNotFound(t, rep.BlobStorage(), "pdeadbeef1")
+
+	// the number of index entries is recorded, so that the next maintenance run compares against it.
+	s, err = maintenance.GetSchedule(ctx, rep)
+	require.NoError(t, err)
+	require.Equal(t, countIndexEntries(ctx, t, rep), s.LastIndexEntryCount)
+
+	// truncated listing is refused.
+	s.LastIndexEntryCount = 1000
+	require.NoError(t, maintenance.SetSchedule(ctx, rep, s))
+
+	mustPutDummyBlob(t, rep.BlobStorage(), "pdeadbeef3")
+
+	_, _, err = maintenance.DeleteUnreferencedBlobsWithListingCheck(ctx, rep, maintenance.DeleteUnreferencedBlobsOptions{}, maintenance.SafetyNone)
+	require.ErrorIs(t, err, maintenance.ErrBlobListingTruncated)
+	verifyBlobExists(t, rep.BlobStorage(), "pdeadbeef3")
+}
+
+// countIndexEntries returns the number of committed index entries, including deleted ones.
+func countIndexEntries(ctx context.Context, t *testing.T, rep repo.DirectRepositoryWriter) int {
+	t.Helper()
+
+	var n int
+
+	require.NoError(t, rep.ContentManager().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(content.Info) error {
+		n++
+		return nil
+	}))
+
+	require.Positive(t, n)
+
+	return n
+}
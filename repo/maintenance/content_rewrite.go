@@ -38,8 +38,18 @@ type contentInfoOrError struct {
 // RewriteContents rewrites contents according to provided criteria and creates new
 // blobs and index entries to point at them.
 func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *RewriteContentsOptions, safety SafetyParameters) error {
+	_, _, err := rewriteContents(ctx, rep, opt, safety)
+
+	return err
+}
+
+// rewriteContents rewrites contents and returns the number and total size of contents rewritten
+// (or to be rewritten in dry-run mode).
+//
+//nolint:funlen
+func rewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *RewriteContentsOptions, safety SafetyParameters) (int, int64, error) {
 	if opt == nil {
-		return errors.New("missing options")
+		return 0, 0, errors.New("missing options")
 	}
 
 	if opt.ShortPacks {
@@ -52,6 +62,7 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 
 	var (
 		mu          sync.Mutex
+		totalCount  int
 		totalBytes  int64
 		failedCount int
 	)
@@ -90,6 +101,7 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 
 				log(ctx).Debugf("Rewriting content %v (%v bytes) from pack %v%v %v", c.ContentID, c.PackedLength, c.PackBlobID, optDeleted, age)
 				mu.Lock()
+				totalCount++
 				totalBytes += int64(c.PackedLength)
 				mu.Unlock()
 
@@ -119,10 +131,10 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 
 	if failedCount == 0 {
 		//nolint:wrapcheck
-		return rep.ContentManager().Flush(ctx)
+		return totalCount, totalBytes, rep.ContentManager().Flush(ctx)
 	}
 
-	return errors.Errorf("failed to rewrite %v contents", failedCount)
+	return 0, 0, errors.Errorf("failed to rewrite %v contents", failedCount)
 }
 
 func getContentToRewrite(ctx context.Context, rep repo.DirectRepository, opt *RewriteContentsOptions) <-chan contentInfoOrError {
@@ -8,6 +8,10 @@
 
 // runTaskIndexCompactionQuick rewrites index blobs to reduce their count but does not drop any contents.
 func runTaskIndexCompactionQuick(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
+	if runParams.skipInDryRun(ctx, TaskIndexCompaction) {
+		return nil
+	}
+
 	return ReportRun(ctx, runParams.rep, TaskIndexCompaction, s, func() error {
 		log(ctx).Info("Compacting indexes...")
 
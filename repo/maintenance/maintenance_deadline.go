@@ -0,0 +1,32 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+)
+
+// ErrDeadlineReached is returned when maintenance stops before starting a task because the deadline set using
+// WithDeadline() has passed. Tasks that were already running are allowed to complete.
+var ErrDeadlineReached = errors.New("maintenance deadline reached")
+
+type deadlineContextKey struct{}
+
+// WithDeadline returns a context that causes maintenance to stop starting new tasks after the provided time.
+// Unlike context.WithDeadline(), the context is not canceled, so that a task in progress is never interrupted.
+func WithDeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, deadlineContextKey{}, deadline)
+}
+
+// checkDeadline returns ErrDeadlineReached if the deadline set using WithDeadline() has passed.
+func checkDeadline(ctx context.Context, taskType TaskType) error {
+	deadline, ok := ctx.Value(deadlineContextKey{}).(time.Time)
+	if !ok || clock.Now().Before(deadline) {
+		return nil
+	}
+
+	return errors.Wrapf(ErrDeadlineReached, "not starting %v", taskType)
+}
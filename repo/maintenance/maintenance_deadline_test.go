@@ -0,0 +1,41 @@
+package maintenance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+func TestReportRunHonorsDeadline(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, format.FormatVersion3)
+
+	dctx := maintenance.WithDeadline(ctx, clock.Now().Add(50*time.Millisecond))
+
+	// the task that started before the deadline is not interrupted when the deadline passes.
+	require.NoError(t, maintenance.ReportRun(dctx, env.RepositoryWriter, "task1", nil, func() error {
+		time.Sleep(100 * time.Millisecond)
+
+		return dctx.Err()
+	}))
+
+	// no new tasks are started after the deadline.
+	ran := false
+
+	err := maintenance.ReportRun(dctx, env.RepositoryWriter, "task2", nil, func() error {
+		ran = true
+		return nil
+	})
+	require.ErrorIs(t, err, maintenance.ErrDeadlineReached)
+	require.False(t, ran)
+
+	s, err := maintenance.GetSchedule(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, s.Runs["task1"], 1)
+	require.Empty(t, s.Runs["task2"])
+}
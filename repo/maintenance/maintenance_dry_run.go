@@ -0,0 +1,161 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+)
+
+// DryRunReport summarizes changes that maintenance would make, computed without modifying the repository.
+type DryRunReport struct {
+	Mode Mode `json:"mode"`
+
+	// contents no longer referenced by snapshots, which snapshot GC would mark as deleted
+	UnreferencedContents     int   `json:"unreferencedContents"`
+	UnreferencedContentBytes int64 `json:"unreferencedContentBytes"`
+
+	// contents in short packs, which would be rewritten into new packs
+	RewrittenContents     int   `json:"rewrittenContents"`
+	RewrittenContentBytes int64 `json:"rewrittenContentBytes"`
+
+	// deleted contents, which would be dropped from the index
+	DroppedContents int `json:"droppedContents"`
+
+	// unreferenced blobs, which would be deleted
+	DeletedBlobs     int   `json:"deletedBlobs"`
+	DeletedBlobBytes int64 `json:"deletedBlobBytes"`
+
+	// blobs with retention time to be extended
+	ExtendedRetentionBlobs int `json:"extendedRetentionBlobs"`
+
+	// log blobs, which would be deleted
+	DeletedLogs     int   `json:"deletedLogs"`
+	DeletedLogBytes int64 `json:"deletedLogBytes"`
+
+	// tasks that would run, but whose impact can't be computed without running them
+	SkippedTasks []TaskType `json:"skippedTasks,omitempty"`
+}
+
+// ReclaimedBytes returns the estimated number of bytes that would be reclaimed by deleting blobs and logs.
+// Space used by unreferenced and rewritten contents is only reclaimed by subsequent maintenance cycles.
+func (r *DryRunReport) ReclaimedBytes() int64 {
+	return r.DeletedBlobBytes + r.DeletedLogBytes
+}
+
+// RunDryRun invokes the callback with parameters of maintenance in the provided mode, which makes
+// maintenance tasks compute and report the changes they would make in the returned report instead
+// of modifying the repository.
+// Unlike RunExclusive, it does not require maintenance ownership, does not acquire the maintenance lock
+// and does not update the maintenance schedule.
+func RunDryRun(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mode, cb func(ctx context.Context, runParams RunParameters) error) (*DryRunReport, error) {
+	rep.DisableIndexRefresh()
+
+	p, err := GetParams(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get maintenance params")
+	}
+
+	if mode == ModeAuto {
+		mode, err = shouldRun(ctx, rep, p)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to determine if maintenance is required")
+		}
+	}
+
+	report := &DryRunReport{Mode: mode}
+
+	if mode == ModeNone {
+		log(ctx).Info("Not due for maintenance.")
+		return report, nil
+	}
+
+	runParams := RunParameters{rep, mode, p, rep.Time(), report}
+
+	log(ctx).Infof("Running %v maintenance in dry-run mode, no changes will be made...", mode)
+	defer log(ctx).Infof("Finished %v maintenance dry run.", mode)
+
+	if err := rep.Refresh(ctx); err != nil {
+		return nil, errors.Wrap(err, "error refreshing indexes before maintenance")
+	}
+
+	return report, cb(ctx, runParams)
+}
+
+// DryRun returns the report of maintenance started with RunDryRun or nil if maintenance modifies the repository.
+func (p RunParameters) DryRun() *DryRunReport {
+	return p.dryRun
+}
+
+// reportRun runs the task and records its run in the schedule, unless maintenance runs in dry-run mode.
+func (p RunParameters) reportRun(ctx context.Context, taskType TaskType, s *Schedule, run func() error) error {
+	if p.dryRun != nil {
+		return run()
+	}
+
+	return ReportRun(ctx, p.rep, taskType, s, run)
+}
+
+// skipInDryRun returns true and records the tasks as skipped if maintenance runs in dry-run mode.
+func (p RunParameters) skipInDryRun(ctx context.Context, taskTypes ...TaskType) bool {
+	if p.dryRun == nil {
+		return false
+	}
+
+	for _, t := range taskTypes {
+		log(ctx).Infof("Skipping %v in dry-run mode.", t)
+	}
+
+	p.dryRun.SkippedTasks = append(p.dryRun.SkippedTasks, taskTypes...)
+
+	return true
+}
+
+// countDroppedContents returns the number of contents deleted before the provided time, which would be dropped from the index.
+func countDroppedContents(ctx context.Context, rep repo.DirectRepository, dropDeletedBefore time.Time) (int, error) {
+	var cnt int
+
+	err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		if ci.Deleted && ci.Timestamp().Before(dropDeletedBefore) {
+			cnt++
+		}
+
+		return nil
+	})
+
+	return cnt, errors.Wrap(err, "error iterating contents")
+}
+
+// rewriteContents rewrites contents or, in dry-run mode, reports contents that would be rewritten.
+func (p RunParameters) rewriteContents(ctx context.Context, opt *RewriteContentsOptions, safety SafetyParameters) error {
+	opt.DryRun = p.dryRun != nil
+
+	cnt, size, err := rewriteContents(ctx, p.rep, opt, safety)
+
+	if r := p.dryRun; r != nil {
+		r.RewrittenContents += cnt
+		r.RewrittenContentBytes += size
+	}
+
+	return err
+}
+
+// deleteUnreferencedBlobs deletes unreferenced blobs or, in dry-run mode, reports blobs that would be deleted.
+func (p RunParameters) deleteUnreferencedBlobs(ctx context.Context, s *Schedule, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters) error {
+	opt.DryRun = p.dryRun != nil
+
+	cnt, size, err := deleteUnreferencedBlobsWithListingCheck(ctx, p.rep, p.Params, s, opt, safety)
+
+	if r := p.dryRun; r != nil {
+		r.DeletedBlobs += cnt
+		r.DeletedBlobBytes += size
+
+		log(ctx).Infof("Would delete %v unreferenced blobs (%v).", cnt, units.BytesString(size))
+	}
+
+	return err
+}
@@ -27,6 +27,11 @@ type Params struct {
 	ExtendObjectLocks bool `json:"extendObjectLocks"`
 
 	ListParallelism int `json:"listParallelism"`
+
+	// MaxBlobListingDropPercent is the maximum percentage by which the number of entries in listed index blobs may drop
+	// since the last blob GC before blob GC refuses to run. Zero means DefaultMaxBlobListingDropPercent,
+	// 100 or more disables the check.
+	MaxBlobListingDropPercent int `json:"maxBlobListingDropPercent,omitempty"`
 }
 
 // isOwnedByByThisUser determines whether current user is the maintenance owner.
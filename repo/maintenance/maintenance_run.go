@@ -19,7 +19,8 @@
 
 var log = logging.Module("maintenance")
 
-const maxClockSkew = 5 * time.Minute
+// MaxClockSkew is the maximum allowed difference between local clock and repository timestamps when running maintenance.
+const MaxClockSkew = 5 * time.Minute
 
 // Mode describes the mode of maintenance to perform.
 type Mode string
@@ -134,6 +135,9 @@ type RunParameters struct {
 
 	// timestamp of the last update of maintenance schedule blob
 	MaintenanceStartTime time.Time
+
+	// non-nil when maintenance only computes the changes it would make, see RunDryRun.
+	dryRun *DryRunReport
 }
 
 // NotOwnedError is returned when maintenance cannot run because it is owned by another user.
@@ -174,7 +178,7 @@ func RunExclusive(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mod
 		return nil
 	}
 
-	lockFile := rep.ConfigFilename() + ".mlock"
+	lockFile := repo.ProfileKey(rep.ConfigFilename(), rep.ConfigProfile()) + ".mlock"
 	log(ctx).Debugf("Acquiring maintenance lock in file %v", lockFile)
 
 	// acquire local lock on a config file
@@ -192,7 +196,7 @@ func RunExclusive(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mod
 
 	defer l.Unlock() //nolint:errcheck
 
-	runParams := RunParameters{rep, mode, p, time.Time{}}
+	runParams := RunParameters{rep, mode, p, time.Time{}, nil}
 
 	// update schedule so that we don't run the maintenance again immediately if
 	// this process crashes.
@@ -230,8 +234,8 @@ func checkClockSkewBounds(rp RunParameters) error {
 		clockSkew = -clockSkew
 	}
 
-	if clockSkew > maxClockSkew {
-		return errors.Errorf("clock skew detected: local clock is out of sync with repository timestamp by more than allowed %v (local: %v repository: %v skew: %s). Refusing to run maintenance.", maxClockSkew, localTime, repoTime, clockSkew) //nolint:revive
+	if clockSkew > MaxClockSkew {
+		return errors.Errorf("clock skew detected: local clock is out of sync with repository timestamp by more than allowed %v (local: %v repository: %v skew: %s). Refusing to run maintenance.", MaxClockSkew, localTime, repoTime, clockSkew) //nolint:revive
 	}
 
 	return nil
@@ -261,6 +265,10 @@ func runQuickMaintenance(ctx context.Context, runParams RunParameters, safety Sa
 	if ok {
 		log(ctx).Debug("running quick epoch maintenance only")
 
+		if runParams.skipInDryRun(ctx, TaskEpochCompactSingle, TaskEpochAdvance) {
+			return nil
+		}
+
 		return runTaskEpochMaintenanceQuick(ctx, em, runParams, s)
 	}
 
@@ -324,8 +332,23 @@ func notDeletingOrphanedBlobs(ctx context.Context, s *Schedule, safety SafetyPar
 }
 
 func runTaskCleanupLogs(ctx context.Context, runParams RunParameters, s *Schedule) error {
-	return ReportRun(ctx, runParams.rep, TaskCleanupLogs, s, func() error {
-		deleted, err := CleanupLogs(ctx, runParams.rep, runParams.Params.LogRetention.OrDefault())
+	return runParams.reportRun(ctx, TaskCleanupLogs, s, func() error {
+		opt := runParams.Params.LogRetention.OrDefault()
+		opt.DryRun = runParams.dryRun != nil
+
+		deleted, err := CleanupLogs(ctx, runParams.rep, opt)
+
+		if r := runParams.dryRun; r != nil {
+			r.DeletedLogs = len(deleted)
+
+			for _, bm := range deleted {
+				r.DeletedLogBytes += bm.Length
+			}
+
+			log(ctx).Infof("Would clean up %v logs.", len(deleted))
+
+			return err
+		}
 
 		log(ctx).Infof("Cleaned up %v logs.", len(deleted))
 
@@ -364,6 +387,10 @@ func runTaskEpochMaintenanceFull(ctx context.Context, runParams RunParameters, s
 		return nil
 	}
 
+	if runParams.skipInDryRun(ctx, TaskEpochCompactSingle, TaskEpochAdvance, TaskEpochGenerateRange, TaskEpochCleanupMarkers, TaskEpochDeleteSupersededIndexes) {
+		return nil
+	}
+
 	// compact a single epoch
 	if err := ReportRun(ctx, runParams.rep, TaskEpochCompactSingle, s, func() error {
 		log(ctx).Info("Compacting an eligible uncompacted epoch...")
@@ -417,14 +444,23 @@ func runTaskDropDeletedContentsFull(ctx context.Context, runParams RunParameters
 
 	log(ctx).Infof("Found safe time to drop indexes: %v", safeDropTime)
 
+	if r := runParams.dryRun; r != nil {
+		cnt, err := countDroppedContents(ctx, runParams.rep, safeDropTime)
+		r.DroppedContents = cnt
+
+		log(ctx).Infof("Would drop %v contents deleted before %v.", cnt, safeDropTime)
+
+		return err
+	}
+
 	return ReportRun(ctx, runParams.rep, TaskDropDeletedContentsFull, s, func() error {
 		return DropDeletedContents(ctx, runParams.rep, safeDropTime, safety)
 	})
 }
 
 func runTaskRewriteContentsQuick(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
-	return ReportRun(ctx, runParams.rep, TaskRewriteContentsQuick, s, func() error {
-		return RewriteContents(ctx, runParams.rep, &RewriteContentsOptions{
+	return runParams.reportRun(ctx, TaskRewriteContentsQuick, s, func() error {
+		return runParams.rewriteContents(ctx, &RewriteContentsOptions{
 			ContentIDRange: index.AllPrefixedIDs,
 			PackPrefix:     content.PackBlobIDPrefixSpecial,
 			ShortPacks:     true,
@@ -433,8 +469,8 @@ func runTaskRewriteContentsQuick(ctx context.Context, runParams RunParameters, s
 }
 
 func runTaskRewriteContentsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
-	return ReportRun(ctx, runParams.rep, TaskRewriteContentsFull, s, func() error {
-		return RewriteContents(ctx, runParams.rep, &RewriteContentsOptions{
+	return runParams.reportRun(ctx, TaskRewriteContentsFull, s, func() error {
+		return runParams.rewriteContents(ctx, &RewriteContentsOptions{
 			ContentIDRange: index.AllIDs,
 			ShortPacks:     true,
 		}, safety)
@@ -442,31 +478,66 @@ func runTaskRewriteContentsFull(ctx context.Context, runParams RunParameters, s
 }
 
 func runTaskDeleteOrphanedBlobsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
-	return ReportRun(ctx, runParams.rep, TaskDeleteOrphanedBlobsFull, s, func() error {
-		_, err := DeleteUnreferencedBlobs(ctx, runParams.rep, DeleteUnreferencedBlobsOptions{
+	return runParams.reportRun(ctx, TaskDeleteOrphanedBlobsFull, s, func() error {
+		return runParams.deleteUnreferencedBlobs(ctx, s, DeleteUnreferencedBlobsOptions{
+			NotAfterTime: runParams.MaintenanceStartTime,
+			Parallel:     runParams.Params.ListParallelism,
+		}, safety)
+	})
+}
+
+// RunFullBlobGC runs the full blob garbage collection task immediately, regardless of the maintenance
+// schedule, and returns the number and total size of deleted blobs.
+//
+// Normally full blob GC is deferred until enough time has passed since the last content rewrite so that
+// all clients had a chance to flush their indexes. Running it ahead of schedule relies solely on
+// the provided safety parameters (BlobDeleteMinAge and SessionExpirationAge) to protect blobs
+// written by other clients that have not yet been committed to the index.
+func RunFullBlobGC(ctx context.Context, runParams RunParameters, safety SafetyParameters) (int, int64, error) {
+	var (
+		deletedCount int
+		deletedBytes int64
+	)
+
+	s, err := GetSchedule(ctx, runParams.rep)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "unable to get schedule")
+	}
+
+	err = ReportRun(ctx, runParams.rep, TaskDeleteOrphanedBlobsFull, s, func() error {
+		var err error
+
+		deletedCount, deletedBytes, err = deleteUnreferencedBlobsWithListingCheck(ctx, runParams.rep, runParams.Params, s, DeleteUnreferencedBlobsOptions{
 			NotAfterTime: runParams.MaintenanceStartTime,
 			Parallel:     runParams.Params.ListParallelism,
 		}, safety)
 
 		return err
 	})
+
+	return deletedCount, deletedBytes, err
 }
 
 func runTaskDeleteOrphanedBlobsQuick(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
-	return ReportRun(ctx, runParams.rep, TaskDeleteOrphanedBlobsQuick, s, func() error {
-		_, err := DeleteUnreferencedBlobs(ctx, runParams.rep, DeleteUnreferencedBlobsOptions{
+	return runParams.reportRun(ctx, TaskDeleteOrphanedBlobsQuick, s, func() error {
+		return runParams.deleteUnreferencedBlobs(ctx, s, DeleteUnreferencedBlobsOptions{
 			NotAfterTime: runParams.MaintenanceStartTime,
 			Prefix:       content.PackBlobIDPrefixSpecial,
 			Parallel:     runParams.Params.ListParallelism,
 		}, safety)
-
-		return err
 	})
 }
 
 func runTaskExtendBlobRetentionTimeFull(ctx context.Context, runParams RunParameters, s *Schedule) error {
-	return ReportRun(ctx, runParams.rep, TaskExtendBlobRetentionTimeFull, s, func() error {
-		_, err := ExtendBlobRetentionTime(ctx, runParams.rep, ExtendBlobRetentionTimeOptions{})
+	return runParams.reportRun(ctx, TaskExtendBlobRetentionTimeFull, s, func() error {
+		cnt, err := ExtendBlobRetentionTime(ctx, runParams.rep, ExtendBlobRetentionTimeOptions{
+			DryRun: runParams.dryRun != nil,
+		})
+
+		if r := runParams.dryRun; r != nil {
+			r.ExtendedRetentionBlobs = cnt
+		}
+
 		return err
 	})
 }
@@ -44,6 +44,9 @@ type Schedule struct {
 	NextQuickMaintenanceTime time.Time `json:"nextQuickMaintenance"`
 
 	Runs map[TaskType][]RunInfo `json:"runs"`
+
+	// LastIndexEntryCount holds the number of entries in index blobs used by the last blob GC.
+	LastIndexEntryCount int `json:"lastIndexEntryCount,omitempty"`
 }
 
 // ReportRun adds the provided run information to the history and discards oldest entried.
@@ -180,7 +183,12 @@ func SetSchedule(ctx context.Context, rep repo.DirectRepositoryWriter, s *Schedu
 }
 
 // ReportRun reports timing of a maintenance run and persists it in repository.
+// The task is not started if the maintenance deadline set using WithDeadline() has passed.
 func ReportRun(ctx context.Context, rep repo.DirectRepositoryWriter, taskType TaskType, s *Schedule, run func() error) error {
+	if err := checkDeadline(ctx, taskType); err != nil {
+		return err
+	}
+
 	if s == nil {
 		var err error
 
@@ -13,6 +13,7 @@
 
 	"github.com/kopia/kopia/internal/cache"
 	"github.com/kopia/kopia/internal/cacheprot"
+	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/crypto"
 	"github.com/kopia/kopia/internal/feature"
 	"github.com/kopia/kopia/internal/metrics"
@@ -46,6 +47,7 @@
 var supportedFeatures = []feature.Feature{
 	"index-v1",
 	"index-v2",
+	format.IndexCompressionFeature,
 }
 
 // throttlingWindow is the duration window during which the throttling token bucket fully replenishes.
@@ -71,6 +73,10 @@ type Options struct {
 	UpgradeOwnerID      string                     // Owner-ID of any upgrade in progress, when this is not set the access may be restricted
 	DoNotWaitForUpgrade bool                       // Disable the exponential forever backoff on an upgrade lock.
 	BeforeFlush         []RepositoryWriterCallback // list of callbacks to invoke before every flush
+	Profile             string                     // name of the profile of the config file to open, empty for the default profile
+
+	MaxUploadBytesPerSecond   float64 // when set, overrides the upload speed limit for this process
+	MaxDownloadBytesPerSecond float64 // when set, overrides the download speed limit for this process
 
 	OnFatalError func(err error) // function to invoke when repository encounters a fatal error, usually invokes os.Exit
 
@@ -88,6 +94,9 @@ type Options struct {
 // is undergoing upgrade that requires exclusive access.
 var ErrRepositoryUnavailableDueToUpgradeInProgress = errors.New("repository upgrade in progress")
 
+// ErrConnectionExpired is returned when opening a repository connection past its expiration time.
+var ErrConnectionExpired = errors.New("repository connection has expired")
+
 // Open opens a Repository specified in the configuration file.
 func Open(ctx context.Context, configFile, password string, options *Options) (rep Repository, err error) {
 	ctx, span := tracer.Start(ctx, "OpenRepository")
@@ -115,7 +124,7 @@ func Open(ctx context.Context, configFile, password string, options *Options) (r
 		return nil, errors.Wrap(err, "error resolving config file path")
 	}
 
-	lc, err := LoadConfigFromFile(configFile)
+	lc, err := LoadConfigFromFile(configFile, options.Profile)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +133,17 @@ func Open(ctx context.Context, configFile, password string, options *Options) (r
 		return nil, ErrCannotWriteToRepoConnectionWithPermissiveCacheLoading
 	}
 
+	if exp := lc.ExpiresAt; exp != nil {
+		now := clock.Now
+		if options.TimeNowFunc != nil {
+			now = options.TimeNowFunc
+		}
+
+		if now().After(*exp) {
+			return nil, errors.Wrapf(ErrConnectionExpired, "connection expired on %v, obtain a new token and reconnect", exp.Local().Format(time.RFC1123))
+		}
+	}
+
 	if lc.APIServer != nil {
 		return openAPIServer(ctx, lc.APIServer, lc.ClientOptions, lc.Caching, password, options)
 	}
@@ -271,20 +291,26 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		limits = *cliOpts.Throttling
 	}
 
-	st, throttler, ferr := addThrottler(st, limits)
+	// per-process overrides are applied on top of the limits, but never saved to the config file.
+	overrides := throttling.Limits{
+		UploadBytesPerSecond:   options.MaxUploadBytesPerSecond,
+		DownloadBytesPerSecond: options.MaxDownloadBytesPerSecond,
+	}
+
+	st, throttler, ferr := addThrottler(st, limits, overrides)
 	if ferr != nil {
 		return nil, errors.Wrap(ferr, "unable to add throttler")
 	}
 
 	throttler.OnUpdate(func(l throttling.Limits) error {
-		lc2, err2 := LoadConfigFromFile(configFile)
+		lc2, err2 := LoadConfigFromFile(configFile, options.Profile)
 		if err2 != nil {
 			return err2
 		}
 
 		lc2.Throttling = &l
 
-		return lc2.writeToFile(configFile)
+		return lc2.writeToFile(configFile, options.Profile)
 	})
 
 	blobcfg, err := fmgr.BlobCfgBlob(ctx)
@@ -349,6 +375,10 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 	closer := newRefCountedCloser(
 		scm.CloseShared,
 		dw.Wait,
+		func(ctx context.Context) error {
+			saveCacheStats(ctx, cacheOpts.CacheDirectory, mr)
+			return nil
+		},
 		mr.Close,
 		st.Close,
 	)
@@ -365,6 +395,7 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 			timeNow:          cmOpts.TimeNow,
 			cliOpts:          cliOpts,
 			configFile:       configFile,
+			configProfile:    options.Profile,
 			nextWriterID:     new(int32),
 			throttler:        throttler,
 			metricsRegistry:  mr,
@@ -376,6 +407,18 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 	return dr, nil
 }
 
+// saveCacheStats adds cache statistics of this process to the ones accumulated in the cache directory,
+// which allows them to be reported by other processes.
+func saveCacheStats(ctx context.Context, cacheDir string, mr *metrics.Registry) {
+	if cacheDir == "" {
+		return
+	}
+
+	if err := cache.AddToStatsFile(cacheDir, cache.StatsFromMetrics(mr.Snapshot(false))); err != nil {
+		log(ctx).Debugf("unable to save cache statistics: %v", err)
+	}
+}
+
 func handleMissingRequiredFeatures(ctx context.Context, fmgr *format.Manager, ignoreErrors bool) error {
 	required, err := fmgr.RequiredFeatures(ctx)
 	if err != nil {
@@ -416,8 +459,8 @@ func wrapLockingStorage(st blob.Storage, r format.BlobStorageConfiguration) blob
 	})
 }
 
-func addThrottler(st blob.Storage, limits throttling.Limits) (blob.Storage, throttling.SettableThrottler, error) {
-	throttler, err := throttling.NewThrottler(limits, throttlingWindow, throttleBucketInitialFill)
+func addThrottler(st blob.Storage, limits, overrides throttling.Limits) (blob.Storage, throttling.SettableThrottler, error) {
+	throttler, err := throttling.NewThrottlerWithOverrides(limits, overrides, throttlingWindow, throttleBucketInitialFill)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "unable to create throttler")
 	}
@@ -83,6 +83,7 @@ type DirectRepository interface {
 	AlsoLogToContentLog(ctx context.Context) context.Context
 	UniqueID() []byte
 	ConfigFilename() string
+	ConfigProfile() string
 	DeriveKey(purpose []byte, keyLength int) []byte
 	Token(password string) (string, error)
 	Throttler() throttling.SettableThrottler
@@ -105,6 +106,7 @@ type DirectRepositoryWriter interface {
 
 type immutableDirectRepositoryParameters struct {
 	configFile      string
+	configProfile   string
 	cachingOptions  content.CachingOptions
 	cliOpts         ClientOptions
 	timeNow         func() time.Time
@@ -180,6 +182,11 @@ func (r *directRepository) ConfigFilename() string {
 	return r.configFile
 }
 
+// ConfigProfile returns the name of the profile of the configuration file, empty for the default profile.
+func (r *directRepository) ConfigProfile() string {
+	return r.configProfile
+}
+
 // NewObjectWriter creates an object writer.
 func (r *directRepository) NewObjectWriter(ctx context.Context, opt object.WriterOptions) object.Writer {
 	return r.omgr.NewWriter(ctx, opt)
@@ -3,6 +3,7 @@
 import (
 	"encoding/base64"
 	"encoding/json"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -13,6 +14,14 @@ type tokenInfo struct {
 	Version  string              `json:"version"`
 	Storage  blob.ConnectionInfo `json:"storage"`
 	Password string              `json:"password,omitempty"`
+	Expires  *time.Time          `json:"expires,omitempty"`
+	ReadOnly bool                `json:"readOnly,omitempty"`
+}
+
+// TokenOptions restricts connections made using a token.
+type TokenOptions struct {
+	Expires  time.Time // zero time means the token does not expire
+	ReadOnly bool      // connections made using the token are read-only
 }
 
 // Token returns an opaque token that contains repository connection information
@@ -24,10 +33,27 @@ func (r *directRepository) Token(password string) (string, error) {
 // EncodeToken returns an opaque token that contains the given connection information
 // and optionally the provided password.
 func EncodeToken(password string, ci blob.ConnectionInfo) (string, error) {
+	return EncodeTokenWithExpiration(password, ci, time.Time{})
+}
+
+// EncodeTokenWithExpiration is like EncodeToken but also records when the token should no
+// longer be used. Zero time means the token does not expire.
+func EncodeTokenWithExpiration(password string, ci blob.ConnectionInfo, expires time.Time) (string, error) {
+	return EncodeTokenWithOptions(password, ci, TokenOptions{Expires: expires})
+}
+
+// EncodeTokenWithOptions is like EncodeToken but also records restrictions of connections made using the token.
+func EncodeTokenWithOptions(password string, ci blob.ConnectionInfo, opt TokenOptions) (string, error) {
 	ti := &tokenInfo{
 		Version:  "1",
 		Storage:  ci,
 		Password: password,
+		ReadOnly: opt.ReadOnly,
+	}
+
+	if !opt.Expires.IsZero() {
+		e := opt.Expires.UTC()
+		ti.Expires = &e
 	}
 
 	v, err := json.Marshal(ti)
@@ -40,20 +66,54 @@ func EncodeToken(password string, ci blob.ConnectionInfo) (string, error) {
 
 // DecodeToken decodes the provided token and returns connection info and password if persisted.
 func DecodeToken(token string) (blob.ConnectionInfo, string, error) {
+	t, err := decodeTokenInfo(token)
+	if err != nil {
+		return blob.ConnectionInfo{}, "", err
+	}
+
+	return t.Storage, t.Password, nil
+}
+
+// TokenExpiration returns the expiration time recorded in the provided token or zero time if the token does not expire.
+func TokenExpiration(token string) (time.Time, error) {
+	opt, err := DecodeTokenOptions(token)
+
+	return opt.Expires, err
+}
+
+// DecodeTokenOptions returns the restrictions recorded in the provided token.
+func DecodeTokenOptions(token string) (TokenOptions, error) {
+	t, err := decodeTokenInfo(token)
+	if err != nil {
+		return TokenOptions{}, err
+	}
+
+	opt := TokenOptions{
+		ReadOnly: t.ReadOnly,
+	}
+
+	if t.Expires != nil {
+		opt.Expires = *t.Expires
+	}
+
+	return opt, nil
+}
+
+func decodeTokenInfo(token string) (*tokenInfo, error) {
 	t := &tokenInfo{}
 
 	v, err := base64.RawURLEncoding.DecodeString(token)
 	if err != nil {
-		return blob.ConnectionInfo{}, "", errors.New("unable to decode token")
+		return nil, errors.New("unable to decode token")
 	}
 
 	if err := json.Unmarshal(v, t); err != nil {
-		return blob.ConnectionInfo{}, "", errors.New("unable to decode token")
+		return nil, errors.New("unable to decode token")
 	}
 
 	if t.Version != "1" {
-		return blob.ConnectionInfo{}, "", errors.New("unsupported token version")
+		return nil, errors.New("unsupported token version")
 	}
 
-	return t.Storage, t.Password, nil
+	return t, nil
 }
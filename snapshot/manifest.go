@@ -129,6 +129,9 @@ type DirEntry struct {
 	GroupID     uint32               `json:"gid,omitempty"`
 	ObjectID    object.ID            `json:"obj,omitempty"`
 	DirSummary  *fs.DirectorySummary `json:"summ,omitempty"`
+
+	// ExtendedAttributes captured at snapshot time, including POSIX ACLs on Linux.
+	ExtendedAttributes map[string][]byte `json:"xattrs,omitempty"`
 }
 
 // Clone returns a clone of the entry.
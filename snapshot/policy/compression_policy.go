@@ -3,6 +3,7 @@
 import (
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/repo/compression"
@@ -18,6 +19,10 @@ type CompressionPolicy struct {
 	NoParentNeverCompress bool             `json:"noParentNeverCompress,omitempty"`
 	MinSize               int64            `json:"minSize,omitempty"`
 	MaxSize               int64            `json:"maxSize,omitempty"`
+
+	// ExtensionCompressors maps file extensions (".log") or glob patterns matched against
+	// file names ("*.tar.*") to the compression algorithm used for matching files, "none" disables compression.
+	ExtensionCompressors map[string]compression.Name `json:"extensionCompressors,omitempty"`
 }
 
 // MetadataCompressionPolicy specifies compression policy for metadata.
@@ -32,6 +37,8 @@ type CompressionPolicyDefinition struct {
 	NeverCompress  snapshot.SourceInfo `json:"neverCompress,omitempty"`
 	MinSize        snapshot.SourceInfo `json:"minSize,omitempty"`
 	MaxSize        snapshot.SourceInfo `json:"maxSize,omitempty"`
+
+	ExtensionCompressors snapshot.SourceInfo `json:"extensionCompressors,omitempty"`
 }
 
 // MetadataCompressionPolicyDefinition specifies which policy definition provided the value of a particular field.
@@ -44,15 +51,23 @@ func (p *CompressionPolicy) CompressorForFile(e fs.Entry) compression.Name {
 	ext := filepath.Ext(e.Name())
 	size := e.Size()
 
-	if p.CompressorName == "none" {
+	if v := p.MinSize; v > 0 && size < v {
 		return ""
 	}
 
-	if v := p.MinSize; v > 0 && size < v {
+	if v := p.MaxSize; v > 0 && size > v {
 		return ""
 	}
 
-	if v := p.MaxSize; v > 0 && size > v {
+	if c, ok := p.extensionCompressor(e.Name()); ok {
+		if c == "none" {
+			return ""
+		}
+
+		return c
+	}
+
+	if p.CompressorName == "none" {
 		return ""
 	}
 
@@ -67,11 +82,48 @@ func (p *CompressionPolicy) CompressorForFile(e fs.Entry) compression.Name {
 	return p.CompressorName
 }
 
+// extensionCompressor returns the compressor configured for the file name in ExtensionCompressors.
+// Exact extension matches take precedence over glob patterns, which are evaluated in lexicographical order.
+func (p *CompressionPolicy) extensionCompressor(name string) (compression.Name, bool) {
+	if len(p.ExtensionCompressors) == 0 {
+		return "", false
+	}
+
+	if c, ok := p.ExtensionCompressors[filepath.Ext(name)]; ok {
+		return c, true
+	}
+
+	patterns := make([]string, 0, len(p.ExtensionCompressors))
+
+	for pattern := range p.ExtensionCompressors {
+		if IsCompressionGlobPattern(pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return p.ExtensionCompressors[pattern], true
+		}
+	}
+
+	return "", false
+}
+
+// IsCompressionGlobPattern returns true if the provided ExtensionCompressors key is a glob pattern
+// as opposed to a file extension.
+func IsCompressionGlobPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
 // Merge applies default values from the provided policy.
 func (p *CompressionPolicy) Merge(src CompressionPolicy, def *CompressionPolicyDefinition, si snapshot.SourceInfo) {
 	mergeCompressionName(&p.CompressorName, src.CompressorName, &def.CompressorName, si)
 	mergeInt64(&p.MinSize, src.MinSize, &def.MinSize, si)
 	mergeInt64(&p.MaxSize, src.MaxSize, &def.MaxSize, si)
+	mergeCompressionNameMap(&p.ExtensionCompressors, src.ExtensionCompressors, &def.ExtensionCompressors, si)
 
 	mergeStrings(&p.OnlyCompress, &p.NoParentOnlyCompress, src.OnlyCompress, src.NoParentOnlyCompress, &def.OnlyCompress, si)
 	mergeStrings(&p.NeverCompress, &p.NoParentNeverCompress, src.NeverCompress, src.NoParentNeverCompress, &def.NeverCompress, si)
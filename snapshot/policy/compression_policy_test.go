@@ -0,0 +1,70 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+func TestCompressorForFileExtensionCompressors(t *testing.T) {
+	dir := mockfs.NewDirectory()
+
+	p := &policy.CompressionPolicy{
+		CompressorName: "s2-default",
+		NeverCompress:  []string{".txt"},
+		MinSize:        2,
+		ExtensionCompressors: map[string]compression.Name{
+			".jpg":     "none",
+			".txt":     "zstd",
+			"*.tar.*":  "gzip",
+			"access.*": "zstd-fastest",
+		},
+	}
+
+	cases := map[string]compression.Name{
+		"photo.jpg":      "",
+		"notes.txt":      "zstd",
+		"backup.tar.bz2": "gzip",
+		"access.log":     "zstd-fastest",
+		"data.bin":       "s2-default",
+	}
+
+	for name, want := range cases {
+		require.Equal(t, want, p.CompressorForFile(dir.AddFile(name, []byte("hello"), 0o644)), name)
+	}
+
+	// size limits apply to all files.
+	require.Equal(t, compression.Name(""), p.CompressorForFile(dir.AddFile("small.txt", []byte("x"), 0o644)))
+
+	// extension rules apply even if compression is disabled otherwise.
+	p.CompressorName = "none"
+	require.Equal(t, compression.Name("zstd"), p.CompressorForFile(dir.AddFile("other.txt", []byte("hello"), 0o644)))
+	require.Equal(t, compression.Name(""), p.CompressorForFile(dir.AddFile("other.bin", []byte("hello"), 0o644)))
+}
+
+func TestMergeExtensionCompressors(t *testing.T) {
+	child := &policy.Policy{
+		Labels: policy.LabelsForSource(snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/tmp"}),
+		CompressionPolicy: policy.CompressionPolicy{
+			ExtensionCompressors: map[string]compression.Name{".log": "zstd"},
+		},
+	}
+
+	parent := &policy.Policy{
+		CompressionPolicy: policy.CompressionPolicy{
+			ExtensionCompressors: map[string]compression.Name{".log": "gzip", ".jpg": "none"},
+		},
+	}
+
+	merged, def := policy.MergePolicies([]*policy.Policy{child, parent}, child.Target())
+	require.Equal(t, map[string]compression.Name{".log": "zstd", ".jpg": "none"}, merged.CompressionPolicy.ExtensionCompressors)
+	require.Equal(t, child.Target(), def.CompressionPolicy.ExtensionCompressors)
+
+	// merging must not modify the source policies.
+	require.Len(t, child.CompressionPolicy.ExtensionCompressors, 1)
+}
@@ -135,6 +135,22 @@ func mergeCompressionName(target *compression.Name, src compression.Name, def *s
 	}
 }
 
+// mergeCompressionNameMap merges individual map entries, values for keys defined by more specific policies win.
+func mergeCompressionNameMap(target *map[string]compression.Name, src map[string]compression.Name, def *snapshot.SourceInfo, si snapshot.SourceInfo) {
+	for k, v := range src {
+		if _, ok := (*target)[k]; ok {
+			continue
+		}
+
+		if len(*target) == 0 {
+			*target = map[string]compression.Name{}
+			*def = si
+		}
+
+		(*target)[k] = v
+	}
+}
+
 func mergeInt64(target *int64, src int64, def *snapshot.SourceInfo, si snapshot.SourceInfo) {
 	if *target == 0 && src != 0 {
 		*target = src
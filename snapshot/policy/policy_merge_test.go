@@ -156,6 +156,10 @@ func testPolicyMergeSingleField(t *testing.T, fieldName string, typ reflect.Type
 		v0 = reflect.ValueOf((*policy.OSSnapshotMode)(nil))
 		v1 = reflect.ValueOf(policy.NewOSSnapshotMode(policy.OSSnapshotNever))
 		v2 = reflect.ValueOf(policy.NewOSSnapshotMode(policy.OSSnapshotAlways))
+	case "map[string]compression.Name":
+		v0 = reflect.ValueOf(map[string]compression.Name(nil))
+		v1 = reflect.ValueOf(map[string]compression.Name{".log": "foo"})
+		v2 = reflect.ValueOf(map[string]compression.Name{".log": "bar"})
 	case "string":
 		v0 = reflect.ValueOf("")
 		v1 = reflect.ValueOf("FIXED-2M")
@@ -0,0 +1,184 @@
+package restore
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// Supported schemes for naming files whose names collide when flattening.
+const (
+	// FlattenCollisionSuffix appends a numeric suffix to the file name, for example 'a-1.pdf'.
+	FlattenCollisionSuffix = "suffix"
+	// FlattenCollisionPath joins the elements of the relative path of the file, for example 'dir_sub_a.pdf'.
+	FlattenCollisionPath = "path"
+)
+
+// FlattenedEntry describes a restored entry that was renamed to avoid name collision.
+type FlattenedEntry struct {
+	RelativePath string `json:"relativePath"`
+	Name         string `json:"name"`
+}
+
+// FlattenOutput wraps another output and writes all files and symlinks directly into
+// its root instead of recreating the directory hierarchy.
+//
+// To keep names assigned to colliding entries deterministic, restores to FlattenOutput
+// are not parallelized.
+type FlattenOutput struct {
+	Output
+
+	collisionScheme string
+
+	mu sync.Mutex
+	// +checklocks:mu
+	names map[string]string // relative path -> flattened name
+	// +checklocks:mu
+	used map[string]bool
+	// +checklocks:mu
+	renamed []FlattenedEntry
+}
+
+// NewFlattenOutput returns an output that flattens the directory hierarchy written to the provided output,
+// renaming colliding entries using the provided scheme.
+func NewFlattenOutput(o Output, collisionScheme string) (*FlattenOutput, error) {
+	switch collisionScheme {
+	case FlattenCollisionSuffix, FlattenCollisionPath:
+	default:
+		return nil, errors.Errorf("unsupported collision naming scheme: %q", collisionScheme)
+	}
+
+	return &FlattenOutput{
+		Output:          o,
+		collisionScheme: collisionScheme,
+		names:           map[string]string{},
+		used:            map[string]bool{},
+	}, nil
+}
+
+// Parallelizable implements restore.Output interface.
+func (o *FlattenOutput) Parallelizable() bool {
+	return false
+}
+
+// BeginDirectory implements restore.Output interface.
+func (o *FlattenOutput) BeginDirectory(ctx context.Context, relativePath string, e fs.Directory) error {
+	if relativePath != "" {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return o.Output.BeginDirectory(ctx, relativePath, e)
+}
+
+// FinishDirectory implements restore.Output interface.
+func (o *FlattenOutput) FinishDirectory(ctx context.Context, relativePath string, e fs.Directory) error {
+	if relativePath != "" {
+		return nil
+	}
+
+	//nolint:wrapcheck
+	return o.Output.FinishDirectory(ctx, relativePath, e)
+}
+
+// WriteDirEntry implements restore.Output interface.
+func (o *FlattenOutput) WriteDirEntry(ctx context.Context, relativePath string, de *snapshot.DirEntry, e fs.Directory) error {
+	//nolint:wrapcheck
+	return o.Output.WriteDirEntry(ctx, o.flattenedName(relativePath), de, e)
+}
+
+// WriteFile implements restore.Output interface.
+func (o *FlattenOutput) WriteFile(ctx context.Context, relativePath string, e fs.File, progressCb FileWriteProgress) error {
+	//nolint:wrapcheck
+	return o.Output.WriteFile(ctx, o.flattenedName(relativePath), e, progressCb)
+}
+
+// FileExists implements restore.Output interface.
+func (o *FlattenOutput) FileExists(ctx context.Context, relativePath string, e fs.File) bool {
+	return o.Output.FileExists(ctx, o.flattenedName(relativePath), e)
+}
+
+// CreateSymlink implements restore.Output interface.
+func (o *FlattenOutput) CreateSymlink(ctx context.Context, relativePath string, e fs.Symlink) error {
+	//nolint:wrapcheck
+	return o.Output.CreateSymlink(ctx, o.flattenedName(relativePath), e)
+}
+
+// SymlinkExists implements restore.Output interface.
+func (o *FlattenOutput) SymlinkExists(ctx context.Context, relativePath string, e fs.Symlink) bool {
+	return o.Output.SymlinkExists(ctx, o.flattenedName(relativePath), e)
+}
+
+// Renamed returns entries that were renamed to avoid name collisions, in the order they were restored.
+func (o *FlattenOutput) Renamed() []FlattenedEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]FlattenedEntry(nil), o.renamed...)
+}
+
+// flattenedName returns the name in the root of the output assigned to the entry with the provided relative path.
+// The same relative path is always assigned the same name.
+func (o *FlattenOutput) flattenedName(relativePath string) string {
+	if relativePath == "" {
+		return relativePath
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if n, ok := o.names[relativePath]; ok {
+		return n
+	}
+
+	n := path.Base(relativePath)
+
+	if o.used[n] {
+		if o.collisionScheme == FlattenCollisionPath {
+			n = strings.ReplaceAll(strings.TrimPrefix(relativePath, "/"), "/", "_")
+		}
+
+		n = o.firstUnusedNameLocked(n)
+
+		o.renamed = append(o.renamed, FlattenedEntry{RelativePath: relativePath, Name: n})
+	}
+
+	o.names[relativePath] = n
+	o.used[n] = true
+
+	return n
+}
+
+// firstUnusedNameLocked returns the provided name or, if already used, the name with the lowest numeric suffix
+// inserted before the extension that is not used yet.
+//
+// +checklocks:o.mu
+func (o *FlattenOutput) firstUnusedNameLocked(name string) string {
+	if !o.used[name] {
+		return name
+	}
+
+	ext := path.Ext(name)
+	if ext == name {
+		// dot-files such as '.profile' have no extension.
+		ext = ""
+	}
+
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; ; i++ {
+		n := base + "-" + strconv.Itoa(i) + ext
+		if !o.used[n] {
+			return n
+		}
+	}
+}
+
+var _ Output = (*FlattenOutput)(nil)
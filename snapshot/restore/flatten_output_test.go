@@ -0,0 +1,42 @@
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenOutputNames(t *testing.T) {
+	_, err := NewFlattenOutput(nil, "no-such-scheme")
+	require.Error(t, err)
+
+	cases := []struct {
+		scheme      string
+		want        []string
+		wantRenamed int
+	}{
+		{FlattenCollisionSuffix, []string{"a.pdf", "b.pdf", "a-1.pdf", "a-2.pdf", ".profile", ".profile-1", "x_a.pdf", "a-3.pdf"}, 4},
+		{FlattenCollisionPath, []string{"a.pdf", "b.pdf", "x_a.pdf", "x_y_a.pdf", ".profile", "x_.profile", "x_a-1.pdf", "z_a.pdf"}, 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.scheme, func(t *testing.T) {
+			o, err := NewFlattenOutput(&FilesystemOutput{}, tc.scheme)
+			require.NoError(t, err)
+			require.False(t, o.Parallelizable())
+
+			var got []string
+
+			for _, p := range []string{"a.pdf", "b.pdf", "x/a.pdf", "x/y/a.pdf", ".profile", "x/.profile", "x_a.pdf", "z/a.pdf"} {
+				got = append(got, o.flattenedName(p))
+			}
+
+			require.Equal(t, tc.want, got)
+
+			// names are stable for the same relative path.
+			require.Equal(t, tc.want[2], o.flattenedName("x/a.pdf"))
+			require.Equal(t, "", o.flattenedName(""))
+			require.Len(t, o.Renamed(), tc.wantRenamed)
+		})
+	}
+}
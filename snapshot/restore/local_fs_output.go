@@ -7,6 +7,7 @@
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync/atomic"
 	"time"
 	"syscall"
 
@@ -18,6 +19,7 @@
 	"github.com/kopia/kopia/internal/iocopy"
 	"github.com/kopia/kopia/internal/sparsefile"
 	"github.com/kopia/kopia/internal/stat"
+	"github.com/kopia/kopia/internal/xattr"
 	"github.com/kopia/kopia/snapshot"
 )
 
@@ -27,8 +29,9 @@
 )
 
 // streamCopier is a generic function type to perform the actual copying of data bits
-// from a source stream to a destination stream.
-type streamCopier func(io.WriteSeeker, io.Reader) (int64, error)
+// from a source stream to a destination stream. In addition to the number of bytes
+// written it returns the number of bytes that were skipped, leaving holes in the destination.
+type streamCopier func(io.WriteSeeker, io.Reader) (written, holeBytes int64, err error)
 
 // getStreamCopier returns a function that can copy data from a source stream to a destination stream.
 func getStreamCopier(ctx context.Context, targetpath string, sparse bool) (streamCopier, error) {
@@ -41,8 +44,8 @@ func getStreamCopier(ctx context.Context, targetpath string, sparse bool) (strea
 				return nil, errors.Wrapf(err, "error getting disk block size for target %v", dirpath)
 			}
 
-			return func(w io.WriteSeeker, r io.Reader) (int64, error) {
-				return sparsefile.Copy(w, r, s)
+			return func(w io.WriteSeeker, r io.Reader) (int64, int64, error) {
+				return sparsefile.CopyWithHoles(w, r, s)
 			}, nil
 		}
 
@@ -50,8 +53,10 @@ func getStreamCopier(ctx context.Context, targetpath string, sparse bool) (strea
 	}
 
 	// Wrap iocopy.Copy to conform to StreamCopier type.
-	return func(w io.WriteSeeker, r io.Reader) (int64, error) {
-		return iocopy.Copy(w, r)
+	return func(w io.WriteSeeker, r io.Reader) (int64, int64, error) {
+		n, err := iocopy.Copy(w, r)
+
+		return n, 0, err
 	}, nil
 }
 
@@ -90,6 +95,10 @@ type FilesystemOutput struct {
 	// error instead.
 	OverwriteSymlinks bool `json:"overwriteSymlinks"`
 
+	// SkipExistingIgnoreMetadata when set to true causes FileExists to report any existing regular file
+	// as already restored, without comparing its size and modification time.
+	SkipExistingIgnoreMetadata bool `json:"skipExistingIgnoreMetadata"`
+
 	// IgnorePermissionErrors causes restore to ignore errors due to invalid permissions.
 	IgnorePermissionErrors bool `json:"ignorePermissionErrors"`
 
@@ -105,12 +114,37 @@ type FilesystemOutput struct {
 	// SkipTimes when set to true causes restore to skip restoring modification times.
 	SkipTimes bool `json:"skipTimes"`
 
+	// RestoreExtendedAttributes when set to true causes restore to set extended attributes captured in the snapshot.
+	RestoreExtendedAttributes bool `json:"restoreExtendedAttributes"`
+
+	// RestoreACLs when set to true causes restore to set POSIX ACLs captured in the snapshot.
+	RestoreACLs bool `json:"restoreACLs"`
+
 	// WriteSparseFiles when set to true, write contents as sparse files, minimizing allocated disk space.
 	WriteSparseFiles bool `json:"writeSparseFiles"`
 
+	// VerifySparseFiles when set to true together with WriteSparseFiles, causes restore to verify that
+	// disk space allocated for each restored file does not exceed the size of its non-zero data.
+	VerifySparseFiles bool `json:"verifySparseFiles"`
+
+	// WriteBufferSize when positive, causes file contents to be fetched ahead of writing using buffers of this size,
+	// overlapping reads from the repository with writes to disk.
+	WriteBufferSize int `json:"writeBufferSize"`
+
 	// copier is the StreamCopier to use for copying the actual bit stream to output.
 	// It is assigned at runtime based on the target filesystem and restore options.
 	copier streamCopier `json:"-"`
+
+	// sparseBytesSaved is the number of bytes that were not allocated on disk thanks to sparse writes.
+	sparseBytesSaved int64
+
+	// xattrWarningLogged ensures that failures to set extended attributes are only reported once.
+	xattrWarningLogged int32
+}
+
+// SparseBytesSaved returns the number of bytes of disk space that were saved by writing sparse files.
+func (o *FilesystemOutput) SparseBytesSaved() int64 {
+	return atomic.LoadInt64(&o.sparseBytesSaved)
 }
 
 // Init initializes the internal members of the filesystem writer output.
@@ -145,7 +179,7 @@ func (o *FilesystemOutput) BeginDirectory(ctx context.Context, relativePath stri
 // FinishDirectory implements restore.Output interface.
 func (o *FilesystemOutput) FinishDirectory(ctx context.Context, relativePath string, e fs.Directory) error {
 	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
-	if err := o.setAttributes(path, e, os.FileMode(0)); err != nil {
+	if err := o.setAttributes(ctx, path, e, os.FileMode(0)); err != nil {
 		return errors.Wrap(err, "error setting attributes")
 	}
 
@@ -188,7 +222,7 @@ func (o *FilesystemOutput) WriteFile(ctx context.Context, relativePath string, f
 		}
 	}
 	
-	if err := o.setAttributes(path, f, os.FileMode(0)); err != nil {
+	if err := o.setAttributes(ctx, path, f, os.FileMode(0)); err != nil {
 		return errors.Wrap(err, "error setting attributes")
 	}
 
@@ -207,6 +241,10 @@ func (o *FilesystemOutput) FileExists(ctx context.Context, relativePath string,
 		return false
 	}
 
+	if o.SkipExistingIgnoreMetadata {
+		return true
+	}
+
 	if st.Size() != e.Size() {
 		// wrong size
 		return false
@@ -253,7 +291,7 @@ func (o *FilesystemOutput) CreateSymlink(ctx context.Context, relativePath strin
 		return errors.Wrap(err, "error creating symlink")
 	}
 
-	if err := o.setAttributes(path, e, os.FileMode(0)); err != nil {
+	if err := o.setAttributes(ctx, path, e, os.FileMode(0)); err != nil {
 		return errors.Wrap(err, "error setting attributes")
 	}
 
@@ -279,7 +317,7 @@ func (o *FilesystemOutput) SymlinkExists(ctx context.Context, relativePath strin
 // setAttributes sets permission, modification time and user/group ids
 // on targetPath. modclear will clear the specified FileMod bits. Pass 0
 // to not clear any.
-func (o *FilesystemOutput) setAttributes(targetPath string, e fs.Entry, modclear os.FileMode) error {
+func (o *FilesystemOutput) setAttributes(ctx context.Context, targetPath string, e fs.Entry, modclear os.FileMode) error {
 	le, err := localfs.NewEntry(targetPath)
 	if err != nil {
 		return errors.Wrap(err, "could not create local FS entry for "+targetPath)
@@ -307,6 +345,9 @@ func (o *FilesystemOutput) setAttributes(targetPath string, e fs.Entry, modclear
 		}
 	}
 
+	// ACLs are applied after permissions since chmod rewrites the ACL mask.
+	o.setExtendedAttributes(ctx, targetPath, e, false)
+
 	// Set file permissions from e
 	if o.shouldUpdatePermissions(le, e, modclear) {
 		if err = o.maybeIgnorePermissionError(osChmod(targetPath, (e.Mode()&fs.ModBits)&^modclear)); err != nil {
@@ -314,6 +355,8 @@ func (o *FilesystemOutput) setAttributes(targetPath string, e fs.Entry, modclear
 		}
 	}
 
+	o.setExtendedAttributes(ctx, targetPath, e, true)
+
 	if o.shouldUpdateTimes(le, e) {
 		if err = o.maybeIgnorePermissionError(osChtimes(targetPath, e.ModTime(), e.ModTime())); err != nil {
 			return errors.Wrap(err, "could not change mod time on "+targetPath)
@@ -323,6 +366,41 @@ func (o *FilesystemOutput) setAttributes(targetPath string, e fs.Entry, modclear
 	return nil
 }
 
+// setExtendedAttributes sets either the ACLs or the remaining extended attributes of e on targetPath.
+// Failures are not fatal since the target filesystem may not support them, only the first one is reported.
+func (o *FilesystemOutput) setExtendedAttributes(ctx context.Context, targetPath string, e fs.Entry, acls bool) {
+	if acls && !o.RestoreACLs || !acls && !o.RestoreExtendedAttributes {
+		return
+	}
+
+	he, ok := e.(fs.HasExtendedAttributes)
+	if !ok {
+		return
+	}
+
+	attrs, err := he.ExtendedAttributes()
+	if err != nil {
+		o.warnExtendedAttributes(ctx, targetPath, err)
+		return
+	}
+
+	for name, value := range attrs {
+		if xattr.IsACL(name) != acls {
+			continue
+		}
+
+		if err := xattr.Set(targetPath, name, value); err != nil {
+			o.warnExtendedAttributes(ctx, targetPath, err)
+		}
+	}
+}
+
+func (o *FilesystemOutput) warnExtendedAttributes(ctx context.Context, targetPath string, err error) {
+	if atomic.CompareAndSwapInt32(&o.xattrWarningLogged, 0, 1) {
+		log(ctx).Warnf("unable to restore extended attributes on %v: %v (further errors will not be reported)", targetPath, err)
+	}
+}
+
 func isSymlink(e fs.Entry) bool {
 	_, ok := e.(fs.Symlink)
 	return ok
@@ -394,26 +472,50 @@ func (o *FilesystemOutput) createDirectory(ctx context.Context, path string) err
 	}
 }
 
-func write(targetPath string, r fs.Reader, size int64, c streamCopier) error {
+func write(targetPath string, r io.Reader, size int64, c streamCopier) (holeBytes int64, err error) {
 	f, err := os.OpenFile(targetPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:gosec,mnd
 	if err != nil {
-		return err //nolint:wrapcheck
+		return 0, err //nolint:wrapcheck
 	}
 
 	if err := f.Truncate(size); err != nil {
-		return err //nolint:wrapcheck
+		return 0, err //nolint:wrapcheck
 	}
 
 	// ensure we always close f. Note that this does not conflict with the
 	// close below, as close is idempotent.
 	defer f.Close() //nolint:errcheck
 
-	if _, err := c(f, r); err != nil {
-		return errors.Wrapf(err, "cannot write data to file %q", f.Name())
+	_, holeBytes, err = c(f, r)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot write data to file %q", f.Name())
 	}
 
 	if err := f.Close(); err != nil {
-		return err //nolint:wrapcheck
+		return 0, err //nolint:wrapcheck
+	}
+
+	return holeBytes, nil
+}
+
+// verifySparseFile ensures that disk space allocated for a sparsely-written file does not exceed
+// the size of its non-zero data, allowing for rounding to block boundaries.
+func verifySparseFile(targetPath string, size, holeBytes int64) error {
+	blk, err := stat.GetBlockSize(filepath.Dir(targetPath))
+	if err != nil {
+		return errors.Wrapf(err, "error getting disk block size for %v", targetPath)
+	}
+
+	alloc, err := stat.GetFileAllocSize(targetPath)
+	if err != nil {
+		return errors.Wrapf(err, "error getting allocated size of %v", targetPath)
+	}
+
+	dataBytes := uint64(size - holeBytes) //nolint:gosec
+
+	// data written to a partial block allocates the whole block and may straddle two blocks.
+	if maxAlloc := (dataBytes+blk-1)/blk*blk + blk; alloc > maxAlloc {
+		return errors.Errorf("%v was not restored sparsely: %v bytes allocated, expected at most %v, the target filesystem may not support sparse files", targetPath, alloc, maxAlloc)
 	}
 
 	return nil
@@ -443,15 +545,35 @@ func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath strin
 		cb:     progressCb,
 	}
 
+	var src io.Reader = rr
+
+	if o.WriteBufferSize > 0 {
+		rar := newReadAheadReader(rr, o.WriteBufferSize)
+		defer rar.Close()
+
+		src = rar
+	}
+
 	log(ctx).Debugf("copying file contents to: %v", targetPath)
 	targetPath = atomicfile.MaybePrefixLongFilenameOnWindows(targetPath)
 
 	if o.WriteFilesAtomically {
 		//nolint:wrapcheck
-		return atomicfile.Write(targetPath, rr)
+		return atomicfile.Write(targetPath, src)
 	}
 
-	return write(targetPath, rr, f.Size(), o.copier)
+	holeBytes, err := write(targetPath, src, f.Size(), o.copier)
+	if err != nil {
+		return err
+	}
+
+	atomic.AddInt64(&o.sparseBytesSaved, holeBytes)
+
+	if o.VerifySparseFiles && o.WriteSparseFiles && holeBytes > 0 && !isWindows() {
+		return verifySparseFile(targetPath, f.Size(), holeBytes)
+	}
+
+	return nil
 }
 
 const bufferSize = 128 * 1024
@@ -0,0 +1,71 @@
+package restore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/xattr"
+)
+
+func TestFilesystemOutputFileExists(t *testing.T) {
+	ctx := testlogging.Context(t)
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing"), []byte("old"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o700))
+
+	f := mockfs.NewDirectory().AddFile("existing", []byte("new contents"), 0o600)
+
+	o := &FilesystemOutput{TargetPath: dir}
+
+	// size and modification time differ.
+	require.False(t, o.FileExists(ctx, "existing", f))
+	require.False(t, o.FileExists(ctx, "missing", f))
+
+	o.SkipExistingIgnoreMetadata = true
+
+	require.True(t, o.FileExists(ctx, "existing", f))
+	require.False(t, o.FileExists(ctx, "missing", f))
+	require.False(t, o.FileExists(ctx, "subdir", f))
+}
+
+type directoryWithXattrs struct {
+	fs.Directory
+
+	attrs map[string][]byte
+}
+
+func (d directoryWithXattrs) ExtendedAttributes() (map[string][]byte, error) {
+	return d.attrs, nil
+}
+
+func TestFilesystemOutputSetExtendedAttributes(t *testing.T) {
+	ctx := testlogging.Context(t)
+	dir := t.TempDir()
+
+	if err := xattr.Set(dir, "user.probe", []byte("x")); err != nil {
+		t.Skipf("extended attributes not supported: %v", err)
+	}
+
+	e := directoryWithXattrs{mockfs.NewDirectory(), map[string][]byte{"user.kopia": []byte("value")}}
+
+	o := &FilesystemOutput{TargetPath: dir}
+	require.NoError(t, o.setAttributes(ctx, dir, e, 0))
+
+	attrs, err := xattr.List(dir)
+	require.NoError(t, err)
+	require.NotContains(t, attrs, "user.kopia")
+
+	o.RestoreExtendedAttributes = true
+	require.NoError(t, o.setAttributes(ctx, dir, e, 0))
+
+	attrs, err = xattr.List(dir)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), attrs["user.kopia"])
+}
@@ -0,0 +1,112 @@
+package restore
+
+import (
+	"io"
+	"sync"
+)
+
+// readAheadChunks is the maximum number of chunks fetched ahead of the writer.
+const readAheadChunks = 2
+
+type readAheadChunk struct {
+	data []byte
+	err  error
+}
+
+// readAheadReader wraps a reader and fetches its contents in a background goroutine,
+// so that reading from the repository overlaps writing to the output.
+// At most readAheadChunks chunks of chunkSize bytes are buffered at any time.
+type readAheadReader struct {
+	chunks  chan readAheadChunk
+	free    chan []byte
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	current []byte
+	pending []byte
+	err     error
+}
+
+func (r *readAheadReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		if r.current != nil {
+			r.free <- r.current[:cap(r.current)]
+			r.current = nil
+		}
+
+		ch, ok := <-r.chunks
+		if !ok {
+			r.err = io.EOF
+			continue
+		}
+
+		r.current = ch.data
+		r.pending = ch.data
+		r.err = ch.err
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
+
+// Close stops the background reader and waits for it to finish.
+func (r *readAheadReader) Close() {
+	close(r.closing)
+	r.wg.Wait()
+}
+
+func (r *readAheadReader) fetch(src io.Reader) {
+	defer r.wg.Done()
+	defer close(r.chunks)
+
+	for {
+		var buf []byte
+
+		select {
+		case buf = <-r.free:
+		case <-r.closing:
+			return
+		}
+
+		n, err := io.ReadFull(src, buf)
+		if err == io.ErrUnexpectedEOF { //nolint:errorlint
+			err = io.EOF
+		}
+
+		select {
+		case r.chunks <- readAheadChunk{buf[:n], err}:
+		case <-r.closing:
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// newReadAheadReader returns a reader that fetches the contents of src in the background
+// using chunks of the provided size.
+func newReadAheadReader(src io.Reader, chunkSize int) *readAheadReader {
+	r := &readAheadReader{
+		chunks:  make(chan readAheadChunk, readAheadChunks),
+		free:    make(chan []byte, readAheadChunks+1),
+		closing: make(chan struct{}),
+	}
+
+	for range readAheadChunks + 1 {
+		r.free <- make([]byte, chunkSize)
+	}
+
+	r.wg.Add(1)
+
+	go r.fetch(src)
+
+	return r
+}
@@ -0,0 +1,50 @@
+package restore
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAheadReader(t *testing.T) {
+	data := make([]byte, 100000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	for _, chunkSize := range []int{1, 7, 4096, 100000, 200000} {
+		r := newReadAheadReader(iotest.HalfReader(bytes.NewReader(data)), chunkSize)
+
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+
+		r.Close()
+	}
+}
+
+func TestReadAheadReaderError(t *testing.T) {
+	errSome := errors.New("some error")
+
+	r := newReadAheadReader(io.MultiReader(bytes.NewReader([]byte("hello")), iotest.ErrReader(errSome)), 3)
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	require.ErrorIs(t, err, errSome)
+	require.Equal(t, []byte("hello"), got)
+}
+
+func TestReadAheadReaderEarlyClose(t *testing.T) {
+	r := newReadAheadReader(bytes.NewReader(make([]byte, 1000000)), 10)
+
+	buf := make([]byte, 5)
+	_, err := r.Read(buf)
+	require.NoError(t, err)
+
+	// must not block even though the background reader has not finished.
+	r.Close()
+}
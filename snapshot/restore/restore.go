@@ -4,6 +4,7 @@
 	"context"
 	"path"
 	"runtime"
+	"sync"
 	"sync/atomic"
 
 	"github.com/pkg/errors"
@@ -12,6 +13,7 @@
 	"github.com/kopia/kopia/internal/parallelwork"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
 )
 
@@ -93,6 +95,7 @@ type Options struct {
 	IgnoreErrors           bool  `json:"ignoreErrors"`
 	RestoreDirEntryAtDepth int32 `json:"restoreDirEntryAtDepth"`
 	MinSizeForPlaceholder  int32 `json:"minSizeForPlaceholder"`
+	PrefetchFiles          bool  `json:"prefetchFiles"`
 
 	ProgressCallback ProgressCallback `json:"-"`
 	Cancel           chan struct{}    `json:"-"` // channel that can be externally closed to signal cancellation
@@ -112,6 +115,11 @@ func Entry(ctx context.Context, rep repo.Repository, output Output, rootEntry fs
 		progressCallback: options.ProgressCallback,
 	}
 
+	if options.PrefetchFiles {
+		c.rep = rep
+		c.prefetchSem = make(chan struct{}, maxConcurrentPrefetches)
+	}
+
 	c.q.ProgressCallback = func(ctx context.Context, enqueued, active, completed int64) {
 		c.reportProgress(ctx)
 	}
@@ -132,7 +140,11 @@ func Entry(ctx context.Context, rep repo.Repository, output Output, rootEntry fs
 		numWorkers = 1
 	}
 
-	if err := c.q.Process(ctx, numWorkers); err != nil {
+	err := c.q.Process(ctx, numWorkers)
+
+	c.prefetchWG.Wait()
+
+	if err != nil {
 		return Stats{}, errors.Wrap(err, "restore error")
 	}
 
@@ -153,6 +165,56 @@ type copier struct {
 	cancel        chan struct{}
 
 	progressCallback ProgressCallback
+
+	// when set, contents of files are prefetched into the cache while preceding files are being written.
+	rep         repo.Repository
+	prefetchSem chan struct{}
+	prefetchWG  sync.WaitGroup
+}
+
+// maxConcurrentPrefetches is the maximum number of directories whose files are prefetched concurrently.
+const maxConcurrentPrefetches = 2
+
+// maybePrefetchFiles starts fetching contents of the provided files in the background, unless
+// too many prefetches are already in progress.
+func (c *copier) maybePrefetchFiles(ctx context.Context, entries []fs.Entry) {
+	if c.rep == nil {
+		return
+	}
+
+	var oids []object.ID
+
+	for _, e := range entries {
+		if e.IsDir() || isSymlink(e) {
+			continue
+		}
+
+		if h, ok := e.(object.HasObjectID); ok {
+			oids = append(oids, h.ObjectID())
+		}
+	}
+
+	if len(oids) == 0 {
+		return
+	}
+
+	select {
+	case c.prefetchSem <- struct{}{}:
+	default:
+		// too many prefetches in progress, files will be fetched when written.
+		return
+	}
+
+	c.prefetchWG.Add(1)
+
+	go func() {
+		defer c.prefetchWG.Done()
+		defer func() { <-c.prefetchSem }()
+
+		if _, err := c.rep.PrefetchObjects(ctx, oids, ""); err != nil {
+			log(ctx).Debugf("error prefetching file contents: %v", err)
+		}
+	}()
 }
 
 func (c *copier) reportProgress(ctx context.Context) {
@@ -295,6 +357,9 @@ func (c *copier) copyDirectoryContent(ctx context.Context, d fs.Directory, targe
 
 	onItemCompletion := parallelwork.OnNthCompletion(len(entries), onCompletion)
 
+	// directories are still created before their files, prefetching only warms up the cache.
+	c.maybePrefetchFiles(ctx, entries)
+
 	for _, e := range entries {
 		if e.IsDir() {
 			c.stats.EnqueuedDirCount.Add(1)
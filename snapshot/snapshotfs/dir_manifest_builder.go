@@ -111,7 +111,7 @@ func (b *DirManifestBuilder) Build(dirModTime fs.UTCTimestamp, incompleteReason
 	})
 
 	return &snapshot.DirManifest{
-		StreamType: directoryStreamType,
+		StreamType: DirectoryStreamType,
 		Summary:    &s,
 		Entries:    entries,
 	}
@@ -10,7 +10,8 @@
 	"github.com/kopia/kopia/snapshot"
 )
 
-const directoryStreamType = "kopia:directory"
+// DirectoryStreamType is the stream type of serialized directory manifests.
+const DirectoryStreamType = "kopia:directory"
 
 // readDirEntries reads all directory entries from the specified reader.
 func readDirEntries(r io.Reader) ([]*snapshot.DirEntry, *fs.DirectorySummary, error) {
@@ -20,7 +21,7 @@ func readDirEntries(r io.Reader) ([]*snapshot.DirEntry, *fs.DirectorySummary, er
 		return nil, nil, errors.Wrap(err, "unable to parse directory object")
 	}
 
-	if dir.StreamType != directoryStreamType {
+	if dir.StreamType != DirectoryStreamType {
 		return nil, nil, errors.New("invalid directory stream type")
 	}
 
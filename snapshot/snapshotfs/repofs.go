@@ -76,6 +76,10 @@ func (e *repositoryEntry) Device() fs.DeviceInfo {
 	return fs.DeviceInfo{}
 }
 
+func (e *repositoryEntry) ExtendedAttributes() (map[string][]byte, error) {
+	return e.metadata.ExtendedAttributes, nil
+}
+
 func (e *repositoryEntry) DirEntry() *snapshot.DirEntry {
 	return e.metadata
 }
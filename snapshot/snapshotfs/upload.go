@@ -93,6 +93,10 @@ type Uploader struct {
 	// When set to true, do not ignore any files, regardless of policy settings.
 	DisableIgnoreRules bool
 
+	// When set to true, extended attributes (including POSIX ACLs on Linux) of files, directories
+	// and symlinks are stored in the snapshot.
+	CaptureExtendedAttributes bool
+
 	// Labels to apply to every checkpoint made for this snapshot.
 	CheckpointLabels map[string]string
 
@@ -863,6 +867,8 @@ func (u *Uploader) processSingle(
 				return errors.Wrap(err, "unable to create dir entry")
 			}
 
+			u.maybeCaptureExtendedAttributes(ctx, entry, cachedDirEntry, entryRelativePath)
+
 			return u.processEntryUploadResult(ctx, cachedDirEntry, nil, entryRelativePath, parentDirBuilder,
 				false,
 				u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.CacheHit.OrDefault(policy.LogDetailNone)),
@@ -899,6 +905,7 @@ func (u *Uploader) processSingle(
 				return errors.Wrapf(err, "unable to process directory %q", entry.Name())
 			}
 		} else {
+			u.maybeCaptureExtendedAttributes(ctx, entry, de, entryRelativePath)
 			parentDirBuilder.AddEntry(de)
 		}
 
@@ -907,6 +914,9 @@ func (u *Uploader) processSingle(
 	case fs.Symlink:
 		childTree := policyTree.Child(entry.Name())
 		de, err := u.uploadSymlinkInternal(ctx, entryRelativePath, entry, childTree.EffectivePolicy().MetadataCompressionPolicy.MetadataCompressor())
+		if err == nil {
+			u.maybeCaptureExtendedAttributes(ctx, entry, de, entryRelativePath)
+		}
 
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
 			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
@@ -917,6 +927,9 @@ func (u *Uploader) processSingle(
 		atomic.AddInt32(&u.stats.NonCachedFiles, 1)
 
 		de, err := u.uploadFileInternal(ctx, parentCheckpointRegistry, entryRelativePath, entry, policyTree.Child(entry.Name()).EffectivePolicy())
+		if err == nil {
+			u.maybeCaptureExtendedAttributes(ctx, entry, de, entryRelativePath)
+		}
 
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
 			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
@@ -946,6 +959,9 @@ func (u *Uploader) processSingle(
 		atomic.AddInt32(&u.stats.NonCachedFiles, 1)
 
 		de, err := u.uploadStreamingFileInternal(ctx, entryRelativePath, entry, policyTree.Child(entry.Name()).EffectivePolicy())
+		if err == nil {
+			u.maybeCaptureExtendedAttributes(ctx, entry, de, entryRelativePath)
+		}
 
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
 			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
@@ -957,6 +973,27 @@ func (u *Uploader) processSingle(
 	}
 }
 
+// maybeCaptureExtendedAttributes stores extended attributes of the provided entry in its DirEntry when enabled.
+// Failures to read them are logged, but do not fail the snapshot.
+func (u *Uploader) maybeCaptureExtendedAttributes(ctx context.Context, entry fs.Entry, de *snapshot.DirEntry, entryRelativePath string) {
+	if !u.CaptureExtendedAttributes || de == nil {
+		return
+	}
+
+	xe, ok := entry.(fs.HasExtendedAttributes)
+	if !ok {
+		return
+	}
+
+	attrs, err := xe.ExtendedAttributes()
+	if err != nil {
+		uploadLog(ctx).Warnw("unable to read extended attributes", "path", entryRelativePath, "error", err)
+		return
+	}
+
+	de.ExtendedAttributes = attrs
+}
+
 //nolint:unparam
 func (u *Uploader) processEntryUploadResult(ctx context.Context, de *snapshot.DirEntry, err error, entryRelativePath string, parentDirBuilder *DirManifestBuilder, isIgnored bool, logDetail policy.LogDetail, logMessage string, t0 timetrack.Timer) error {
 	if err != nil {
@@ -1293,6 +1330,8 @@ func (u *Uploader) Upload(
 		return nil, rootCauseError(err)
 	}
 
+	u.maybeCaptureExtendedAttributes(ctx, source, s.RootEntry, ".")
+
 	s.IncompleteReason = u.incompleteReason()
 	s.EndTime = fs.UTCTimestampFromTime(u.repo.Time())
 	s.Stats = *u.stats
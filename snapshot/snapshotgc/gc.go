@@ -77,7 +77,7 @@ func Run(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, sa
 	var st Stats
 
 	err := maintenance.ReportRun(ctx, rep, maintenance.TaskSnapshotGarbageCollection, nil, func() error {
-		if err := runInternal(ctx, rep, gcDelete, safety, maintenanceStartTime, &st); err != nil {
+		if err := runInternal(ctx, rep, gcDelete, safety, maintenanceStartTime, &st, false); err != nil {
 			return err
 		}
 
@@ -98,7 +98,22 @@ func Run(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, sa
 	return st, errors.Wrap(err, "error running snapshot gc")
 }
 
-func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, safety maintenance.SafetyParameters, maintenanceStartTime time.Time, st *Stats) error {
+// DryRun finds contents that garbage collection would mark as deleted, without modifying the repository.
+func DryRun(ctx context.Context, rep repo.DirectRepositoryWriter, safety maintenance.SafetyParameters, maintenanceStartTime time.Time) (Stats, error) {
+	var st Stats
+
+	if err := runInternal(ctx, rep, false, safety, maintenanceStartTime, &st, true); err != nil {
+		return st, errors.Wrap(err, "error running snapshot gc")
+	}
+
+	log(ctx).Infof("GC would mark %v unused contents as deleted (%v)", st.UnusedCount, units.BytesString(st.UnusedBytes))
+
+	return st, nil
+}
+
+// runInternal finds contents not referenced by snapshots and deletes them if gcDelete is set.
+// In dry-run mode, referenced contents marked as deleted are not undeleted either.
+func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, safety maintenance.SafetyParameters, maintenanceStartTime time.Time, st *Stats, dryRun bool) error {
 	var unused, inUse, system, tooRecent, undeleted stats.CountSum
 
 	used, serr := bigmap.NewSet(ctx)
@@ -124,7 +139,7 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 		var cidbuf [128]byte
 
 		if used.Contains(ci.ContentID.Append(cidbuf[:0])) {
-			if ci.Deleted {
+			if ci.Deleted && !dryRun {
 				if err := rep.ContentManager().UndeleteContent(ctx, ci.ContentID); err != nil {
 					return errors.Wrapf(err, "Could not undelete referenced content: %v", ci)
 				}
@@ -176,5 +191,9 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 		return errors.Wrap(err, "error iterating contents")
 	}
 
+	if dryRun {
+		return nil
+	}
+
 	return errors.Wrap(rep.Flush(ctx), "flush error")
 }
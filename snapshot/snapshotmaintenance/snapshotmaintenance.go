@@ -11,19 +11,73 @@
 	"github.com/kopia/kopia/snapshot/snapshotgc"
 )
 
+// Hooks are invoked around maintenance once it has been determined that maintenance is due
+// and the maintenance lock has been acquired.
+type Hooks struct {
+	// Before is invoked before maintenance starts, if it returns an error the maintenance is not run.
+	Before func(ctx context.Context, mode maintenance.Mode) error
+
+	// After is invoked after maintenance finishes with the result of the maintenance.
+	After func(ctx context.Context, mode maintenance.Mode, err error)
+}
+
 // Run runs the complete snapshot and repository maintenance.
 func Run(ctx context.Context, dr repo.DirectRepositoryWriter, mode maintenance.Mode, force bool, safety maintenance.SafetyParameters) error {
+	return RunWithHooks(ctx, dr, mode, force, safety, Hooks{})
+}
+
+// RunWithHooks runs the complete snapshot and repository maintenance, invoking the provided hooks around it.
+func RunWithHooks(ctx context.Context, dr repo.DirectRepositoryWriter, mode maintenance.Mode, force bool, safety maintenance.SafetyParameters, hooks Hooks) error {
 	//nolint:wrapcheck
 	return maintenance.RunExclusive(ctx, dr, mode, force,
 		func(ctx context.Context, runParams maintenance.RunParameters) error {
-			// run snapshot GC before full maintenance
-			if runParams.Mode == maintenance.ModeFull {
-				if _, err := snapshotgc.Run(ctx, dr, true, safety, runParams.MaintenanceStartTime); err != nil {
-					return errors.Wrap(err, "snapshot GC failure")
+			if hooks.Before != nil {
+				if err := hooks.Before(ctx, runParams.Mode); err != nil {
+					return errors.Wrap(err, "pre-maintenance hook failed")
 				}
 			}
 
-			//nolint:wrapcheck
-			return maintenance.Run(ctx, runParams, safety)
+			err := runSnapshotAndRepositoryMaintenance(ctx, dr, runParams, safety)
+
+			if hooks.After != nil {
+				hooks.After(ctx, runParams.Mode, err)
+			}
+
+			return err
+		})
+}
+
+// RunDryRun computes and reports changes that the complete snapshot and repository maintenance
+// would make, without modifying the repository.
+func RunDryRun(ctx context.Context, dr repo.DirectRepositoryWriter, mode maintenance.Mode, safety maintenance.SafetyParameters) (*maintenance.DryRunReport, error) {
+	//nolint:wrapcheck
+	return maintenance.RunDryRun(ctx, dr, mode,
+		func(ctx context.Context, runParams maintenance.RunParameters) error {
+			return runSnapshotAndRepositoryMaintenance(ctx, dr, runParams, safety)
 		})
 }
+
+func runSnapshotAndRepositoryMaintenance(ctx context.Context, dr repo.DirectRepositoryWriter, runParams maintenance.RunParameters, safety maintenance.SafetyParameters) error {
+	if r := runParams.DryRun(); r != nil && runParams.Mode == maintenance.ModeFull {
+		st, err := snapshotgc.DryRun(ctx, dr, safety, runParams.MaintenanceStartTime)
+		if err != nil {
+			return errors.Wrap(err, "snapshot GC failure")
+		}
+
+		r.UnreferencedContents = int(st.UnusedCount)
+		r.UnreferencedContentBytes = st.UnusedBytes
+
+		//nolint:wrapcheck
+		return maintenance.Run(ctx, runParams, safety)
+	}
+
+	// run snapshot GC before full maintenance
+	if runParams.Mode == maintenance.ModeFull {
+		if _, err := snapshotgc.Run(ctx, dr, true, safety, runParams.MaintenanceStartTime); err != nil {
+			return errors.Wrap(err, "snapshot GC failure")
+		}
+	}
+
+	//nolint:wrapcheck
+	return maintenance.Run(ctx, runParams, safety)
+}
@@ -39,8 +39,47 @@ func (s *formatSpecificTestSuite) TestContentListAndStats(t *testing.T) {
 	require.True(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "list", "-l"), contentID.String()))
 	require.True(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "list", "-c"), contentID.String()))
 	require.True(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "list", "--summary"), "Total: "))
+	require.True(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "list", "--newer-than=1h"), contentID.String()))
+	require.False(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "list", "--older-than=1h"), contentID.String()))
 
-	e.RunAndExpectSuccess(t, "content", "stats")
+	stats := e.RunAndExpectSuccess(t, "content", "stats")
+	require.True(t, containsLineStartingWith(stats, "Median Packed: "))
+	require.True(t, containsLineStartingWith(stats, "95th Percentile Packed: "))
+	require.True(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "stats", "--include-deleted"), "Deleted: "))
+	e.RunAndExpectFailure(t, "content", "stats", "--json")
+	require.True(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "stats", "--by-compression"), "pgzip"))
+
+	var byCompression []struct {
+		Compression   string  `json:"compression"`
+		Count         int64   `json:"count"`
+		OriginalBytes int64   `json:"originalBytes"`
+		StoredBytes   int64   `json:"storedBytes"`
+		SavedBytes    int64   `json:"savedBytes"`
+		Ratio         float64 `json:"ratio"`
+		MedianStored  int64   `json:"medianStoredBytes"`
+		P95Stored     int64   `json:"p95StoredBytes"`
+	}
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "content", "stats", "--by-compression", "--json"), &byCompression)
+	require.NotEmpty(t, byCompression)
+	require.Equal(t, "pgzip", byCompression[0].Compression)
+	require.Positive(t, byCompression[0].SavedBytes)
+	require.Positive(t, byCompression[0].MedianStored)
+	require.GreaterOrEqual(t, byCompression[0].P95Stored, byCompression[0].MedianStored)
+
+	require.True(t, containsLineStartingWith(e.RunAndExpectSuccess(t, "content", "owners", contentID.String()), contentID.String()+" "+string(man.ID)))
+
+	var owners []struct {
+		ContentID  string              `json:"contentID"`
+		ObjectID   string              `json:"objectID"`
+		Path       string              `json:"path"`
+		Source     snapshot.SourceInfo `json:"source"`
+		SnapshotID string              `json:"snapshotID"`
+	}
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "content", "owners", contentID.String(), "--json", "--limit=1"), &owners)
+	require.Len(t, owners, 1)
+	require.Equal(t, man.RootObjectID().String(), owners[0].ObjectID)
 
 	// sleep a bit to ensure at least one second passes, otherwise delete may end up happen on the same
 	// second as create, in which case creation will prevail.
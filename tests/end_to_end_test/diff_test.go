@@ -3,10 +3,12 @@
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/kopia/kopia/internal/diff"
 	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/tests/clitestutil"
 	"github.com/kopia/kopia/tests/testenv"
@@ -72,3 +74,74 @@ func TestDiff(t *testing.T) {
 		}
 	}
 }
+
+func TestDiffFormats(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dataDir := testutil.TempDirectory(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "changed"), []byte("hello"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "removed"), []byte("bye"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "parent", "child"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "parent", "child", "nested"), []byte("a"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "chmod-dir"), 0o700))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dataDir)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "changed"), []byte("hello world"), 0o600))
+	require.NoError(t, os.Remove(filepath.Join(dataDir, "removed")))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "added-dir"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "parent", "child", "nested"), []byte("abc"), 0o600))
+	require.NoError(t, os.Chmod(filepath.Join(dataDir, "chmod-dir"), 0o750))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dataDir)
+
+	si := clitestutil.ListSnapshotsAndExpectSuccess(t, e, dataDir)
+	require.Len(t, si, 1)
+	require.Len(t, si[0].Snapshots, 2)
+
+	oid1, oid2 := si[0].Snapshots[0].ObjectID, si[0].Snapshots[1].ObjectID
+
+	var changes []diff.Change
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "diff", "--format=json", oid1, oid2), &changes)
+
+	byPath := map[string]diff.Change{}
+	for _, ch := range changes {
+		byPath[ch.Path] = ch
+	}
+
+	require.Equal(t, diff.ChangeAdded, byPath["./added-dir"].Kind)
+	require.Equal(t, "directory", byPath["./added-dir"].Type)
+	require.Equal(t, diff.ChangeRemoved, byPath["./removed"].Kind)
+	require.EqualValues(t, 3, *byPath["./removed"].OldSize)
+	require.Equal(t, diff.ChangeModified, byPath["./changed"].Kind)
+	require.EqualValues(t, 5, *byPath["./changed"].OldSize)
+	require.EqualValues(t, 11, *byPath["./changed"].NewSize)
+	require.Equal(t, diff.ChangeModified, byPath["./parent/child/nested"].Kind)
+
+	// ancestors of changed entries are not reported as modified, since only their size and modification time changed.
+	require.NotContains(t, byPath, ".")
+	require.NotContains(t, byPath, "./parent")
+	require.NotContains(t, byPath, "./parent/child")
+
+	if runtime.GOOS != "windows" {
+		require.Equal(t, diff.ChangeModified, byPath["./chmod-dir"].Kind)
+		require.Equal(t, "directory", byPath["./chmod-dir"].Type)
+	}
+
+	lines := e.RunAndExpectSuccess(t, "diff", "--format=unified", oid1, oid2)
+	require.Equal(t, "--- "+oid1, lines[0])
+	require.Equal(t, "+++ "+oid2, lines[1])
+	require.Contains(t, lines, "+./added-dir/")
+	require.Contains(t, lines, "-./removed\t3 bytes")
+	require.Contains(t, lines, "-./changed\t5 bytes")
+	require.Contains(t, lines, "+./changed\t11 bytes")
+
+	e.RunAndExpectFailure(t, "diff", "--format=json", "-f", oid1, oid2)
+}
@@ -73,4 +73,7 @@ func (s *formatSpecificTestSuite) TestFullMaintenance(t *testing.T) {
 	if got, want := e.RunAndExpectSuccess(t, "blob", "list", "--data-only"), blobCountAfterFullWipeout; len(got) > want {
 		t.Fatalf("maintenance left unwanted blobs: %v, want %v", got, want)
 	}
+
+	// full blob GC can be run on demand, regardless of schedule.
+	e.RunAndExpectSuccess(t, "maintenance", "run", "--full-blob-gc", "--safety=none", "--disable-internal-log")
 }
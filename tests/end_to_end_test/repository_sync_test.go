@@ -1,9 +1,19 @@
 package endtoend_test
 
 import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
 	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/filesystem"
 	"github.com/kopia/kopia/tests/clitestutil"
 	"github.com/kopia/kopia/tests/testenv"
 )
@@ -55,3 +65,85 @@ func TestRepositorySync(t *testing.T) {
 	// syncing to the directory should fail because it contains incompatible format blob.
 	e2.RunAndExpectFailure(t, "repo", "sync-to", "filesystem", "--path", dir2)
 }
+
+func TestRepositoryMigrate(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir1)
+
+	sources := clitestutil.ListSnapshotsAndExpectSuccess(t, e)
+
+	dir2 := testutil.TempDirectory(t)
+	connFile := filepath.Join(testutil.TempDirectory(t), "to.json")
+
+	require.NoError(t, os.WriteFile(connFile, []byte(`{"type":"filesystem","config":{"path":`+strconv.Quote(dir2)+`}}`), 0o600))
+
+	// migrating to the same location is rejected.
+	sameFile := filepath.Join(testutil.TempDirectory(t), "same.json")
+	require.NoError(t, os.WriteFile(sameFile, []byte(`{"type":"filesystem","config":{"path":`+strconv.Quote(e.RepoDir)+`}}`), 0o600))
+	e.RunAndExpectFailure(t, "repo", "migrate", "--to", sameFile)
+
+	// dry run does not copy any data blobs.
+	e.RunAndExpectSuccess(t, "repo", "migrate", "--to", connFile, "--dry-run")
+	require.Empty(t, listDataFiles(t, dir2))
+
+	e.RunAndExpectSuccess(t, "repo", "migrate", "--to", connFile)
+
+	// repository is now connected to the new location and old location is left intact.
+	sources2 := clitestutil.ListSnapshotsAndExpectSuccess(t, e)
+	require.Len(t, sources2, len(sources))
+
+	e.RunAndExpectSuccess(t, "snapshot", "verify")
+
+	require.NotEmpty(t, listDataFiles(t, e.RepoDir))
+
+	// deletion of source requires confirmation.
+	e.RunAndExpectFailure(t, "repo", "delete-migrated-source", "--from", sameFile)
+
+	// the storage the repository is connected to can't be deleted.
+	e.RunAndExpectFailure(t, "repo", "delete-migrated-source", "--from", connFile, "--i-am-sure-source-can-be-deleted")
+
+	// source data that was not copied is not deleted.
+	ctx := testlogging.Context(t)
+	src, err := filesystem.New(ctx, &filesystem.Options{Path: e.RepoDir}, false)
+	require.NoError(t, err)
+
+	defer src.Close(ctx)
+
+	require.NoError(t, src.PutBlob(ctx, "xextra", gather.FromSlice([]byte("extra")), blob.PutOptions{}))
+	e.RunAndExpectFailure(t, "repo", "delete-migrated-source", "--from", sameFile, "--i-am-sure-source-can-be-deleted")
+	require.NotEmpty(t, listDataFiles(t, e.RepoDir))
+	require.NoError(t, src.DeleteBlob(ctx, "xextra"))
+
+	e.RunAndExpectSuccess(t, "repo", "delete-migrated-source", "--from", sameFile, "--i-am-sure-source-can-be-deleted")
+	require.Empty(t, listDataFiles(t, e.RepoDir))
+
+	e.RunAndExpectSuccess(t, "snapshot", "verify")
+}
+
+// listDataFiles returns the names of files in the provided directory tree, excluding format and sharding files.
+func listDataFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var result []string
+
+	require.NoError(t, filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		if n := d.Name(); n != ".shards" && !strings.HasPrefix(n, "kopia.repository") {
+			result = append(result, path)
+		}
+
+		return nil
+	}))
+
+	return result
+}
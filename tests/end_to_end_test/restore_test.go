@@ -410,6 +410,80 @@ func TestRestoreSymlinkWithoutTarget(t *testing.T) {
 	e.RunAndExpectSuccess(t, "snapshot", "restore", "--no-ignore-permission-errors", snapID, restoredDir)
 }
 
+func TestRestoreFlatten(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	source := testutil.TempDirectory(t)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "d1", "d2"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "a.pdf"), []byte("a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "d1", "a.pdf"), []byte("d1/a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "d1", "d2", "a.pdf"), []byte("d1/d2/a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "d1", "d2", "b.pdf"), []byte("b"), 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", source)
+
+	si := clitestutil.ListSnapshotsAndExpectSuccess(t, e, source)
+	snapID := si[0].Snapshots[0].SnapshotID
+
+	readRestored := func(tarFile string) map[string]string {
+		t.Helper()
+
+		f, err := os.Open(tarFile)
+		require.NoError(t, err)
+
+		defer f.Close()
+
+		result := map[string]string{}
+		tr := tar.NewReader(f)
+
+		for {
+			h, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, byte(tar.TypeReg), h.Typeflag, h.Name)
+
+			b, err := io.ReadAll(tr)
+			require.NoError(t, err)
+
+			result[h.Name] = string(b)
+		}
+
+		return result
+	}
+
+	restoredFile := filepath.Join(testutil.TempDirectory(t), "restored.tar")
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "restore", snapID, restoredFile, "--flatten")
+	require.Equal(t, map[string]string{
+		"a.pdf":   "a",
+		"a-1.pdf": "d1/a",
+		"a-2.pdf": "d1/d2/a",
+		"b.pdf":   "b",
+	}, readRestored(restoredFile))
+	require.Contains(t, stderr, "Renamed 2 entries to avoid name collisions.")
+
+	restoredFile = filepath.Join(testutil.TempDirectory(t), "restored.tar")
+	e.RunAndExpectSuccess(t, "snapshot", "restore", snapID, restoredFile, "--flatten", "--flatten-collisions=path")
+	require.Equal(t, map[string]string{
+		"a.pdf":       "a",
+		"d1_a.pdf":    "d1/a",
+		"d1_d2_a.pdf": "d1/d2/a",
+		"b.pdf":       "b",
+	}, readRestored(restoredFile))
+
+	e.RunAndExpectFailure(t, "snapshot", "restore", snapID, testutil.TempDirectory(t), "--flatten", "--shallow=1")
+}
+
 func TestRestoreSymlinkWithNonSymlinkOverwrite(t *testing.T) {
 	t.Parallel()
 
@@ -455,6 +529,52 @@ func TestRestoreSymlinkWithNonSymlinkOverwrite(t *testing.T) {
 	e.RunAndExpectFailure(t, "snapshot", "restore", snapID, restoreDir)
 }
 
+func TestRestoreWithReadAheadAndPrefetch(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	sourceFile := filepath.Join(testutil.TempDirectory(t), "single-file")
+
+	data := make([]byte, 100000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	require.NoError(t, os.WriteFile(sourceFile, data, 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", sourceFile)
+
+	si := clitestutil.ListSnapshotsAndExpectSuccess(t, e, sourceFile)
+	snapID := si[0].Snapshots[0].SnapshotID
+
+	// restore writes directly into the existing target.
+	restoredFile := filepath.Join(testutil.TempDirectory(t), "restored")
+	require.NoError(t, os.WriteFile(restoredFile, nil, 0o600))
+
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "restore", snapID, restoredFile, "--write-buffer-size=4KiB", "--prefetch-files")
+
+	got, err := os.ReadFile(restoredFile)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+
+	throughputRE := regexp.MustCompile(`Restore took .* \(.*/s\)\.`)
+	foundThroughput := false
+
+	for _, l := range stderr {
+		if throughputRE.MatchString(l) {
+			foundThroughput = true
+		}
+	}
+
+	require.True(t, foundThroughput, "throughput not reported")
+}
+
 func TestRestoreSnapshotOfSingleFile(t *testing.T) {
 	t.Parallel()
 
@@ -600,6 +600,10 @@ func TestSnapshotCreateWithStdinStream(t *testing.T) {
 
 	streamFileName := "stream-file"
 
+	// stdin can only be stored in a single snapshot under a plain file name.
+	e.RunAndExpectFailure(t, "snapshot", "create", "rootdir", "otherdir", "--stdin-file", streamFileName)
+	e.RunAndExpectFailure(t, "snapshot", "create", "rootdir", "--stdin-file", "subdir/"+streamFileName)
+
 	runner.SetNextStdin(r)
 
 	e.RunAndExpectSuccess(t, "snapshot", "create", "rootdir", "--stdin-file", streamFileName)
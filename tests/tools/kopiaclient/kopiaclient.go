@@ -76,12 +76,12 @@ func (kc *KopiaClient) CreateOrConnectRepo(ctx context.Context, repoDir, bucketN
 
 // SetCacheLimits sets cache size limits to the already connected repository.
 func (kc *KopiaClient) SetCacheLimits(ctx context.Context, repoDir, bucketName string, cacheOpts *content.CachingOptions) error {
-	err := repo.SetCachingOptions(ctx, kc.configPath, cacheOpts)
+	err := repo.SetCachingOptions(ctx, kc.configPath, "", cacheOpts)
 	if err != nil {
 		return err
 	}
 
-	cacheOptsObtained, err := repo.GetCachingOptions(ctx, kc.configPath)
+	cacheOptsObtained, err := repo.GetCachingOptions(ctx, kc.configPath, "")
 	if err != nil {
 		return err
 	}